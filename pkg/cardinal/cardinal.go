@@ -2,7 +2,14 @@ package cardinal
 
 import (
 	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
 	"os/signal"
+	"reflect"
+	"sync"
 	"sync/atomic"
 	"syscall"
 	"time"
@@ -11,11 +18,14 @@ import (
 	"github.com/argus-labs/world-engine/pkg/cardinal/internal/ecs"
 	"github.com/argus-labs/world-engine/pkg/cardinal/internal/event"
 	"github.com/argus-labs/world-engine/pkg/cardinal/snapshot"
+	"github.com/argus-labs/world-engine/pkg/cardinal/ticklog"
 	"github.com/argus-labs/world-engine/pkg/micro"
 	"github.com/argus-labs/world-engine/pkg/telemetry"
 	"github.com/argus-labs/world-engine/pkg/telemetry/posthog"
 	"github.com/argus-labs/world-engine/pkg/telemetry/sentry"
 	cardinalv1 "github.com/argus-labs/world-engine/proto/gen/go/worldengine/cardinal/v1"
+	iscv1 "github.com/argus-labs/world-engine/proto/gen/go/worldengine/isc/v1"
+	"github.com/cespare/xxhash/v2"
 	"github.com/rotisserie/eris"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/timestamppb"
@@ -26,18 +36,33 @@ const (
 	addressPProf   = ":6060"
 )
 
+// errCorruptedSnapshot is returned by applySnapshot if a snapshot's state violates the ECS invariants,
+// which restore falls back to an earlier snapshot from.
+var errCorruptedSnapshot = errors.New("snapshot state is corrupted")
+
 // World represents your game world and serves as the main entry point for Cardinal.
 type World struct {
 	world           *ecs.World                          // The ECS world storing the game's state and systems
 	commands        command.Manager                     // Receives commands for systems
 	events          event.Manager                       // Collects and dispatches events
+	eventKinds      map[reflect.Type]event.Kind         // Custom event kinds by payload type
+	interest        *interestManager                    // Interest regions for area-of-interest events
 	address         *micro.ServiceAddress               // This world's NATS address
 	service         *service                            // ConnectRPC direct client-facing service
 	snapshotStorage snapshot.Storage                    // Snapshot storage
+	snapshotChain   snapshotChain                       // Chain the next delta snapshot is stored in
+	snapshots       *snapshotWriter                     // Background snapshot writer, nil if snapshots are written inline
+	migrations      migrationRegistry                   // Migrations of the components in stored snapshots
+	tickLogStore    ticklog.Store                       // Tick log store, nil if the tick log is disabled
+	tickLog         *tickLog                            // Records every tick to the tick log store, nil if disabled
 	state           atomic.Pointer[cardinalv1.Snapshot] // Latest world state; swap only, never mutate
 	debug           *debugModule                        // For debug only utils and services
 	pprof           *pprofModule                        // Optional pprof HTTP server
 	currentTick     Tick                                // The current tick
+	systemNames     map[string]int                      // Number of systems registered with each seed name
+	tickMu          sync.Mutex                          // Serializes ticks with clients' schedule changes
+	stateHash       uint64                              // Hash of the world state after the last tick
+	seed            uint64                              // Seed of the systems' random numbers
 	options         WorldOptions                        // Options
 	tel             telemetry.Telemetry                 // Telemetry for logging and tracing
 }
@@ -73,15 +98,21 @@ func NewWorld(opts WorldOptions) (*World, error) {
 	defer tel.RecoverAndFlush(true)
 
 	world := &World{
-		world:    ecs.NewWorld(),
-		commands: command.NewManager(),
-		events:   event.NewManager(1024), // Default event channel capacity
+		world:      ecs.NewWorld(),
+		commands:   command.NewManager(),
+		events:     event.NewManager(1024), // Default event channel capacity
+		eventKinds: make(map[reflect.Type]event.Kind),
+		interest:   newInterestManager(options.InterestCellSize),
 		address: micro.GetAddress(
 			options.Region, micro.RealmWorld, options.Organization, options.Project, options.ShardID),
-		currentTick: Tick{height: 0}, // timestamp will be set by cardinal.Tick
+		currentTick: Tick{height: 0},       // timestamp will be set by cardinal.Tick
+		seed:        max(rand.Uint64(), 1), // 0 isn't a valid seed
 		options:     options,
 		tel:         tel,
 	}
+	if options.Seed != nil {
+		world.seed = *options.Seed
+	}
 
 	// Seed a valid empty state so GetState is always servable, even before the first tick.
 	world.state.Store(&cardinalv1.Snapshot{WorldState: &cardinalv1.WorldState{}})
@@ -91,8 +122,9 @@ func NewWorld(opts WorldOptions) (*World, error) {
 		return world.debug.register("component", zero)
 	})
 
-	// Create the ConnectRPC client-facing service.
-	world.service = newService(world, options.AuthMode, options.ArgusAuthURL)
+	// Create the ConnectRPC client-facing service, and index the empty state for it.
+	world.service = newService(world, options)
+	world.service.publishState(world.state.Load())
 
 	// Register event handlers with the ConnectRPC service publishers.
 	world.events.RegisterHandler(event.KindDefault, world.service.publishDefaultEvent)
@@ -119,6 +151,14 @@ func NewWorld(opts WorldOptions) (*World, error) {
 			return nil, eris.Wrap(err, "failed to create S3 snapshot storage")
 		}
 		world.snapshotStorage = snapshotS3
+	case snapshot.StorageTypeFile:
+		snapshotFile, err := snapshot.NewFileStorage(snapshot.FileStorageOptions{
+			Logger: tel.GetLogger("snapshot"),
+		})
+		if err != nil {
+			return nil, eris.Wrap(err, "failed to create file snapshot storage")
+		}
+		world.snapshotStorage = snapshotFile
 	case snapshot.StorageTypeNop:
 		world.snapshotStorage = snapshot.NewNopStorage()
 	case snapshot.StorageTypeUndefined:
@@ -127,6 +167,33 @@ func NewWorld(opts WorldOptions) (*World, error) {
 		panic("unreachable")
 	}
 
+	// Setup the tick log store. The tick log is disabled with the no-op store.
+	switch options.TickLogStoreType {
+	case ticklog.StoreTypeFile:
+		tickLogFile, err := ticklog.NewFileStore(ticklog.FileStoreOptions{
+			Logger: tel.GetLogger("ticklog"),
+		})
+		if err != nil {
+			return nil, eris.Wrap(err, "failed to create file tick log store")
+		}
+		world.tickLogStore = tickLogFile
+	case ticklog.StoreTypeJetStream:
+		tickLogJS, err := ticklog.NewJetStreamStore(ticklog.JetStreamStoreOptions{
+			Logger:     tel.GetLogger("ticklog"),
+			Address:    world.address,
+			NATSConfig: options.NATSConfig,
+		})
+		if err != nil {
+			return nil, eris.Wrap(err, "failed to create jetstream tick log store")
+		}
+		world.tickLogStore = tickLogJS
+	case ticklog.StoreTypeNop:
+	case ticklog.StoreTypeUndefined:
+		fallthrough
+	default:
+		panic("unreachable")
+	}
+
 	// Create the debug module only if debug is on.
 	if *options.Debug {
 		world.debug = newDebugModule(world)
@@ -145,6 +212,12 @@ func (w *World) StartGame() {
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
+	// A migration dry run only checks the snapshot, so it skips the shutdown and its final snapshot.
+	if dryRun := w.options.MigrationDryRun; dryRun != nil && *dryRun {
+		w.migrationDryRun(ctx)
+		return
+	}
+
 	defer w.shutdown()
 	defer w.tel.RecoverAndFlush(true)
 
@@ -177,6 +250,18 @@ func (w *World) run(ctx context.Context) error {
 		return eris.Wrap(err, "failed to restore state from snapshot")
 	}
 
+	// Write snapshots in the background from here on, so slow storage doesn't stall the tick.
+	w.snapshots = newSnapshotWriter(w.snapshotStorage, w.tel.GetLogger("snapshot"))
+
+	// Record the ticks from the restored tick on, continuing the epoch chain of the tick log.
+	if w.tickLogStore != nil {
+		tl, err := newTickLog(ctx, w.tickLogStore, w.tel.GetLogger("ticklog"))
+		if err != nil {
+			return eris.Wrap(err, "failed to start tick log")
+		}
+		w.tickLog = tl
+	}
+
 	logger := w.tel.GetLogger("shard")
 	logger.Info().Msg("starting core shard loop")
 
@@ -192,7 +277,7 @@ func (w *World) run(ctx context.Context) error {
 				w.Tick(ctx, time.Now())
 				replyCh <- w.currentTick.height
 			case replyCh := <-w.debug.resetChan():
-				w.reset()
+				w.debugReset(ctx)
 				replyCh <- struct{}{}
 			case <-ctx.Done():
 				return ctx.Err()
@@ -213,8 +298,23 @@ func (w *World) run(ctx context.Context) error {
 }
 
 func (w *World) Tick(ctx context.Context, timestamp time.Time) {
-	// TODO: commands returned to be used for debug epoch log.
-	_ = w.commands.Drain()
+	w.tick(ctx, timestamp, true)
+}
+
+// tick runs a tick with the commands in the queues. If release is set, the scheduled commands that
+// are due are moved into the queues first. Both happen under tickMu, so a client's schedule change
+// is either made before the release and recorded for this tick, or made after the tick.
+func (w *World) tick(ctx context.Context, timestamp time.Time, release bool) {
+	w.tickMu.Lock()
+	defer w.tickMu.Unlock()
+
+	if release {
+		if err := w.commands.Release(w.currentTick.height); err != nil {
+			w.tel.Logger.Warn().Err(err).Msg("errors encountered releasing scheduled commands")
+		}
+	}
+	commands := w.commands.Drain()
+	scheduleChanges := w.commands.ScheduleChanges()
 
 	w.currentTick.timestamp = timestamp
 	w.debug.startPerfTick()
@@ -222,12 +322,24 @@ func (w *World) Tick(ctx context.Context, timestamp time.Time) {
 	// Tick ECS world.
 	w.world.Tick()
 
+	// Hash the new state, so the tick where two runs of the same ticks diverged can be found.
+	w.updateStateHash(w.currentTick.height)
+	w.tickLog.record(w.currentTick, commands, scheduleChanges, w.stateHash)
+
+	// Mark the commands collected this tick as processed now that systems have run.
+	w.commands.Settle()
+
 	w.debug.recordTick(w.currentTick.height, timestamp)
 
+	// Move interest regions to where the controlled entities are now, before events are delivered.
+	w.interest.update()
+
 	// Emit events.
 	if err := w.events.Dispatch(); err != nil {
 		w.tel.Logger.Warn().Err(err).Msg("errors encountered dispatching events")
 	}
+	w.service.flushEventBatches(w.currentTick)
+	w.service.sink.reportDropped(w.currentTick)
 
 	// Publish state to snapshot and debug module.
 	w.persistState(ctx, timestamp)
@@ -236,87 +348,344 @@ func (w *World) Tick(ctx context.Context, timestamp time.Time) {
 	w.currentTick.height++
 }
 
-// persistState serializes world state once and publishes it to w.state.
+// persistState serializes world state once and publishes it to w.state and the state streams.
 // Best effort: we just log errors instead of returning them, which would cause the
 // world to stop and restart, effectively losing unsaved state. If a state serialization
 // fails, the main loop still continues and we retry in the next persistState call.
 func (w *World) persistState(ctx context.Context, timestamp time.Time) {
 	snapshotDue := w.currentTick.height%uint64(w.options.SnapshotRate) == 0
-	if !snapshotDue && w.debug == nil {
+	publish := w.debug != nil || w.service.hasStateSubscribers()
+	if !snapshotDue && !publish {
 		return
 	}
 
-	worldState, err := w.world.ToProto()
+	// A delta snapshot only serializes what changed, so the full state is only serialized if it's
+	// published or due as a full snapshot. A waiting snapshot is replaced by the next one, so the next
+	// one can't be a delta chained to it.
+	if w.snapshots.takeChainBroken() {
+		w.snapshotChain.valid = false
+	}
+	deltaDue := snapshotDue && w.snapshotChain.valid && w.snapshotChain.deltas < w.options.SnapshotDeltas &&
+		!w.snapshots.busy()
+	if deltaDue {
+		w.snapshotDelta(ctx, timestamp)
+		if !publish {
+			return
+		}
+	}
+
+	worldState, err := w.stateToProto()
 	if err != nil {
 		w.tel.Logger.Warn().Err(err).Msg("failed to serialize the world's state")
 		return
 	}
-	w.state.Store(&cardinalv1.Snapshot{
+	snap := &cardinalv1.Snapshot{
 		TickHeight: w.currentTick.height,
 		Timestamp:  timestamppb.New(timestamp),
 		WorldState: worldState,
-	})
+		StateHash:  w.stateHash,
+	}
+	w.state.Store(snap)
+	w.service.publishState(snap)
 
-	if snapshotDue {
+	if snapshotDue && !deltaDue {
 		w.snapshot(ctx, timestamp, worldState)
 	}
 }
 
-// snapshot writes an already-serialized world state to storage, best-effort: errors are logged, not
-// returned, so a failed write doesn't stop the world and lose unsaved state — the next snapshot retries.
+// updateStateHash hashes the world state after the tick of the given height into w.stateHash and
+// logs it. The hash covers everything a snapshot restores: the ECS world, the command schedule, and
+// the seed, so a divergence of any of them is found at the tick it happened. If hashing fails, the
+// hash is zero, which replays don't compare.
+func (w *World) updateStateHash(height uint64) {
+	var hash uint64
+	ecsHash, err := w.world.StateHash()
+	if err != nil {
+		w.tel.Logger.Warn().Err(err).Uint64("tick", height).Msg("failed to hash the world's state")
+	} else {
+		buf := make([]byte, 0, 24)
+		buf = binary.BigEndian.AppendUint64(buf, ecsHash)
+		buf = binary.BigEndian.AppendUint64(buf, w.commands.ScheduleHash())
+		buf = binary.BigEndian.AppendUint64(buf, w.seed)
+		hash = xxhash.Sum64(buf)
+	}
+	w.stateHash = hash
+	if e := w.tel.Logger.Debug(); e.Enabled() {
+		e.Uint64("tick", height).Str("state_hash", formatStateHash(hash)).Msg("world state hashed")
+	}
+}
+
+// formatStateHash formats a state hash as fixed-width hex, the way it's shown in logs and errors.
+func formatStateHash(hash uint64) string {
+	return fmt.Sprintf("%016x", hash)
+}
+
+// stateToProto serializes the ECS world state along with the command schedule, which is not part of
+// the ECS world but must survive restarts.
+func (w *World) stateToProto() (*cardinalv1.WorldState, error) {
+	worldState, err := w.world.ToProto()
+	if err != nil {
+		return nil, err
+	}
+	worldState.CommandSchedule = w.commands.ScheduleToProto()
+	worldState.ComponentVersions = w.migrations.currentVersions()
+	worldState.Seed = w.seed
+	return worldState, nil
+}
+
+// snapshotChain tracks the snapshots stored since the last full snapshot, which delta snapshots are
+// chained to.
+type snapshotChain struct {
+	valid  bool   // Whether the next snapshot can be a delta of the last stored snapshot
+	parent uint64 // Tick height of the last stored snapshot
+	deltas uint32 // Number of delta snapshots stored since the last full snapshot
+}
+
+// snapshot submits an already-serialized world state to be written to storage as a full snapshot.
 func (w *World) snapshot(ctx context.Context, timestamp time.Time, worldState *cardinalv1.WorldState) {
-	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
-	defer cancel()
+	// The world state was serialized this tick, so the next delta starts from here.
+	w.world.ClearChanges()
+
+	w.snapshotChain = snapshotChain{valid: true, parent: w.currentTick.height}
+	w.submitSnapshot(ctx, &snapshotJob{tickHeight: w.currentTick.height, timestamp: timestamp, state: worldState})
+}
 
-	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(worldState)
+// snapshotDelta submits the changes since the last stored snapshot to be written to storage as a
+// delta snapshot. The changes are cleared either way, so if serializing fails, the next snapshot is a
+// full snapshot.
+func (w *World) snapshotDelta(ctx context.Context, timestamp time.Time) {
+	delta, err := w.world.ToProtoDelta()
+	w.world.ClearChanges()
 	if err != nil {
-		w.tel.Logger.Warn().Err(err).Msg("failed to marshal world state to bytes")
+		w.tel.Logger.Warn().Err(err).Msg("failed to serialize the world's state changes")
+		w.snapshotChain.valid = false
 		return
 	}
-	snap := &snapshot.Snapshot{
-		TickHeight: w.currentTick.height,
-		Timestamp:  timestamp,
-		Data:       data,
-		Version:    snapshot.CurrentVersion,
+	delta.ParentTickHeight = w.snapshotChain.parent
+	delta.CommandSchedule = w.commands.ScheduleToProto()
+	delta.ComponentVersions = w.migrations.currentVersions()
+	delta.Seed = w.seed
+
+	w.snapshotChain.parent = w.currentTick.height
+	w.snapshotChain.deltas++
+	w.submitSnapshot(ctx, &snapshotJob{
+		tickHeight: w.currentTick.height, timestamp: timestamp, state: delta, delta: true,
+	})
+}
+
+// submitSnapshot hands a snapshot to the background writer while the world loop runs. Otherwise, it's
+// written inline, best-effort: errors are logged, not returned, so a failed write doesn't stop the
+// world and lose unsaved state. A failed write breaks the delta chain, so the next snapshot is full.
+func (w *World) submitSnapshot(ctx context.Context, job *snapshotJob) {
+	// Seal the ticks up to the snapshot, so the ticks after it can be replayed from it after a crash.
+	w.tickLog.flush()
+
+	if w.snapshots != nil {
+		w.snapshots.submit(job)
+		return
 	}
-	if err := w.snapshotStorage.Store(ctx, snap); err != nil {
-		w.tel.Logger.Warn().Err(err).Msg("failed to store snapshot")
+	if err := writeSnapshot(ctx, w.snapshotStorage, job); err != nil {
+		w.tel.Logger.Warn().Err(err).Bool("delta", job.delta).Msg("failed to write snapshot")
+		w.snapshotChain.valid = false
 		return
 	}
-	w.tel.Logger.Debug().Msg("published snapshot")
+	w.tel.Logger.Debug().Bool("delta", job.delta).Msg("published snapshot")
 }
 
 func (w *World) restore(ctx context.Context) error {
 	logger := w.tel.GetLogger("snapshot")
 
-	logger.Debug().Msg("restoring from snapshot")
-	snap, err := w.snapshotStorage.Load(ctx)
-	if err != nil {
-		if eris.Is(err, snapshot.ErrSnapshotNotFound) {
-			logger.Debug().Msg("no snapshot found")
-			return nil
-		}
-		return eris.Wrap(err, "failed to load snapshot")
+	snap, err := w.loadSnapshot(ctx)
+	if err != nil || snap == nil {
+		return err
 	}
 
-	// Unmarshal snapshot bytes into proto and restore ECS world.
-	var worldState cardinalv1.WorldState
-	if err := proto.Unmarshal(snap.Data, &worldState); err != nil {
-		return eris.Wrap(err, "failed to unmarshal snapshot data")
+	// A snapshot whose state violates the ECS invariants must not be ticked on. Fall back to the earlier
+	// snapshots in turn, unless a tick was requested or restores are strict, and refuse to start if none
+	// of them is valid. Snapshots that fail their checksum are already skipped when loading. Other errors
+	// don't fall back, e.g. migrations or components that don't deserialize, as they're likely to fail
+	// the earlier snapshots the same way, and silently losing ticks to them would hide the bug.
+	strict := w.options.RestoreTick != nil || (w.options.RestoreStrict != nil && *w.options.RestoreStrict)
+	var worldState *cardinalv1.WorldState
+	var failures []error
+	for {
+		worldState, err = w.migrateSnapshot(snap)
+		if err != nil {
+			return err
+		}
+		err = w.applySnapshot(snap.TickHeight, worldState)
+		if err == nil {
+			break
+		}
+		if !eris.Is(err, errCorruptedSnapshot) {
+			return eris.Wrapf(err, "failed to restore snapshot of tick %d, refusing to start", snap.TickHeight)
+		}
+		failures = append(failures, eris.Wrapf(err, "snapshot of tick %d is invalid", snap.TickHeight))
+		if strict {
+			return eris.Wrap(errors.Join(failures...), "refusing to start")
+		}
+
+		logger.Error().Err(err).Uint64("tick", snap.TickHeight).
+			Msg("restored snapshot is invalid, falling back to an earlier snapshot")
+		snap, err = w.loadSnapshotBefore(ctx, snap.TickHeight)
+		if err != nil {
+			failures = append(failures, err)
+			return eris.Wrap(errors.Join(failures...), "no valid snapshot to restore, refusing to start")
+		}
 	}
-	if err := w.world.FromProto(&worldState); err != nil {
-		return eris.Wrap(err, "failed to restore world from snapshot")
+	if len(failures) > 0 {
+		logger.Warn().Uint64("tick", snap.TickHeight).Int("skipped", len(failures)).
+			Msg("restored an earlier snapshot, later ticks are lost")
 	}
 
 	// Only update shard state after successful restoration and validation.
+	w.updateStateHash(snap.TickHeight)
 	w.currentTick.height = snap.TickHeight + 1
+	w.commands.SetNextTick(w.currentTick.height)
 
 	// Publish the unmarshaled proto as-is; it already is the restored state.
-	w.state.Store(&cardinalv1.Snapshot{
+	restored := &cardinalv1.Snapshot{
 		TickHeight: snap.TickHeight,
 		Timestamp:  timestamppb.New(snap.Timestamp),
-		WorldState: &worldState,
-	})
+		WorldState: worldState,
+		StateHash:  w.stateHash,
+	}
+	w.state.Store(restored)
+	w.service.publishState(restored)
+
+	// The shard continues from the restored tick, so later snapshots describe a discarded history. There
+	// are some whenever a tick was requested, or the restore fell back, including when loading skipped
+	// snapshots that fail their checksum. Quarantine them, or the next restart would restore the latest
+	// of them instead of the shard's new state, and retention would count them as the newest generations.
+	// They aren't deleted, so an operator can still inspect or recover them.
+	if err := w.quarantineSnapshotsFrom(ctx, snap.TickHeight+1); err != nil {
+		return eris.Wrap(err, "failed to quarantine snapshots after the restored tick")
+	}
+	return nil
+}
+
+// migrateSnapshot unmarshals a snapshot's world state and migrates it to the current component
+// schemas, logging the migrations that ran.
+func (w *World) migrateSnapshot(snap *snapshot.Snapshot) (*cardinalv1.WorldState, error) {
+	logger := w.tel.GetLogger("snapshot")
+
+	var worldState cardinalv1.WorldState
+	if err := proto.Unmarshal(snap.Data, &worldState); err != nil {
+		return nil, eris.Wrap(err, "failed to unmarshal snapshot data")
+	}
+	applied, err := w.migrations.migrate(w.world, &worldState)
+	if err != nil {
+		return nil, eris.Wrapf(err, "failed to migrate snapshot of tick %d", snap.TickHeight)
+	}
+	for _, migration := range applied {
+		logger.Info().Uint64("tick", snap.TickHeight).Str("migration", migration).Msg("migrated snapshot")
+	}
+	return &worldState, nil
+}
+
+// applySnapshot restores the ECS world, command schedule, and seed from a snapshot's world state, and
+// checks that the restored world satisfies the ECS invariants, returning errCorruptedSnapshot if not.
+// Snapshots taken before seeds were stored keep the world's seed.
+func (w *World) applySnapshot(tickHeight uint64, worldState *cardinalv1.WorldState) error {
+	if err := w.world.FromProto(worldState); err != nil {
+		return eris.Wrap(err, "failed to restore world from snapshot")
+	}
+	if err := w.world.CheckInvariants(); err != nil {
+		return eris.Wrapf(errCorruptedSnapshot, "restored world violates the ECS invariants: %v", err)
+	}
+	if err := w.commands.ScheduleFromProto(worldState.GetCommandSchedule(), tickHeight+1); err != nil {
+		return eris.Wrap(err, "failed to restore command schedule from snapshot")
+	}
+	if seed := worldState.GetSeed(); seed != 0 {
+		w.seed = seed
+	}
+	return nil
+}
+
+// loadSnapshotBefore loads the latest snapshot of a tick before tickHeight that can be loaded.
+func (w *World) loadSnapshotBefore(ctx context.Context, tickHeight uint64) (*snapshot.Snapshot, error) {
+	logger := w.tel.GetLogger("snapshot")
+
+	infos, err := w.snapshotStorage.List(ctx)
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to list snapshots")
+	}
+	for i := len(infos) - 1; i >= 0; i-- {
+		if infos[i].TickHeight >= tickHeight {
+			continue
+		}
+		snap, err := w.snapshotStorage.LoadAt(ctx, infos[i].TickHeight)
+		if err != nil {
+			logger.Warn().Err(err).Uint64("tick", infos[i].TickHeight).Msg("skipping invalid snapshot")
+			continue
+		}
+		return snap, nil
+	}
+	return nil, eris.Wrapf(snapshot.ErrSnapshotNotFound, "no snapshot before tick %d can be loaded", tickHeight)
+}
+
+// loadSnapshot loads the snapshot to restore: the one of options.RestoreTick if it's set, or else the
+// latest one. Returns nil if there's no snapshot to restore.
+func (w *World) loadSnapshot(ctx context.Context) (*snapshot.Snapshot, error) {
+	logger := w.tel.GetLogger("snapshot")
+
+	if tick := w.options.RestoreTick; tick != nil {
+		// A requested snapshot that doesn't exist is an error, not a fresh start.
+		logger.Info().Uint64("tick", *tick).Msg("restoring from snapshot of requested tick")
+		snap, err := w.snapshotStorage.LoadAt(ctx, *tick)
+		if err != nil {
+			return nil, eris.Wrapf(err, "failed to load snapshot of tick %d", *tick)
+		}
+		return snap, nil
+	}
+
+	logger.Debug().Msg("restoring from snapshot")
+	snap, err := w.snapshotStorage.Load(ctx)
+	if err != nil {
+		if eris.Is(err, snapshot.ErrSnapshotNotFound) {
+			logger.Debug().Msg("no snapshot found")
+			return nil, nil //nolint:nilnil // No snapshot means starting fresh
+		}
+		return nil, eris.Wrap(err, "failed to load snapshot")
+	}
+	return snap, nil
+}
+
+// quarantineSnapshotsFrom quarantines the stored snapshots of tick and later ticks.
+func (w *World) quarantineSnapshotsFrom(ctx context.Context, tick uint64) error {
+	logger := w.tel.GetLogger("snapshot")
+	infos, err := w.snapshotStorage.List(ctx)
+	if err != nil {
+		return eris.Wrap(err, "failed to list snapshots")
+	}
+	for _, info := range infos {
+		if info.TickHeight < tick {
+			continue
+		}
+		if err := w.snapshotStorage.Quarantine(ctx, info.TickHeight); err != nil {
+			return eris.Wrapf(err, "failed to quarantine snapshot of tick %d", info.TickHeight)
+		}
+		logger.Warn().Uint64("tick", info.TickHeight).Msg("quarantined snapshot of a discarded history")
+	}
+	return nil
+}
+
+// deleteSnapshotsFrom deletes the stored snapshots of tick and later ticks.
+func (w *World) deleteSnapshotsFrom(ctx context.Context, tick uint64) error {
+	logger := w.tel.GetLogger("snapshot")
+	infos, err := w.snapshotStorage.List(ctx)
+	if err != nil {
+		return eris.Wrap(err, "failed to list snapshots")
+	}
+	for _, info := range infos {
+		if info.TickHeight < tick {
+			continue
+		}
+		if err := w.snapshotStorage.Delete(ctx, info.TickHeight); err != nil {
+			return eris.Wrapf(err, "failed to delete snapshot of tick %d", info.TickHeight)
+		}
+		logger.Warn().Uint64("tick", info.TickHeight).Msg("deleted snapshot of a discarded history")
+	}
 	return nil
 }
 
@@ -336,13 +705,28 @@ func (w *World) shutdown() {
 	// instead of being severed on the first cleanup step. Telemetry goes last
 	// so it can flush log lines emitted by every preceding step.
 
-	// 1. Final snapshot. Producer-side; serialize world state for snapshot.
-	if worldState, err := w.world.ToProto(); err != nil {
+	// 1. Final snapshot and tick log. Producer-side; wait for the background writer, then write the final
+	// snapshot inline so it's stored before the process exits.
+	if err := w.snapshots.close(ctx); err != nil {
+		w.tel.Logger.Warn().Err(err).Msg("failed to flush snapshot writer")
+	}
+	w.snapshots = nil
+	if worldState, err := w.stateToProto(); err != nil {
 		w.tel.Logger.Warn().Err(err).Msg("failed to serialize world for final snapshot")
 	} else {
 		w.snapshot(ctx, time.Now(), worldState)
 	}
 
+	// Append the last ticks to the tick log, including the ones of the unfinished epoch.
+	if err := w.tickLog.close(ctx); err != nil {
+		w.tel.Logger.Warn().Err(err).Msg("failed to flush tick log")
+	}
+	if w.tickLogStore != nil {
+		if err := w.tickLogStore.Close(); err != nil {
+			w.tel.Logger.Warn().Err(err).Msg("failed to close tick log store")
+		}
+	}
+
 	// 2. Shard service (NATS) — drain queued commands/events. Typically quick,
 	// but the producer side should stop before observers do.
 	if err := w.service.shutdown(ctx); err != nil {
@@ -372,27 +756,77 @@ func (w *World) reset() {
 	w.world.Reset()
 	w.world.Init()
 
-	// Clear command and event buffers from previous tick.
+	// Clear command and event buffers from previous tick, and scheduled commands.
 	w.commands.Clear()
 	w.events.Clear()
+	w.interest.reset()
 
 	// Reset tick bookkeeping fields.
 	w.currentTick.height = 0
 	w.currentTick.timestamp = time.Time{}
 
+	// The reset world isn't chained to the stored snapshots, so the next snapshot is a full snapshot.
+	w.snapshotChain = snapshotChain{}
+	w.updateStateHash(w.currentTick.height)
+
 	// Republish state so it doesn't describe the pre-reset world, and clear perf data.
-	if worldState, err := w.world.ToProto(); err != nil {
+	if worldState, err := w.stateToProto(); err != nil {
 		w.tel.Logger.Warn().Err(err).Msg("failed to serialize the world's state")
 	} else {
-		w.state.Store(&cardinalv1.Snapshot{
+		snap := &cardinalv1.Snapshot{
 			TickHeight: w.currentTick.height,
 			Timestamp:  timestamppb.New(w.currentTick.timestamp),
 			WorldState: worldState,
-		})
+			StateHash:  w.stateHash,
+		}
+		w.state.Store(snap)
+		w.service.publishState(snap)
 	}
 	w.debug.resetPerf()
 }
 
+// debugReset resets the world for the debug Reset RPC. Unlike reset, which DST also uses to simulate
+// restarts, it deletes the stored snapshots, as they describe the discarded history: otherwise the
+// next restart would restore the latest of them instead of the reset world. The snapshots submitted
+// before the reset are written first, so none of them is stored after the others are deleted.
+func (w *World) debugReset(ctx context.Context) {
+	w.reset()
+	if err := w.snapshots.flush(ctx); err != nil {
+		w.tel.Logger.Warn().Err(err).Msg("failed to flush snapshots before deleting them")
+		return
+	}
+	if err := w.deleteSnapshotsFrom(ctx, 0); err != nil {
+		w.tel.Logger.Warn().Err(err).Msg("failed to delete the snapshots of the reset world")
+	}
+}
+
+// selfCommand builds the wire form of a command this shard sends to itself.
+func (w *World) selfCommand(cmd Command) (*iscv1.Command, error) {
+	payload, err := cmd.MarshalWire()
+	if err != nil {
+		return nil, eris.Wrapf(err, "failed to marshal command %s", cmd.Name())
+	}
+	return &iscv1.Command{
+		Name:    cmd.Name(),
+		Address: w.address,
+		Persona: &iscv1.Persona{Id: micro.String(w.address)},
+		Payload: payload,
+	}, nil
+}
+
+// delayTicks converts a delay to a number of ticks, rounded up.
+func (w *World) delayTicks(delay time.Duration) uint64 {
+	if delay <= 0 {
+		return 0
+	}
+	// Delays too long to convert are clamped; they're beyond command.MaxScheduleTicks anyway.
+	ticks := math.Ceil(delay.Seconds() * w.options.TickRate)
+	if ticks >= math.MaxUint64 {
+		return math.MaxUint64
+	}
+	return uint64(ticks)
+}
+
 type Tick struct {
 	height    uint64
 	timestamp time.Time
@@ -1,8 +1,12 @@
 package cardinal
 
 import (
+	"math"
+	"time"
+
 	"github.com/argus-labs/world-engine/pkg/assert"
 	"github.com/argus-labs/world-engine/pkg/cardinal/snapshot"
+	"github.com/argus-labs/world-engine/pkg/cardinal/ticklog"
 	"github.com/argus-labs/world-engine/pkg/micro"
 	"github.com/caarlos0/env/v11"
 	"github.com/rotisserie/eris"
@@ -17,12 +21,25 @@ type WorldOptions struct {
 	ShardID             string               // Unique ID for of world's instance
 	TickRate            float64              // Number of ticks per second
 	SnapshotStorageType snapshot.StorageType // Snapshot storage type
+	TickLogStoreType    ticklog.StoreType    // Tick log store type
 	SnapshotRate        uint32               // Number of ticks per snapshot
+	SnapshotDeltas      uint32               // Number of delta snapshots between two full snapshots
 	Debug               *bool                // Enable debug server
 	Pprof               *bool                // Enable pprof server
 	NATSConfig          *micro.NATSConfig    // Optional NATS config override (nil = use env/defaults)
 	AuthMode            AuthMode             // Auth mode for the client-facing ConnectRPC service
 	ArgusAuthURL        string               // URL of the Argus Auth service when AuthMode is ARGUS
+	EventHistorySize    int                  // Number of broadcast events retained for stream resumes
+	TargetedEventTTL    time.Duration        // How long targeted events are retained for stream resumes
+	EventStreamQueue    int                  // Capacity of each event stream's outbound queue
+	EventStreamOverflow OverflowPolicy       // What to do when an event stream's outbound queue is full
+	InterestCellSize    float64              // Cell size of the area-of-interest grid, in world units
+	QueryAuthorizer     QueryAuthorizer      // Optional filter of what each persona sees in queries and state streams
+	EventSink           *bool                // Publish every dispatched event to a JetStream stream
+	RestoreTick         *uint64              // Restore the snapshot of this tick instead of the latest
+	MigrationDryRun     *bool                // Only check that the snapshot migrates, then exit
+	RestoreStrict       *bool                // Refuse to start instead of restoring an earlier snapshot
+	Seed                *uint64              // Seed of the systems' random numbers, not 0 (nil = random)
 }
 
 // newDefaultWorldOptions creates WorldOptions with default values.
@@ -36,11 +53,17 @@ func newDefaultWorldOptions() WorldOptions {
 		ShardID:             "",
 		TickRate:            0,
 		SnapshotStorageType: snapshot.StorageTypeNop, // Default to nop snapshot
+		TickLogStoreType:    ticklog.StoreTypeNop,    // Default to no tick log
 		SnapshotRate:        0,
 		Debug:               nil,
 		Pprof:               nil,
 		AuthMode:            AuthModeDev,
 		ArgusAuthURL:        "",
+		EventHistorySize:    4096,
+		TargetedEventTTL:    5 * time.Minute,
+		EventStreamQueue:    256,
+		EventStreamOverflow: OverflowDropOldest,
+		InterestCellSize:    64,
 	}
 }
 
@@ -64,9 +87,15 @@ func (opt *WorldOptions) apply(newOpt WorldOptions) {
 	if newOpt.SnapshotStorageType.IsValid() {
 		opt.SnapshotStorageType = newOpt.SnapshotStorageType
 	}
+	if newOpt.TickLogStoreType.IsValid() {
+		opt.TickLogStoreType = newOpt.TickLogStoreType
+	}
 	if newOpt.SnapshotRate != 0 {
 		opt.SnapshotRate = newOpt.SnapshotRate
 	}
+	if newOpt.SnapshotDeltas != 0 {
+		opt.SnapshotDeltas = newOpt.SnapshotDeltas
+	}
 	if newOpt.Debug != nil {
 		opt.Debug = newOpt.Debug
 	}
@@ -82,6 +111,39 @@ func (opt *WorldOptions) apply(newOpt WorldOptions) {
 	if newOpt.ArgusAuthURL != "" {
 		opt.ArgusAuthURL = newOpt.ArgusAuthURL
 	}
+	if newOpt.EventHistorySize != 0 {
+		opt.EventHistorySize = newOpt.EventHistorySize
+	}
+	if newOpt.TargetedEventTTL != 0 {
+		opt.TargetedEventTTL = newOpt.TargetedEventTTL
+	}
+	if newOpt.EventStreamQueue != 0 {
+		opt.EventStreamQueue = newOpt.EventStreamQueue
+	}
+	if newOpt.EventStreamOverflow.IsValid() {
+		opt.EventStreamOverflow = newOpt.EventStreamOverflow
+	}
+	if newOpt.InterestCellSize != 0 {
+		opt.InterestCellSize = newOpt.InterestCellSize
+	}
+	if newOpt.QueryAuthorizer != nil {
+		opt.QueryAuthorizer = newOpt.QueryAuthorizer
+	}
+	if newOpt.EventSink != nil {
+		opt.EventSink = newOpt.EventSink
+	}
+	if newOpt.RestoreTick != nil {
+		opt.RestoreTick = newOpt.RestoreTick
+	}
+	if newOpt.MigrationDryRun != nil {
+		opt.MigrationDryRun = newOpt.MigrationDryRun
+	}
+	if newOpt.RestoreStrict != nil {
+		opt.RestoreStrict = newOpt.RestoreStrict
+	}
+	if newOpt.Seed != nil {
+		opt.Seed = newOpt.Seed
+	}
 }
 
 // validate checks that all required options are set and valid.
@@ -104,6 +166,9 @@ func (opt *WorldOptions) validate() error {
 	if !opt.SnapshotStorageType.IsValid() {
 		return eris.New("snapshot storage type must be specified")
 	}
+	if !opt.TickLogStoreType.IsValid() {
+		return eris.New("tick log store type must be specified")
+	}
 	if opt.SnapshotRate == 0 {
 		return eris.New("snapshot frequency cannot be 0")
 	}
@@ -119,6 +184,25 @@ func (opt *WorldOptions) validate() error {
 	if opt.AuthMode == AuthModeArgus && opt.ArgusAuthURL == "" {
 		return eris.New("argus auth URL cannot be empty when auth mode is ARGUS")
 	}
+	if opt.EventHistorySize <= 0 {
+		return eris.New("event history size must be greater than 0")
+	}
+	if opt.TargetedEventTTL <= 0 {
+		return eris.New("targeted event TTL must be greater than 0")
+	}
+	if opt.EventStreamQueue <= 0 {
+		return eris.New("event stream queue size must be greater than 0")
+	}
+	if !opt.EventStreamOverflow.IsValid() {
+		return eris.Errorf("invalid event stream overflow policy: %s (must be one of: DROP_OLDEST, DISCONNECT)",
+			opt.EventStreamOverflow)
+	}
+	if opt.InterestCellSize <= 0 || math.IsNaN(opt.InterestCellSize) || math.IsInf(opt.InterestCellSize, 0) {
+		return eris.New("interest cell size must be a positive number")
+	}
+	if opt.Seed != nil && *opt.Seed == 0 {
+		return eris.New("seed cannot be 0")
+	}
 	return nil
 }
 
@@ -158,12 +242,19 @@ type worldOptionsEnv struct {
 	// Unique ID of this world's instance.
 	ShardID string `env:"CARDINAL_SHARD_ID"`
 
-	// Snapshot storage type ("NOP", "JETSTREAM", or "S3").
+	// Snapshot storage type ("NOP", "JETSTREAM", "S3", or "FILE").
 	SnapshotStorageTypeStr string `env:"CARDINAL_SNAPSHOT_STORAGE_TYPE" envDefault:"NOP"`
 
+	// Tick log store type ("NOP", "FILE", or "JETSTREAM"). The tick log records every tick's commands.
+	TickLogStoreTypeStr string `env:"CARDINAL_TICK_LOG_STORE_TYPE" envDefault:"NOP"`
+
 	// Number of ticks per snapshot.
 	SnapshotRate uint32 `env:"CARDINAL_SNAPSHOT_RATE"`
 
+	// Number of delta snapshots between two full snapshots. A delta snapshot only contains the archetypes
+	// and columns that changed since the previous snapshot. Defaults to 0, only taking full snapshots.
+	SnapshotDeltas uint32 `env:"CARDINAL_SNAPSHOT_DELTAS"`
+
 	// Enable debug server.
 	Debug bool `env:"CARDINAL_DEBUG" envDefault:"false"`
 
@@ -175,6 +266,47 @@ type worldOptionsEnv struct {
 
 	// URL of the Argus Auth service when AuthMode is ARGUS.
 	ArgusAuthURL string `env:"CARDINAL_ARGUS_AUTH_URL"`
+
+	// Number of broadcast events retained for stream resumes.
+	EventHistorySize int `env:"CARDINAL_EVENT_HISTORY_SIZE"`
+
+	// How long targeted events are retained for stream resumes, e.g. "5m".
+	TargetedEventTTL time.Duration `env:"CARDINAL_TARGETED_EVENT_TTL"`
+
+	// Capacity of each event stream's outbound queue.
+	EventStreamQueue int `env:"CARDINAL_EVENT_STREAM_QUEUE"`
+
+	// What to do when an event stream's outbound queue is full (DROP_OLDEST or DISCONNECT).
+	EventStreamOverflowStr string `env:"CARDINAL_EVENT_STREAM_OVERFLOW" envDefault:"DROP_OLDEST"`
+
+	// Cell size of the area-of-interest grid, in world units. Should be close to the typical interest
+	// region diameter.
+	InterestCellSize float64 `env:"CARDINAL_INTEREST_CELL_SIZE"`
+
+	// Publish every dispatched event to a JetStream stream. The stream is configured with the
+	// CARDINAL_EVENT_SINK_* env variables.
+	EventSink bool `env:"CARDINAL_EVENT_SINK" envDefault:"false"`
+
+	// Restore the snapshot of this tick instead of the latest one. Snapshots of later ticks are
+	// quarantined once it's restored: they're renamed with a "quarantine-" prefix, which hides them from
+	// restores and retention. An operator recovers one by stopping the shard and removing the prefix from
+	// its name. The snapshots kept are configured with the CARDINAL_SNAPSHOT_GENERATIONS,
+	// CARDINAL_SNAPSHOT_KEEP_HOURLY, and CARDINAL_SNAPSHOT_KEEP_DAILY env variables.
+	RestoreTick *uint64 `env:"CARDINAL_RESTORE_TICK"`
+
+	// Load the snapshot that would be restored, run its migrations, and check that every component
+	// decodes, then exit without running the world or writing snapshots.
+	MigrationDryRun bool `env:"CARDINAL_MIGRATION_DRY_RUN" envDefault:"false"`
+
+	// Refuse to start if the restored snapshot's state is corrupted, instead of falling back to the
+	// latest earlier snapshot whose state is valid. Snapshots of a requested restore tick never fall
+	// back.
+	RestoreStrict bool `env:"CARDINAL_RESTORE_STRICT" envDefault:"false"`
+
+	// Seed of the random numbers systems get from BaseSystemState.Rand. It's stored in the snapshots, so
+	// it only applies to a world without snapshots. Defaults to a random seed. It can't be 0, which
+	// marks snapshots taken before seeds were stored.
+	Seed *uint64 `env:"CARDINAL_SEED"`
 }
 
 // loadWorldOptionsEnv loads the world options from environment variables.
@@ -197,6 +329,9 @@ func (cfg *worldOptionsEnv) validate() error {
 	if _, err := snapshot.ParseStorageType(cfg.SnapshotStorageTypeStr); err != nil {
 		return err
 	}
+	if _, err := ticklog.ParseStoreType(cfg.TickLogStoreTypeStr); err != nil {
+		return err
+	}
 	authMode, err := ParseAuthMode(cfg.AuthModeStr)
 	if err != nil {
 		return eris.Wrap(err, "failed to parse auth mode")
@@ -204,6 +339,9 @@ func (cfg *worldOptionsEnv) validate() error {
 	if authMode == AuthModeArgus && cfg.ArgusAuthURL == "" {
 		return eris.New("CARDINAL_ARGUS_AUTH_URL cannot be empty when CARDINAL_AUTH_MODE is ARGUS")
 	}
+	if _, err := ParseOverflowPolicy(cfg.EventStreamOverflowStr); err != nil {
+		return eris.Wrap(err, "failed to parse event stream overflow policy")
+	}
 	return nil
 }
 
@@ -212,19 +350,37 @@ func (cfg *worldOptionsEnv) toOptions() WorldOptions {
 	snapshotStorageType, err := snapshot.ParseStorageType(cfg.SnapshotStorageTypeStr)
 	assert.That(err == nil, "config not validated")
 
+	tickLogStoreType, err := ticklog.ParseStoreType(cfg.TickLogStoreTypeStr)
+	assert.That(err == nil, "config not validated")
+
 	authMode, err := ParseAuthMode(cfg.AuthModeStr)
 	assert.That(err == nil, "config not validated")
 
+	overflow, err := ParseOverflowPolicy(cfg.EventStreamOverflowStr)
+	assert.That(err == nil, "config not validated")
+
 	return WorldOptions{
 		Region:              cfg.Region,
 		Organization:        cfg.Organization,
 		Project:             cfg.Project,
 		ShardID:             cfg.ShardID,
 		SnapshotStorageType: snapshotStorageType,
+		TickLogStoreType:    tickLogStoreType,
 		SnapshotRate:        cfg.SnapshotRate,
+		SnapshotDeltas:      cfg.SnapshotDeltas,
 		Debug:               &cfg.Debug,
 		Pprof:               &cfg.Pprof,
 		AuthMode:            authMode,
 		ArgusAuthURL:        cfg.ArgusAuthURL,
+		EventHistorySize:    cfg.EventHistorySize,
+		TargetedEventTTL:    cfg.TargetedEventTTL,
+		EventStreamQueue:    cfg.EventStreamQueue,
+		EventStreamOverflow: overflow,
+		InterestCellSize:    cfg.InterestCellSize,
+		EventSink:           &cfg.EventSink,
+		RestoreTick:         cfg.RestoreTick,
+		MigrationDryRun:     &cfg.MigrationDryRun,
+		RestoreStrict:       &cfg.RestoreStrict,
+		Seed:                cfg.Seed,
 	}
 }
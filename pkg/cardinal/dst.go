@@ -84,6 +84,7 @@ func RunDST(t *testing.T, setup DSTSetupFunc, preTestCommands []Command) {
 
 			// Assert structural ECS invariants after every tick.
 			ecs.CheckWorld(t, fix.world.world)
+			fix.stateHashes[fix.world.currentTick.height-1] = fix.world.stateHash
 
 			tick++
 
@@ -99,6 +100,13 @@ func RunDST(t *testing.T, setup DSTSetupFunc, preTestCommands []Command) {
 			fix.world.reset()
 			require.NoError(t, fix.world.restore(context.Background()))
 
+			// The restored state must hash the same as the state the snapshot was taken of. Components
+			// with map fields don't serialize deterministically, so they can fail this check.
+			if height := fix.world.currentTick.height; height > 0 {
+				assert.Equal(t, fix.stateHashes[height-1], fix.world.stateHash,
+					"state hash of the snapshot of tick %d changed on restore", height-1)
+			}
+
 			// Verify snapshot roundtrip fidelity: restored state re-serializes to identical bytes.
 			// fix.verifySnapshotRoundtrip(t)
 		}
@@ -138,6 +146,7 @@ type dstConfig struct {
 	Ticks        int
 	OpWeights    testutils.OpWeights
 	SnapshotRate uint32
+	Seed         uint64
 }
 
 func newDSTConfig(rng *rand.Rand) dstConfig {
@@ -154,6 +163,7 @@ func newDSTConfig(rng *rand.Rand) dstConfig {
 		Ticks:        *numTicks,
 		OpWeights:    opWeights,
 		SnapshotRate: uint32(1 + rng.IntN(25)), //nolint:gosec // bounded to [1,25]
+		Seed:         max(rng.Uint64(), 1),     // 0 isn't a valid seed
 	}
 }
 
@@ -172,6 +182,7 @@ func (c *dstConfig) log(t *testing.T) {
 	t.Logf("  ticks:         %d", c.Ticks)
 	t.Logf("  op_weights:    %v", c.OpWeights)
 	t.Logf("  snapshot_rate: %d", c.SnapshotRate)
+	t.Logf("  seed:          %d", c.Seed)
 }
 
 // -------------------------------------------------------------------------------------------------
@@ -179,9 +190,10 @@ func (c *dstConfig) log(t *testing.T) {
 // -------------------------------------------------------------------------------------------------
 
 type dstFixture struct {
-	world    *World
-	storage  *memSnapshotStorage
-	cmdTypes map[string]reflect.Type // command name -> concrete payload type
+	world       *World
+	storage     *memSnapshotStorage
+	cmdTypes    map[string]reflect.Type // command name -> concrete payload type
+	stateHashes map[uint64]uint64       // tick height -> hash of the world state after the tick
 }
 
 func newDSTFixture(t *testing.T, cfg dstConfig, setup DSTSetupFunc) *dstFixture {
@@ -199,6 +211,7 @@ func newDSTFixture(t *testing.T, cfg dstConfig, setup DSTSetupFunc) *dstFixture
 		TickRate:            1,
 		SnapshotStorageType: snapshot.StorageTypeNop,
 		SnapshotRate:        cfg.SnapshotRate,
+		Seed:                &cfg.Seed,
 		Debug:               &debug,
 	})
 	require.NoError(t, err)
@@ -237,9 +250,10 @@ func newDSTFixture(t *testing.T, cfg dstConfig, setup DSTSetupFunc) *dstFixture
 	}
 
 	return &dstFixture{
-		world:    w,
-		storage:  storage,
-		cmdTypes: cmdTypes,
+		world:       w,
+		storage:     storage,
+		cmdTypes:    cmdTypes,
+		stateHashes: make(map[uint64]uint64),
 	}
 }
 
@@ -397,3 +411,29 @@ func (m *memSnapshotStorage) Load(_ context.Context) (*snapshot.Snapshot, error)
 
 	return &cp, nil
 }
+
+func (m *memSnapshotStorage) LoadAt(ctx context.Context, tickHeight uint64) (*snapshot.Snapshot, error) {
+	if m.snap == nil || m.snap.TickHeight != tickHeight {
+		return nil, snapshot.ErrSnapshotNotFound
+	}
+	return m.Load(ctx)
+}
+
+func (m *memSnapshotStorage) List(_ context.Context) ([]snapshot.Info, error) {
+	if m.snap == nil {
+		return nil, nil
+	}
+	return []snapshot.Info{{TickHeight: m.snap.TickHeight, Timestamp: m.snap.Timestamp}}, nil
+}
+
+func (m *memSnapshotStorage) Delete(_ context.Context, tickHeight uint64) error {
+	if m.snap != nil && m.snap.TickHeight == tickHeight {
+		m.snap = nil
+	}
+	return nil
+}
+
+// Quarantine drops the snapshot like Delete, as DST never inspects quarantined snapshots.
+func (m *memSnapshotStorage) Quarantine(ctx context.Context, tickHeight uint64) error {
+	return m.Delete(ctx, tickHeight)
+}
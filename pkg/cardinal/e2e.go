@@ -196,7 +196,7 @@ func newE2EFixture(t *testing.T, setup E2ESetupFunc) *e2eFixture {
 		w.options.NATSConfig.URL = natsURL
 	}
 	w.options.AuthMode = AuthModeDev
-	w.service = newService(w, w.options.AuthMode, w.options.ArgusAuthURL)
+	w.service = newService(w, w.options)
 	w.events.RegisterHandler(event.KindDefault, w.service.publishDefaultEvent)
 
 	connectAddr := "127.0.0.1:5000"
@@ -0,0 +1,134 @@
+package cardinal
+
+import (
+	"sync"
+
+	"github.com/argus-labs/world-engine/pkg/assert"
+	"github.com/goccy/go-json"
+	"github.com/google/cel-go/cel"
+	"github.com/rotisserie/eris"
+)
+
+// filterCostLimit bounds the work a single filter evaluation can do, so a client can't make the
+// publisher spend unbounded time on one event.
+const filterCostLimit = 10_000
+
+// maxCachedFilters is the number of compiled filters kept for reuse. Filters held by open streams
+// stay valid when the cache is reset.
+const maxCachedFilters = 1024
+
+// eventFilter is a compiled subscription filter. Streams with the same filter expression share an
+// eventFilter, so it is evaluated once per event no matter how many streams use it.
+type eventFilter struct {
+	expr    string
+	program cel.Program
+}
+
+// filterCompiler compiles CEL filter expressions over event fields, and caches them by expression.
+type filterCompiler struct {
+	env     *cel.Env
+	filters map[string]*eventFilter
+	mu      sync.Mutex
+}
+
+func newFilterCompiler() *filterCompiler {
+	// Event fields are decoded from JSON, so numbers are doubles. Comparing them with int literals,
+	// e.g. event.zone_id == 3, needs cross type numeric comparisons.
+	env, err := cel.NewEnv(
+		cel.Variable("event", cel.MapType(cel.StringType, cel.DynType)),
+		cel.CrossTypeNumericComparisons(true),
+	)
+	assert.That(err == nil, "static CEL environment should be valid")
+
+	return &filterCompiler{
+		env:     env,
+		filters: make(map[string]*eventFilter),
+	}
+}
+
+// compile returns the compiled filter for expr. It returns nil for an empty expression, which
+// matches every event.
+func (c *filterCompiler) compile(expr string) (*eventFilter, error) {
+	if expr == "" {
+		return nil, nil //nolint:nilnil // A nil filter means no filter
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if filter, ok := c.filters[expr]; ok {
+		return filter, nil
+	}
+
+	ast, issues := c.env.Compile(expr)
+	if issues != nil && issues.Err() != nil {
+		return nil, eris.Wrapf(issues.Err(), "invalid filter %q", expr)
+	}
+	if !ast.OutputType().IsAssignableType(cel.BoolType) {
+		return nil, eris.Errorf("filter %q must evaluate to a bool, got %s", expr, ast.OutputType())
+	}
+	program, err := c.env.Program(ast, cel.CostLimit(filterCostLimit))
+	if err != nil {
+		return nil, eris.Wrapf(err, "failed to build filter %q", expr)
+	}
+
+	if len(c.filters) >= maxCachedFilters {
+		c.filters = make(map[string]*eventFilter)
+	}
+	filter := &eventFilter{expr: expr, program: program}
+	c.filters[expr] = filter
+	return filter, nil
+}
+
+// eventMatch matches one event against stream subscriptions. The event's fields are only decoded if
+// a subscription has a filter, and each distinct filter is evaluated at most once.
+type eventMatch struct {
+	name    string
+	payload any                   // The event payload, decoded into fields on first use
+	fields  map[string]any        // The event's fields, by their JSON names
+	results map[*eventFilter]bool // Filter -> whether the event passed it
+}
+
+func newEventMatch(name string, payload any) *eventMatch {
+	return &eventMatch{name: name, payload: payload}
+}
+
+// passes reports whether the event passes filter. A nil filter passes every event. Events that
+// can't be decoded or fail to evaluate, e.g. because they don't have a field the filter uses, don't
+// pass.
+func (m *eventMatch) passes(filter *eventFilter) bool {
+	if filter == nil {
+		return true
+	}
+	if result, ok := m.results[filter]; ok {
+		return result
+	}
+	if m.results == nil {
+		m.results = make(map[*eventFilter]bool)
+	}
+
+	result := false
+	if fields := m.decode(); fields != nil {
+		out, _, err := filter.program.Eval(map[string]any{"event": fields})
+		if err == nil {
+			passed, ok := out.Value().(bool)
+			result = ok && passed
+		}
+	}
+	m.results[filter] = result
+	return result
+}
+
+// decode returns the event's fields, or nil if the payload isn't an object.
+func (m *eventMatch) decode() map[string]any {
+	if m.fields != nil || m.payload == nil {
+		return m.fields
+	}
+
+	data, err := json.Marshal(m.payload)
+	if err == nil {
+		_ = json.Unmarshal(data, &m.fields)
+	}
+	m.payload = nil // Don't retry if the payload can't be decoded
+	return m.fields
+}
@@ -0,0 +1,190 @@
+package cardinal
+
+import (
+	"sync"
+	"time"
+
+	iscv1 "github.com/argus-labs/world-engine/proto/gen/go/worldengine/isc/v1"
+)
+
+// historyEntry is an event that has been published to event streams.
+type historyEntry struct {
+	seq       uint64       // Sequence number of the event
+	recipient string       // Recipient of a targeted event, empty for broadcast events
+	event     *iscv1.Event // The serialized event
+	payload   any          // The event payload, for evaluating subscription filters on replay
+	position  *Position    // Position of an area-of-interest broadcast, nil otherwise
+	tick      Tick         // The tick the event was emitted in
+	expiresAt time.Time    // When a targeted event is evicted, zero for broadcast events
+}
+
+// maxEvictedRecipients is the number of recipients whose latest evicted targeted event the history
+// remembers. Beyond it, the records are folded into the broadcast eviction, which makes older cursors
+// of every recipient truncated rather than letting a recipient miss events silently.
+const maxEvictedRecipients = 1 << 12
+
+// eventHistory retains recently published events so clients can resume an event stream without
+// missing events. Every event gets a monotonically increasing sequence number. The latest broadcast
+// events are kept in a ring buffer of fixed capacity, and targeted events are kept per recipient
+// until their TTL expires, so they survive the recipient not having an open stream.
+//
+// The history is in-memory only. Sequence numbers continue from a start chosen when the shard starts,
+// so cursors from before a restart are older than every retained event and are reported as truncated.
+type eventHistory struct {
+	lastSeq   uint64                    // Sequence number of the latest event
+	ring      []historyEntry            // Broadcast events ring buffer
+	head      int                       // Index of the oldest broadcast event in ring
+	count     int                       // Number of broadcast events in ring
+	evicted   uint64                    // Sequence number of the latest evicted event visible to everyone
+	targeted  map[string][]historyEntry // Recipient -> targeted events in sequence order
+	dropped   map[string]uint64         // Recipient -> sequence number of its latest evicted targeted event
+	expiry    []historyEntry            // Targeted events in sequence (and so expiry) order
+	ttl       time.Duration             // How long targeted events are retained for
+	perTarget int                       // Max number of targeted events retained per recipient
+	mu        sync.Mutex
+}
+
+// newEventHistory creates an event history that keeps the latest capacity broadcast events, and up
+// to capacity targeted events per recipient for ttl. The first event's sequence number is start+1.
+func newEventHistory(capacity int, ttl time.Duration, start uint64) *eventHistory {
+	return &eventHistory{
+		lastSeq:   start,
+		ring:      make([]historyEntry, capacity),
+		evicted:   start, // Events up to start were published before the shard started
+		targeted:  make(map[string][]historyEntry),
+		dropped:   make(map[string]uint64),
+		expiry:    make([]historyEntry, 0),
+		ttl:       ttl,
+		perTarget: capacity,
+	}
+}
+
+// append records an event published at now and returns its sequence number. The entry's recipient
+// is empty for broadcast events, and its sequence number and expiry are set by the history.
+func (h *eventHistory) append(entry historyEntry, now time.Time) uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.expire(now)
+
+	h.lastSeq++
+	entry.seq = h.lastSeq
+	recipient := entry.recipient
+
+	if recipient == "" {
+		if len(h.ring) == 0 {
+			h.evicted = entry.seq
+			return entry.seq
+		}
+		if h.count == len(h.ring) {
+			h.evicted = h.ring[h.head].seq
+			h.ring[h.head] = entry
+			h.head = (h.head + 1) % len(h.ring)
+		} else {
+			h.ring[(h.head+h.count)%len(h.ring)] = entry
+			h.count++
+		}
+		return entry.seq
+	}
+
+	entry.expiresAt = now.Add(h.ttl)
+	entries := append(h.targeted[recipient], entry)
+	if len(entries) > h.perTarget {
+		h.drop(recipient, entries[len(entries)-h.perTarget-1].seq)
+		entries = entries[len(entries)-h.perTarget:]
+	}
+	if len(entries) == 0 {
+		delete(h.targeted, recipient)
+	} else {
+		h.targeted[recipient] = entries
+	}
+	h.expiry = append(h.expiry, entry)
+	return entry.seq
+}
+
+// since returns the events visible to recipient with a sequence number greater than cursor, in
+// sequence order. truncated is true if events after cursor visible to recipient were already evicted
+// and can't be replayed, or if cursor is newer than the latest event, i.e. it is from before a restart.
+func (h *eventHistory) since(recipient string, cursor uint64, now time.Time) ([]historyEntry, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.expire(now)
+
+	targeted := h.targeted[recipient]
+	entries := make([]historyEntry, 0)
+	i, j := 0, 0
+	// Merge broadcast and targeted events, which are both in sequence order.
+	for i < h.count || j < len(targeted) {
+		var next historyEntry
+		if j == len(targeted) || (i < h.count && h.ring[(h.head+i)%len(h.ring)].seq < targeted[j].seq) {
+			next = h.ring[(h.head+i)%len(h.ring)]
+			i++
+		} else {
+			next = targeted[j]
+			j++
+		}
+		if next.seq > cursor {
+			entries = append(entries, next)
+		}
+	}
+	truncated := cursor < h.evicted || cursor < h.dropped[recipient] || cursor > h.lastSeq
+	return entries, truncated
+}
+
+// latest returns the sequence number of the latest event.
+func (h *eventHistory) latest() uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.lastSeq
+}
+
+// expire evicts targeted events whose TTL has passed. Expects the caller to hold the lock.
+func (h *eventHistory) expire(now time.Time) {
+	count := 0
+	for count < len(h.expiry) && !h.expiry[count].expiresAt.After(now) {
+		expired := h.expiry[count]
+		count++
+		h.drop(expired.recipient, expired.seq)
+
+		// The recipient's oldest event is the expired one, unless it was already dropped to stay
+		// within the per-recipient limit.
+		entries := h.targeted[expired.recipient]
+		if len(entries) > 0 && entries[0].seq == expired.seq {
+			entries = entries[1:]
+		}
+		if len(entries) == 0 {
+			delete(h.targeted, expired.recipient)
+		} else {
+			h.targeted[expired.recipient] = entries
+		}
+	}
+	h.expiry = h.expiry[count:]
+}
+
+// drop records that the targeted event seq of recipient was evicted. Expects the caller to hold the
+// lock.
+func (h *eventHistory) drop(recipient string, seq uint64) {
+	if seq <= h.evicted {
+		return
+	}
+	h.dropped[recipient] = max(h.dropped[recipient], seq)
+	if len(h.dropped) <= maxEvictedRecipients {
+		return
+	}
+
+	// Records older than the latest evicted broadcast event are redundant. If that isn't enough, fold
+	// all records into it.
+	latest := h.evicted
+	for r, last := range h.dropped {
+		latest = max(latest, last)
+		if last <= h.evicted {
+			delete(h.dropped, r)
+		}
+	}
+	if len(h.dropped) > maxEvictedRecipients {
+		h.evicted = latest
+		clear(h.dropped)
+	}
+}
@@ -0,0 +1,119 @@
+package cardinal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/argus-labs/world-engine/pkg/testutils"
+	iscv1 "github.com/argus-labs/world-engine/proto/gen/go/worldengine/isc/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// -------------------------------------------------------------------------------------------------
+// Model-based fuzzing event history
+// -------------------------------------------------------------------------------------------------
+// This test verifies that the event history assigns increasing sequence numbers, keeps the latest
+// broadcast events up to its capacity, keeps targeted events per recipient until their TTL expires,
+// and reports when a resume cursor is older than the retained events of the recipient, or newer than
+// the latest event. The model is the full list of appended events with the time they were appended at.
+// -------------------------------------------------------------------------------------------------
+
+func TestEventHistory_ModelFuzz(t *testing.T) {
+	t.Parallel()
+	prng := testutils.NewRand(t)
+
+	const (
+		opsMax   = 1 << 14 // 16_384 iterations
+		opAppend = "append"
+		opSince  = "since"
+		opSleep  = "sleep"
+	)
+
+	capacity := prng.IntN(64) + 1
+	ttl := time.Duration(prng.IntN(100)+1) * time.Second
+	start := uint64(prng.IntN(1000))
+	impl := newEventHistory(capacity, ttl, start)
+
+	type entry struct {
+		seq       uint64
+		recipient string
+		at        time.Time
+	}
+	var model []entry
+	recipients := []string{"", "alice", "bob", "carol"}
+	now := time.Unix(0, 0)
+
+	operations := []string{opAppend, opSince, opSleep}
+	weights := testutils.RandOpWeights(prng, operations)
+
+	for range opsMax {
+		op := testutils.RandWeightedOp(prng, weights)
+		switch op {
+		case opAppend:
+			recipient := recipients[prng.IntN(len(recipients))]
+			seq := impl.append(historyEntry{recipient: recipient, event: &iscv1.Event{Name: testutils.RandString(prng, 8)}}, now)
+
+			// Property: sequence numbers start after start and increase by 1 with every event.
+			assert.Equal(t, start+uint64(len(model)+1), seq, "sequence number mismatch")
+			assert.Equal(t, seq, impl.latest(), "latest sequence mismatch")
+			model = append(model, entry{seq: seq, recipient: recipient, at: now})
+
+		case opSince:
+			recipient := recipients[1+prng.IntN(len(recipients)-1)]
+			cursor := uint64(prng.IntN(int(start) + len(model) + 2))
+			got, truncated := impl.since(recipient, cursor, now)
+
+			// Model: the latest capacity broadcast events, and the recipient's latest capacity
+			// targeted events that haven't expired.
+			var expected []uint64
+			evicted := start
+			broadcasts, targeted := 0, 0
+			for i := len(model) - 1; i >= 0; i-- {
+				e := model[i]
+				switch e.recipient {
+				case "":
+					broadcasts++
+					if broadcasts > capacity {
+						evicted = max(evicted, e.seq)
+						continue
+					}
+				case recipient:
+					targeted++
+					if targeted > capacity || !e.at.Add(ttl).After(now) {
+						evicted = max(evicted, e.seq)
+						continue
+					}
+				default:
+					continue
+				}
+				if e.seq > cursor {
+					expected = append([]uint64{e.seq}, expected...)
+				}
+			}
+
+			gotSeqs := make([]uint64, 0, len(got))
+			for _, e := range got {
+				gotSeqs = append(gotSeqs, e.seq)
+				// Property: only broadcast events and the recipient's own events are visible.
+				require.Contains(t, []string{"", recipient}, e.recipient, "saw another recipient's event")
+			}
+			// Property: the retained events after the cursor are returned in sequence order.
+			if len(expected) == 0 {
+				assert.Empty(t, gotSeqs, "unexpected events after cursor %d", cursor)
+			} else {
+				assert.Equal(t, expected, gotSeqs, "events after cursor %d mismatch", cursor)
+			}
+			// Property: truncated iff events after the cursor were evicted, or the cursor is from the
+			// future, e.g. from before a restart.
+			latest := start + uint64(len(model))
+			assert.Equal(t, cursor < evicted || cursor > latest, truncated, "truncated mismatch for cursor %d", cursor)
+
+		case opSleep:
+			now = now.Add(time.Duration(prng.IntN(int(ttl/time.Millisecond))) * time.Millisecond)
+
+		default:
+			panic("unreachable")
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package cardinal
+
+import (
+	"github.com/argus-labs/world-engine/pkg/cardinal/internal/ecs"
+	"github.com/argus-labs/world-engine/pkg/cardinal/internal/interest"
+)
+
+// Position is a point in the world. Events broadcast at a position with WithEvent.BroadcastAt are
+// only delivered to the clients whose interest region contains it.
+type Position = interest.Position
+
+// Positioned is a component that places its entity in the world. The position of the entity a
+// persona controls defines the persona's interest region, see WithInterest.
+type Positioned interface {
+	ecs.Component
+	WorldPosition() Position
+}
+
+// interestControl is the entity a persona controls, and the radius of its interest region.
+type interestControl struct {
+	entity EntityID
+	radius float64
+}
+
+// interestManager maintains the interest region of every persona that controls an entity. The
+// regions are recomputed from the controlled entities' positions every tick, after the systems run
+// and before events are dispatched, so area-of-interest broadcasts are delivered based on where the
+// entities are at the end of the tick.
+type interestManager struct {
+	grid      *interest.Grid                  // Interest regions by persona, read by the service
+	controls  map[string]interestControl      // Persona -> controlled entity, only used by the tick
+	position  func(EntityID) (Position, bool) // Reads an entity's position, nil without WithInterest
+	component string                          // Name of the position component
+}
+
+func newInterestManager(cellSize float64) *interestManager {
+	return &interestManager{
+		grid:     interest.NewGrid(cellSize),
+		controls: make(map[string]interestControl),
+	}
+}
+
+// update recomputes the interest regions from the controlled entities' positions. Personas whose
+// entity no longer exists or has no position don't have a region until it does again.
+func (m *interestManager) update() {
+	if m.position == nil {
+		return
+	}
+	for persona, control := range m.controls {
+		pos, ok := m.position(control.entity)
+		if !ok || !interest.ValidPosition(pos) {
+			m.grid.Remove(persona)
+			continue
+		}
+		m.grid.Set(persona, interest.Region{Center: pos, Radius: control.radius})
+	}
+}
+
+// reset releases the controlled entities of all personas.
+func (m *interestManager) reset() {
+	clear(m.controls)
+	m.grid.Clear()
+}
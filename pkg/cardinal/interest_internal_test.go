@@ -0,0 +1,190 @@
+package cardinal
+
+import (
+	"context"
+	"math"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/argus-labs/world-engine/pkg/cardinal/internal/event"
+	"github.com/argus-labs/world-engine/pkg/testutils"
+	cardinalv1 "github.com/argus-labs/world-engine/proto/gen/go/worldengine/cardinal/v1"
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// -------------------------------------------------------------------------------------------------
+// Area-of-interest smoke tests
+// -------------------------------------------------------------------------------------------------
+// Verifies that events broadcast at a position are only delivered to the users whose controlled
+// entity is within their interest radius, that interest regions follow the entities every tick, and
+// that only events in the user's current region are replayed on resume.
+// -------------------------------------------------------------------------------------------------
+
+func TestWithEvent_BroadcastAt(t *testing.T) {
+	t.Parallel()
+
+	t.Run("delivered to interested users", func(t *testing.T) {
+		t.Parallel()
+		prng := testutils.NewRand(t)
+		fixture := newInterestFixture(t, prng)
+
+		radius := prng.Float64()*100 + 1
+		origin := Position{X: (prng.Float64() - 0.5) * 1000, Y: (prng.Float64() - 0.5) * 1000}
+		near := fixture.spawn("near", Position{X: origin.X + radius/2, Y: origin.Y}, radius)
+		fixture.spawn("far", Position{X: origin.X + radius*3, Y: origin.Y}, radius)
+		streams := map[string]*streamSubscriber{
+			"near": fixture.addTestSubscriber(t, "near", "stream", 8, OverflowDropOldest),
+			"far":  fixture.addTestSubscriber(t, "far", "stream", 8, OverflowDropOldest),
+			"none": fixture.addTestSubscriber(t, "none", "stream", 8, OverflowDropOldest),
+		}
+
+		fixture.tick(origin)
+		assert.Len(t, streams["near"].queue, 1)
+		assert.Empty(t, streams["far"].queue)
+		assert.Empty(t, streams["none"].queue, "users without a controlled entity shouldn't receive the event")
+
+		// The region follows the entity when it moves.
+		player, err := fixture.state.Players.GetByID(near)
+		require.NoError(t, err)
+		player.Transform.Set(testTransform{X: origin.X - radius*3, Y: origin.Y})
+		fixture.tick(origin)
+		assert.Len(t, streams["near"].queue, 1)
+
+		// Broadcasts without a position still go to everyone.
+		fixture.state.Events.Broadcast(testutils.SimpleEvent{})
+		fixture.tick(origin)
+		assert.Len(t, streams["near"].queue, 2)
+		assert.Len(t, streams["far"].queue, 1)
+		assert.Len(t, streams["none"].queue, 1)
+	})
+
+	t.Run("released and destroyed entities", func(t *testing.T) {
+		t.Parallel()
+		prng := testutils.NewRand(t)
+		fixture := newInterestFixture(t, prng)
+
+		pos := Position{X: prng.Float64(), Y: prng.Float64()}
+		fixture.spawn("released", pos, 10)
+		destroyed := fixture.spawn("destroyed", pos, 10)
+		released := fixture.addTestSubscriber(t, "released", "stream", 8, OverflowDropOldest)
+		gone := fixture.addTestSubscriber(t, "destroyed", "stream", 8, OverflowDropOldest)
+
+		fixture.state.Interest.Release("released")
+		require.True(t, fixture.state.Players.Destroy(destroyed))
+		fixture.tick(pos)
+		assert.Empty(t, released.queue)
+		assert.Empty(t, gone.queue)
+	})
+
+	t.Run("invalid positions dropped", func(t *testing.T) {
+		t.Parallel()
+		prng := testutils.NewRand(t)
+		fixture := newInterestFixture(t, prng)
+
+		fixture.spawn("player", Position{}, 10)
+		stream := fixture.addTestSubscriber(t, "player", "stream", 8, OverflowDropOldest)
+
+		fixture.tick(Position{X: math.NaN()})
+		fixture.tick(Position{Y: math.Inf(1)})
+		fixture.tick(Position{X: math.MaxFloat64})
+		assert.Empty(t, stream.queue)
+		assert.Zero(t, fixture.svc.history.latest(), "dropped events shouldn't be recorded")
+	})
+
+	t.Run("out of range radii", func(t *testing.T) {
+		t.Parallel()
+		prng := testutils.NewRand(t)
+		fixture := newInterestFixture(t, prng)
+
+		maxRadius := fixture.world.interest.grid.MaxRadius()
+		origin := Position{X: prng.Float64(), Y: prng.Float64()}
+		fixture.spawn("capped", origin, maxRadius*(prng.Float64()*10+2))
+		fixture.spawn("zero", origin, 0)
+		capped := fixture.addTestSubscriber(t, "capped", "stream", 8, OverflowDropOldest)
+		zero := fixture.addTestSubscriber(t, "zero", "stream", 8, OverflowDropOldest)
+
+		fixture.tick(Position{X: origin.X + maxRadius/2, Y: origin.Y})
+		fixture.tick(Position{X: origin.X + maxRadius*3, Y: origin.Y})
+		assert.Len(t, capped.queue, 1, "radius should be capped at the max radius")
+		assert.Empty(t, zero.queue, "a radius that isn't positive shouldn't give an interest region")
+	})
+
+	t.Run("replay only in region", func(t *testing.T) {
+		t.Parallel()
+		prng := testutils.NewRand(t)
+		fixture := newInterestFixture(t, prng)
+
+		origin := Position{X: prng.Float64(), Y: prng.Float64()}
+		fixture.spawn("player", origin, 10)
+		fixture.world.interest.update()
+
+		fixture.tick(origin)
+		fixture.tick(Position{X: origin.X + 100, Y: origin.Y})
+
+		_, replay, _, err := fixture.svc.addSubscriber(context.Background(), &User{ID: "player"}, nil,
+			&cardinalv1.StartEventStreamRequest{ResumeAfter: new(uint64)}, map[string]*eventFilter{"*": nil})
+		require.NoError(t, err)
+		require.Len(t, replay, 1)
+		assert.Equal(t, uint64(1), replay[0].seq)
+	})
+}
+
+type interestFixture struct {
+	*serviceFixture
+	t     *testing.T
+	state *interestSystemState
+}
+
+type interestSystemState struct {
+	BaseSystemState
+	Interest WithInterest[testTransform]
+	Events   WithEvent[testutils.SimpleEvent]
+	Players  Contains[struct{ Transform Ref[testTransform] }]
+}
+
+func newInterestFixture(t *testing.T, prng *rand.Rand) *interestFixture {
+	t.Helper()
+
+	fixture := newServiceFixture(t, prng, false)
+	fixture.world.events.RegisterHandler(event.KindDefault, fixture.svc.publishDefaultEvent)
+
+	state := &interestSystemState{}
+	require.NoError(t, initSystemFields(state, fixture.world))
+	return &interestFixture{serviceFixture: fixture, t: t, state: state}
+}
+
+// spawn creates an entity at pos controlled by persona.
+func (f *interestFixture) spawn(persona string, pos Position, radius float64) EntityID {
+	eid, player := f.state.Players.Create()
+	player.Transform.Set(testTransform{X: pos.X, Y: pos.Y})
+	f.state.Interest.Control(persona, eid, radius)
+	return eid
+}
+
+// tick broadcasts an event at pos, and then updates the interest regions and dispatches the events
+// like the end of a tick.
+func (f *interestFixture) tick(pos Position) {
+	f.state.Events.BroadcastAt(pos, testutils.SimpleEvent{})
+	f.world.interest.update()
+	require.NoError(f.t, f.world.events.Dispatch())
+}
+
+// testTransform is a component with a position for interest management.
+type testTransform struct {
+	X float64
+	Y float64
+}
+
+func (testTransform) Name() string { return "test_transform" }
+
+func (t testTransform) WorldPosition() Position { return Position{X: t.X, Y: t.Y} }
+
+func (t testTransform) MarshalWire() ([]byte, error) { return json.Marshal(t) }
+
+func (testTransform) UnmarshalWire(b []byte) (any, error) {
+	var t testTransform
+	err := json.Unmarshal(b, &t)
+	return t, err
+}
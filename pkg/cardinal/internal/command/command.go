@@ -6,6 +6,7 @@ import (
 	"github.com/argus-labs/world-engine/pkg/assert"
 	"github.com/argus-labs/world-engine/pkg/cardinal/internal/schema"
 	"github.com/argus-labs/world-engine/pkg/micro"
+	cardinalv1 "github.com/argus-labs/world-engine/proto/gen/go/worldengine/cardinal/v1"
 	iscv1 "github.com/argus-labs/world-engine/proto/gen/go/worldengine/isc/v1"
 	"github.com/rotisserie/eris"
 )
@@ -16,6 +17,33 @@ type Command struct {
 	Address *micro.ServiceAddress // Service address this command is sent to
 	Persona string                // Sender's persona
 	Payload Payload               // The command payload itself
+	Receipt ReceiptID             // ID of the command's receipt, 0 if it has none
+	ReplyID ReplyID               // ID of the request waiting for a reply, 0 if none
+}
+
+// ToProto serializes the command back to the form it was enqueued in. The engine-assigned receipt and
+// reply IDs aren't included.
+func (c *Command) ToProto() (*iscv1.Command, error) {
+	payload, err := c.Payload.MarshalWire()
+	if err != nil {
+		return nil, eris.Wrapf(err, "failed to encode command payload for %q", c.Name)
+	}
+	return &iscv1.Command{
+		Name:    c.Name,
+		Address: c.Address,
+		Persona: &iscv1.Persona{Id: c.Persona},
+		Payload: payload,
+	}, nil
+}
+
+// ReplyID identifies a request waiting for a reply to its command, e.g. SendCommandWithReply. Reply
+// IDs are assigned by the caller of EnqueueWithReply and carried on the command as is.
+type ReplyID = uint64
+
+// Metadata is the engine-assigned data attached to a command when it's enqueued.
+type Metadata struct {
+	Receipt ReceiptID // ID of the command's receipt, 0 if it has none
+	ReplyID ReplyID   // ID of the request waiting for a reply, 0 if none
 }
 
 // Payload is the interface all command payloads must implement.
@@ -43,6 +71,8 @@ type Manager struct {
 	catalog  map[string]ID // Command name -> command ID
 	queues   []Queue       // queue for incoming commands, indexed by command ID
 	commands [][]Command   // read-only commands slice used by ECS systems, indexed by command ID
+	schedule *schedule     // Commands waiting to be enqueued at a future tick
+	receipts *receipts     // Receipts of commands sent with EnqueueWithReceipt
 }
 
 // NewManager creates a new command manager.
@@ -54,6 +84,8 @@ func NewManager() Manager {
 		catalog:  make(map[string]ID),
 		queues:   make([]Queue, 0),
 		commands: make([][]Command, 0),
+		schedule: newSchedule(),
+		receipts: newReceipts(),
 	}
 }
 
@@ -105,7 +137,86 @@ func (m *Manager) Enqueue(command *iscv1.Command) error {
 	if !exists {
 		return eris.Errorf("unregistered command: %s", name)
 	}
-	return m.queues[id].Enqueue(command)
+	return m.queues[id].Enqueue(command, Metadata{})
+}
+
+// EnqueueWithReceipt is like Enqueue, but also issues a receipt for the command, which tracks whether
+// it has been processed. Returns the receipt ID and the height of the tick that will collect the
+// command.
+func (m *Manager) EnqueueWithReceipt(command *iscv1.Command) (ReceiptID, uint64, error) {
+	return m.EnqueueWithReply(command, 0)
+}
+
+// EnqueueWithReply is like EnqueueWithReceipt, but also attaches the ID of the request waiting for a
+// reply to the command, so systems can route their reply to it.
+func (m *Manager) EnqueueWithReply(command *iscv1.Command, replyID ReplyID) (ReceiptID, uint64, error) {
+	assert.That(command.GetName() != "", "command has empty name")
+	assert.That(command.GetAddress() != nil, "command has nil address")
+	assert.That(command.GetPersona() != nil, "command has nil persona")
+
+	name := command.GetName()
+	id, exists := m.catalog[name]
+	if !exists {
+		return 0, 0, eris.Errorf("unregistered command: %s", name)
+	}
+
+	m.receipts.mu.Lock()
+	defer m.receipts.mu.Unlock()
+
+	return m.receipts.issue(command, func(receipt ReceiptID) error {
+		return m.queues[id].Enqueue(command, Metadata{Receipt: receipt, ReplyID: replyID})
+	})
+}
+
+// EnqueueBatchWithReceipt enqueues a batch of commands with receipts atomically: either every command
+// is enqueued and collected by the same Drain, or none are. All commands are validated first; if any
+// is invalid, nothing is enqueued and errs holds an error at the index of each invalid command (nil for
+// valid ones). Otherwise errs is nil, and the receipt IDs are returned in command order along with the
+// height of the tick that will collect the commands.
+func (m *Manager) EnqueueBatchWithReceipt(commands []*iscv1.Command) ([]ReceiptID, uint64, []error) {
+	ids := make([]ID, len(commands))
+	errs := make([]error, len(commands))
+	valid := true
+	for i, command := range commands {
+		ids[i], errs[i] = m.check(command)
+		valid = valid && errs[i] == nil
+	}
+	if !valid {
+		return nil, 0, errs
+	}
+
+	m.receipts.mu.Lock()
+	defer m.receipts.mu.Unlock()
+
+	receipts := make([]ReceiptID, len(commands))
+	var tick uint64
+	for i, command := range commands {
+		receipt, expected, err := m.receipts.issue(command, func(receipt ReceiptID) error {
+			return m.queues[ids[i]].Enqueue(command, Metadata{Receipt: receipt})
+		})
+		// The payload decoded in check, so enqueueing can't fail and leave the batch half enqueued.
+		assert.That(err == nil, "failed to enqueue validated command: %v", err)
+		receipts[i] = receipt
+		tick = expected
+	}
+	return receipts, tick, nil
+}
+
+// check verifies that a command is registered and its payload decodes, for callers that can't let a
+// malformed command fail later in Enqueue. Returns the command's ID.
+func (m *Manager) check(command *iscv1.Command) (ID, error) {
+	assert.That(command.GetName() != "", "command has empty name")
+	assert.That(command.GetAddress() != nil, "command has nil address")
+	assert.That(command.GetPersona() != nil, "command has nil persona")
+
+	id, exists := m.catalog[command.GetName()]
+	if !exists {
+		return 0, eris.Errorf("unregistered command: %s", command.GetName())
+	}
+	if _, err := m.queues[id].Zero().UnmarshalWire(command.GetPayload()); err != nil {
+		return 0, eris.Wrapf(err, "failed to decode command payload for %q", command.GetName())
+	}
+	return id, nil
 }
 
 // Get retrieves a slice of commands given the command ID. The ID is returned from Register, and
@@ -128,20 +239,194 @@ func (m *Manager) Drain() []Command {
 		m.commands[id] = m.commands[id][:0]
 	}
 
+	// Hold the receipts lock so no command with a receipt is enqueued between the queues being
+	// drained and the tick advancing.
+	m.receipts.mu.Lock()
+	defer m.receipts.mu.Unlock()
+
 	all := make([]Command, 0, len(m.commands)*initialCommandBufferCapacity)
 	for id, queue := range m.queues {
 		queue.Drain(&m.commands[id])
 		all = append(all, m.commands[id]...)
 	}
+	m.receipts.collect(all)
 	return all
 }
 
-// Clear discards all pending commands from both queues and buffers.
+// Clear discards all pending commands from the queues, buffers, and schedule, and all receipts.
 func (m *Manager) Clear() {
 	for id := range m.queues {
 		m.queues[id].Drain(&m.commands[id])
 		m.commands[id] = m.commands[id][:0]
 	}
+	m.schedule.clear()
+	m.receipts.reset(0)
+}
+
+// SetNextTick sets the height of the next tick to be drained, which is the tick recorded in new
+// receipts. It is expected to be called when the world's tick height jumps, e.g. after a restore.
+func (m *Manager) SetNextTick(tick uint64) {
+	m.receipts.reset(tick)
+}
+
+// -------------------------------------------------------------------------------------------------
+// Scheduled commands
+// -------------------------------------------------------------------------------------------------
+
+// Schedule stores a command to be enqueued at the first released tick with a height greater than or
+// equal to tick. Returns the handle of the scheduled command and the tick it is scheduled at, which
+// is later than the requested tick if that tick has already been released. Like Enqueue, Schedule
+// expects callers to validate the command. The payload is decoded here so a malformed command is
+// rejected now instead of when it is released.
+//
+// Returns ErrScheduleTooFar if tick is more than MaxScheduleTicks after the next tick.
+func (m *Manager) Schedule(command *iscv1.Command, tick uint64) (ScheduleHandle, uint64, error) {
+	if _, err := m.check(command); err != nil {
+		return 0, 0, err
+	}
+	return m.schedule.add(command, tick, false)
+}
+
+// ScheduleIn stores a command to be enqueued the given number of ticks after the last released
+// tick. A delay of 0 or 1 enqueues the command in the next tick. See Schedule.
+func (m *Manager) ScheduleIn(command *iscv1.Command, ticks uint64) (ScheduleHandle, uint64, error) {
+	if _, err := m.check(command); err != nil {
+		return 0, 0, err
+	}
+	return m.schedule.addIn(command, ticks, false)
+}
+
+// ScheduleOwned is like Schedule, for commands scheduled by clients: it returns ErrScheduleQuota if
+// the command's persona already has MaxPendingPerPersona pending commands.
+func (m *Manager) ScheduleOwned(command *iscv1.Command, tick uint64) (ScheduleHandle, uint64, error) {
+	if _, err := m.check(command); err != nil {
+		return 0, 0, err
+	}
+	return m.schedule.add(command, tick, true)
+}
+
+// ScheduleOwnedIn is like ScheduleIn, with the quota of ScheduleOwned.
+func (m *Manager) ScheduleOwnedIn(command *iscv1.Command, ticks uint64) (ScheduleHandle, uint64, error) {
+	if _, err := m.check(command); err != nil {
+		return 0, 0, err
+	}
+	return m.schedule.addIn(command, ticks, true)
+}
+
+// Cancel removes a scheduled command before it is enqueued. Returns ErrScheduledNotFound if the
+// handle doesn't refer to a pending command.
+func (m *Manager) Cancel(handle ScheduleHandle) error {
+	if !m.schedule.remove(handle, "") {
+		return eris.Wrapf(ErrScheduledNotFound, "handle %d", handle)
+	}
+	return nil
+}
+
+// CancelOwned is like Cancel, but only removes the scheduled command if it was sent by persona.
+// Commands scheduled by other personas are reported as not found.
+func (m *Manager) CancelOwned(handle ScheduleHandle, persona string) error {
+	assert.That(persona != "", "persona must not be empty (use Cancel)")
+	if !m.schedule.remove(handle, persona) {
+		return eris.Wrapf(ErrScheduledNotFound, "handle %d", handle)
+	}
+	return nil
+}
+
+// Release enqueues every scheduled command due at or before tick into its queue, in scheduled
+// order, so they are collected by the following Drain. Release is expected to be called once at
+// the start of each tick, before Drain. Commands that fail to enqueue are dropped and returned as
+// an error; they were validated when scheduled, so this only happens if a registration changed.
+func (m *Manager) Release(tick uint64) error {
+	var errs []error
+	for _, command := range m.schedule.release(tick) {
+		if err := m.Enqueue(command); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return eris.Errorf("failed to release %d scheduled command(s): %v", len(errs), errs)
+	}
+	return nil
+}
+
+// Skip removes the scheduled commands due at or before tick without enqueueing them. It is used in
+// place of Release when replaying recorded ticks, whose recorded commands include the released ones.
+func (m *Manager) Skip(tick uint64) {
+	m.schedule.release(tick)
+}
+
+// ScheduleChanges returns the changes clients made to the schedule with ScheduleOwned,
+// ScheduleOwnedIn, and CancelOwned since the last call, in the order they were made. They're recorded
+// in the tick log, so replays can make them again with ApplyScheduleChanges.
+func (m *Manager) ScheduleChanges() []*iscv1.ScheduleChange {
+	return m.schedule.drainChanges()
+}
+
+// ApplyScheduleChanges makes recorded changes to the schedule again, with their recorded handles and
+// ticks. Returns ErrScheduledNotFound if a cancelled command isn't pending.
+func (m *Manager) ApplyScheduleChanges(changes []*iscv1.ScheduleChange) error {
+	for _, change := range changes {
+		if !m.schedule.apply(change) {
+			return eris.Wrapf(ErrScheduledNotFound, "handle %d", change.GetHandle())
+		}
+	}
+	return nil
+}
+
+// ScheduleHash returns a hash of the schedule: the pending scheduled commands, their ticks and handles,
+// and the next handle. Equal schedules have equal hashes, as ScheduleToProto would return equal
+// messages for them.
+func (m *Manager) ScheduleHash() uint64 {
+	return m.schedule.hash()
+}
+
+// ScheduleToProto converts the pending scheduled commands to a protobuf message for serialization.
+func (m *Manager) ScheduleToProto() *cardinalv1.CommandSchedule {
+	return m.schedule.toProto()
+}
+
+// ScheduleFromProto replaces the pending scheduled commands with the ones in the protobuf message.
+// nextTick is the height of the next tick that will be released.
+func (m *Manager) ScheduleFromProto(pb *cardinalv1.CommandSchedule, nextTick uint64) error {
+	return m.schedule.fromProto(pb, nextTick)
+}
+
+// -------------------------------------------------------------------------------------------------
+// Receipts
+// -------------------------------------------------------------------------------------------------
+
+// Settle marks the commands collected by the last Drain as processed, except the ones a system
+// reported as failed. Settle is expected to be called at the end of each tick, after systems run.
+func (m *Manager) Settle() {
+	m.receipts.settle()
+}
+
+// Fail marks the receipt of a command collected in the current tick as failed with reason. It is a
+// no-op for commands without a receipt.
+func (m *Manager) Fail(receipt ReceiptID, reason string) {
+	m.receipts.update(receipt, func(r *Receipt) {
+		r.Status = ReceiptFailed
+		r.Error = reason
+	})
+}
+
+// SetResult records the result a system reported for a command collected in the current tick. It is
+// a no-op for commands without a receipt.
+func (m *Manager) SetResult(receipt ReceiptID, result *iscv1.Event) {
+	m.receipts.update(receipt, func(r *Receipt) {
+		r.Result = result
+	})
+}
+
+// Receipt returns the receipt of a command sent by persona. Receipts of commands sent by other
+// personas are reported as not found.
+func (m *Manager) Receipt(id ReceiptID, persona string) (Receipt, error) {
+	assert.That(persona != "", "persona must not be empty")
+	receipt, ok := m.receipts.get(id, persona)
+	if !ok {
+		return Receipt{}, eris.Wrapf(ErrReceiptNotFound, "receipt %d", id)
+	}
+	return receipt, nil
 }
 
 // -------------------------------------------------------------------------------------------------
@@ -10,7 +10,7 @@ import (
 // Queue defines the interface for command queuing operations.
 // It provides methods to enqueue commands and drain all queued commands.
 type Queue interface {
-	Enqueue(*iscv1.Command) error
+	Enqueue(command *iscv1.Command, meta Metadata) error
 	Drain(target *[]Command)
 	Len() int
 	Zero() Payload
@@ -37,8 +37,9 @@ func NewQueue[T Payload]() Queue {
 
 // Enqueue validates and adds a command to the queue. It performs type checking to ensure the
 // command matches the expected type T, unmarshals the command payload, and appends it to the queue.
-// Returns an error if validation fails or marshaling/unmarshaling operations fail.
-func (q *sliceQueue[T]) Enqueue(cmd *iscv1.Command) error {
+// Returns an error if validation fails or marshaling/unmarshaling operations fail. meta is attached to
+// the queued command as is.
+func (q *sliceQueue[T]) Enqueue(cmd *iscv1.Command, meta Metadata) error {
 	var zero T
 
 	if cmd.GetName() != zero.Name() {
@@ -60,6 +61,8 @@ func (q *sliceQueue[T]) Enqueue(cmd *iscv1.Command) error {
 		Address: cmd.GetAddress(),
 		Persona: cmd.GetPersona().GetId(),
 		Payload: payload,
+		Receipt: meta.Receipt,
+		ReplyID: meta.ReplyID,
 	})
 	q.mu.Unlock()
 	return nil
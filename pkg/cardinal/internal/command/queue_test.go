@@ -59,7 +59,7 @@ func TestQueue_ModelFuzz(t *testing.T) {
 			}
 
 			sizeBefore := impl.Len()
-			err = impl.Enqueue(cmdpb)
+			err = impl.Enqueue(cmdpb, command.Metadata{})
 
 			if corruptName {
 				// Property: enqueue with wrong name must fail.
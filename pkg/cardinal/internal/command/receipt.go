@@ -0,0 +1,168 @@
+package command
+
+import (
+	"sync"
+
+	iscv1 "github.com/argus-labs/world-engine/proto/gen/go/worldengine/isc/v1"
+	"github.com/rotisserie/eris"
+)
+
+// ReceiptID identifies a command receipt. The zero value means the command has no receipt.
+type ReceiptID = uint64
+
+// ReceiptStatus is the processing status of a command with a receipt.
+type ReceiptStatus uint8
+
+const (
+	// ReceiptPending means the command is queued and hasn't been processed yet.
+	ReceiptPending ReceiptStatus = iota + 1
+	// ReceiptProcessed means the command was collected and its tick has completed.
+	ReceiptProcessed
+	// ReceiptFailed means a system reported that it failed to process the command.
+	ReceiptFailed
+)
+
+// ErrReceiptNotFound is returned when a receipt ID doesn't refer to a retained receipt.
+var ErrReceiptNotFound = eris.New("command receipt not found")
+
+// receiptRetention is the number of ticks a settled receipt is kept for before it is evicted.
+const receiptRetention = 1024
+
+// MaxReceipts is the number of settled receipts kept. When more commands with receipts settle within
+// receiptRetention ticks, the oldest receipts are evicted early, so a burst of commands can't grow
+// the store without bound.
+const MaxReceipts = 1 << 16
+
+// Receipt records what happened to a command.
+type Receipt struct {
+	ID         ReceiptID     // The receipt ID
+	Name       string        // The command name
+	Persona    string        // Sender's persona
+	Status     ReceiptStatus // The processing status
+	TickHeight uint64        // The tick the command is (or is expected to be) processed at
+	Error      string        // Failure reason reported by the system, if any
+	Result     *iscv1.Event  // Result reported by the system, if any
+}
+
+// receipts stores the receipts of pending commands and of the last MaxReceipts commands settled in
+// the last receiptRetention ticks. Receipts are in-memory only and aren't persisted in snapshots.
+//
+// The lock is held while a command with a receipt is enqueued and while the queues are drained, so
+// the tick recorded in a receipt is exactly the tick whose Drain collects the command.
+type receipts struct {
+	nextID   ReceiptID              // Next receipt ID to assign
+	nextTick uint64                 // Height of the tick the next Drain collects commands for
+	entries  map[ReceiptID]*Receipt // Receipt ID -> receipt
+	inFlight []ReceiptID            // Receipts of commands drained in the current tick
+	settled  []ReceiptID            // Settled receipts in tick order, used for eviction
+	mu       sync.Mutex
+}
+
+// newReceipts creates a new empty receipt store.
+func newReceipts() *receipts {
+	return &receipts{
+		nextID:   1, // Reserve 0 as the "no receipt" ID
+		entries:  make(map[ReceiptID]*Receipt),
+		inFlight: make([]ReceiptID, 0),
+		settled:  make([]ReceiptID, 0),
+	}
+}
+
+// issue creates a pending receipt for a command and calls enqueue with its ID. The receipt is only
+// recorded if enqueue succeeds. Expects the caller to hold the lock.
+func (r *receipts) issue(command *iscv1.Command, enqueue func(ReceiptID) error) (ReceiptID, uint64, error) {
+	id := r.nextID
+	if err := enqueue(id); err != nil {
+		return 0, 0, err
+	}
+	r.nextID++
+
+	r.entries[id] = &Receipt{
+		ID:         id,
+		Name:       command.GetName(),
+		Persona:    command.GetPersona().GetId(),
+		Status:     ReceiptPending,
+		TickHeight: r.nextTick,
+	}
+	return id, r.nextTick, nil
+}
+
+// collect marks the receipts of drained commands as in flight and advances to the next tick. Expects
+// the caller to hold the lock.
+func (r *receipts) collect(commands []Command) {
+	for _, cmd := range commands {
+		if cmd.Receipt != 0 {
+			r.inFlight = append(r.inFlight, cmd.Receipt)
+		}
+	}
+	r.nextTick++
+}
+
+// settle marks the in-flight receipts as processed, unless a system reported a failure, and evicts
+// receipts settled more than receiptRetention ticks ago, and the oldest ones beyond MaxReceipts.
+func (r *receipts) settle() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, id := range r.inFlight {
+		if receipt, ok := r.entries[id]; ok && receipt.Status == ReceiptPending {
+			receipt.Status = ReceiptProcessed
+		}
+	}
+	r.settled = append(r.settled, r.inFlight...)
+	r.inFlight = r.inFlight[:0]
+
+	count := 0
+	for count < len(r.settled) {
+		receipt, ok := r.entries[r.settled[count]]
+		if ok && receipt.TickHeight+receiptRetention >= r.nextTick && len(r.settled)-count <= MaxReceipts {
+			break
+		}
+		delete(r.entries, r.settled[count])
+		count++
+	}
+	r.settled = r.settled[count:]
+}
+
+// update applies fn to the receipt of a command collected in the current tick. Unknown receipts and
+// receipts from other ticks are ignored.
+func (r *receipts) update(id ReceiptID, fn func(*Receipt)) {
+	if id == 0 {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Receipts of commands collected by the last Drain are the only ones systems can see, and they
+	// stay mutable until the tick settles.
+	receipt, ok := r.entries[id]
+	if ok && receipt.TickHeight+1 == r.nextTick && receipt.Status != ReceiptProcessed {
+		fn(receipt)
+	}
+}
+
+// get returns a copy of a receipt. If persona is non-empty, only receipts of commands sent by that
+// persona are returned.
+func (r *receipts) get(id ReceiptID, persona string) (Receipt, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	receipt, ok := r.entries[id]
+	if !ok || (persona != "" && receipt.Persona != persona) {
+		return Receipt{}, false
+	}
+	return *receipt, true
+}
+
+// reset discards all receipts and sets the height of the next tick to be drained. IDs keep counting
+// up so a stale receipt ID never refers to an unrelated command.
+func (r *receipts) reset(nextTick uint64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextTick = nextTick
+	r.entries = make(map[ReceiptID]*Receipt)
+	r.inFlight = r.inFlight[:0]
+	r.settled = r.settled[:0]
+}
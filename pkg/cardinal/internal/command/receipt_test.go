@@ -0,0 +1,246 @@
+package command_test
+
+import (
+	"testing"
+
+	"github.com/argus-labs/world-engine/pkg/cardinal/internal/command"
+	"github.com/argus-labs/world-engine/pkg/testutils"
+	iscv1 "github.com/argus-labs/world-engine/proto/gen/go/worldengine/isc/v1"
+	microv1 "github.com/argus-labs/world-engine/proto/gen/go/worldengine/micro/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// -------------------------------------------------------------------------------------------------
+// Model-based fuzzing command receipts
+// -------------------------------------------------------------------------------------------------
+// This test verifies that receipts record the tick that collects their command, move to processed
+// or failed when the tick settles, are only visible to the sender, and are evicted after the
+// retention window. The model is a map of receipts with the tick they settled at.
+// -------------------------------------------------------------------------------------------------
+
+func TestReceipt_ModelFuzz(t *testing.T) {
+	t.Parallel()
+	prng := testutils.NewRand(t)
+
+	const (
+		opsMax    = 1 << 14 // 16_384 iterations
+		retention = 1024    // Must match receiptRetention
+		opSend    = "send"
+		opTick    = "tick"
+		opLookup  = "lookup"
+	)
+
+	impl := command.NewManager()
+	id, err := impl.Register(testutils.CommandA{}.Name(), command.NewQueue[testutils.CommandA]())
+	require.NoError(t, err)
+
+	type receipt struct {
+		persona string
+		status  command.ReceiptStatus
+		tick    uint64
+		reason  string
+	}
+	model := make(map[command.ReceiptID]*receipt)
+	var issued []command.ReceiptID
+	personas := []string{"alice", "bob", "carol"}
+	nextTick := uint64(0)
+
+	operations := []string{opSend, opTick, opLookup}
+	weights := testutils.RandOpWeights(prng, operations)
+
+	for range opsMax {
+		op := testutils.RandWeightedOp(prng, weights)
+		switch op {
+		case opSend:
+			persona := personas[prng.IntN(len(personas))]
+			payload := testutils.CommandA{X: prng.Float64(), Y: prng.Float64(), Z: prng.Float64()}
+			data, err := payload.MarshalWire()
+			require.NoError(t, err)
+
+			rid, tick, err := impl.EnqueueWithReceipt(&iscv1.Command{
+				Name:    payload.Name(),
+				Address: &microv1.ServiceAddress{},
+				Persona: &iscv1.Persona{Id: persona},
+				Payload: data,
+			})
+			require.NoError(t, err)
+
+			// Property: the command is collected by the next tick.
+			assert.Equal(t, nextTick, tick, "expected tick mismatch")
+			// Property: receipt IDs are unique and non-zero.
+			assert.NotZero(t, rid, "receipt ID is zero")
+			assert.NotContains(t, model, rid, "receipt ID %d reused", rid)
+
+			model[rid] = &receipt{persona: persona, status: command.ReceiptPending, tick: tick}
+			issued = append(issued, rid)
+
+		case opTick:
+			impl.Drain()
+			cmds, err := impl.Get(id)
+			require.NoError(t, err)
+
+			// Randomly fail some of the collected commands, like a system would.
+			for _, cmd := range cmds {
+				expected, ok := model[cmd.Receipt]
+				require.True(t, ok, "collected command has unknown receipt %d", cmd.Receipt)
+				// Property: a receipt's tick is the tick that collects its command.
+				assert.Equal(t, nextTick, expected.tick, "collected at the wrong tick")
+
+				expected.status = command.ReceiptProcessed
+				if testutils.RandBool(prng) {
+					reason := testutils.RandString(prng, 8)
+					impl.Fail(cmd.Receipt, reason)
+					expected.status = command.ReceiptFailed
+					expected.reason = reason
+				}
+			}
+			impl.Settle()
+			nextTick++
+
+			// Model: evict receipts settled more than the retention window ago.
+			for rid, r := range model {
+				if r.status != command.ReceiptPending && r.tick+retention < nextTick {
+					delete(model, rid)
+				}
+			}
+
+		case opLookup:
+			if len(issued) == 0 {
+				continue
+			}
+			rid := issued[prng.IntN(len(issued))]
+			persona := personas[prng.IntN(len(personas))]
+
+			got, err := impl.Receipt(rid, persona)
+			expected, ok := model[rid]
+
+			// Property: receipts are only visible to their sender, and only within the window.
+			if !ok || expected.persona != persona {
+				require.ErrorIs(t, err, command.ErrReceiptNotFound)
+				continue
+			}
+			require.NoError(t, err)
+			assert.Equal(t, rid, got.ID, "receipt ID mismatch")
+			assert.Equal(t, expected.status, got.Status, "status mismatch for receipt %d", rid)
+			assert.Equal(t, expected.tick, got.TickHeight, "tick mismatch for receipt %d", rid)
+			assert.Equal(t, expected.reason, got.Error, "error mismatch for receipt %d", rid)
+
+		default:
+			panic("unreachable")
+		}
+	}
+}
+
+// -------------------------------------------------------------------------------------------------
+// Concurrent batch enqueue test
+// -------------------------------------------------------------------------------------------------
+// This test verifies that a batch is never split across ticks, even when the queues are drained
+// concurrently with the batch being enqueued. Run with -race to detect data races.
+// -------------------------------------------------------------------------------------------------
+
+func TestReceipt_ConcurrentEnqueueBatch(t *testing.T) {
+	t.Parallel()
+	prng := testutils.NewRand(t)
+
+	const (
+		numBatches = 500
+		batchSize  = 20
+	)
+
+	impl := command.NewManager()
+	id, err := impl.Register(testutils.CommandA{}.Name(), command.NewQueue[testutils.CommandA]())
+	require.NoError(t, err)
+
+	batch := make([]*iscv1.Command, batchSize)
+	for i := range batch {
+		payload := testutils.CommandA{X: prng.Float64(), Y: prng.Float64(), Z: prng.Float64()}
+		data, err := payload.MarshalWire()
+		require.NoError(t, err)
+		batch[i] = &iscv1.Command{
+			Name:    payload.Name(),
+			Address: &microv1.ServiceAddress{},
+			Persona: &iscv1.Persona{Id: "bot"},
+			Payload: data,
+		}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range numBatches {
+			_, _, errs := impl.EnqueueBatchWithReceipt(batch)
+			if errs != nil {
+				t.Errorf("batch rejected: %v", errs)
+				return
+			}
+		}
+	}()
+
+	total := 0
+	for drained := false; !drained; {
+		select {
+		case <-done:
+			drained = true
+		default:
+		}
+		impl.Drain()
+		cmds, err := impl.Get(id)
+		require.NoError(t, err)
+
+		// Property: every tick collects whole batches only.
+		assert.Zero(t, len(cmds)%batchSize, "batch split across ticks")
+		total += len(cmds)
+	}
+	assert.Equal(t, numBatches*batchSize, total, "total command count mismatch")
+}
+
+// -------------------------------------------------------------------------------------------------
+// Receipt count limit
+// -------------------------------------------------------------------------------------------------
+// This test verifies that when more than MaxReceipts commands settle within the retention window,
+// the oldest receipts are evicted and the newest ones are kept.
+// -------------------------------------------------------------------------------------------------
+
+func TestReceipt_MaxReceipts(t *testing.T) {
+	t.Parallel()
+
+	const overflow = 100
+
+	impl := command.NewManager()
+	_, err := impl.Register(testutils.CommandA{}.Name(), command.NewQueue[testutils.CommandA]())
+	require.NoError(t, err)
+
+	data, err := testutils.CommandA{}.MarshalWire()
+	require.NoError(t, err)
+	cmd := &iscv1.Command{
+		Name:    testutils.CommandA{}.Name(),
+		Address: &microv1.ServiceAddress{},
+		Persona: &iscv1.Persona{Id: "alice"},
+		Payload: data,
+	}
+
+	// Settle the commands over two ticks, so eviction has to cross a tick boundary.
+	rids := make([]command.ReceiptID, 0, command.MaxReceipts+overflow)
+	for _, count := range []int{command.MaxReceipts / 2, command.MaxReceipts/2 + overflow} {
+		for range count {
+			rid, _, err := impl.EnqueueWithReceipt(cmd)
+			require.NoError(t, err)
+			rids = append(rids, rid)
+		}
+		impl.Drain()
+		impl.Settle()
+	}
+
+	// Property: the oldest receipts beyond the limit are evicted.
+	for _, rid := range rids[:overflow] {
+		_, err := impl.Receipt(rid, "alice")
+		require.ErrorIs(t, err, command.ErrReceiptNotFound, "receipt %d not evicted", rid)
+	}
+	// Property: the newest MaxReceipts receipts are kept.
+	for _, rid := range rids[overflow:] {
+		got, err := impl.Receipt(rid, "alice")
+		require.NoError(t, err, "receipt %d evicted", rid)
+		assert.Equal(t, command.ReceiptProcessed, got.Status)
+	}
+}
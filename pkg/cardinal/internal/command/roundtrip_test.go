@@ -9,6 +9,7 @@ import (
 	microv1 "github.com/argus-labs/world-engine/proto/gen/go/worldengine/micro/v1"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
 )
 
 // assertCodecRoundTripType marshals value with its registered codec, enqueues it onto a queue typed for
@@ -26,12 +27,13 @@ func assertCodecRoundTripType[T command.Payload](t *testing.T, value T) {
 	require.NoError(t, err)
 
 	q := command.NewQueue[T]()
-	require.NoError(t, q.Enqueue(&iscv1.Command{
+	cmd := &iscv1.Command{
 		Name:    value.Name(),
 		Address: &microv1.ServiceAddress{},
 		Persona: &iscv1.Persona{Id: "round-trip"},
 		Payload: payload,
-	}))
+	}
+	require.NoError(t, q.Enqueue(cmd, command.Metadata{}))
 
 	var drained []command.Command
 	q.Drain(&drained)
@@ -40,6 +42,11 @@ func assertCodecRoundTripType[T command.Payload](t *testing.T, value T) {
 	got, ok := drained[0].Payload.(T)
 	require.Truef(t, ok, "payload type identity lost: got %T, want %T", drained[0].Payload, value)
 	assert.Equal(t, value, got)
+
+	// The drained command serializes back to the enqueued one, as recorded in the tick log.
+	pb, err := drained[0].ToProto()
+	require.NoError(t, err)
+	assert.True(t, proto.Equal(cmd, pb), "serialized command differs from the enqueued one")
 }
 
 // TestQueue_CodecRoundTripPreservesType round-trips each fixture command through
@@ -0,0 +1,310 @@
+package command
+
+import (
+	"cmp"
+	"encoding/binary"
+	"slices"
+	"sort"
+	"sync"
+
+	cardinalv1 "github.com/argus-labs/world-engine/proto/gen/go/worldengine/cardinal/v1"
+	iscv1 "github.com/argus-labs/world-engine/proto/gen/go/worldengine/isc/v1"
+	"github.com/cespare/xxhash/v2"
+	"github.com/rotisserie/eris"
+	"google.golang.org/protobuf/proto"
+)
+
+// ScheduleHandle identifies a scheduled command. Handles are unique for the lifetime of a world,
+// including across snapshot restores, so a stale handle never cancels an unrelated command.
+type ScheduleHandle = uint64
+
+// ErrScheduledNotFound is returned when a schedule handle doesn't refer to a pending command.
+var ErrScheduledNotFound = eris.New("scheduled command not found")
+
+// ErrScheduleTooFar is returned when a command is scheduled more than MaxScheduleTicks ticks ahead.
+var ErrScheduleTooFar = eris.New("scheduled tick is too far in the future")
+
+// ErrScheduleQuota is returned when a persona already has MaxPendingPerPersona pending commands.
+var ErrScheduleQuota = eris.New("too many pending scheduled commands")
+
+const (
+	// MaxScheduleTicks is the number of ticks after the next tick a command can be scheduled at. The
+	// schedule is persisted in every snapshot, so it must not hold commands for the far future, and
+	// the tick arithmetic can't overflow.
+	MaxScheduleTicks uint64 = 1 << 24
+
+	// MaxPendingPerPersona is the number of pending commands a persona can have when it schedules
+	// another with ScheduleOwned or ScheduleOwnedIn, so a single client can't grow the schedule without
+	// bound. Commands scheduled for the persona by systems count too, but systems aren't limited.
+	MaxPendingPerPersona = 256
+)
+
+// scheduledCommand is a command waiting in the schedule for its tick.
+type scheduledCommand struct {
+	handle  ScheduleHandle
+	tick    uint64
+	command *iscv1.Command
+	hash    uint64 // Hash of the command, see commandHash
+}
+
+// commandHash returns the hash of a command's deterministic serialization. Commands were unmarshaled
+// or validated before they're scheduled, so they always marshal.
+func commandHash(command *iscv1.Command) uint64 {
+	data, _ := proto.MarshalOptions{Deterministic: true}.Marshal(command)
+	return xxhash.Sum64(data)
+}
+
+// schedule stores commands to be released into the command queues at a future tick. Commands are
+// kept sorted by (tick, handle), so releasing is a prefix pop and the release order is deterministic.
+// The schedule is written to from both the tick loop (systems) and the service (clients), so all
+// access is lock protected.
+type schedule struct {
+	nextHandle ScheduleHandle          // Next handle to assign
+	nextTick   uint64                  // Height of the next tick to be released
+	commands   []scheduledCommand      // Pending commands sorted by (tick, handle)
+	pending    map[string]int          // Persona -> number of its pending commands
+	changes    []*iscv1.ScheduleChange // Changes clients made since the last drainChanges
+	mu         sync.Mutex
+}
+
+// newSchedule creates a new empty schedule.
+func newSchedule() *schedule {
+	return &schedule{
+		nextHandle: 1, // Reserve 0 as the invalid handle
+		commands:   make([]scheduledCommand, 0),
+		pending:    make(map[string]int),
+	}
+}
+
+// add inserts a command to be released at the given tick. Ticks that have already been released
+// are bumped to the next tick, so a command is never lost to a race with the tick loop. If owned is
+// set, the command counts against its persona's MaxPendingPerPersona.
+func (s *schedule) add(command *iscv1.Command, tick uint64, owned bool) (ScheduleHandle, uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if tick > s.nextTick && tick-s.nextTick > MaxScheduleTicks {
+		return 0, 0, eris.Wrapf(ErrScheduleTooFar, "tick %d is more than %d ticks after the next tick %d",
+			tick, MaxScheduleTicks, s.nextTick)
+	}
+	return s.insert(command, tick, owned)
+}
+
+// addIn inserts a command to be released the given number of ticks after the last released tick.
+// A delay of zero releases the command in the next tick. See add.
+func (s *schedule) addIn(command *iscv1.Command, ticks uint64, owned bool) (ScheduleHandle, uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if ticks > MaxScheduleTicks {
+		return 0, 0, eris.Wrapf(ErrScheduleTooFar, "delay of %d ticks is more than %d ticks", ticks, MaxScheduleTicks)
+	}
+	tick := s.nextTick
+	if ticks > 0 {
+		tick += ticks - 1
+	}
+	return s.insert(command, tick, owned)
+}
+
+// insert adds an entry in (tick, handle) order. Owned entries are recorded as changes. Expects the
+// caller to hold the lock.
+func (s *schedule) insert(command *iscv1.Command, tick uint64, owned bool) (ScheduleHandle, uint64, error) {
+	persona := command.GetPersona().GetId()
+	if owned && s.pending[persona] >= MaxPendingPerPersona {
+		return 0, 0, eris.Wrapf(ErrScheduleQuota, "persona %s has %d pending commands", persona, MaxPendingPerPersona)
+	}
+
+	tick = max(tick, s.nextTick)
+	handle := s.nextHandle
+	s.place(scheduledCommand{handle: handle, tick: tick, command: command})
+	if owned {
+		s.changes = append(s.changes, &iscv1.ScheduleChange{
+			Handle:     handle,
+			TickHeight: tick,
+			Command:    proto.CloneOf(command),
+		})
+	}
+	return handle, tick, nil
+}
+
+// place adds an entry with its handle in (tick, handle) order. Expects the caller to hold the lock.
+func (s *schedule) place(entry scheduledCommand) {
+	entry.hash = commandHash(entry.command)
+	index := sort.Search(len(s.commands), func(i int) bool {
+		c := s.commands[i]
+		return c.tick > entry.tick || (c.tick == entry.tick && c.handle > entry.handle)
+	})
+	s.commands = slices.Insert(s.commands, index, entry)
+	s.nextHandle = max(s.nextHandle, entry.handle+1)
+	s.pending[entry.command.GetPersona().GetId()]++
+}
+
+// uncount removes a command that's no longer pending from its persona's count. Expects the caller to
+// hold the lock.
+func (s *schedule) uncount(command *iscv1.Command) {
+	persona := command.GetPersona().GetId()
+	if s.pending[persona] <= 1 {
+		delete(s.pending, persona)
+		return
+	}
+	s.pending[persona]--
+}
+
+// remove removes a pending command. If owner is non-empty, the command is only removed if it was
+// sent by that persona, and the removal is recorded as a change. Returns false if no matching command
+// exists.
+func (s *schedule) remove(handle ScheduleHandle, owner string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, entry := range s.commands {
+		if entry.handle != handle {
+			continue
+		}
+		if owner != "" && entry.command.GetPersona().GetId() != owner {
+			return false
+		}
+		s.uncount(entry.command)
+		s.commands = slices.Delete(s.commands, i, i+1)
+		if owner != "" {
+			s.changes = append(s.changes, &iscv1.ScheduleChange{Handle: handle})
+		}
+		return true
+	}
+	return false
+}
+
+// drainChanges removes and returns the changes clients made since the last call, in order.
+func (s *schedule) drainChanges() []*iscv1.ScheduleChange {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	changes := s.changes
+	s.changes = nil
+	return changes
+}
+
+// apply makes a recorded change again: the command is scheduled with its recorded handle and tick, or
+// the command of the handle is cancelled. Returns false if a cancelled command doesn't exist.
+func (s *schedule) apply(change *iscv1.ScheduleChange) bool {
+	if change.GetCommand() == nil {
+		return s.remove(change.GetHandle(), "")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.place(scheduledCommand{handle: change.GetHandle(), tick: change.GetTickHeight(), command: change.GetCommand()})
+	return true
+}
+
+// release removes and returns all commands due at or before tick, in (tick, handle) order.
+func (s *schedule) release(tick uint64) []*iscv1.Command {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextTick = tick + 1
+
+	count := 0
+	for count < len(s.commands) && s.commands[count].tick <= tick {
+		count++
+	}
+	if count == 0 {
+		return nil
+	}
+
+	due := make([]*iscv1.Command, count)
+	for i := range count {
+		due[i] = s.commands[i].command
+		s.uncount(due[i])
+	}
+	s.commands = slices.Delete(s.commands, 0, count)
+	return due
+}
+
+// clear discards all pending commands and rewinds the schedule to tick 0. Handles keep counting up.
+func (s *schedule) clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextTick = 0
+	s.commands = s.commands[:0]
+	s.changes = nil
+	clear(s.pending)
+}
+
+// hash returns a hash of the next handle and the pending commands in (tick, handle) order. The
+// commands' hashes are computed when they're scheduled, so hashing doesn't serialize them.
+func (s *schedule) hash() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	digest := xxhash.New()
+	buf := make([]byte, 0, 8)
+	write := func(v uint64) {
+		buf = binary.BigEndian.AppendUint64(buf[:0], v)
+		_, _ = digest.Write(buf)
+	}
+	write(s.nextHandle)
+	write(uint64(len(s.commands)))
+	for _, entry := range s.commands {
+		write(entry.handle)
+		write(entry.tick)
+		write(entry.hash)
+	}
+	return digest.Sum64()
+}
+
+// toProto converts the schedule to a protobuf message for serialization.
+func (s *schedule) toProto() *cardinalv1.CommandSchedule {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	commands := make([]*cardinalv1.ScheduledCommand, len(s.commands))
+	for i, entry := range s.commands {
+		commands[i] = &cardinalv1.ScheduledCommand{
+			Handle:     entry.handle,
+			TickHeight: entry.tick,
+			Command:    proto.CloneOf(entry.command),
+		}
+	}
+	return &cardinalv1.CommandSchedule{
+		NextHandle: s.nextHandle,
+		Commands:   commands,
+	}
+}
+
+// fromProto populates the schedule from a protobuf message. A nil message restores an empty
+// schedule, which is what snapshots taken before scheduling existed contain.
+func (s *schedule) fromProto(pb *cardinalv1.CommandSchedule, nextTick uint64) error {
+	commands := make([]scheduledCommand, len(pb.GetCommands()))
+	pending := make(map[string]int)
+	nextHandle := max(pb.GetNextHandle(), 1)
+	for i, pbCmd := range pb.GetCommands() {
+		if pbCmd.GetCommand() == nil {
+			return eris.Errorf("scheduled command %d has nil command", pbCmd.GetHandle())
+		}
+		if pbCmd.GetHandle() >= nextHandle {
+			return eris.Errorf("scheduled command handle %d >= next handle %d", pbCmd.GetHandle(), nextHandle)
+		}
+		commands[i] = scheduledCommand{
+			handle:  pbCmd.GetHandle(),
+			tick:    pbCmd.GetTickHeight(),
+			command: pbCmd.GetCommand(),
+			hash:    commandHash(pbCmd.GetCommand()),
+		}
+		pending[pbCmd.GetCommand().GetPersona().GetId()]++
+	}
+	slices.SortFunc(commands, func(a, b scheduledCommand) int {
+		return cmp.Or(cmp.Compare(a.tick, b.tick), cmp.Compare(a.handle, b.handle))
+	})
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextHandle = nextHandle
+	s.nextTick = nextTick
+	s.commands = commands
+	s.pending = pending
+	return nil
+}
@@ -0,0 +1,383 @@
+package command_test
+
+import (
+	"testing"
+
+	"github.com/argus-labs/world-engine/pkg/cardinal/internal/command"
+	"github.com/argus-labs/world-engine/pkg/testutils"
+	iscv1 "github.com/argus-labs/world-engine/proto/gen/go/worldengine/isc/v1"
+	microv1 "github.com/argus-labs/world-engine/proto/gen/go/worldengine/micro/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+// -------------------------------------------------------------------------------------------------
+// Model-based fuzzing scheduled commands
+// -------------------------------------------------------------------------------------------------
+// This test verifies that scheduled commands are released into the queues at the right tick, in
+// (tick, handle) order, that cancelled commands never run, and that the schedule survives a proto
+// round trip with the same hash. The model is a plain list of pending commands scanned linearly on
+// every tick.
+// -------------------------------------------------------------------------------------------------
+
+func TestSchedule_ModelFuzz(t *testing.T) {
+	t.Parallel()
+	prng := testutils.NewRand(t)
+
+	const (
+		opsMax        = 1 << 13 // 8192 iterations
+		opSchedule    = "schedule"
+		opScheduleIn  = "schedule_in"
+		opCancel      = "cancel"
+		opCancelOwned = "cancel_owned"
+		opTick        = "tick"
+		opRoundTrip   = "round_trip"
+	)
+
+	impl := command.NewManager()
+	id, err := impl.Register(testutils.CommandA{}.Name(), command.NewQueue[testutils.CommandA]())
+	require.NoError(t, err)
+
+	type pending struct {
+		handle  command.ScheduleHandle
+		tick    uint64
+		persona string
+		payload testutils.CommandA
+	}
+	var model []pending
+	var handles []command.ScheduleHandle
+	personas := []string{"alice", "bob", "carol"}
+	nextTick := uint64(0)
+
+	newCommand := func(persona string) (*iscv1.Command, testutils.CommandA) {
+		payload := testutils.CommandA{X: prng.Float64(), Y: prng.Float64(), Z: prng.Float64()}
+		data, err := payload.MarshalWire()
+		require.NoError(t, err)
+		return &iscv1.Command{
+			Name:    payload.Name(),
+			Address: &microv1.ServiceAddress{},
+			Persona: &iscv1.Persona{Id: persona},
+			Payload: data,
+		}, payload
+	}
+
+	operations := []string{opSchedule, opScheduleIn, opCancel, opCancelOwned, opTick, opRoundTrip}
+	weights := testutils.RandOpWeights(prng, operations)
+
+	for range opsMax {
+		op := testutils.RandWeightedOp(prng, weights)
+		switch op {
+		case opSchedule, opScheduleIn:
+			persona := personas[prng.IntN(len(personas))]
+			cmd, payload := newCommand(persona)
+
+			var handle command.ScheduleHandle
+			var tick uint64
+			var expectedTick uint64
+			if op == opSchedule {
+				// Include ticks that were already released, which must be bumped to the next tick.
+				requested := uint64(prng.IntN(int(nextTick) + 20))
+				handle, tick, err = impl.Schedule(cmd, requested)
+				expectedTick = max(requested, nextTick)
+			} else {
+				delay := uint64(prng.IntN(20))
+				handle, tick, err = impl.ScheduleIn(cmd, delay)
+				expectedTick = nextTick + max(delay, 1) - 1
+			}
+			require.NoError(t, err)
+
+			// Property: the command is scheduled at the requested tick unless it was already released.
+			assert.Equal(t, expectedTick, tick, "scheduled tick mismatch")
+			// Property: handles are unique and monotonic.
+			if len(handles) > 0 {
+				assert.Greater(t, handle, handles[len(handles)-1], "handle is not monotonic")
+			}
+			handles = append(handles, handle)
+			model = append(model, pending{handle: handle, tick: tick, persona: persona, payload: payload})
+
+		case opCancel, opCancelOwned:
+			if len(handles) == 0 {
+				continue
+			}
+			handle := handles[prng.IntN(len(handles))]
+			persona := personas[prng.IntN(len(personas))]
+
+			index := -1
+			for i, p := range model {
+				if p.handle == handle && (op == opCancel || p.persona == persona) {
+					index = i
+				}
+			}
+
+			if op == opCancel {
+				err = impl.Cancel(handle)
+			} else {
+				err = impl.CancelOwned(handle, persona)
+			}
+
+			// Property: only pending commands (owned by the persona, if given) can be cancelled.
+			if index < 0 {
+				require.ErrorIs(t, err, command.ErrScheduledNotFound)
+				continue
+			}
+			require.NoError(t, err)
+			model = append(model[:index], model[index+1:]...)
+
+		case opTick:
+			require.NoError(t, impl.Release(nextTick))
+			impl.Drain()
+			cmds, err := impl.Get(id)
+			require.NoError(t, err)
+
+			// Model: release everything due in (tick, handle) order. The model list is kept in insertion
+			// order, and handles are monotonic, so a stable selection by tick gives the expected order.
+			var expected []command.Command
+			var remaining []pending
+			for tick := uint64(0); tick <= nextTick; tick++ {
+				for _, p := range model {
+					if p.tick == tick {
+						expected = append(expected, command.Command{
+							Name:    p.payload.Name(),
+							Address: &microv1.ServiceAddress{},
+							Persona: p.persona,
+							Payload: p.payload,
+						})
+					}
+				}
+			}
+			for _, p := range model {
+				if p.tick > nextTick {
+					remaining = append(remaining, p)
+				}
+			}
+			model = remaining
+			nextTick++
+
+			// Property: released commands arrive in the tick they're scheduled at, in order.
+			assert.Len(t, cmds, len(expected), "released count mismatch at tick %d", nextTick-1)
+			for i := range min(len(cmds), len(expected)) {
+				assert.Equal(t, expected[i].Persona, cmds[i].Persona, "persona mismatch at index %d", i)
+				assert.Equal(t, expected[i].Payload, cmds[i].Payload, "payload mismatch at index %d", i)
+			}
+
+		case opRoundTrip:
+			pb := impl.ScheduleToProto()
+
+			// Property: the serialized schedule contains exactly the pending commands.
+			assert.Len(t, pb.GetCommands(), len(model), "serialized schedule size mismatch")
+
+			restored := command.NewManager()
+			id, err = restored.Register(testutils.CommandA{}.Name(), command.NewQueue[testutils.CommandA]())
+			require.NoError(t, err)
+			require.NoError(t, restored.ScheduleFromProto(pb, nextTick))
+
+			// Property: the restored schedule hashes the same.
+			assert.Equal(t, impl.ScheduleHash(), restored.ScheduleHash(), "schedule hash changed by round trip")
+			impl = restored
+
+		default:
+			panic("unreachable")
+		}
+	}
+}
+
+func TestSchedule_Validation(t *testing.T) {
+	t.Parallel()
+
+	impl := command.NewManager()
+	_, err := impl.Register(testutils.CommandA{}.Name(), command.NewQueue[testutils.CommandA]())
+	require.NoError(t, err)
+
+	t.Run("unregistered command", func(t *testing.T) {
+		t.Parallel()
+		_, _, err := impl.Schedule(&iscv1.Command{
+			Name:    "unregistered",
+			Address: &microv1.ServiceAddress{},
+			Persona: &iscv1.Persona{Id: "alice"},
+		}, 10)
+		require.Error(t, err)
+	})
+
+	t.Run("malformed payload", func(t *testing.T) {
+		t.Parallel()
+		_, _, err := impl.ScheduleIn(&iscv1.Command{
+			Name:    testutils.CommandA{}.Name(),
+			Address: &microv1.ServiceAddress{},
+			Persona: &iscv1.Persona{Id: "alice"},
+			Payload: []byte{0xff, 0xff, 0xff},
+		}, 10)
+		require.Error(t, err)
+	})
+}
+
+// -------------------------------------------------------------------------------------------------
+// Schedule limits
+// -------------------------------------------------------------------------------------------------
+// This test verifies that commands can't be scheduled more than MaxScheduleTicks ticks ahead, that
+// ticks close to the uint64 limit don't overflow, and that ScheduleOwned caps the pending commands
+// of each persona until some of them are cancelled or released.
+// -------------------------------------------------------------------------------------------------
+
+func TestSchedule_Limits(t *testing.T) {
+	t.Parallel()
+
+	newCommand := func(t *testing.T, persona string) *iscv1.Command {
+		t.Helper()
+		data, err := testutils.CommandA{}.MarshalWire()
+		require.NoError(t, err)
+		return &iscv1.Command{
+			Name:    testutils.CommandA{}.Name(),
+			Address: &microv1.ServiceAddress{},
+			Persona: &iscv1.Persona{Id: persona},
+			Payload: data,
+		}
+	}
+	newManager := func(t *testing.T) *command.Manager {
+		t.Helper()
+		impl := command.NewManager()
+		_, err := impl.Register(testutils.CommandA{}.Name(), command.NewQueue[testutils.CommandA]())
+		require.NoError(t, err)
+		return &impl
+	}
+
+	t.Run("too far ahead", func(t *testing.T) {
+		t.Parallel()
+		impl := newManager(t)
+		require.NoError(t, impl.Release(99))
+
+		_, tick, err := impl.Schedule(newCommand(t, "alice"), 100+command.MaxScheduleTicks)
+		require.NoError(t, err)
+		assert.Equal(t, 100+command.MaxScheduleTicks, tick)
+
+		_, _, err = impl.Schedule(newCommand(t, "alice"), 101+command.MaxScheduleTicks)
+		require.ErrorIs(t, err, command.ErrScheduleTooFar)
+		_, _, err = impl.ScheduleIn(newCommand(t, "alice"), command.MaxScheduleTicks+1)
+		require.ErrorIs(t, err, command.ErrScheduleTooFar)
+	})
+
+	t.Run("no overflow", func(t *testing.T) {
+		t.Parallel()
+		impl := newManager(t)
+		require.NoError(t, impl.Release(99))
+
+		_, _, err := impl.Schedule(newCommand(t, "alice"), ^uint64(0))
+		require.ErrorIs(t, err, command.ErrScheduleTooFar)
+		_, _, err = impl.ScheduleIn(newCommand(t, "alice"), ^uint64(0))
+		require.ErrorIs(t, err, command.ErrScheduleTooFar)
+	})
+
+	t.Run("pending per persona", func(t *testing.T) {
+		t.Parallel()
+		impl := newManager(t)
+
+		handles := make([]command.ScheduleHandle, 0, command.MaxPendingPerPersona)
+		for i := range command.MaxPendingPerPersona {
+			handle, _, err := impl.ScheduleOwned(newCommand(t, "alice"), uint64(i))
+			require.NoError(t, err)
+			handles = append(handles, handle)
+		}
+		_, _, err := impl.ScheduleOwnedIn(newCommand(t, "alice"), 10)
+		require.ErrorIs(t, err, command.ErrScheduleQuota)
+
+		// Other personas and systems aren't limited by alice's commands.
+		_, _, err = impl.ScheduleOwned(newCommand(t, "bob"), 10)
+		require.NoError(t, err)
+		_, _, err = impl.Schedule(newCommand(t, "alice"), 10)
+		require.NoError(t, err)
+
+		// Cancelling and releasing commands frees the quota. The command scheduled by a system counts too.
+		require.NoError(t, impl.CancelOwned(handles[len(handles)-1], "alice"))
+		require.NoError(t, impl.CancelOwned(handles[len(handles)-2], "alice"))
+		_, _, err = impl.ScheduleOwned(newCommand(t, "alice"), 10)
+		require.NoError(t, err)
+		_, _, err = impl.ScheduleOwned(newCommand(t, "alice"), 10)
+		require.ErrorIs(t, err, command.ErrScheduleQuota)
+
+		require.NoError(t, impl.Release(0))
+		_, _, err = impl.ScheduleOwned(newCommand(t, "alice"), 10)
+		require.NoError(t, err)
+
+		// The quota survives a proto round trip.
+		restored := newManager(t)
+		require.NoError(t, restored.ScheduleFromProto(impl.ScheduleToProto(), 1))
+		_, _, err = restored.ScheduleOwned(newCommand(t, "alice"), 10)
+		require.ErrorIs(t, err, command.ErrScheduleQuota)
+	})
+}
+
+// -------------------------------------------------------------------------------------------------
+// Recorded schedule changes
+// -------------------------------------------------------------------------------------------------
+// This test verifies that applying the schedule changes recorded before each tick to a second
+// manager, which makes the same system changes in the ticks, reproduces the schedule exactly,
+// including the handles, even when clients change the schedule between releasing and running a tick.
+// -------------------------------------------------------------------------------------------------
+
+func TestSchedule_Changes(t *testing.T) {
+	t.Parallel()
+	prng := testutils.NewRand(t)
+
+	newManager := func() *command.Manager {
+		impl := command.NewManager()
+		_, err := impl.Register(testutils.CommandA{}.Name(), command.NewQueue[testutils.CommandA]())
+		require.NoError(t, err)
+		return &impl
+	}
+	newCommand := func(persona string) *iscv1.Command {
+		data, err := testutils.CommandA{X: prng.Float64()}.MarshalWire()
+		require.NoError(t, err)
+		return &iscv1.Command{
+			Name:    testutils.CommandA{}.Name(),
+			Address: &microv1.ServiceAddress{},
+			Persona: &iscv1.Persona{Id: persona},
+			Payload: data,
+		}
+	}
+
+	recorded, replayed := newManager(), newManager()
+	personas := []string{"alice", "bob"}
+	var handles []command.ScheduleHandle
+	clientChanges := func() {
+		for range prng.IntN(4) {
+			persona := personas[prng.IntN(len(personas))]
+			if len(handles) > 0 && prng.IntN(3) == 0 {
+				// Cancelling a command of another persona fails and isn't recorded.
+				_ = recorded.CancelOwned(handles[prng.IntN(len(handles))], persona)
+				continue
+			}
+			handle, _, err := recorded.ScheduleOwnedIn(newCommand(persona), uint64(prng.IntN(5)))
+			require.NoError(t, err)
+			handles = append(handles, handle)
+		}
+	}
+
+	for tick := range uint64(200) {
+		clientChanges()
+		require.NoError(t, recorded.Release(tick))
+		clientChanges()
+		changes := recorded.ScheduleChanges()
+
+		require.NoError(t, replayed.ApplyScheduleChanges(changes))
+		replayed.Skip(tick)
+
+		// Systems make the same changes in both runs, which aren't recorded.
+		for range prng.IntN(3) {
+			cmd := newCommand("system")
+			delay := uint64(prng.IntN(5))
+			handle, _, err := recorded.ScheduleIn(cmd, delay)
+			require.NoError(t, err)
+			replayedHandle, _, err := replayed.ScheduleIn(cmd, delay)
+			require.NoError(t, err)
+			require.Equal(t, handle, replayedHandle, "handle mismatch at tick %d", tick)
+		}
+		assert.Empty(t, recorded.ScheduleChanges())
+		require.True(t, proto.Equal(recorded.ScheduleToProto(), replayed.ScheduleToProto()),
+			"schedule mismatch at tick %d", tick)
+		require.Equal(t, recorded.ScheduleHash(), replayed.ScheduleHash(), "schedule hash mismatch at tick %d", tick)
+	}
+
+	// Cancelling a command that isn't pending can't be applied.
+	err := replayed.ApplyScheduleChanges([]*iscv1.ScheduleChange{{Handle: 1_000_000}})
+	require.ErrorIs(t, err, command.ErrScheduledNotFound)
+}
@@ -2,6 +2,8 @@ package ecs
 
 import (
 	"bytes"
+	"slices"
+	"strings"
 
 	"github.com/argus-labs/world-engine/pkg/assert"
 	cardinalv1 "github.com/argus-labs/world-engine/proto/gen/go/worldengine/cardinal/v1"
@@ -24,6 +26,8 @@ type archetype struct {
 	entities   []EntityID       // List of entities of this archetype
 	columns    []abstractColumn // List of columns containing component data
 	compCount  int              // Number of component types in the archetype
+	dirty      bool             // Whether entities were added or removed since the last clearChanges
+	hashOrder  []int            // Indices of the columns in component name order, nil until hashed
 }
 
 // newArchetype creates an archetype for the given component types.
@@ -55,6 +59,7 @@ func (a *archetype) contains(components bitmap.Bitmap) bool {
 }
 
 func (a *archetype) reset() {
+	a.dirty = true
 	a.rows.clear()
 	a.entities = a.entities[:0]
 	for _, column := range a.columns {
@@ -72,6 +77,8 @@ func (a *archetype) reset() {
 // zero values. This is done to ensure the length of each column matches the length of the entities
 // slice.
 func (a *archetype) newEntity(eid EntityID) {
+	a.dirty = true
+
 	// Add to the entities slice.
 	a.entities = append(a.entities, eid)
 
@@ -93,6 +100,7 @@ func (a *archetype) removeEntity(eid EntityID) {
 	assert.That(exists, "entity is not in archetype")
 
 	lastIndex := len(a.entities) - 1
+	a.dirty = true
 
 	// Swap the entity to remove with the last entity in the array.
 	a.entities[row] = a.entities[lastIndex]
@@ -156,8 +164,71 @@ func (a *archetype) moveEntity(destination *archetype, eid EntityID) {
 // Serialization
 // -------------------------------------------------------------------------------------------------
 
+// changed returns true if the archetype's entities or component data changed since the last
+// clearChanges.
+func (a *archetype) changed() bool {
+	if a.dirty {
+		return true
+	}
+	for _, column := range a.columns {
+		if column.changed() {
+			return true
+		}
+	}
+	return false
+}
+
+// clearChanges marks the archetype and its columns as unchanged.
+func (a *archetype) clearChanges() {
+	a.dirty = false
+	for _, column := range a.columns {
+		column.clearChanges()
+	}
+}
+
+// writeStateHash writes the archetype's ID, its entities, and the hashes of its columns in component
+// name order to h, so the hash doesn't depend on the order the components were registered in.
+func (a *archetype) writeStateHash(h *stateHasher) error {
+	if a.hashOrder == nil {
+		a.hashOrder = make([]int, len(a.columns))
+		for i := range a.hashOrder {
+			a.hashOrder[i] = i
+		}
+		slices.SortFunc(a.hashOrder, func(i, j int) int {
+			return strings.Compare(a.columns[i].name(), a.columns[j].name())
+		})
+	}
+
+	h.uint64(uint64(a.id)) //nolint:gosec // archetype IDs are indices
+	h.uint64(uint64(len(a.entities)))
+	for _, eid := range a.entities {
+		h.uint64(uint64(eid))
+	}
+	for _, i := range a.hashOrder {
+		hash, err := a.columns[i].stateHash()
+		if err != nil {
+			return eris.Wrapf(err, "failed to hash column %s", a.columns[i].name())
+		}
+		h.string(a.columns[i].name())
+		h.uint64(hash)
+	}
+	return nil
+}
+
 // toProto converts the archetype to a protobuf message for serialization.
 func (a *archetype) toProto() (*cardinalv1.Archetype, error) {
+	return a.serialize(false)
+}
+
+// toProtoDelta converts the archetype to a protobuf message with only the columns that changed since
+// the last clearChanges. The entities and rows are always included.
+func (a *archetype) toProtoDelta() (*cardinalv1.Archetype, error) {
+	return a.serialize(true)
+}
+
+// serialize converts the archetype to a protobuf message, skipping the unchanged columns if
+// changedOnly is true.
+func (a *archetype) serialize(changedOnly bool) (*cardinalv1.Archetype, error) {
 	componentsBitmap := bytes.Clone(a.components.ToBytes())
 
 	entities := make([]uint32, len(a.entities))
@@ -165,13 +236,16 @@ func (a *archetype) toProto() (*cardinalv1.Archetype, error) {
 		entities[i] = uint32(eid)
 	}
 
-	columns := make([]*cardinalv1.Column, len(a.columns))
+	columns := make([]*cardinalv1.Column, 0, len(a.columns))
 	for i, column := range a.columns {
+		if changedOnly && !column.changed() {
+			continue
+		}
 		data, err := column.toProto()
 		if err != nil {
 			return nil, eris.Wrapf(err, "failed to serialize column %d", i)
 		}
-		columns[i] = data
+		columns = append(columns, data)
 	}
 
 	return &cardinalv1.Archetype{
@@ -224,5 +298,7 @@ func (a *archetype) fromProto(pb *cardinalv1.Archetype, cm *componentManager) er
 		a.columns[i] = column
 	}
 	a.compCount = len(a.columns)
+	a.hashOrder = nil
+	a.dirty = true // Restored data isn't part of a snapshot taken by this world yet
 	return nil
 }
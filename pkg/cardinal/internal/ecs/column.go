@@ -1,8 +1,11 @@
 package ecs
 
 import (
+	"encoding/binary"
+
 	"github.com/argus-labs/world-engine/pkg/assert"
 	cardinalv1 "github.com/argus-labs/world-engine/proto/gen/go/worldengine/cardinal/v1"
+	"github.com/cespare/xxhash/v2"
 	"github.com/rotisserie/eris"
 )
 
@@ -19,6 +22,10 @@ type abstractColumn interface {
 	getAbstract(row int) Component
 	remove(row int)
 
+	changed() bool
+	clearChanges()
+	stateHash() (uint64, error)
+
 	toProto() (*cardinalv1.Column, error)
 	fromProto(*cardinalv1.Column) error
 }
@@ -30,6 +37,9 @@ var _ abstractColumn = &column[Component]{}
 type column[T Component] struct {
 	compName   string // The name of the component stored in this column
 	components []T    // Array containing the component data
+	dirty      bool   // Whether the component data changed since the last clearChanges
+	hash       uint64 // Cached stateHash of the component data
+	hashed     bool   // Whether hash is up to date with the component data
 }
 
 const columnCapacity = 16
@@ -73,6 +83,8 @@ func (c *column[T]) extend() {
 
 	var zero T
 	c.components = append(c.components, zero)
+	c.dirty = true
+	c.hashed = false
 }
 
 // set sets the component in a given row. A row corresponds to a single entity. Whenever possible
@@ -81,6 +93,8 @@ func (c *column[T]) extend() {
 func (c *column[T]) set(row int, component T) {
 	assert.That(row < len(c.components), "column isn't extended when entity is created")
 	c.components[row] = component
+	c.dirty = true
+	c.hashed = false
 }
 
 // setAbstract sets the component in a given row. A row corresponds to a single entity. Use this
@@ -118,6 +132,40 @@ func (c *column[T]) remove(row int) {
 	c.components[row] = c.components[lastIndex]
 	// Truncate the array to remove the last component.
 	c.components = c.components[:lastIndex]
+	c.dirty = true
+	c.hashed = false
+}
+
+// changed returns true if the component data changed since the last clearChanges.
+func (c *column[T]) changed() bool {
+	return c.dirty
+}
+
+// clearChanges marks the component data as unchanged.
+func (c *column[T]) clearChanges() {
+	c.dirty = false
+}
+
+// stateHash returns the hash of the serialized component data, in row order. It's cached until the
+// component data changes, so only the columns changed since the last call are serialized again.
+func (c *column[T]) stateHash() (uint64, error) {
+	if c.hashed {
+		return c.hash, nil
+	}
+	digest := xxhash.New()
+	var size [8]byte
+	for i, component := range c.components {
+		data, err := component.MarshalWire()
+		if err != nil {
+			return 0, eris.Wrapf(err, "failed to serialize component at index %d", i)
+		}
+		// Prefix the length, so different splits of the same bytes hash differently.
+		binary.BigEndian.PutUint64(size[:], uint64(len(data)))
+		_, _ = digest.Write(size[:])
+		_, _ = digest.Write(data)
+	}
+	c.hash, c.hashed = digest.Sum64(), true
+	return c.hash, nil
 }
 
 // toProto converts the column to a protobuf message for serialization. Each component encodes through its
@@ -166,5 +214,7 @@ func (c *column[T]) fromProto(pb *cardinalv1.Column) error {
 	}
 
 	c.components = components
+	c.dirty = true // Restored data isn't part of a snapshot taken by this world yet
+	c.hashed = false
 	return nil
 }
@@ -6,6 +6,7 @@ import (
 
 	"github.com/argus-labs/world-engine/pkg/assert"
 	"github.com/argus-labs/world-engine/pkg/cardinal/internal/schema"
+	cardinalv1 "github.com/argus-labs/world-engine/proto/gen/go/worldengine/cardinal/v1"
 	"github.com/rotisserie/eris"
 )
 
@@ -116,3 +117,21 @@ func RegisterComponent[T Component](world *World) (ComponentID, error) {
 	}
 	return world.state.components.register(zero.Name(), newColumnFactory[T]())
 }
+
+// LookupComponent returns the ID of a registered component, given its name.
+func LookupComponent(world *World, name string) (ComponentID, error) {
+	return world.state.components.getID(name)
+}
+
+// DecodeComponent decodes a component serialized in a world state snapshot, given its name.
+func DecodeComponent(world *World, name string, data []byte) (Component, error) {
+	cid, err := world.state.components.getID(name)
+	if err != nil {
+		return nil, err
+	}
+	column := world.state.components.factories[cid]()
+	if err := column.fromProto(&cardinalv1.Column{ComponentName: name, Components: [][]byte{data}}); err != nil {
+		return nil, eris.Wrapf(err, "failed to decode component %s", name)
+	}
+	return column.getAbstract(0), nil
+}
@@ -1,11 +1,14 @@
 package ecs
 
 import (
+	"encoding/binary"
+	"errors"
 	"math"
 	"sync"
 
 	"github.com/argus-labs/world-engine/pkg/assert"
 	cardinalv1 "github.com/argus-labs/world-engine/proto/gen/go/worldengine/cardinal/v1"
+	"github.com/cespare/xxhash/v2"
 	"github.com/kelindar/bitmap"
 	"github.com/rotisserie/eris"
 )
@@ -30,6 +33,9 @@ type worldState struct {
 	entityArch sparseSet
 	archetypes []*archetype // Array of archetypes
 	mu         sync.Mutex
+
+	// Number of archetypes at the last clearChanges. Archetypes created after it are new as a whole.
+	cleanArchetypes int
 }
 
 // newWorldState creates a new world state.
@@ -59,6 +65,7 @@ func (ws *worldState) reset() {
 	ws.archetypes = ws.archetypes[:1] // Keep the void archetype slot
 	// Reset the void archetype to avoid stale data.
 	ws.archetypes[voidArchetypeID] = ws.newArchetype(voidArchetypeID, bitmap.Bitmap{})
+	ws.cleanArchetypes = 0
 }
 
 // -------------------------------------------------------------------------------------------------
@@ -298,6 +305,96 @@ func (ws *worldState) toProto() (*cardinalv1.WorldState, error) {
 	}, nil
 }
 
+// stateHash returns a hash of the worldState: the entity ID state, and the hashes of the archetypes in
+// archetype order. Only the columns that changed since the last call are serialized.
+func (ws *worldState) stateHash() (uint64, error) {
+	h := stateHasher{digest: xxhash.New()}
+	h.uint64(uint64(ws.nextID))
+	h.uint64(uint64(len(ws.free)))
+	for _, eid := range ws.free {
+		h.uint64(uint64(eid))
+	}
+	for i, arch := range ws.archetypes {
+		if err := arch.writeStateHash(&h); err != nil {
+			return 0, eris.Wrapf(err, "failed to hash archetype %d", i)
+		}
+	}
+	return h.digest.Sum64(), nil
+}
+
+// archetypeHashes returns the hash of every archetype, in archetype order.
+func (ws *worldState) archetypeHashes() ([]uint64, error) {
+	hashes := make([]uint64, len(ws.archetypes))
+	for i, arch := range ws.archetypes {
+		h := stateHasher{digest: xxhash.New()}
+		if err := arch.writeStateHash(&h); err != nil {
+			return nil, eris.Wrapf(err, "failed to hash archetype %d", i)
+		}
+		hashes[i] = h.digest.Sum64()
+	}
+	return hashes, nil
+}
+
+// stateHasher writes the fields of the world state to the digest of its hash.
+type stateHasher struct {
+	digest *xxhash.Digest
+	buf    []byte
+}
+
+func (h *stateHasher) uint64(v uint64) {
+	h.buf = binary.BigEndian.AppendUint64(h.buf[:0], v)
+	_, _ = h.digest.Write(h.buf)
+}
+
+func (h *stateHasher) string(s string) {
+	h.uint64(uint64(len(s)))
+	_, _ = h.digest.WriteString(s)
+}
+
+// toProtoDelta converts the changes to the worldState since the last clearChanges to a protobuf
+// message. Archetypes created since then are included whole, and the other changed archetypes only
+// with their changed columns.
+func (ws *worldState) toProtoDelta() (*cardinalv1.WorldStateDelta, error) {
+	freeIDs := make([]uint32, len(ws.free))
+	for i, entityID := range ws.free {
+		freeIDs[i] = uint32(entityID)
+	}
+
+	var pbArchetypes []*cardinalv1.Archetype
+	for i, arch := range ws.archetypes {
+		var pbArch *cardinalv1.Archetype
+		var err error
+		switch {
+		case i >= ws.cleanArchetypes:
+			pbArch, err = arch.toProto()
+		case arch.changed():
+			pbArch, err = arch.toProtoDelta()
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, eris.Wrapf(err, "failed to serialize archetype %d", i)
+		}
+		pbArchetypes = append(pbArchetypes, pbArch)
+	}
+
+	return &cardinalv1.WorldStateDelta{
+		NextId:     uint32(ws.nextID),
+		FreeIds:    freeIDs,
+		EntityArch: ws.entityArch.toInt64Slice(),
+		Archetypes: pbArchetypes,
+	}, nil
+}
+
+// clearChanges marks the worldState as unchanged, so the next toProtoDelta only contains the changes
+// after this call.
+func (ws *worldState) clearChanges() {
+	for _, arch := range ws.archetypes {
+		arch.clearChanges()
+	}
+	ws.cleanArchetypes = len(ws.archetypes)
+}
+
 // fromProto populates the worldState from a protobuf message.
 func (ws *worldState) fromProto(pb *cardinalv1.WorldState) error {
 	ws.nextID = EntityID(pb.GetNextId())
@@ -316,9 +413,195 @@ func (ws *worldState) fromProto(pb *cardinalv1.WorldState) error {
 			return eris.Wrapf(err, "failed to deserialize archetype %d", i)
 		}
 	}
+	ws.cleanArchetypes = 0
 	return nil
 }
 
+// -------------------------------------------------------------------------------------------------
+// Invariants
+// -------------------------------------------------------------------------------------------------
+
+// maxInvariantViolations bounds the violations reported by checkInvariants, as a corrupted state can
+// violate an invariant for every entity.
+const maxInvariantViolations = 20
+
+// invariantViolations collects the invariant violations found by checkInvariants.
+type invariantViolations struct {
+	errs  []error
+	count int
+}
+
+// addf records a violation, keeping the first maxInvariantViolations of them.
+func (v *invariantViolations) addf(format string, args ...any) {
+	v.count++
+	if len(v.errs) < maxInvariantViolations {
+		v.errs = append(v.errs, eris.Errorf(format, args...))
+	}
+}
+
+// err returns an error listing the violations, or nil if there are none.
+func (v *invariantViolations) err() error {
+	if v.count == 0 {
+		return nil
+	}
+	if omitted := v.count - len(v.errs); omitted > 0 {
+		v.errs = append(v.errs, eris.Errorf("and %d more violations", omitted))
+	}
+	return eris.Wrapf(errors.Join(v.errs...), "world state violates %d invariants", v.count)
+}
+
+// checkInvariants checks the structural invariants that must always hold regardless of game logic.
+func (ws *worldState) checkInvariants() error {
+	var v invariantViolations
+
+	// The void archetype (index 0) always exists, has no components, and no columns.
+	if len(ws.archetypes) == 0 || ws.archetypes[voidArchetypeID] == nil {
+		v.addf("missing void archetype")
+		return v.err()
+	}
+	if ws.archetypes[voidArchetypeID].components.Count() != 0 {
+		v.addf("void archetype has non-empty components bitmap")
+	}
+	if len(ws.archetypes[voidArchetypeID].columns) != 0 {
+		v.addf("void archetype has columns")
+	}
+
+	// Collect all live entities from archetypes (ground truth).
+	liveEntities := make(map[EntityID]int)
+	for aid, arch := range ws.archetypes {
+		if arch == nil {
+			v.addf("archetype %d is nil", aid)
+			continue
+		}
+		ws.checkArchetype(aid, arch, &v)
+
+		// No entity appears in multiple archetypes.
+		for _, eid := range arch.entities {
+			if existingAid, exists := liveEntities[eid]; exists {
+				v.addf("entity %d in archetype %d and %d", eid, existingAid, aid)
+			}
+			liveEntities[eid] = aid
+		}
+	}
+
+	// The entityArch mapping matches archetype membership.
+	for eid, expectedAid := range liveEntities {
+		aid, exists := ws.entityArch.get(eid)
+		if !exists {
+			v.addf("entity %d in archetype %d but not in entityArch", eid, expectedAid)
+		} else if aid != expectedAid {
+			v.addf("entity %d: entityArch=%d but in archetype %d", eid, aid, expectedAid)
+		}
+	}
+
+	// Every non-tombstone entry in entityArch corresponds to a live entity.
+	for i, val := range ws.entityArch {
+		if val == sparseTombstone {
+			continue
+		}
+		eid := EntityID(i) //nolint:gosec // sparset max length is entity id max
+		if _, exists := liveEntities[eid]; !exists {
+			v.addf("entityArch has entity %d -> archetype %d but entity not in any archetype", eid, val)
+		}
+	}
+
+	// The free list has no duplicates, no live entities, and only IDs below nextID.
+	freeSeen := make(map[EntityID]struct{}, len(ws.free))
+	for _, freeID := range ws.free {
+		if _, dup := freeSeen[freeID]; dup {
+			v.addf("duplicate free ID %d", freeID)
+		}
+		freeSeen[freeID] = struct{}{}
+		if aid, exists := liveEntities[freeID]; exists {
+			v.addf("entity %d is both free and live (archetype %d)", freeID, aid)
+		}
+		if freeID >= ws.nextID {
+			v.addf("free ID %d >= nextID %d", freeID, ws.nextID)
+		}
+	}
+
+	// All live IDs are below nextID.
+	for eid := range liveEntities {
+		if eid >= ws.nextID {
+			v.addf("live entity %d >= nextID %d", eid, ws.nextID)
+		}
+	}
+
+	// Every ID below nextID is either live or free (no gaps).
+	if int(ws.nextID) != len(liveEntities)+len(ws.free) {
+		v.addf("nextID=%d but live=%d + free=%d = %d",
+			ws.nextID, len(liveEntities), len(ws.free), len(liveEntities)+len(ws.free))
+	}
+	return v.err()
+}
+
+// checkArchetype checks the invariants of a single archetype.
+func (ws *worldState) checkArchetype(aid int, arch *archetype, v *invariantViolations) {
+	// The archetype ID matches its index in the array.
+	if arch.id != aid {
+		v.addf("archetype at index %d has id %d", aid, arch.id)
+	}
+
+	// compCount matches components.Count() and len(columns).
+	if arch.components.Count() != arch.compCount {
+		v.addf("archetype %d: compCount %d != components.Count() %d",
+			aid, arch.compCount, arch.components.Count())
+	}
+	if len(arch.columns) != arch.compCount {
+		v.addf("archetype %d: len(columns) %d != compCount %d", aid, len(arch.columns), arch.compCount)
+	}
+
+	// Every bit in the components bitmap corresponds to a registered component, whose column is at the
+	// bit's position among the set bits.
+	index := 0
+	arch.components.Range(func(cid uint32) {
+		if cid >= ws.components.nextID {
+			v.addf("archetype %d: component ID %d not registered (nextID=%d)", aid, cid, ws.components.nextID)
+		} else if index < len(arch.columns) {
+			name := arch.columns[index].name()
+			if id, ok := ws.components.catalog[name]; !ok || id != cid {
+				v.addf("archetype %d: column %d is component %s, but the bitmap has component ID %d",
+					aid, index, name, cid)
+			}
+		}
+		index++
+	})
+
+	// Every column length matches the entity count.
+	for _, col := range arch.columns {
+		if col.len() != len(arch.entities) {
+			v.addf("archetype %d: column %s length %d != entity count %d",
+				aid, col.name(), col.len(), len(arch.entities))
+		}
+	}
+
+	// The rows sparseSet is a bijection between entities and row indices [0, len).
+	rowsSeen := make(map[int]EntityID, len(arch.entities))
+	for _, eid := range arch.entities {
+		row, exists := arch.rows.get(eid)
+		switch {
+		case !exists:
+			v.addf("archetype %d: entity %d has no row entry", aid, eid)
+		case row >= len(arch.entities):
+			v.addf("archetype %d: entity %d row %d out of bounds (len=%d)", aid, eid, row, len(arch.entities))
+		default:
+			if otherEid, dup := rowsSeen[row]; dup {
+				v.addf("archetype %d: entities %d and %d share row %d", aid, otherEid, eid, row)
+			}
+			rowsSeen[row] = eid
+		}
+	}
+	rows := 0
+	for _, row := range arch.rows {
+		if row != sparseTombstone {
+			rows++
+		}
+	}
+	if rows != len(arch.entities) {
+		v.addf("archetype %d: %d row entries != entity count %d", aid, rows, len(arch.entities))
+	}
+}
+
 // -------------------------------------------------------------------------------------------------
 // Archetype helpers
 // -------------------------------------------------------------------------------------------------
@@ -8,9 +8,11 @@ import (
 	"testing"
 
 	"github.com/argus-labs/world-engine/pkg/testutils"
+	cardinalv1 "github.com/argus-labs/world-engine/proto/gen/go/worldengine/cardinal/v1"
 	"github.com/rotisserie/eris"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
 )
 
 // -------------------------------------------------------------------------------------------------
@@ -175,6 +177,9 @@ func TestWorldState_ModelFuzz(t *testing.T) {
 		}
 	}
 
+	// Property: the structural invariants hold.
+	require.NoError(t, impl.checkInvariants())
+
 	// Property: no duplicate entities across all archetypes.
 	seenEntities := make(map[EntityID]archetypeID)
 	for _, arch := range impl.archetypes {
@@ -518,6 +523,185 @@ func TestWorldState_SerializationSmoke(t *testing.T) {
 	assertWorldStateEqual(t, ws1, ws2)
 }
 
+// -------------------------------------------------------------------------------------------------
+// Delta serialization smoke test
+// -------------------------------------------------------------------------------------------------
+// Verifies that a delta only contains what changed since the changes were last cleared: the changed
+// columns of existing archetypes, and new archetypes in full.
+// -------------------------------------------------------------------------------------------------
+
+func TestWorldState_DeltaSmoke(t *testing.T) {
+	t.Parallel()
+	prng := testutils.NewRand(t)
+
+	ws := newTestWorldState(t)
+	eid := ws.newEntity()
+	setComponentAbstract(t, ws, eid, randComponentByName(prng, testutils.ComponentA{}.Name()))
+	setComponentAbstract(t, ws, eid, randComponentByName(prng, testutils.ComponentB{}.Name()))
+	ws.clearChanges()
+
+	// Nothing changed since the changes were cleared.
+	delta, err := ws.toProtoDelta()
+	require.NoError(t, err)
+	assert.Empty(t, delta.GetArchetypes())
+
+	// Updating a component in place only includes its column.
+	setComponentAbstract(t, ws, eid, randComponentByName(prng, testutils.ComponentA{}.Name()))
+	delta, err = ws.toProtoDelta()
+	require.NoError(t, err)
+	require.Len(t, delta.GetArchetypes(), 1)
+	columns := delta.GetArchetypes()[0].GetColumns()
+	require.Len(t, columns, 1)
+	assert.Equal(t, testutils.ComponentA{}.Name(), columns[0].GetComponentName())
+	ws.clearChanges()
+
+	// A new archetype is included in full.
+	other := ws.newEntity()
+	setComponentAbstract(t, ws, other, randComponentByName(prng, testutils.ComponentC{}.Name()))
+	delta, err = ws.toProtoDelta()
+	require.NoError(t, err)
+	full, err := ws.toProto()
+	require.NoError(t, err)
+	require.NotEmpty(t, delta.GetArchetypes())
+	last := delta.GetArchetypes()[len(delta.GetArchetypes())-1]
+	assert.Equal(t, full.GetArchetypes()[last.GetId()].GetColumns(), last.GetColumns())
+	assert.Equal(t, full.GetNextId(), delta.GetNextId())
+	assert.Equal(t, full.GetEntityArch(), delta.GetEntityArch())
+}
+
+// -------------------------------------------------------------------------------------------------
+// State hash smoke test
+// -------------------------------------------------------------------------------------------------
+// Verifies that equal world states hash the same, also when their components were registered in a
+// different order or the state was restored from its serialized form, and that the cached column
+// hashes are invalidated when components change.
+// -------------------------------------------------------------------------------------------------
+
+func TestWorldState_StateHash(t *testing.T) {
+	t.Parallel()
+	prng := testutils.NewRand(t)
+
+	type op struct {
+		eid  EntityID
+		comp Component
+	}
+	ops := []op{{eid: 0, comp: randComponentByName(prng, testutils.ComponentA{}.Name())}}
+	for eid := range EntityID(1 + prng.IntN(100)) {
+		for _, name := range allComponentNames {
+			if prng.IntN(2) == 0 {
+				ops = append(ops, op{eid: eid, comp: randComponentByName(prng, name)})
+			}
+		}
+	}
+	build := func(ws *worldState) {
+		for _, o := range ops {
+			for ws.nextID <= o.eid {
+				ws.newEntity()
+			}
+			setComponentAbstract(t, ws, o.eid, o.comp)
+		}
+	}
+
+	ws := newTestWorldState(t)
+	build(ws)
+	hash, err := ws.stateHash()
+	require.NoError(t, err)
+
+	// The archetype columns of a world that registered the components in reverse order are in a
+	// different order.
+	w := NewWorld()
+	w.OnComponentRegister(func(Component) error { return nil })
+	_, err = RegisterComponent[testutils.ComponentC](w)
+	require.NoError(t, err)
+	_, err = RegisterComponent[testutils.ComponentB](w)
+	require.NoError(t, err)
+	_, err = RegisterComponent[testutils.ComponentA](w)
+	require.NoError(t, err)
+	build(w.state)
+	reversed, err := w.state.stateHash()
+	require.NoError(t, err)
+	assert.Equal(t, hash, reversed)
+
+	pb, err := ws.toProto()
+	require.NoError(t, err)
+	restored := newTestWorldState(t)
+	require.NoError(t, restored.fromProto(pb))
+	restoredHash, err := restored.stateHash()
+	require.NoError(t, err)
+	assert.Equal(t, hash, restoredHash)
+
+	// Changing a component changes the hash, and changing it back restores it.
+	last := ops[len(ops)-1]
+	comp := randComponentByName(prng, last.comp.Name())
+	for comp == last.comp {
+		comp = randComponentByName(prng, last.comp.Name())
+	}
+	setComponentAbstract(t, ws, last.eid, comp)
+	changed, err := ws.stateHash()
+	require.NoError(t, err)
+	assert.NotEqual(t, hash, changed)
+	setComponentAbstract(t, ws, last.eid, last.comp)
+	reverted, err := ws.stateHash()
+	require.NoError(t, err)
+	assert.Equal(t, hash, reverted)
+
+	// Removing an entity changes the hash.
+	ws.removeEntity(last.eid)
+	removed, err := ws.stateHash()
+	require.NoError(t, err)
+	assert.NotEqual(t, hash, removed)
+}
+
+// -------------------------------------------------------------------------------------------------
+// Invariant checker smoke test
+// -------------------------------------------------------------------------------------------------
+// Verifies that world states deserialized from corrupted snapshots, which deserialize without errors,
+// are reported as violating the structural invariants.
+// -------------------------------------------------------------------------------------------------
+
+func TestWorldState_CheckInvariants(t *testing.T) {
+	t.Parallel()
+	prng := testutils.NewRand(t)
+
+	ws := newTestWorldState(t)
+	for range 3 {
+		eid := ws.newEntity()
+		setComponentAbstract(t, ws, eid, randComponentByName(prng, testutils.ComponentA{}.Name()))
+		setComponentAbstract(t, ws, eid, randComponentByName(prng, testutils.ComponentB{}.Name()))
+	}
+	ws.removeEntity(ws.newEntity())
+	require.NoError(t, ws.checkInvariants())
+
+	// The entities are in the last archetype, with components A and B.
+	lastArchetype := func(pb *cardinalv1.WorldState) *cardinalv1.Archetype {
+		return pb.GetArchetypes()[len(pb.GetArchetypes())-1]
+	}
+	valid, err := ws.toProto()
+	require.NoError(t, err)
+
+	corruptions := map[string]func(pb *cardinalv1.WorldState){
+		"entity in the wrong archetype": func(pb *cardinalv1.WorldState) { pb.EntityArch[0] = 0 },
+		"gap in entity IDs":             func(pb *cardinalv1.WorldState) { pb.NextId++ },
+		"free ID is live":               func(pb *cardinalv1.WorldState) { pb.FreeIds[0] = 0 },
+		"rows out of bounds":            func(pb *cardinalv1.WorldState) { lastArchetype(pb).Rows[0] = 5 },
+		"columns swapped": func(pb *cardinalv1.WorldState) {
+			columns := lastArchetype(pb).GetColumns()
+			columns[0], columns[1] = columns[1], columns[0]
+		},
+		"column too short": func(pb *cardinalv1.WorldState) {
+			column := lastArchetype(pb).GetColumns()[0]
+			column.Components = column.GetComponents()[1:]
+		},
+	}
+	for name, corrupt := range corruptions {
+		pb := proto.Clone(valid).(*cardinalv1.WorldState)
+		corrupt(pb)
+		restored := newTestWorldState(t)
+		require.NoError(t, restored.fromProto(pb), name)
+		assert.ErrorContains(t, restored.checkInvariants(), "violates", name)
+	}
+}
+
 // assertWorldStateEqual checks if two worldStates are structurally equal. This function is
 // extracted so it can be reused in serialization tests "above" this layer.
 func assertWorldStateEqual(t *testing.T, ws1, ws2 *worldState) {
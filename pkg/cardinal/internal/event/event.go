@@ -5,6 +5,7 @@ import (
 	"sync"
 
 	"github.com/argus-labs/world-engine/pkg/assert"
+	"github.com/argus-labs/world-engine/pkg/cardinal/internal/interest"
 	"github.com/argus-labs/world-engine/pkg/cardinal/internal/schema"
 	"github.com/rotisserie/eris"
 )
@@ -14,6 +15,11 @@ type Event struct {
 	Kind      Kind   // The event kind
 	Payload   any    // The event payload itself
 	Recipient string // Empty recipient means broadcast to all matching subscribers; non-empty targets a single user
+	ReplyTo   uint64 // ID of the request waiting for this event as a reply, 0 if none
+
+	// Position of an area-of-interest broadcast, which is only delivered to subscribers whose interest
+	// region contains it. Nil for events that aren't tied to a position.
+	Position *interest.Position
 }
 
 // Payload is the interface all default event payloads must implement. It is schema.Serializable — the
@@ -30,6 +36,7 @@ type Kind uint8
 const (
 	KindDefault           Kind = 0 // The default event type
 	KindInterShardCommand Kind = 1 // Inter-shard commands
+	KindCustom            Kind = 2 // The first kind available for custom events
 )
 
 // Handler is a function called to handle emitted events.
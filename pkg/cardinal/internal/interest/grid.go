@@ -0,0 +1,200 @@
+package interest
+
+import (
+	"math"
+	"sync"
+
+	"github.com/argus-labs/world-engine/pkg/assert"
+)
+
+const (
+	// MaxCoordinate is the largest absolute coordinate of a position the grid accepts. Beyond it,
+	// float64 positions are too coarse to be meaningful, and cell coordinates could overflow.
+	MaxCoordinate = 1 << 40
+
+	// MaxRadiusCells is the largest region radius in cells, see Grid.MaxRadius. It bounds the number
+	// of cells a region overlaps, which Set and Remove visit.
+	MaxRadiusCells = 16
+)
+
+// Position is a point in the world.
+type Position struct {
+	X float64
+	Y float64
+}
+
+// Region is the area a subscriber is interested in, a circle around its center.
+type Region struct {
+	Center Position
+	Radius float64
+}
+
+// ValidPosition reports whether pos is finite and within MaxCoordinate on both axes.
+func ValidPosition(pos Position) bool {
+	// NaN fails both comparisons, and infinities fail one.
+	return math.Abs(pos.X) <= MaxCoordinate && math.Abs(pos.Y) <= MaxCoordinate
+}
+
+// Contains reports whether pos is inside the region, including its edge.
+func (r Region) Contains(pos Position) bool {
+	dx, dy := pos.X-r.Center.X, pos.Y-r.Center.Y
+	return dx*dx+dy*dy <= r.Radius*r.Radius
+}
+
+// cell is the coordinate of a grid cell.
+type cell struct {
+	x int64
+	y int64
+}
+
+// Grid is a uniform spatial grid that indexes subscriber regions by the cells they overlap, so
+// finding the subscribers interested in a position only checks the regions in that position's cell.
+// It is safe for concurrent use.
+type Grid struct {
+	cellSize float64
+	cells    map[cell]map[string]struct{} // Cell -> IDs of the regions overlapping it
+	regions  map[string]Region            // Subscriber ID -> region
+	mu       sync.RWMutex
+}
+
+// NewGrid creates an empty grid with square cells of the given size. Regions much larger than the
+// cell size overlap many cells, so the cell size should be close to the typical region diameter.
+func NewGrid(cellSize float64) *Grid {
+	assert.That(cellSize > 0, "grid cell size must be positive")
+	return &Grid{
+		cellSize: cellSize,
+		cells:    make(map[cell]map[string]struct{}),
+		regions:  make(map[string]Region),
+	}
+}
+
+// Set sets the region of a subscriber, replacing its previous region. The radius is capped at
+// MaxRadius. A region with an invalid center (see ValidPosition) or a radius that isn't positive
+// removes the subscriber's region instead.
+func (g *Grid) Set(id string, region Region) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if !ValidPosition(region.Center) || !(region.Radius > 0) {
+		if previous, exists := g.regions[id]; exists {
+			g.remove(id, previous)
+		}
+		return
+	}
+	region.Radius = min(region.Radius, g.MaxRadius())
+
+	if previous, exists := g.regions[id]; exists {
+		if previous == region {
+			return
+		}
+		g.remove(id, previous)
+	}
+	g.regions[id] = region
+	g.forEachCell(region, func(c cell) {
+		ids := g.cells[c]
+		if ids == nil {
+			ids = make(map[string]struct{})
+			g.cells[c] = ids
+		}
+		ids[id] = struct{}{}
+	})
+}
+
+// Remove removes the region of a subscriber. It is a no-op if the subscriber has no region.
+func (g *Grid) Remove(id string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if region, exists := g.regions[id]; exists {
+		g.remove(id, region)
+	}
+}
+
+// Query returns the IDs of the subscribers whose region contains pos, in no particular order. No
+// region contains an invalid position, see ValidPosition.
+func (g *Grid) Query(pos Position) []string {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	ids := make([]string, 0)
+	if !ValidPosition(pos) {
+		return ids
+	}
+	for id := range g.cells[g.cellOf(pos.X, pos.Y)] {
+		if g.regions[id].Contains(pos) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// Contains reports whether the subscriber has a region that contains pos.
+func (g *Grid) Contains(id string, pos Position) bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	region, exists := g.regions[id]
+	return exists && region.Contains(pos)
+}
+
+// IDs returns the IDs of all subscribers with a region, in no particular order.
+func (g *Grid) IDs() []string {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	ids := make([]string, 0, len(g.regions))
+	for id := range g.regions {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// MaxRadius returns the largest region radius, MaxRadiusCells cells.
+func (g *Grid) MaxRadius() float64 {
+	return MaxRadiusCells * g.cellSize
+}
+
+// Clear removes all regions.
+func (g *Grid) Clear() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	clear(g.cells)
+	clear(g.regions)
+}
+
+// remove removes a subscriber's region from the cells it overlaps. Expects the caller to hold the
+// lock.
+func (g *Grid) remove(id string, region Region) {
+	delete(g.regions, id)
+	g.forEachCell(region, func(c cell) {
+		ids := g.cells[c]
+		delete(ids, id)
+		if len(ids) == 0 {
+			delete(g.cells, c)
+		}
+	})
+}
+
+// forEachCell calls fn for every cell overlapping the region's bounding box.
+func (g *Grid) forEachCell(region Region, fn func(cell)) {
+	low := g.cellOf(region.Center.X-region.Radius, region.Center.Y-region.Radius)
+	high := g.cellOf(region.Center.X+region.Radius, region.Center.Y+region.Radius)
+	for x := low.x; x <= high.x; x++ {
+		for y := low.y; y <= high.y; y++ {
+			fn(cell{x: x, y: y})
+		}
+	}
+}
+
+// cellOf returns the cell containing the point (x, y). The point must be finite. Cell coordinates
+// are clamped to ±2^62, which only matters for cell sizes too small to be useful.
+func (g *Grid) cellOf(x, y float64) cell {
+	return cell{x: cellCoordinate(x / g.cellSize), y: cellCoordinate(y / g.cellSize)}
+}
+
+// cellCoordinate converts a position in cells to a cell coordinate without overflowing int64.
+func cellCoordinate(v float64) int64 {
+	const limit = 1 << 62
+	return int64(math.Floor(max(-limit, min(v, limit))))
+}
@@ -0,0 +1,167 @@
+package interest_test
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/argus-labs/world-engine/pkg/cardinal/internal/interest"
+	"github.com/argus-labs/world-engine/pkg/testutils"
+	"github.com/stretchr/testify/assert"
+)
+
+// -------------------------------------------------------------------------------------------------
+// Model-based fuzzing grid operations
+// -------------------------------------------------------------------------------------------------
+// This test verifies the grid implementation correctness by applying random sequences of operations
+// and comparing it against a map of regions as the model, which is queried by checking every region.
+// -------------------------------------------------------------------------------------------------
+
+func TestGrid_ModelFuzz(t *testing.T) {
+	t.Parallel()
+	prng := testutils.NewRand(t)
+
+	const (
+		opsMax     = 1 << 14 // 16_384 iterations
+		opSet      = "set"
+		opRemove   = "remove"
+		opQuery    = "query"
+		opContains = "contains"
+		opClear    = "clear"
+	)
+
+	cellSize := prng.Float64()*32 + 1
+	impl := interest.NewGrid(cellSize)
+	model := make(map[string]interest.Region)
+
+	ids := make([]string, 32)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("player-%d", i)
+	}
+	// Keep positions in a small area, so regions overlap each other and their cells' edges.
+	randPosition := func() interest.Position {
+		return interest.Position{X: (prng.Float64() - 0.5) * 8 * cellSize, Y: (prng.Float64() - 0.5) * 8 * cellSize}
+	}
+
+	operations := []string{opSet, opRemove, opQuery, opContains, opClear}
+	weights := testutils.RandOpWeights(prng, operations)
+	weights[opClear] = min(weights[opClear], 1)
+
+	for range opsMax {
+		op := testutils.RandWeightedOp(prng, weights)
+		switch op {
+		case opSet:
+			id := ids[prng.IntN(len(ids))]
+			region := interest.Region{Center: randPosition(), Radius: prng.Float64() * 3 * cellSize}
+			impl.Set(id, region)
+			model[id] = region
+
+		case opRemove:
+			id := ids[prng.IntN(len(ids))]
+			impl.Remove(id)
+			delete(model, id)
+
+		case opQuery:
+			pos := randQueryPosition(prng, ids, model, randPosition)
+			expected := make([]string, 0)
+			for id, region := range model {
+				if region.Contains(pos) {
+					expected = append(expected, id)
+				}
+			}
+
+			// Property: Query returns exactly the subscribers whose region contains the position.
+			assert.ElementsMatch(t, expected, impl.Query(pos), "query mismatch at %+v", pos)
+
+		case opContains:
+			id := ids[prng.IntN(len(ids))]
+			pos := randQueryPosition(prng, ids, model, randPosition)
+			region, exists := model[id]
+
+			// Property: Contains matches the subscriber's region.
+			assert.Equal(t, exists && region.Contains(pos), impl.Contains(id, pos), "contains mismatch for %s", id)
+
+		case opClear:
+			impl.Clear()
+			clear(model)
+
+		default:
+			panic("unreachable")
+		}
+	}
+
+	// Final state check: the grid has a region for exactly the model's subscribers.
+	expected := make([]string, 0, len(model))
+	for id := range model {
+		expected = append(expected, id)
+	}
+	assert.ElementsMatch(t, expected, impl.IDs(), "subscriber IDs mismatch")
+}
+
+// -------------------------------------------------------------------------------------------------
+// Position and radius limits
+// -------------------------------------------------------------------------------------------------
+// This test verifies that the grid never converts NaN, infinite, or out-of-range positions to cells,
+// and that region radii are capped so a single region can't make Set visit millions of cells.
+// -------------------------------------------------------------------------------------------------
+
+func TestGrid_Limits(t *testing.T) {
+	t.Parallel()
+
+	invalid := []interest.Position{
+		{X: math.NaN(), Y: 0},
+		{X: 0, Y: math.Inf(1)},
+		{X: math.Inf(-1), Y: math.NaN()},
+		{X: interest.MaxCoordinate * 2, Y: 0},
+		{X: 0, Y: -math.MaxFloat64},
+	}
+
+	t.Run("invalid positions", func(t *testing.T) {
+		t.Parallel()
+		impl := interest.NewGrid(1)
+		impl.Set("player", interest.Region{Center: interest.Position{}, Radius: 10})
+
+		for _, pos := range invalid {
+			assert.False(t, interest.ValidPosition(pos), "%+v is valid", pos)
+			assert.Empty(t, impl.Query(pos), "query at %+v", pos)
+
+			// A region at an invalid position removes the subscriber's region.
+			impl.Set("other", interest.Region{Center: interest.Position{}, Radius: 10})
+			impl.Set("other", interest.Region{Center: pos, Radius: 10})
+			assert.ElementsMatch(t, []string{"player"}, impl.IDs(), "region at %+v", pos)
+		}
+		assert.True(t, interest.ValidPosition(interest.Position{X: interest.MaxCoordinate, Y: -interest.MaxCoordinate}))
+	})
+
+	t.Run("radius is capped", func(t *testing.T) {
+		t.Parallel()
+		impl := interest.NewGrid(1)
+		impl.Set("player", interest.Region{Center: interest.Position{}, Radius: math.Inf(1)})
+
+		maxRadius := impl.MaxRadius()
+		assert.InDelta(t, float64(interest.MaxRadiusCells), maxRadius, 0)
+		assert.Equal(t, []string{"player"}, impl.Query(interest.Position{X: maxRadius}))
+		assert.Empty(t, impl.Query(interest.Position{X: maxRadius + 1}))
+	})
+
+	t.Run("tiny cells", func(t *testing.T) {
+		t.Parallel()
+		impl := interest.NewGrid(math.SmallestNonzeroFloat64)
+		pos := interest.Position{X: interest.MaxCoordinate, Y: interest.MaxCoordinate}
+		impl.Set("player", interest.Region{Center: pos, Radius: 1})
+		assert.Equal(t, []string{"player"}, impl.Query(pos))
+	})
+}
+
+// randQueryPosition returns a random position, or sometimes a position on the edge of a random
+// region to exercise the boundary.
+func randQueryPosition(
+	prng *rand.Rand, ids []string, model map[string]interest.Region, randPosition func() interest.Position,
+) interest.Position {
+	region, exists := model[ids[prng.IntN(len(ids))]]
+	if !exists || prng.IntN(4) != 0 {
+		return randPosition()
+	}
+	return interest.Position{X: region.Center.X + region.Radius, Y: region.Center.Y}
+}
@@ -0,0 +1,436 @@
+package cardinal
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/argus-labs/world-engine/pkg/assert"
+	"github.com/argus-labs/world-engine/pkg/cardinal/internal/ecs"
+	cardinalv1 "github.com/argus-labs/world-engine/proto/gen/go/worldengine/cardinal/v1"
+	"github.com/kelindar/bitmap"
+	"github.com/rotisserie/eris"
+)
+
+// Migration upgrades a component in snapshots stored before its schema changed, so they can still be
+// restored. Migrations are registered with RegisterMigration.
+type Migration struct {
+	kind      migrationKind
+	to        string                            // New name of a renamed component
+	transform func(data []byte) ([]byte, error) // Transforms the wire bytes of a component
+	value     []byte                            // Wire bytes of an added component
+	valueName string                            // Name of an added component
+	with      string                            // Component whose entities get an added component
+}
+
+type migrationKind uint8
+
+const (
+	migrationRename migrationKind = iota + 1
+	migrationTransform
+	migrationDrop
+	migrationAdd
+)
+
+// RenameComponent is a migration that renames a component. The component keeps its version under the
+// new name, so the migrations that follow it are registered under the new name.
+func RenameComponent(to string) Migration {
+	return Migration{kind: migrationRename, to: to}
+}
+
+// TransformComponent is a migration that transforms the wire bytes of every value of a component, e.g.
+// to decode them with the old type and encode them with the new one.
+func TransformComponent(transform func(data []byte) ([]byte, error)) Migration {
+	assert.That(transform != nil, "migration transform must not be nil")
+	return Migration{kind: migrationTransform, transform: transform}
+}
+
+// DropComponent is a migration that removes a component from every entity.
+func DropComponent() Migration {
+	return Migration{kind: migrationDrop}
+}
+
+// AddComponent is a migration that adds a component with the given value to every entity that has the
+// component named with, or to every entity if with is empty. Panics if value can't be serialized.
+func AddComponent[T ecs.Component](value T, with string) Migration {
+	data, err := value.MarshalWire()
+	if err != nil {
+		panic(eris.Wrapf(err, "failed to serialize default value of component %s", value.Name()))
+	}
+	return Migration{kind: migrationAdd, value: data, valueName: value.Name(), with: with}
+}
+
+// RegisterMigration registers the migration of a component from a version to the next. Components are
+// at version 0 until they have migrations, and snapshots record the version of every component, so a
+// restored snapshot runs the migrations from the versions it was stored with, in the order they were
+// registered. Panics if the component already has a migration from that version, consistent with other
+// registration functions.
+//
+// Example, renaming Health to HitPoints and then adding a MaxHitPoints field to it:
+//
+//	cardinal.RegisterMigration(world, "Health", 0, cardinal.RenameComponent("HitPoints"))
+//	cardinal.RegisterMigration(world, "HitPoints", 1, cardinal.TransformComponent(addMaxHitPoints))
+//	cardinal.RegisterMigration(world, "Shield", 0, cardinal.AddComponent(Shield{}, "HitPoints"))
+func RegisterMigration(world *World, component string, version uint32, migration Migration) {
+	assert.That(migration.kind != 0, "migration must be created with a migration function")
+	if migration.kind == migrationAdd && migration.valueName != component {
+		panic(eris.Errorf("migration of component %s adds component %s", component, migration.valueName))
+	}
+	if err := world.migrations.register(component, version, migration); err != nil {
+		panic(eris.Wrap(err, "failed to register migration"))
+	}
+}
+
+// migrationDryRun loads the snapshot the world would restore, migrates it, and checks that every
+// component decodes, logging the result. It doesn't modify the world or the stored snapshots.
+func (w *World) migrationDryRun(ctx context.Context) {
+	logger := w.tel.GetLogger("snapshot")
+	if err := w.validateSnapshot(ctx); err != nil {
+		w.tel.CaptureException(ctx, err)
+		logger.Error().Err(err).Msg("migration dry run failed, the snapshot can't be restored")
+	} else {
+		logger.Info().Msg("migration dry run succeeded")
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := w.tel.Shutdown(shutdownCtx); err != nil {
+		logger.Error().Err(err).Msg("telemetry shutdown error")
+	}
+}
+
+// validateSnapshot migrates the snapshot the world would restore and decodes every component of it.
+func (w *World) validateSnapshot(ctx context.Context) error {
+	logger := w.tel.GetLogger("snapshot")
+
+	snap, err := w.loadSnapshot(ctx)
+	if err != nil {
+		return err
+	}
+	if snap == nil {
+		logger.Info().Msg("no snapshot to migrate")
+		return nil
+	}
+
+	worldState, err := w.migrateSnapshot(snap)
+	if err != nil {
+		return err
+	}
+	for _, archetype := range worldState.GetArchetypes() {
+		for _, column := range archetype.GetColumns() {
+			for row, data := range column.GetComponents() {
+				if _, err := ecs.DecodeComponent(w.world, column.GetComponentName(), data); err != nil {
+					return eris.Wrapf(err, "component %s in row %d of archetype %d doesn't decode after migration",
+						column.GetComponentName(), row, archetype.GetId())
+				}
+			}
+		}
+	}
+	logger.Info().Uint64("tick", snap.TickHeight).Msg("snapshot migrates")
+	return nil
+}
+
+// -------------------------------------------------------------------------------------------------
+// Registry
+// -------------------------------------------------------------------------------------------------
+
+// migrationKey identifies a migration by the component it applies to and the version it upgrades.
+type migrationKey struct {
+	component string
+	version   uint32
+}
+
+// registeredMigration is a migration with the component and version it applies to.
+type registeredMigration struct {
+	migrationKey
+	Migration
+}
+
+// String describes the migration for logs.
+func (m registeredMigration) String() string {
+	switch m.kind {
+	case migrationRename:
+		return fmt.Sprintf("rename %s v%d to %s", m.component, m.version, m.to)
+	case migrationTransform:
+		return fmt.Sprintf("transform %s v%d", m.component, m.version)
+	case migrationDrop:
+		return fmt.Sprintf("drop %s v%d", m.component, m.version)
+	case migrationAdd:
+		return fmt.Sprintf("add %s v%d", m.component, m.version)
+	default:
+		return fmt.Sprintf("unknown migration of %s v%d", m.component, m.version)
+	}
+}
+
+// migrationRegistry holds the registered migrations, in registration order. The zero value is an empty
+// registry.
+type migrationRegistry struct {
+	migrations []registeredMigration
+	keys       map[migrationKey]struct{}
+	versions   map[string]uint32 // Current version of every component with migrations
+}
+
+// register adds a migration, updating the current versions of the components it applies to.
+func (r *migrationRegistry) register(component string, version uint32, migration Migration) error {
+	if r.keys == nil {
+		r.keys = make(map[migrationKey]struct{})
+		r.versions = make(map[string]uint32)
+	}
+
+	key := migrationKey{component: component, version: version}
+	if _, ok := r.keys[key]; ok {
+		return eris.Errorf("component %s already has a migration from version %d", component, version)
+	}
+	r.keys[key] = struct{}{}
+	r.migrations = append(r.migrations, registeredMigration{migrationKey: key, Migration: migration})
+
+	r.versions[component] = max(r.versions[component], version+1)
+	if migration.kind == migrationRename {
+		r.versions[migration.to] = max(r.versions[migration.to], version+1)
+	}
+	return nil
+}
+
+// currentVersions returns a copy of the current version of every component with migrations, which
+// snapshots record.
+func (r *migrationRegistry) currentVersions() map[string]uint32 {
+	if len(r.versions) == 0 {
+		return nil
+	}
+	return maps.Clone(r.versions)
+}
+
+// -------------------------------------------------------------------------------------------------
+// Applying migrations
+// -------------------------------------------------------------------------------------------------
+
+// migrationArchetype is an archetype of a world state being migrated, by component name.
+type migrationArchetype struct {
+	columns  []*cardinalv1.Column
+	entities []uint32
+	rows     int // Length of the archetype's rows sparse set
+}
+
+// column returns the index of the named column, or -1 if the archetype doesn't have it.
+func (a *migrationArchetype) column(name string) int {
+	return slices.IndexFunc(a.columns, func(c *cardinalv1.Column) bool { return c.GetComponentName() == name })
+}
+
+// migrate runs the migrations a world state needs to be restored by this world, from the versions it
+// was stored with. It then rebuilds the archetypes from the component names, as the components may be
+// registered with other IDs than when it was stored. Returns the migrations that ran. Fails if the
+// world state has components that aren't registered or that can't be migrated to their current
+// version.
+func (r *migrationRegistry) migrate(world *ecs.World, pb *cardinalv1.WorldState) ([]string, error) {
+	archetypes := make([]*migrationArchetype, len(pb.GetArchetypes()))
+	for i, archetype := range pb.GetArchetypes() {
+		bitmapBytes := archetype.GetComponentsBitmap()
+		if len(bitmapBytes)%8 != 0 {
+			return nil, eris.Errorf("archetype %d has an invalid bitmap length %d", i, len(bitmapBytes))
+		}
+		if count := bitmap.FromBytes(bitmapBytes).Count(); count != len(archetype.GetColumns()) {
+			return nil, eris.Errorf("archetype %d has %d components but %d columns",
+				i, count, len(archetype.GetColumns()))
+		}
+		// The migrations index the columns by row, so a truncated column must not reach them.
+		for _, column := range archetype.GetColumns() {
+			if len(column.GetComponents()) != len(archetype.GetEntities()) {
+				return nil, eris.Errorf("archetype %d has %d entities but %d %s components", i,
+					len(archetype.GetEntities()), len(column.GetComponents()), column.GetComponentName())
+			}
+		}
+		archetypes[i] = &migrationArchetype{
+			columns:  archetype.GetColumns(),
+			entities: archetype.GetEntities(),
+			rows:     len(archetype.GetRows()),
+		}
+	}
+
+	versions := maps.Clone(pb.GetComponentVersions())
+	if versions == nil {
+		versions = make(map[string]uint32)
+	}
+
+	// Run the migrations until none applies, as one migration can make another one apply, e.g. the
+	// migrations registered under the new name of a renamed component. Each runs at most once.
+	var applied []string
+	ran := make(map[migrationKey]struct{}, len(r.migrations))
+	for progress := true; progress; {
+		progress = false
+		for _, m := range r.migrations {
+			if _, ok := ran[m.migrationKey]; ok || versions[m.component] != m.version {
+				continue
+			}
+			if err := m.apply(archetypes, versions); err != nil {
+				return nil, eris.Wrapf(err, "failed to %s", m)
+			}
+			ran[m.migrationKey] = struct{}{}
+			applied = append(applied, m.String())
+			progress = true
+		}
+	}
+
+	// Every stored component must be at its current version now.
+	for _, archetype := range archetypes {
+		for _, column := range archetype.columns {
+			name := column.GetComponentName()
+			if current := r.versions[name]; versions[name] != current {
+				return nil, eris.Errorf("component %s is at version %d, but there's no migration to version %d",
+					name, versions[name], current)
+			}
+		}
+	}
+
+	if err := rebuildArchetypes(world, pb, archetypes); err != nil {
+		return nil, err
+	}
+	pb.ComponentVersions = r.currentVersions()
+	return applied, nil
+}
+
+// apply runs the migration on the archetypes of a world state and updates the component versions.
+func (m registeredMigration) apply(archetypes []*migrationArchetype, versions map[string]uint32) error {
+	switch m.kind {
+	case migrationRename:
+		for _, archetype := range archetypes {
+			if i := archetype.column(m.component); i >= 0 {
+				if archetype.column(m.to) >= 0 {
+					return eris.Errorf("entities already have component %s", m.to)
+				}
+				archetype.columns[i] = &cardinalv1.Column{
+					ComponentName: m.to,
+					Components:    archetype.columns[i].GetComponents(),
+				}
+			}
+		}
+		versions[m.component] = m.version + 1
+		versions[m.to] = m.version + 1
+
+	case migrationTransform:
+		for _, archetype := range archetypes {
+			i := archetype.column(m.component)
+			if i < 0 {
+				continue
+			}
+			components := make([][]byte, len(archetype.columns[i].GetComponents()))
+			for j, data := range archetype.columns[i].GetComponents() {
+				transformed, err := m.transform(data)
+				if err != nil {
+					return eris.Wrapf(err, "failed to transform component of entity %d", archetype.entities[j])
+				}
+				components[j] = transformed
+			}
+			archetype.columns[i] = &cardinalv1.Column{ComponentName: m.component, Components: components}
+		}
+		versions[m.component] = m.version + 1
+
+	case migrationDrop:
+		for _, archetype := range archetypes {
+			if i := archetype.column(m.component); i >= 0 {
+				archetype.columns = slices.Delete(slices.Clone(archetype.columns), i, i+1)
+			}
+		}
+		versions[m.component] = m.version + 1
+
+	case migrationAdd:
+		for _, archetype := range archetypes {
+			if m.with != "" && archetype.column(m.with) < 0 {
+				continue
+			}
+			if archetype.column(m.component) >= 0 {
+				return eris.Errorf("entities already have component %s", m.component)
+			}
+			components := make([][]byte, len(archetype.entities))
+			for j := range components {
+				components[j] = m.value
+			}
+			archetype.columns = append(slices.Clone(archetype.columns),
+				&cardinalv1.Column{ComponentName: m.component, Components: components})
+		}
+		versions[m.component] = m.version + 1
+
+	default:
+		return eris.Errorf("unknown migration kind %d", m.kind)
+	}
+	return nil
+}
+
+// rebuildArchetypes replaces the archetypes of a world state with the migrated ones. The archetype
+// bitmaps are rebuilt from the registered component IDs, and archetypes that ended up with the same
+// components are merged. The void archetype stays first.
+func rebuildArchetypes(world *ecs.World, pb *cardinalv1.WorldState, archetypes []*migrationArchetype) error {
+	voidRows := 0
+	if len(archetypes) > 0 {
+		voidRows = archetypes[0].rows
+	}
+	var void bitmap.Bitmap
+	rebuilt := []*cardinalv1.Archetype{{Id: 0, ComponentsBitmap: void.ToBytes()}}
+	rows := []int{voidRows}
+	byComponents := map[string]int{string(rebuilt[0].GetComponentsBitmap()): 0}
+
+	entityArch := slices.Clone(pb.GetEntityArch())
+	for _, archetype := range archetypes {
+		// Columns are ordered by component ID, like the bitmap.
+		ids := make(map[string]ecs.ComponentID, len(archetype.columns))
+		var components bitmap.Bitmap
+		for _, column := range archetype.columns {
+			id, err := ecs.LookupComponent(world, column.GetComponentName())
+			if err != nil {
+				return eris.Wrapf(err, "component %s of the snapshot isn't registered, register it or "+
+					"add a migration that renames or drops it", column.GetComponentName())
+			}
+			ids[column.GetComponentName()] = id
+			components.Set(id)
+		}
+		columns := slices.SortedFunc(slices.Values(archetype.columns), func(a, b *cardinalv1.Column) int {
+			return int(ids[a.GetComponentName()]) - int(ids[b.GetComponentName()])
+		})
+
+		key := string(components.ToBytes())
+		aid, ok := byComponents[key]
+		if !ok {
+			aid = len(rebuilt)
+			byComponents[key] = aid
+			rebuilt = append(rebuilt, &cardinalv1.Archetype{
+				Id:               int32(aid), //nolint:gosec // it's ok
+				ComponentsBitmap: components.ToBytes(),
+			})
+			for _, column := range columns {
+				rebuilt[aid].Columns = append(rebuilt[aid].Columns,
+					&cardinalv1.Column{ComponentName: column.GetComponentName()})
+			}
+			rows = append(rows, 0)
+		}
+
+		target := rebuilt[aid]
+		rows[aid] = max(rows[aid], archetype.rows)
+		for i, column := range columns {
+			target.Columns[i].Components = append(target.Columns[i].Components, column.GetComponents()...)
+		}
+		for _, eid := range archetype.entities {
+			target.Entities = append(target.Entities, eid)
+			if int(eid) >= len(entityArch) {
+				return eris.Errorf("entity %d is missing from the entity archetype mapping", eid)
+			}
+			entityArch[eid] = int64(aid)
+		}
+	}
+
+	// Rebuild the rows of every archetype, keeping the sparse sets at least as long as they were.
+	for aid, archetype := range rebuilt {
+		length := rows[aid]
+		for _, eid := range archetype.GetEntities() {
+			length = max(length, int(eid)+1)
+		}
+		archetype.Rows = slices.Repeat([]int64{-1}, length)
+		for row, eid := range archetype.GetEntities() {
+			archetype.Rows[eid] = int64(row)
+		}
+	}
+
+	pb.Archetypes = rebuilt
+	pb.EntityArch = entityArch
+	return nil
+}
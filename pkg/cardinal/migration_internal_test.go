@@ -0,0 +1,218 @@
+package cardinal
+
+import (
+	"context"
+	"math/rand/v2"
+	"testing"
+	"time"
+
+	"github.com/argus-labs/world-engine/pkg/cardinal/internal/ecs"
+	"github.com/argus-labs/world-engine/pkg/cardinal/snapshot"
+	"github.com/argus-labs/world-engine/pkg/testutils"
+	cardinalv1 "github.com/argus-labs/world-engine/proto/gen/go/worldengine/cardinal/v1"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+// -------------------------------------------------------------------------------------------------
+// Migration smoke tests
+// -------------------------------------------------------------------------------------------------
+// Verifies that a snapshot stored before the component schemas changed restores after renaming,
+// transforming, dropping, and adding components, that entities which end up with the same components
+// share an archetype, that a snapshot of the migrated world doesn't migrate again, and that changing
+// the versions a world state records doesn't change the world's.
+// -------------------------------------------------------------------------------------------------
+
+func TestWorld_RestoreMigrations(t *testing.T) {
+	t.Parallel()
+	prng := testutils.NewRand(t)
+
+	storage := newMigrationTestStorage(t)
+
+	// The source world has entities with components A and B, A and C, and only A.
+	source := newSnapshotTestWorld(t, prng, storage)
+	_, err := ecs.RegisterComponent[testutils.ComponentB](source.world)
+	require.NoError(t, err)
+	_, err = ecs.RegisterComponent[testutils.ComponentC](source.world)
+	require.NoError(t, err)
+
+	withB := ecs.Create(source.world)
+	b := testutils.ComponentB{ID: uint64(prng.Uint32() % 1000), Label: "b"}
+	require.NoError(t, ecs.Set(source.world, withB, testutils.ComponentA{X: 1}))
+	require.NoError(t, ecs.Set(source.world, withB, b))
+	withC := ecs.Create(source.world)
+	require.NoError(t, ecs.Set(source.world, withC, testutils.ComponentA{X: 2}))
+	require.NoError(t, ecs.Set(source.world, withC, testutils.ComponentC{Counter: 3}))
+	onlyA := ecs.Create(source.world)
+	require.NoError(t, ecs.Set(source.world, onlyA, testutils.ComponentA{X: 3}))
+
+	state, err := source.stateToProto()
+	require.NoError(t, err)
+	source.snapshot(context.Background(), time.Now(), state)
+
+	// The restored world drops C, renames B to C and transforms it, and adds SimpleComponent to every
+	// entity with A. Its components are registered in another order, so their IDs differ.
+	restored := newMigrationTestWorld(t, prng, storage)
+	require.NoError(t, restored.restore(context.Background()))
+
+	assert.InDelta(t, 1.0, migrationTestGet[testutils.ComponentA](t, restored, withB).X, 1e-9)
+	assert.Equal(t, testutils.ComponentC{Counter: uint16(b.ID)}, //nolint:gosec // ID is below 1000
+		migrationTestGet[testutils.ComponentC](t, restored, withB))
+	for _, eid := range []ecs.EntityID{withB, withC, onlyA} {
+		assert.Equal(t, testutils.SimpleComponent{Value: 7},
+			migrationTestGet[testutils.SimpleComponent](t, restored, eid))
+	}
+	_, err = ecs.Get[testutils.ComponentC](restored.world, withC)
+	require.Error(t, err, "the dropped component should be gone")
+
+	migrated := restored.state.Load().GetWorldState()
+	archetypes := map[int64]int{}
+	for _, eid := range []ecs.EntityID{withB, withC, onlyA} {
+		archetypes[migrated.GetEntityArch()[eid]]++
+	}
+	assert.Len(t, archetypes, 2, "entities with the same components should share an archetype")
+	assert.Equal(t, map[string]uint32{"component_b": 1, "component_c": 2, "simple_component": 1},
+		migrated.GetComponentVersions())
+
+	// A snapshot of the migrated world is at the current versions, so migrating it changes nothing.
+	current, err := restored.stateToProto()
+	require.NoError(t, err)
+	again := proto.Clone(current).(*cardinalv1.WorldState)
+	applied, err := restored.migrations.migrate(restored.world, again)
+	require.NoError(t, err)
+	assert.Empty(t, applied)
+	assert.True(t, proto.Equal(current, again), "migrating a current world state should be a no-op")
+
+	again.GetComponentVersions()["component_c"] = 0
+	assert.Equal(t, uint32(2), restored.migrations.currentVersions()["component_c"])
+}
+
+// -------------------------------------------------------------------------------------------------
+// Migration failures
+// -------------------------------------------------------------------------------------------------
+// Verifies that a snapshot doesn't restore if it has components that aren't registered and have no
+// migration, components at a version there's no migration from, components a migration fails on, or
+// columns without a component for every entity, and that the dry run reports the same failures
+// without touching the stored snapshots.
+// -------------------------------------------------------------------------------------------------
+
+func TestWorld_RestoreMigrationsFail(t *testing.T) {
+	t.Parallel()
+	prng := testutils.NewRand(t)
+
+	storage := newMigrationTestStorage(t)
+	source := newSnapshotTestWorld(t, prng, storage)
+	_, err := ecs.RegisterComponent[testutils.ComponentB](source.world)
+	require.NoError(t, err)
+	eid := ecs.Create(source.world)
+	require.NoError(t, ecs.Set(source.world, eid, testutils.ComponentB{ID: 1}))
+	state, err := source.stateToProto()
+	require.NoError(t, err)
+	source.snapshot(context.Background(), time.Now(), state)
+
+	t.Run("unregistered component", func(t *testing.T) {
+		w := newSnapshotTestWorld(t, prng, storage)
+		require.ErrorContains(t, w.restore(context.Background()), "isn't registered")
+		require.ErrorContains(t, w.validateSnapshot(context.Background()), "isn't registered")
+	})
+
+	t.Run("no migration from stored version", func(t *testing.T) {
+		w := newSnapshotTestWorld(t, prng, storage)
+		_, err := ecs.RegisterComponent[testutils.ComponentB](w.world)
+		require.NoError(t, err)
+		RegisterMigration(w, "component_b", 1, DropComponent())
+		require.ErrorContains(t, w.restore(context.Background()), "no migration to version 2")
+	})
+
+	t.Run("transform fails to decode", func(t *testing.T) {
+		w := newSnapshotTestWorld(t, prng, storage)
+		_, err := ecs.RegisterComponent[testutils.ComponentB](w.world)
+		require.NoError(t, err)
+		RegisterMigration(w, "component_b", 0, TransformComponent(func([]byte) ([]byte, error) {
+			return []byte("not gob"), nil
+		}))
+		require.ErrorContains(t, w.validateSnapshot(context.Background()), "doesn't decode")
+		require.Error(t, w.restore(context.Background()))
+	})
+
+	t.Run("truncated column", func(t *testing.T) {
+		w := newSnapshotTestWorld(t, prng, storage)
+		_, err := ecs.RegisterComponent[testutils.ComponentB](w.world)
+		require.NoError(t, err)
+		RegisterMigration(w, "component_b", 0, TransformComponent(func(data []byte) ([]byte, error) {
+			return data, nil
+		}))
+		truncated := proto.Clone(state).(*cardinalv1.WorldState)
+		for _, archetype := range truncated.GetArchetypes() {
+			for _, column := range archetype.GetColumns() {
+				column.Components = column.GetComponents()[:0]
+			}
+		}
+		_, err = w.migrations.migrate(w.world, truncated)
+		require.ErrorContains(t, err, "but 0 component_b components")
+	})
+
+	infos, err := storage.List(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, infos, 1, "failed restores and dry runs shouldn't modify the stored snapshots")
+}
+
+func TestRegisterMigration_Panics(t *testing.T) {
+	t.Parallel()
+	prng := testutils.NewRand(t)
+
+	w := newServiceFixture(t, prng, false).world
+	RegisterMigration(w, "component_a", 0, DropComponent())
+	assert.Panics(t, func() { RegisterMigration(w, "component_a", 0, DropComponent()) })
+	assert.Panics(t, func() { RegisterMigration(w, "component_a", 1, Migration{}) })
+	assert.Panics(t, func() {
+		RegisterMigration(w, "component_b", 0, AddComponent(testutils.ComponentA{}, ""))
+	})
+}
+
+// newMigrationTestStorage creates a file storage for the snapshots of migration tests.
+func newMigrationTestStorage(t *testing.T) snapshot.Storage {
+	t.Helper()
+	storage, err := snapshot.NewFileStorage(snapshot.FileStorageOptions{
+		Logger:    zerolog.Nop(),
+		Dir:       t.TempDir(),
+		Retention: snapshot.RetentionPolicy{Generations: 10},
+	})
+	require.NoError(t, err)
+	return storage
+}
+
+// newMigrationTestWorld creates the world of TestWorld_RestoreMigrations with its migrations.
+func newMigrationTestWorld(t *testing.T, prng *rand.Rand, storage snapshot.Storage) *World {
+	t.Helper()
+	w := newServiceFixture(t, prng, false).world
+	w.snapshotStorage = storage
+	_, err := ecs.RegisterComponent[testutils.SimpleComponent](w.world)
+	require.NoError(t, err)
+	_, err = ecs.RegisterComponent[testutils.ComponentC](w.world)
+	require.NoError(t, err)
+	_, err = ecs.RegisterComponent[testutils.ComponentA](w.world)
+	require.NoError(t, err)
+
+	RegisterMigration(w, "component_c", 0, DropComponent())
+	RegisterMigration(w, "component_b", 0, RenameComponent("component_c"))
+	RegisterMigration(w, "component_c", 1, TransformComponent(func(data []byte) ([]byte, error) {
+		b, err := testutils.ComponentB{}.UnmarshalWire(data)
+		if err != nil {
+			return nil, err
+		}
+		return testutils.ComponentC{Counter: uint16(b.(testutils.ComponentB).ID)}.MarshalWire() //nolint:gosec // test
+	}))
+	RegisterMigration(w, "simple_component", 0, AddComponent(testutils.SimpleComponent{Value: 7}, "component_a"))
+	return w
+}
+
+// migrationTestGet returns a component of an entity of a restored world.
+func migrationTestGet[T ecs.Component](t *testing.T, w *World, eid ecs.EntityID) T {
+	t.Helper()
+	component, err := ecs.Get[T](w.world, eid)
+	require.NoError(t, err)
+	return component
+}
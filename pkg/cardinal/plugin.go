@@ -6,7 +6,8 @@ package cardinal
 //
 // Components, commands, and events are automatically registered when referenced by system
 // state fields (via Ref[T], WithCommand[T], WithEvent[T]), so a plugin's Register method
-// typically only needs to call RegisterSystem.
+// typically only needs to call RegisterSystem. Plugins that handle events outside of event streams,
+// e.g. webhooks or audit logs, add their own event kinds with RegisterEventKind.
 //
 // Example:
 //
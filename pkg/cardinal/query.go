@@ -0,0 +1,148 @@
+package cardinal
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strconv"
+
+	"connectrpc.com/connect"
+	"github.com/argus-labs/world-engine/pkg/assert"
+	"github.com/argus-labs/world-engine/pkg/cardinal/internal/ecs"
+	cardinalv1 "github.com/argus-labs/world-engine/proto/gen/go/worldengine/cardinal/v1"
+	"github.com/rotisserie/eris"
+)
+
+// defaultQueryPageSize is the number of entities QueryEntities returns if the request doesn't set a
+// page size.
+const defaultQueryPageSize = 100
+
+// QueryAuthorizer decides whether a persona may see a component of an entity in QueryEntities
+// results and SubscribeState streams. Entities without any component the persona may see are left
+// out of them. It is called from request handlers and the tick, concurrently, so it must only use its
+// arguments.
+//
+// Example:
+//
+//	func(persona string, entity cardinal.EntityID, component ecs.Component) bool {
+//	    inventory, ok := component.(Inventory)
+//	    return !ok || inventory.Owner == persona
+//	}
+type QueryAuthorizer func(persona string, entity EntityID, component ecs.Component) bool
+
+// QueryEntities evaluates a component filter against the latest published world state. It reads the
+// immutable index of the published state, so it runs on the request's goroutine without blocking the
+// tick.
+func (s *service) QueryEntities(
+	ctx context.Context,
+	req *connect.Request[cardinalv1.QueryEntitiesRequest],
+) (*connect.Response[cardinalv1.QueryEntitiesResponse], error) {
+	user := UserFromContext(ctx)
+	assert.That(user != nil, "user should exist in authenticated request context")
+
+	var cursor uint64
+	if token := req.Msg.GetPageToken(); token != "" {
+		var err error
+		cursor, err = strconv.ParseUint(token, 10, 32)
+		if err != nil {
+			return nil, connect.NewError(connect.CodeInvalidArgument, eris.Errorf("invalid page token %q", token))
+		}
+		cursor++ // The token is the last entity of the previous page
+	}
+	pageSize := int(req.Msg.GetPageSize())
+	if pageSize == 0 {
+		pageSize = defaultQueryPageSize
+	}
+
+	index := s.index.Load()
+	query := newEntityQuery(req.Msg)
+
+	var matched []uint32
+	for _, archetype := range index.archetypes {
+		if !query.matches(archetype) {
+			continue
+		}
+		for _, eid := range archetype.entities {
+			if uint64(eid) >= cursor {
+				matched = append(matched, eid)
+			}
+		}
+	}
+	slices.Sort(matched)
+
+	response := &cardinalv1.QueryEntitiesResponse{TickHeight: index.snap.GetTickHeight()}
+	for _, eid := range matched {
+		if len(response.GetEntities()) == pageSize {
+			// There's at least one more matching entity, so there's a next page.
+			last := response.GetEntities()[pageSize-1].GetEntityId()
+			response.NextPageToken = strconv.FormatUint(uint64(last), 10)
+			break
+		}
+		if entity := s.projectEntity(user, index, eid, query.projection); entity != nil {
+			response.Entities = append(response.Entities, entity)
+		}
+	}
+	return connect.NewResponse(response), nil
+}
+
+// projectEntity returns the entity's projected components the user may see, or nil if there are
+// none.
+func (s *service) projectEntity(
+	user *User, index *stateIndex, eid uint32, projection []string,
+) *cardinalv1.EntityState {
+	var visible map[string][]byte
+	for _, name := range projection {
+		data, ok := index.component(eid, name)
+		if !ok {
+			continue
+		}
+		allowed, err := index.authorized(s.authorizer, user.ID, eid, name, data)
+		if err != nil {
+			s.log.Warn().Err(err).Str("component", name).Msg("failed to decode component for query authorization")
+			continue
+		}
+		if !allowed {
+			continue
+		}
+		if visible == nil {
+			visible = make(map[string][]byte)
+		}
+		visible[name] = data
+	}
+	if visible == nil {
+		return nil
+	}
+	return &cardinalv1.EntityState{EntityId: eid, Components: visible}
+}
+
+// entityQuery is a QueryEntities component filter.
+type entityQuery struct {
+	components map[string]struct{} // The components the entities are filtered by
+	exact      bool                // Whether entities must have exactly the components
+	projection []string            // The components returned for each entity
+}
+
+func newEntityQuery(req *cardinalv1.QueryEntitiesRequest) entityQuery {
+	query := entityQuery{
+		components: make(map[string]struct{}, len(req.GetComponents())),
+		exact:      req.GetMatch() == cardinalv1.QueryMatch_QUERY_MATCH_EXACT,
+		projection: req.GetProjection(),
+	}
+	for _, name := range req.GetComponents() {
+		query.components[name] = struct{}{}
+	}
+	if len(query.projection) == 0 {
+		query.projection = slices.Sorted(maps.Keys(query.components))
+	}
+	return query
+}
+
+// matches reports whether the entities of an archetype match the filter.
+func (q entityQuery) matches(archetype *archetypeIndex) bool {
+	for name := range q.components {
+		if _, ok := archetype.columns[name]; !ok {
+			return false
+		}
+	}
+	return !q.exact || len(archetype.columns) == len(q.components)
+}
@@ -0,0 +1,175 @@
+package cardinal
+
+import (
+	"testing"
+
+	"connectrpc.com/connect"
+	"github.com/argus-labs/world-engine/pkg/cardinal/internal/ecs"
+	"github.com/argus-labs/world-engine/pkg/testutils"
+	cardinalv1 "github.com/argus-labs/world-engine/proto/gen/go/worldengine/cardinal/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// -------------------------------------------------------------------------------------------------
+// Entity query smoke tests
+// -------------------------------------------------------------------------------------------------
+// Verifies that QueryEntities matches entities against the published world state with Contains and
+// Exact semantics, pages through the matches in entity ID order, projects the requested components,
+// and hides what the query authorizer doesn't allow.
+// -------------------------------------------------------------------------------------------------
+
+func TestService_QueryEntities(t *testing.T) {
+	t.Parallel()
+
+	nameA := testutils.ComponentA{}.Name()
+	nameB := testutils.ComponentB{}.Name()
+
+	// newQueryFixture publishes a world with count entities that have ComponentA, every other one
+	// also having ComponentB owned by alternating players.
+	newQueryFixture := func(t *testing.T, count int) (*serviceFixture, []ecs.EntityID) {
+		t.Helper()
+		prng := testutils.NewRand(t)
+		fixture := newServiceFixture(t, prng, false)
+		world := fixture.world.world
+		_, err := ecs.RegisterComponent[testutils.ComponentA](world)
+		require.NoError(t, err)
+		_, err = ecs.RegisterComponent[testutils.ComponentB](world)
+		require.NoError(t, err)
+
+		entities := make([]ecs.EntityID, 0, count)
+		for i := range count {
+			eid := ecs.Create(world)
+			require.NoError(t, ecs.Set(world, eid, testutils.ComponentA{X: float64(i)}))
+			if i%2 == 1 {
+				owner := "alice"
+				if i%4 == 3 {
+					owner = "bob"
+				}
+				require.NoError(t, ecs.Set(world, eid, testutils.ComponentB{Label: owner}))
+			}
+			entities = append(entities, eid)
+		}
+		publishTestState(t, fixture, 7)
+		return fixture, entities
+	}
+
+	query := func(
+		t *testing.T, fixture *serviceFixture, user string, msg *cardinalv1.QueryEntitiesRequest,
+	) *cardinalv1.QueryEntitiesResponse {
+		t.Helper()
+		response, err := fixture.svc.QueryEntities(serviceTestContext(user), connect.NewRequest(msg))
+		require.NoError(t, err)
+		return response.Msg
+	}
+
+	entityIDs := func(response *cardinalv1.QueryEntitiesResponse) []uint32 {
+		ids := make([]uint32, 0, len(response.GetEntities()))
+		for _, entity := range response.GetEntities() {
+			ids = append(ids, entity.GetEntityId())
+		}
+		return ids
+	}
+
+	t.Run("contains and exact", func(t *testing.T) {
+		t.Parallel()
+		fixture, entities := newQueryFixture(t, 8)
+
+		contains := query(t, fixture, "alice", &cardinalv1.QueryEntitiesRequest{Components: []string{nameA}})
+		assert.Equal(t, uint64(7), contains.GetTickHeight())
+		assert.Len(t, contains.GetEntities(), len(entities))
+		assert.Empty(t, contains.GetNextPageToken())
+
+		exact := query(t, fixture, "alice", &cardinalv1.QueryEntitiesRequest{
+			Components: []string{nameA},
+			Match:      cardinalv1.QueryMatch_QUERY_MATCH_EXACT,
+		})
+		for i, eid := range entityIDs(exact) {
+			assert.Equal(t, uint32(entities[i*2]), eid)
+		}
+		assert.Len(t, exact.GetEntities(), len(entities)/2)
+
+		both := query(t, fixture, "alice", &cardinalv1.QueryEntitiesRequest{
+			Components: []string{nameA, nameB},
+			Match:      cardinalv1.QueryMatch_QUERY_MATCH_EXACT,
+		})
+		assert.Len(t, both.GetEntities(), len(entities)/2)
+		for _, entity := range both.GetEntities() {
+			assert.Len(t, entity.GetComponents(), 2)
+		}
+	})
+
+	t.Run("pagination", func(t *testing.T) {
+		t.Parallel()
+		fixture, entities := newQueryFixture(t, 25)
+
+		var seen []uint32
+		token := ""
+		for pages := 0; ; pages++ {
+			require.Less(t, pages, len(entities), "pagination didn't terminate")
+			page := query(t, fixture, "alice", &cardinalv1.QueryEntitiesRequest{
+				Components: []string{nameA},
+				PageSize:   4,
+				PageToken:  token,
+			})
+			assert.LessOrEqual(t, len(page.GetEntities()), 4)
+			seen = append(seen, entityIDs(page)...)
+			token = page.GetNextPageToken()
+			if token == "" {
+				break
+			}
+		}
+		require.Len(t, seen, len(entities))
+		for i, eid := range entities {
+			assert.Equal(t, uint32(eid), seen[i])
+		}
+
+		_, err := fixture.svc.QueryEntities(serviceTestContext("alice"), connect.NewRequest(
+			&cardinalv1.QueryEntitiesRequest{Components: []string{nameA}, PageToken: "not-a-cursor"}))
+		assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+	})
+
+	t.Run("projection", func(t *testing.T) {
+		t.Parallel()
+		fixture, entities := newQueryFixture(t, 4)
+
+		response := query(t, fixture, "alice", &cardinalv1.QueryEntitiesRequest{
+			Components: []string{nameA},
+			Projection: []string{nameB},
+		})
+		// Entities without a projected component aren't returned.
+		require.Len(t, response.GetEntities(), len(entities)/2)
+		for _, entity := range response.GetEntities() {
+			assert.Len(t, entity.GetComponents(), 1)
+			assert.Contains(t, entity.GetComponents(), nameB)
+		}
+	})
+
+	t.Run("authorizer", func(t *testing.T) {
+		t.Parallel()
+		fixture, entities := newQueryFixture(t, 8)
+		fixture.svc.authorizer = func(persona string, entity EntityID, component ecs.Component) bool {
+			b, ok := component.(testutils.ComponentB)
+			return !ok || b.Label == persona
+		}
+
+		response := query(t, fixture, "bob", &cardinalv1.QueryEntitiesRequest{Components: []string{nameB}})
+		require.Len(t, response.GetEntities(), len(entities)/4)
+		for _, entity := range response.GetEntities() {
+			assert.Equal(t, uint32(3), (entity.GetEntityId()-uint32(entities[0]))%4)
+		}
+
+		// Components the persona may see are still returned without the hidden ones.
+		mixed := query(t, fixture, "bob", &cardinalv1.QueryEntitiesRequest{
+			Components: []string{nameA, nameB},
+		})
+		require.Len(t, mixed.GetEntities(), len(entities)/2)
+		hidden := 0
+		for _, entity := range mixed.GetEntities() {
+			if _, ok := entity.GetComponents()[nameB]; !ok {
+				hidden++
+			}
+		}
+		assert.Equal(t, len(entities)/4, hidden)
+	})
+}
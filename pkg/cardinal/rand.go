@@ -0,0 +1,82 @@
+package cardinal
+
+import (
+	"encoding/binary"
+	"math/rand/v2"
+
+	"github.com/cespare/xxhash/v2"
+)
+
+// Rand is a deterministic pseudo-random number generator, returned by BaseSystemState.Rand. Its numbers
+// only depend on the world's seed, the tick height, the system, and the substream, so the same inputs
+// always produce the same outcomes, e.g. when a tick log is replayed or a DST run is reproduced. Systems
+// must use it instead of math/rand, whose numbers differ in every run.
+//
+// The numbers of a stream depend on how many were taken before them. Substreams are independent of
+// their parent stream and of each other, so the numbers of an entity or a command don't change when
+// the system takes numbers for other entities or commands, or in another order.
+type Rand struct {
+	*rand.Rand
+
+	seed uint64 // Seed of the stream, which the seeds of its substreams are derived from
+}
+
+// newRand returns the stream of random numbers of seed.
+func newRand(seed uint64) *Rand {
+	return &Rand{Rand: rand.New(rand.NewPCG(seed, ^seed)), seed: seed}
+}
+
+// Entity returns the substream of entity eid. It returns a new stream on every call, which starts
+// from the same numbers.
+//
+// Example:
+//
+//	for eid, monster := range state.Monsters.Iter() {
+//	    if state.Rand().Entity(eid).Float64() < 0.1 {
+//	        // ...
+//	    }
+//	}
+func (r *Rand) Entity(eid EntityID) *Rand {
+	return newRand(deriveSeed(r.seed, "entity", uint64(eid)))
+}
+
+// Command returns the substream of cmd, keyed by the command's name and its position among the
+// commands of its type in the tick. It returns a new stream on every call, which starts from the same
+// numbers.
+//
+// Example:
+//
+//	for cmd := range state.OpenChestCmds.Iter() {
+//	    loot := lootTable[state.Rand().Command(cmd).IntN(len(lootTable))]
+//	    // ...
+//	}
+func (r *Rand) Command(cmd RandKeyed) *Rand {
+	name, index := cmd.randKey()
+	return newRand(deriveSeed(r.seed, "command:"+name, index))
+}
+
+// Stream returns the substream of key, for things that aren't entities or commands, e.g. a match. It
+// returns a new stream on every call, which starts from the same numbers.
+func (r *Rand) Stream(key uint64) *Rand {
+	return newRand(deriveSeed(r.seed, "stream", key))
+}
+
+// RandKeyed is a command that has its own substream of random numbers, see Rand.Command. It is
+// implemented by CommandContext.
+type RandKeyed interface {
+	randKey() (name string, index uint64)
+}
+
+// deriveSeed returns the seed of the substream of a stream with seed parent, named by domain and key.
+func deriveSeed(parent uint64, domain string, key uint64) uint64 {
+	var buf [8]byte
+	digest := xxhash.New()
+	binary.BigEndian.PutUint64(buf[:], parent)
+	_, _ = digest.Write(buf[:])
+	binary.BigEndian.PutUint64(buf[:], uint64(len(domain)))
+	_, _ = digest.Write(buf[:])
+	_, _ = digest.WriteString(domain)
+	binary.BigEndian.PutUint64(buf[:], key)
+	_, _ = digest.Write(buf[:])
+	return digest.Sum64()
+}
@@ -0,0 +1,366 @@
+package cardinal
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/argus-labs/world-engine/pkg/cardinal/internal/ecs"
+	"github.com/argus-labs/world-engine/pkg/cardinal/internal/event"
+	"github.com/argus-labs/world-engine/pkg/cardinal/snapshot"
+	"github.com/argus-labs/world-engine/pkg/cardinal/ticklog"
+	cardinalv1 "github.com/argus-labs/world-engine/proto/gen/go/worldengine/cardinal/v1"
+	iscv1 "github.com/argus-labs/world-engine/proto/gen/go/worldengine/isc/v1"
+	"github.com/rotisserie/eris"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+// ErrReplayDiverged is returned by Replay if the replayed state differs from a recorded state hash or
+// snapshot.
+var ErrReplayDiverged = errors.New("replay diverged from the recorded state")
+
+// maxReplayDiffs bounds the number of differences reported when a replay diverges.
+const maxReplayDiffs = 20
+
+// ReplayOptions configures Replay.
+type ReplayOptions struct {
+	Snapshots snapshot.Storage // Recorded snapshots (nil = the world's snapshot storage)
+	TickLog   ticklog.Store    // Recorded tick log (nil = the world's tick log store)
+	FromTick  *uint64          // Tick of the snapshot to start from (nil = the earliest snapshot)
+	ToTick    *uint64          // Last tick to replay (nil = the last recorded tick)
+}
+
+// ReplayResult describes a successful replay.
+type ReplayResult struct {
+	FromTick uint64   // Height of the first replayed tick
+	ToTick   uint64   // Height of the last replayed tick
+	Verified []uint64 // Ticks of the recorded snapshots the replayed state matched
+}
+
+// Replay restores a recorded snapshot into w and runs the ticks recorded after it in the tick log,
+// with their exact commands and timestamps, through the same tick as the running shard. After every
+// replayed tick, the hash of the replayed state is compared with the recorded one, and if the tick has
+// a recorded snapshot, the replayed state is compared with the snapshot. ErrReplayDiverged is returned
+// at the first difference. It's used to reproduce production bugs
+// locally, and to check that a new build processes recorded matches the same way.
+//
+// w must be a new world with the same systems registered as the recorded shard, that isn't started.
+// The replay has no side effects: events aren't published and snapshots aren't stored. If there are no
+// recorded snapshots, the replay starts from the initial state, which needs the tick log from tick 0
+// and w to have the recorded shard's WorldOptions.Seed.
+//
+// Example:
+//
+//	world, err := cardinal.NewWorld(cardinal.WorldOptions{})
+//	// ... register all systems
+//	result, err := cardinal.Replay(ctx, world, cardinal.ReplayOptions{})
+func Replay(ctx context.Context, w *World, opts ReplayOptions) (*ReplayResult, error) {
+	snapshots := opts.Snapshots
+	if snapshots == nil {
+		snapshots = w.snapshotStorage
+	}
+	tickLog := opts.TickLog
+	if tickLog == nil {
+		tickLog = w.tickLogStore
+	}
+	if tickLog == nil {
+		return nil, eris.New("no tick log to replay, set ReplayOptions.TickLog or CARDINAL_TICK_LOG_STORE_TYPE")
+	}
+
+	// Discard the events and snapshots of the replayed ticks.
+	discard := func(event.Event) error { return nil }
+	w.events.RegisterHandler(event.KindDefault, discard)
+	w.events.RegisterHandler(event.KindInterShardCommand, discard)
+	for _, kind := range w.eventKinds {
+		w.events.RegisterHandler(kind, discard)
+	}
+	w.snapshotStorage = snapshot.NewNopStorage()
+
+	w.world.Init()
+
+	infos, err := snapshots.List(ctx)
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to list recorded snapshots")
+	}
+	if err := w.replayStart(ctx, snapshots, infos, opts.FromTick); err != nil {
+		return nil, err
+	}
+	ticks, err := readReplayTicks(ctx, tickLog, w.currentTick.height, opts.ToTick)
+	if err != nil {
+		return nil, err
+	}
+
+	recorded := make(map[uint64]bool, len(infos))
+	for _, info := range infos {
+		recorded[info.TickHeight] = true
+	}
+	result := &ReplayResult{FromTick: w.currentTick.height}
+	for _, tick := range ticks {
+		if err := ctx.Err(); err != nil {
+			return nil, eris.Wrap(err, "replay cancelled")
+		}
+		height := tick.GetHeader().GetTickHeight()
+		if err := w.replayTick(ctx, tick); err != nil {
+			return nil, err
+		}
+		result.ToTick = height
+
+		// The recorded state hash finds the exact tick the replay diverged at. It's zero if the tick was
+		// recorded without one.
+		if want := tick.GetHeader().GetStateHash(); want != 0 && want != w.stateHash {
+			return nil, eris.Wrapf(ErrReplayDiverged, "state hash of tick %d is %s, recorded %s",
+				height, formatStateHash(w.stateHash), formatStateHash(want))
+		}
+		if !recorded[height] {
+			continue
+		}
+		if err := w.verifyReplay(ctx, snapshots, height); err != nil {
+			return nil, err
+		}
+		result.Verified = append(result.Verified, height)
+	}
+	return result, nil
+}
+
+// replayStart restores the recorded snapshot the replay starts from: the one of fromTick if it's set,
+// or else the earliest one. The initial state is kept if there are no recorded snapshots.
+func (w *World) replayStart(
+	ctx context.Context, snapshots snapshot.Storage, infos []snapshot.Info, fromTick *uint64,
+) error {
+	var tick uint64
+	switch {
+	case fromTick != nil:
+		tick = *fromTick
+	case len(infos) > 0:
+		tick = infos[0].TickHeight
+	default:
+		return nil
+	}
+
+	snap, err := snapshots.LoadAt(ctx, tick)
+	if err != nil {
+		return eris.Wrapf(err, "failed to load snapshot of tick %d", tick)
+	}
+	worldState, err := w.migrateSnapshot(snap)
+	if err != nil {
+		return err
+	}
+	if err := w.applySnapshot(snap.TickHeight, worldState); err != nil {
+		return eris.Wrapf(err, "snapshot of tick %d is invalid", snap.TickHeight)
+	}
+	w.currentTick.height = snap.TickHeight + 1
+	w.commands.SetNextTick(w.currentTick.height)
+	return nil
+}
+
+// replayTick runs a recorded tick. The changes clients made to the schedule before the tick are made
+// again first, with their recorded handles. The recorded commands include the scheduled commands
+// released in the tick, so the schedule is advanced without releasing them again. A tick with commands
+// that weren't recorded can't be replayed.
+func (w *World) replayTick(ctx context.Context, tick *iscv1.Tick) error {
+	height := tick.GetHeader().GetTickHeight()
+	if unrecorded := tick.GetData().GetUnrecordedCommands(); len(unrecorded) > 0 {
+		return eris.Errorf("tick %d can't be replayed, its commands %v weren't recorded", height, unrecorded)
+	}
+	if err := w.commands.ApplyScheduleChanges(tick.GetData().GetScheduleChanges()); err != nil {
+		return eris.Wrapf(err, "failed to apply recorded schedule changes of tick %d", height)
+	}
+	w.commands.Skip(height)
+	for _, cmd := range tick.GetData().GetCommands() {
+		if err := w.commands.Enqueue(cmd); err != nil {
+			return eris.Wrapf(err, "failed to enqueue recorded command of tick %d", height)
+		}
+	}
+	w.tick(ctx, tick.GetHeader().GetTimestamp().AsTime(), false)
+	return nil
+}
+
+// verifyReplay compares the replayed state with the recorded snapshot of the tick that just ran.
+func (w *World) verifyReplay(ctx context.Context, snapshots snapshot.Storage, height uint64) error {
+	snap, err := snapshots.LoadAt(ctx, height)
+	if err != nil {
+		return eris.Wrapf(err, "failed to load snapshot of tick %d", height)
+	}
+	want, err := w.migrateSnapshot(snap)
+	if err != nil {
+		return err
+	}
+	got, err := w.stateToProto()
+	if err != nil {
+		return eris.Wrapf(err, "failed to serialize replayed state of tick %d", height)
+	}
+	if diffs := w.diffWorldStates(want, got); len(diffs) > 0 {
+		return eris.Wrapf(ErrReplayDiverged, "state of tick %d differs from its snapshot:\n%s",
+			height, strings.Join(diffs, "\n"))
+	}
+	return nil
+}
+
+// readReplayTicks reads the recorded ticks from tick from to tick to, checking the hash chain of their
+// epochs and skipping duplicated epochs. A tick recorded again supersedes the earlier recordings of it
+// and of the ticks after it, as the shard continued from an earlier tick.
+func readReplayTicks(ctx context.Context, store ticklog.Store, from uint64, to *uint64) ([]*iscv1.Tick, error) {
+	var ticks []*iscv1.Tick
+	var prevHash []byte
+	var prevHeight uint64
+	var prev *iscv1.Epoch
+	first := true
+	for epoch, err := range store.Read(ctx, 0) {
+		if err != nil {
+			return nil, eris.Wrap(err, "failed to read tick log")
+		}
+		if ticklog.Duplicate(prev, epoch) {
+			continue
+		}
+		prev = epoch
+
+		// The epochs before the first one read may have expired, so the chain is checked from there.
+		height := epoch.GetEpochHeight()
+		switch {
+		case first && height > 0:
+		case !first && height != prevHeight+1:
+			return nil, eris.Errorf("tick log epoch %d follows epoch %d", height, prevHeight)
+		default:
+			hash, err := ticklog.Hash(prevHash, epoch)
+			if err != nil {
+				return nil, err
+			}
+			if !bytes.Equal(hash, epoch.GetHash()) {
+				return nil, eris.Errorf("hash mismatch of tick log epoch %d", height)
+			}
+		}
+		prevHash, prevHeight, first = epoch.GetHash(), height, false
+
+		for _, tick := range epoch.GetTicks() {
+			h := tick.GetHeader().GetTickHeight()
+			for len(ticks) > 0 && ticks[len(ticks)-1].GetHeader().GetTickHeight() >= h {
+				ticks = ticks[:len(ticks)-1]
+			}
+			if h >= from && (to == nil || h <= *to) {
+				ticks = append(ticks, tick)
+			}
+		}
+	}
+
+	if len(ticks) == 0 {
+		return nil, eris.Errorf("tick log has no ticks to replay from tick %d", from)
+	}
+	for i, tick := range ticks {
+		if want := from + uint64(i); tick.GetHeader().GetTickHeight() != want { //nolint:gosec // i >= 0
+			return nil, eris.Errorf("tick log is missing tick %d", want)
+		}
+	}
+	return ticks, nil
+}
+
+// diffWorldStates describes the differences between a recorded and a replayed world state, up to
+// maxReplayDiffs. Components are compared by their decoded values, as the serialization of maps isn't
+// deterministic.
+func (w *World) diffWorldStates(want, got *cardinalv1.WorldState) []string {
+	var diffs []string
+	addf := func(format string, args ...any) {
+		if len(diffs) < maxReplayDiffs {
+			diffs = append(diffs, fmt.Sprintf(format, args...))
+		}
+	}
+
+	if want.GetSeed() != 0 && want.GetSeed() != got.GetSeed() {
+		addf("seed is %d, want %d", got.GetSeed(), want.GetSeed())
+	}
+	if want.GetNextId() != got.GetNextId() {
+		addf("next entity ID is %d, want %d", got.GetNextId(), want.GetNextId())
+	}
+	if !slices.Equal(want.GetFreeIds(), got.GetFreeIds()) {
+		addf("free entity IDs are %v, want %v", got.GetFreeIds(), want.GetFreeIds())
+	}
+	if !slices.Equal(want.GetEntityArch(), got.GetEntityArch()) {
+		addf("entity archetypes are %v, want %v", got.GetEntityArch(), want.GetEntityArch())
+	}
+	if !proto.Equal(want.GetCommandSchedule(), got.GetCommandSchedule()) {
+		addf("command schedule has %d commands, want %d",
+			len(got.GetCommandSchedule().GetCommands()), len(want.GetCommandSchedule().GetCommands()))
+	}
+
+	if len(want.GetArchetypes()) != len(got.GetArchetypes()) {
+		addf("%d archetypes, want %d", len(got.GetArchetypes()), len(want.GetArchetypes()))
+		return diffs
+	}
+	for i, wantArch := range want.GetArchetypes() {
+		gotArch := got.GetArchetypes()[i]
+		if !bytes.Equal(wantArch.GetComponentsBitmap(), gotArch.GetComponentsBitmap()) ||
+			len(wantArch.GetColumns()) != len(gotArch.GetColumns()) {
+			addf("archetype %d has different components", wantArch.GetId())
+			continue
+		}
+		if !slices.Equal(wantArch.GetEntities(), gotArch.GetEntities()) {
+			addf("archetype %d has entities %v, want %v", wantArch.GetId(), gotArch.GetEntities(),
+				wantArch.GetEntities())
+			continue
+		}
+		for j, wantCol := range wantArch.GetColumns() {
+			name := wantCol.GetComponentName()
+			gotCol := gotArch.GetColumns()[j]
+			if gotCol.GetComponentName() != name || len(gotCol.GetComponents()) != len(wantCol.GetComponents()) ||
+				len(wantCol.GetComponents()) != len(wantArch.GetEntities()) {
+				addf("archetype %d has a different %s column", wantArch.GetId(), name)
+				continue
+			}
+			for row, eid := range wantArch.GetEntities() {
+				wantData, gotData := wantCol.GetComponents()[row], gotCol.GetComponents()[row]
+				if bytes.Equal(wantData, gotData) {
+					continue
+				}
+				wantComp, wantErr := ecs.DecodeComponent(w.world, name, wantData)
+				gotComp, gotErr := ecs.DecodeComponent(w.world, name, gotData)
+				if wantErr != nil || gotErr != nil || !reflect.DeepEqual(wantComp, gotComp) {
+					addf("entity %d has %s %+v, want %+v", eid, name, gotComp, wantComp)
+				}
+			}
+		}
+	}
+	return diffs
+}
+
+// -------------------------------------------------------------------------------------------------
+// Test helper
+// -------------------------------------------------------------------------------------------------
+
+// ReplaySetupFunc builds and returns the world a recording is replayed on, the same way production
+// does, with all systems registered.
+type ReplaySetupFunc func() *World
+
+// RunReplay replays a recording on the world returned by setup, and fails the test if the replay
+// diverges from the recorded snapshots. It's used to check that a build is behavior-compatible with
+// recorded matches:
+//
+//	func TestReplay(t *testing.T) {
+//	    snapshots, _ := snapshot.NewFileStorage(snapshot.FileStorageOptions{Dir: "testdata/snapshots"})
+//	    tickLog, _ := ticklog.NewFileStore(ticklog.FileStoreOptions{Dir: "testdata/ticklog"})
+//	    cardinal.RunReplay(t, func() *cardinal.World {
+//	        world, _ := cardinal.NewWorld(cardinal.WorldOptions{})
+//	        cardinal.RegisterSystem(world, system.MySystem)
+//	        // ... register all systems
+//	        return world
+//	    }, cardinal.ReplayOptions{Snapshots: snapshots, TickLog: tickLog})
+//	}
+func RunReplay(t *testing.T, setup ReplaySetupFunc, opts ReplayOptions) *ReplayResult {
+	t.Helper()
+
+	// Suppress world logs during the replay to reduce noise.
+	t.Setenv("LOG_LEVEL", "disabled")
+
+	w := setup()
+	require.NotNil(t, w, "replay setup returned nil world")
+
+	result, err := Replay(context.Background(), w, opts)
+	require.NoError(t, err)
+	t.Logf("replayed ticks %d to %d, verified against %d snapshots",
+		result.FromTick, result.ToTick, len(result.Verified))
+	return result
+}
@@ -0,0 +1,291 @@
+package cardinal
+
+import (
+	"context"
+	"math/rand/v2"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/argus-labs/world-engine/pkg/cardinal/internal/ecs"
+	"github.com/argus-labs/world-engine/pkg/cardinal/snapshot"
+	"github.com/argus-labs/world-engine/pkg/cardinal/ticklog"
+	"github.com/argus-labs/world-engine/pkg/testutils"
+	cardinalv1 "github.com/argus-labs/world-engine/proto/gen/go/worldengine/cardinal/v1"
+	iscv1 "github.com/argus-labs/world-engine/proto/gen/go/worldengine/isc/v1"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// -------------------------------------------------------------------------------------------------
+// Replay smoke tests
+// -------------------------------------------------------------------------------------------------
+// Verifies that replaying the tick log of a recorded shard, which restarted from an earlier snapshot
+// once, reproduces every later recorded state hash and snapshot, including scheduled commands and
+// timestamps, from the earliest snapshot or a given range of ticks, and that a changed system makes the
+// replay diverge. Commands clients scheduled and cancelled through the service between the ticks are
+// replayed with the same handles as well.
+// -------------------------------------------------------------------------------------------------
+
+type replayTestState struct {
+	BaseSystemState
+	Command  WithCommand[testutils.SimpleCommand]
+	Entities Exact[struct {
+		A Ref[testutils.ComponentA]
+	}]
+}
+
+// newReplayTestSetup returns a setup of a world whose system creates an entity per command, schedules
+// a follow-up command for even values, and adds step to every entity each tick.
+func newReplayTestSetup(t *testing.T, step float64) ReplaySetupFunc {
+	return func() *World {
+		debug := false
+		w, err := NewWorld(WorldOptions{
+			Region:              "replay",
+			Organization:        "replay",
+			Project:             "replay",
+			ShardID:             "0",
+			TickRate:            1,
+			SnapshotStorageType: snapshot.StorageTypeNop,
+			TickLogStoreType:    ticklog.StoreTypeNop,
+			SnapshotRate:        5,
+			Debug:               &debug,
+		})
+		require.NoError(t, err)
+
+		RegisterSystem(w, func(state *replayTestState) {
+			for cmd := range state.Command.Iter() {
+				_, entity := state.Entities.Create()
+				entity.A.Set(testutils.ComponentA{
+					X: float64(cmd.Payload.Value),
+					Y: float64(state.Timestamp().Unix()),
+				})
+				if cmd.Payload.Value%2 == 0 {
+					_, err := state.Schedule(2*time.Second, testutils.SimpleCommand{Value: cmd.Payload.Value + 1})
+					assert.NoError(t, err)
+				}
+			}
+			for _, entity := range state.Entities.Iter() {
+				a := entity.A.Get()
+				a.Z += step
+				entity.A.Set(a)
+			}
+		})
+		return w
+	}
+}
+
+func TestReplay(t *testing.T) {
+	t.Setenv("LOG_LEVEL", "disabled")
+	prng := testutils.NewRand(t)
+	ctx := context.Background()
+
+	storage, err := snapshot.NewFileStorage(snapshot.FileStorageOptions{
+		Logger:    zerolog.Nop(),
+		Dir:       t.TempDir(),
+		Retention: snapshot.RetentionPolicy{Generations: 100},
+	})
+	require.NoError(t, err)
+	store, err := ticklog.NewFileStore(ticklog.FileStoreOptions{Logger: zerolog.Nop(), Dir: t.TempDir()})
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, store.Close())
+	}()
+	setup := newReplayTestSetup(t, 1)
+
+	// Record ticks 0 to 29, then restart from the snapshot of tick 20 and record ticks 21 to 34, which
+	// supersede the first recording of ticks 21 to 29.
+	recordReplayTicks(t, prng, setup(), storage, store, nil, 30, nil)
+	restoreTick := uint64(20)
+	recordReplayTicks(t, prng, setup(), storage, store, &restoreTick, 14, nil)
+
+	result := RunReplay(t, setup, ReplayOptions{Snapshots: storage, TickLog: store})
+	assert.Equal(t, uint64(1), result.FromTick)
+	assert.Equal(t, uint64(34), result.ToTick)
+	assert.Equal(t, []uint64{5, 10, 15, 20, 25, 30}, result.Verified)
+
+	from, to := uint64(10), uint64(26)
+	result = RunReplay(t, setup, ReplayOptions{Snapshots: storage, TickLog: store, FromTick: &from, ToTick: &to})
+	assert.Equal(t, uint64(11), result.FromTick)
+	assert.Equal(t, uint64(26), result.ToTick)
+	assert.Equal(t, []uint64{15, 20, 25}, result.Verified)
+
+	// A world whose system changed diverges at the first tick whose recorded state hash differs.
+	_, err = Replay(ctx, newReplayTestSetup(t, 2)(), ReplayOptions{Snapshots: storage, TickLog: store})
+	require.ErrorIs(t, err, ErrReplayDiverged)
+	assert.ErrorContains(t, err, "state hash of tick")
+}
+
+func TestReplay_ClientSchedule(t *testing.T) {
+	t.Setenv("LOG_LEVEL", "disabled")
+	prng := testutils.NewRand(t)
+
+	storage, err := snapshot.NewFileStorage(snapshot.FileStorageOptions{
+		Logger:    zerolog.Nop(),
+		Dir:       t.TempDir(),
+		Retention: snapshot.RetentionPolicy{Generations: 100},
+	})
+	require.NoError(t, err)
+	store, err := ticklog.NewFileStore(ticklog.FileStoreOptions{Logger: zerolog.Nop(), Dir: t.TempDir()})
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, store.Close())
+	}()
+	setup := newReplayTestSetup(t, 1)
+
+	// Before every tick, clients schedule commands at a tick or after a delay, which interleave with the
+	// follow-ups the system schedules, and cancel some of the commands they scheduled.
+	users := []string{testutils.RandString(prng, 8), testutils.RandString(prng, 8)}
+	type scheduled struct {
+		user   string
+		handle uint64
+	}
+	var handles []scheduled
+	cancelled := 0
+	clients := func(w *World) {
+		for range prng.IntN(3) {
+			user := users[prng.IntN(len(users))]
+			ctx := serviceTestContext(user)
+			if len(handles) > 0 && prng.IntN(3) == 0 {
+				// Cancel one of the latest commands, which are likely pending, usually as its user.
+				i := len(handles) - 1 - prng.IntN(min(len(handles), 3))
+				if prng.IntN(4) > 0 {
+					ctx = serviceTestContext(handles[i].user)
+				}
+				req := &cardinalv1.CancelScheduledCommandRequest{Address: w.address, Handle: handles[i].handle}
+				if _, err := w.service.CancelScheduledCommand(ctx, connect.NewRequest(req)); err == nil {
+					cancelled++
+				}
+				continue
+			}
+
+			payload, err := testutils.SimpleCommand{Value: prng.IntN(1000)}.MarshalWire()
+			require.NoError(t, err)
+			req := &cardinalv1.ScheduleCommandRequest{
+				Command: &iscv1.Command{
+					Name:    testutils.SimpleCommand{}.Name(),
+					Address: w.address,
+					Persona: &iscv1.Persona{Id: user},
+					Payload: payload,
+				},
+			}
+			if prng.IntN(2) == 0 {
+				delay := time.Duration(prng.IntN(10)) * time.Second
+				req.When = &cardinalv1.ScheduleCommandRequest_Delay{Delay: durationpb.New(delay)}
+			} else {
+				tick := w.currentTick.height + uint64(prng.IntN(10)) //nolint:gosec // small
+				req.When = &cardinalv1.ScheduleCommandRequest_TickHeight{TickHeight: tick}
+			}
+			res, err := w.service.ScheduleCommand(ctx, connect.NewRequest(req))
+			require.NoError(t, err)
+			handles = append(handles, scheduled{user: user, handle: res.Msg.GetHandle()})
+		}
+
+		// Every few ticks, a client cancels a command that's surely still pending.
+		if w.currentTick.height%5 == 0 {
+			ctx := serviceTestContext(users[0])
+			payload, err := testutils.SimpleCommand{Value: prng.IntN(1000)}.MarshalWire()
+			require.NoError(t, err)
+			res, err := w.service.ScheduleCommand(ctx, connect.NewRequest(&cardinalv1.ScheduleCommandRequest{
+				Command: &iscv1.Command{
+					Name:    testutils.SimpleCommand{}.Name(),
+					Address: w.address,
+					Persona: &iscv1.Persona{Id: users[0]},
+					Payload: payload,
+				},
+				When: &cardinalv1.ScheduleCommandRequest_TickHeight{TickHeight: w.currentTick.height + 100},
+			}))
+			require.NoError(t, err)
+			req := &cardinalv1.CancelScheduledCommandRequest{Address: w.address, Handle: res.Msg.GetHandle()}
+			_, err = w.service.CancelScheduledCommand(ctx, connect.NewRequest(req))
+			require.NoError(t, err)
+			cancelled++
+		}
+	}
+	recordReplayTicks(t, prng, setup(), storage, store, nil, 30, clients)
+	require.NotEmpty(t, handles)
+	require.Positive(t, cancelled)
+
+	result := RunReplay(t, setup, ReplayOptions{Snapshots: storage, TickLog: store})
+	assert.Equal(t, uint64(29), result.ToTick)
+	assert.Equal(t, []uint64{5, 10, 15, 20, 25}, result.Verified)
+}
+
+// -------------------------------------------------------------------------------------------------
+// State hash
+// -------------------------------------------------------------------------------------------------
+// Verifies that the state hash covers the command schedule and the seed besides the ECS world, and
+// that a world restored from a snapshot of the state hashes the same.
+// -------------------------------------------------------------------------------------------------
+
+func TestWorld_StateHash(t *testing.T) {
+	t.Parallel()
+	prng := testutils.NewRand(t)
+
+	w := newSnapshotTestWorld(t, prng, snapshot.NewNopStorage())
+	eid := ecs.Create(w.world)
+	require.NoError(t, ecs.Set(w.world, eid, testutils.ComponentA{X: prng.Float64()}))
+	w.updateStateHash(0)
+	initial := w.stateHash
+
+	cmd, err := w.selfCommand(testutils.SimpleCommand{Value: prng.IntN(1000)})
+	require.NoError(t, err)
+	cmd.Persona = &iscv1.Persona{Id: testutils.RandString(prng, 8)}
+	handle, _, err := w.commands.Schedule(cmd, 10)
+	require.NoError(t, err)
+	w.updateStateHash(0)
+	scheduled := w.stateHash
+	assert.NotEqual(t, initial, scheduled, "scheduling a command should change the state hash")
+
+	w.seed++
+	w.updateStateHash(0)
+	seeded := w.stateHash
+	assert.NotEqual(t, scheduled, seeded, "changing the seed should change the state hash")
+
+	state, err := w.stateToProto()
+	require.NoError(t, err)
+	restored := newSnapshotTestWorld(t, prng, snapshot.NewNopStorage())
+	require.NoError(t, restored.applySnapshot(0, state))
+	restored.updateStateHash(0)
+	assert.Equal(t, seeded, restored.stateHash)
+
+	require.NoError(t, w.commands.Cancel(handle))
+	w.updateStateHash(0)
+	assert.NotEqual(t, seeded, w.stateHash, "cancelling a command should change the state hash")
+}
+
+// recordReplayTicks runs count ticks of w with random commands, storing snapshots in storage and
+// recording the ticks in store, after restoring the snapshot of restoreTick if it's set. If clients is
+// set, it's called before every tick.
+func recordReplayTicks(
+	t *testing.T, prng *rand.Rand, w *World, storage snapshot.Storage, store ticklog.Store,
+	restoreTick *uint64, count int, clients func(w *World),
+) {
+	t.Helper()
+	ctx := context.Background()
+
+	w.snapshotStorage = storage
+	w.options.RestoreTick = restoreTick
+	w.world.Init()
+	require.NoError(t, w.restore(ctx))
+	tl, err := newTickLog(ctx, store, zerolog.Nop())
+	require.NoError(t, err)
+	w.tickLog = tl
+
+	start := time.UnixMilli(1_700_000_000_000)
+	for range count {
+		for range prng.IntN(3) {
+			cmd, err := w.selfCommand(testutils.SimpleCommand{Value: prng.IntN(1000)})
+			require.NoError(t, err)
+			cmd.Persona = &iscv1.Persona{Id: testutils.RandString(prng, 8)}
+			require.NoError(t, w.commands.Enqueue(cmd))
+		}
+		if clients != nil {
+			clients(w)
+		}
+		w.Tick(ctx, start.Add(time.Duration(w.currentTick.height)*time.Second))
+	}
+	require.NoError(t, w.tickLog.close(ctx))
+}
@@ -3,10 +3,12 @@ package cardinal
 import (
 	"context"
 	"io"
+	"maps"
 	"net"
 	"net/http"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"buf.build/go/protovalidate"
@@ -24,6 +26,7 @@ import (
 	iscv1 "github.com/argus-labs/world-engine/proto/gen/go/worldengine/isc/v1"
 	"github.com/goccy/go-json"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"github.com/rotisserie/eris"
 	"github.com/rs/zerolog"
 	"google.golang.org/grpc/codes"
@@ -39,23 +42,58 @@ type service struct {
 	client       *micro.Client
 	microService *micro.Service
 	commands     map[string]struct{}
-	subscribers  map[string]*streamSubscriber
-	replyWaiters map[string][]chan *iscv1.Event
+	subscribers  map[string]map[string]*streamSubscriber // User ID -> stream ID -> stream
+	history      *eventHistory
+	queueSize    int            // Capacity of each event stream's outbound queue
+	overflow     OverflowPolicy // What to do when an event stream's outbound queue is full
+	metrics      *streamMetrics
+	filters      *filterCompiler
+	authorizer   QueryAuthorizer // Filters what each persona sees in queries and state streams, nil to show all
+	sink         *eventSink      // Publishes dispatched events to JetStream, nil if disabled
+	replyWaiters map[command.ReplyID]chan *iscv1.Event
+	nextReplyID  command.ReplyID
 	mu           sync.RWMutex
+
+	stateSubscribers map[string]map[*stateSubscriber]struct{} // User ID -> open state streams
+	stateMu          sync.Mutex
+	index            atomic.Pointer[stateIndex] // Index of the latest published world state
 }
 
+// maxStreamsPerUser is the number of event streams a user can have open at once.
+const maxStreamsPerUser = 8
+
+// defaultReplyTimeout is how long SendCommandWithReply waits for a reply if the request doesn't set a
+// timeout.
+const defaultReplyTimeout = 30 * time.Second
+
 var _ cardinalv1connect.CardinalServiceHandler = (*service)(nil)
 
 // newService creates a new direct client-facing Cardinal service.
-func newService(world *World, authMode AuthMode, argusAuthURL string) *service {
+func newService(world *World, options WorldOptions) *service {
+	log := world.tel.GetLogger("service")
+	metrics, err := newStreamMetrics()
+	if err != nil {
+		log.Warn().Err(err).Msg("event stream metrics may not be exported")
+	}
+
 	return &service{
 		world:        world,
-		log:          world.tel.GetLogger("service"),
-		authMode:     authMode,
-		argusAuthURL: argusAuthURL,
+		log:          log,
+		authMode:     options.AuthMode,
+		argusAuthURL: options.ArgusAuthURL,
 		commands:     make(map[string]struct{}),
-		subscribers:  make(map[string]*streamSubscriber),
-		replyWaiters: make(map[string][]chan *iscv1.Event),
+		subscribers:  make(map[string]map[string]*streamSubscriber),
+		// Sequence numbers start from the clock, so they're greater than the ones of earlier runs.
+		history:      newEventHistory(options.EventHistorySize, options.TargetedEventTTL, uint64(time.Now().UnixMicro())),
+		queueSize:    options.EventStreamQueue,
+		overflow:     options.EventStreamOverflow,
+		metrics:      metrics,
+		filters:      newFilterCompiler(),
+		authorizer:   options.QueryAuthorizer,
+		replyWaiters: make(map[command.ReplyID]chan *iscv1.Event),
+		nextReplyID:  1, // Reserve 0 as the "no reply" ID
+
+		stateSubscribers: make(map[string]map[*stateSubscriber]struct{}),
 	}
 }
 
@@ -85,6 +123,14 @@ func (s *service) init(address string) error {
 	}
 	s.microService = microService
 
+	if enabled := s.world.options.EventSink; enabled != nil && *enabled {
+		sink, err := newEventSink(client, s.world.address, s.world.tel.GetLogger("sink"))
+		if err != nil {
+			return eris.Wrap(err, "failed to create event sink")
+		}
+		s.sink = sink
+	}
+
 	// Keep these for now cuz ISC requires a bit more work than client connections. Will need another
 	// refactor after the current clients are migrated to connect directly to the shards.
 	if err = s.microService.AddEndpoint("ping", s.handlePing); err != nil {
@@ -172,6 +218,9 @@ func (s *service) shutdown(ctx context.Context) error {
 			return eris.Wrap(err, "failed to close micro service")
 		}
 	}
+	if err := s.sink.close(ctx); err != nil {
+		s.log.Error().Err(err).Msg("failed to flush event sink")
+	}
 	s.client.Close()
 
 	return nil
@@ -188,20 +237,6 @@ func (s *service) registerCommandHandler(name string) {
 // TODO: eventually, we'll probably have more user fields in the command metadata, possibly a User
 // struct field instead of a single persona ID.
 
-type streamSubscriber struct {
-	ctx    context.Context
-	stream *connect.ServerStream[cardinalv1.StartEventStreamResponse]
-	events map[string]struct{}
-	mu     sync.Mutex
-}
-
-func (s *streamSubscriber) send(response *cardinalv1.StartEventStreamResponse) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	return s.stream.Send(response)
-}
-
 func (s *service) SendCommand(
 	ctx context.Context,
 	req *connect.Request[cardinalv1.SendCommandRequest],
@@ -216,20 +251,66 @@ func (s *service) SendCommand(
 	assert.That(user != nil, "user should exist in authenticated request context")
 
 	cmd := req.Msg.GetCommand()
-	assert.That(cmd != nil, "command should have been validated")
-	assert.That(cmd.GetPersona() != nil, "command persona should have been validated")
+	if err := s.prepareCommand(user, cmd); err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
 
-	cmd.Persona.Id = user.ID
+	receipt, tick, err := s.world.commands.EnqueueWithReceipt(cmd)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, eris.Wrap(err, "failed to enqueue command"))
+	}
 
-	if micro.String(s.world.address) != micro.String(cmd.GetAddress()) {
-		return nil, connect.NewError(connect.CodeInvalidArgument, eris.New("address doesn't match shard address"))
+	return connect.NewResponse(&cardinalv1.SendCommandResponse{
+		ReceiptId:  receipt,
+		TickHeight: tick,
+	}), nil
+}
+
+func (s *service) SendCommands(
+	ctx context.Context,
+	req *connect.Request[cardinalv1.SendCommandsRequest],
+) (*connect.Response[cardinalv1.SendCommandsResponse], error) {
+	select {
+	case <-ctx.Done():
+		return nil, connect.NewError(connect.CodeCanceled, eris.Wrap(ctx.Err(), "context cancelled"))
+	default:
 	}
 
-	if err := s.world.commands.Enqueue(cmd); err != nil {
-		return nil, connect.NewError(connect.CodeInvalidArgument, eris.Wrap(err, "failed to enqueue command"))
+	user := UserFromContext(ctx)
+	assert.That(user != nil, "user should exist in authenticated request context")
+
+	commands := req.Msg.GetCommands()
+	results := make([]*cardinalv1.SendCommandResult, len(commands))
+	accepted := true
+	for i, cmd := range commands {
+		results[i] = &cardinalv1.SendCommandResult{}
+		if err := s.prepareCommand(user, cmd); err != nil {
+			results[i].Error = err.Error()
+			accepted = false
+		}
+	}
+	if !accepted {
+		return connect.NewResponse(&cardinalv1.SendCommandsResponse{Results: results}), nil
 	}
 
-	return connect.NewResponse(&cardinalv1.SendCommandResponse{}), nil
+	receipts, tick, errs := s.world.commands.EnqueueBatchWithReceipt(commands)
+	if errs != nil {
+		for i, err := range errs {
+			if err != nil {
+				results[i].Error = err.Error()
+			}
+		}
+		return connect.NewResponse(&cardinalv1.SendCommandsResponse{Results: results}), nil
+	}
+
+	for i, receipt := range receipts {
+		results[i].ReceiptId = receipt
+	}
+	return connect.NewResponse(&cardinalv1.SendCommandsResponse{
+		Accepted:   true,
+		TickHeight: tick,
+		Results:    results,
+	}), nil
 }
 
 func (s *service) SendCommandWithReply(
@@ -240,53 +321,186 @@ func (s *service) SendCommandWithReply(
 	assert.That(user != nil, "user should exist in authenticated request context")
 
 	cmd := req.Msg.GetCommand()
-	assert.That(cmd != nil, "command should have been validated")
-	assert.That(cmd.GetPersona() != nil, "command persona should have been validated")
-
-	cmd.Persona.Id = user.ID
-
-	if micro.String(s.world.address) != micro.String(cmd.GetAddress()) {
-		return nil, connect.NewError(connect.CodeInvalidArgument, eris.New("address doesn't match shard address"))
+	if err := s.prepareCommand(user, cmd); err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
 	}
 
-	if err := s.world.commands.Enqueue(cmd); err != nil {
+	// Register the waiter before enqueueing, so the reply can't be published before we're listening.
+	replyID, waiter := s.addReplyWaiter()
+	defer s.removeReplyWaiter(replyID)
+
+	receipt, _, err := s.world.commands.EnqueueWithReply(cmd, replyID)
+	if err != nil {
 		return nil, connect.NewError(connect.CodeInvalidArgument, eris.Wrap(err, "failed to enqueue command"))
 	}
 
-	waiter := s.addReplyWaiter(req.Msg.GetEventName())
-	defer s.removeReplyWaiter(req.Msg.GetEventName(), waiter)
+	timeout := defaultReplyTimeout
+	if req.Msg.GetTimeout() != nil {
+		timeout = req.Msg.GetTimeout().AsDuration()
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
 
 	select {
 	case <-ctx.Done():
 		return nil, connect.NewError(connect.CodeCanceled, eris.Wrap(ctx.Err(), "waiting for reply event"))
+	case <-timer.C:
+		return nil, connect.NewError(connect.CodeDeadlineExceeded,
+			eris.Errorf("no reply to command %s (receipt %d) within %s", cmd.GetName(), receipt, timeout))
 	case event := <-waiter:
-		return connect.NewResponse(&cardinalv1.SendCommandWithReplyResponse{Event: event}), nil
+		return connect.NewResponse(&cardinalv1.SendCommandWithReplyResponse{
+			Event:     event,
+			ReceiptId: receipt,
+		}), nil
+	}
+}
+
+// prepareCommand sets the persona of a client command to the authenticated user, so clients can't
+// send commands on behalf of others, and checks that the command is addressed to this shard.
+func (s *service) prepareCommand(user *User, cmd *iscv1.Command) error {
+	assert.That(cmd != nil, "command should have been validated")
+	assert.That(cmd.GetPersona() != nil, "command persona should have been validated")
+
+	cmd.Persona.Id = user.ID
+
+	if micro.String(s.world.address) != micro.String(cmd.GetAddress()) {
+		return eris.New("address doesn't match shard address")
 	}
+	return nil
 }
 
-func (s *service) addReplyWaiter(eventName string) chan *iscv1.Event {
+// addReplyWaiter registers a channel that receives the reply to a command, and returns the reply ID
+// to attach to the command.
+func (s *service) addReplyWaiter() (command.ReplyID, chan *iscv1.Event) {
 	waiter := make(chan *iscv1.Event, 1)
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	s.replyWaiters[eventName] = append(s.replyWaiters[eventName], waiter)
-	return waiter
+	replyID := s.nextReplyID
+	s.nextReplyID++
+	s.replyWaiters[replyID] = waiter
+	return replyID, waiter
 }
 
-func (s *service) removeReplyWaiter(eventName string, waiter chan *iscv1.Event) {
+func (s *service) removeReplyWaiter(replyID command.ReplyID) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	waiters := s.replyWaiters[eventName]
-	for i, current := range waiters {
-		if current == waiter {
-			s.replyWaiters[eventName] = append(waiters[:i], waiters[i+1:]...)
-			break
-		}
+	delete(s.replyWaiters, replyID)
+}
+
+func (s *service) ScheduleCommand(
+	ctx context.Context,
+	req *connect.Request[cardinalv1.ScheduleCommandRequest],
+) (*connect.Response[cardinalv1.ScheduleCommandResponse], error) {
+	user := UserFromContext(ctx)
+	assert.That(user != nil, "user should exist in authenticated request context")
+
+	cmd := req.Msg.GetCommand()
+	if err := s.prepareCommand(user, cmd); err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
 	}
-	if len(s.replyWaiters[eventName]) == 0 {
-		delete(s.replyWaiters, eventName)
+
+	handle, tick, err := s.scheduleOwned(req.Msg)
+	if eris.Is(err, command.ErrScheduleQuota) {
+		return nil, connect.NewError(connect.CodeResourceExhausted, eris.Wrap(err, "failed to schedule command"))
+	}
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, eris.Wrap(err, "failed to schedule command"))
+	}
+
+	return connect.NewResponse(&cardinalv1.ScheduleCommandResponse{
+		Handle:     handle,
+		TickHeight: tick,
+	}), nil
+}
+
+// scheduleOwned schedules the command of a request for its user. The schedule is changed between
+// ticks, so the change is recorded in the tick log before the next tick and replays make it at the
+// same point.
+func (s *service) scheduleOwned(
+	req *cardinalv1.ScheduleCommandRequest,
+) (handle command.ScheduleHandle, tick uint64, err error) {
+	s.world.tickMu.Lock()
+	defer s.world.tickMu.Unlock()
+
+	switch when := req.GetWhen().(type) {
+	case *cardinalv1.ScheduleCommandRequest_TickHeight:
+		return s.world.commands.ScheduleOwned(req.GetCommand(), when.TickHeight)
+	case *cardinalv1.ScheduleCommandRequest_Delay:
+		return s.world.commands.ScheduleOwnedIn(req.GetCommand(), s.world.delayTicks(when.Delay.AsDuration()))
+	default:
+		assert.That(false, "schedule time should have been validated")
+		return 0, 0, nil
+	}
+}
+
+func (s *service) CancelScheduledCommand(
+	ctx context.Context,
+	req *connect.Request[cardinalv1.CancelScheduledCommandRequest],
+) (*connect.Response[cardinalv1.CancelScheduledCommandResponse], error) {
+	user := UserFromContext(ctx)
+	assert.That(user != nil, "user should exist in authenticated request context")
+
+	if micro.String(s.world.address) != micro.String(req.Msg.GetAddress()) {
+		return nil, connect.NewError(connect.CodeInvalidArgument, eris.New("address doesn't match shard address"))
+	}
+
+	// Users can only cancel the commands they scheduled themselves. Like scheduling, cancelling
+	// happens between ticks.
+	s.world.tickMu.Lock()
+	err := s.world.commands.CancelOwned(req.Msg.GetHandle(), user.ID)
+	s.world.tickMu.Unlock()
+	if err != nil {
+		return nil, connect.NewError(connect.CodeNotFound, err)
+	}
+
+	return connect.NewResponse(&cardinalv1.CancelScheduledCommandResponse{}), nil
+}
+
+func (s *service) GetCommandReceipt(
+	ctx context.Context,
+	req *connect.Request[cardinalv1.GetCommandReceiptRequest],
+) (*connect.Response[cardinalv1.GetCommandReceiptResponse], error) {
+	user := UserFromContext(ctx)
+	assert.That(user != nil, "user should exist in authenticated request context")
+
+	if micro.String(s.world.address) != micro.String(req.Msg.GetAddress()) {
+		return nil, connect.NewError(connect.CodeInvalidArgument, eris.New("address doesn't match shard address"))
+	}
+
+	// Users can only look up the receipts of commands they sent themselves.
+	receipt, err := s.world.commands.Receipt(req.Msg.GetReceiptId(), user.ID)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeNotFound, err)
+	}
+
+	return connect.NewResponse(&cardinalv1.GetCommandReceiptResponse{
+		Receipt: receiptToProto(receipt),
+	}), nil
+}
+
+// receiptToProto converts a command receipt to its protobuf representation.
+func receiptToProto(receipt command.Receipt) *cardinalv1.CommandReceipt {
+	var status cardinalv1.CommandStatus
+	switch receipt.Status {
+	case command.ReceiptPending:
+		status = cardinalv1.CommandStatus_COMMAND_STATUS_PENDING
+	case command.ReceiptProcessed:
+		status = cardinalv1.CommandStatus_COMMAND_STATUS_PROCESSED
+	case command.ReceiptFailed:
+		status = cardinalv1.CommandStatus_COMMAND_STATUS_FAILED
+	default:
+		status = cardinalv1.CommandStatus_COMMAND_STATUS_UNSPECIFIED
+	}
+	return &cardinalv1.CommandReceipt{
+		ReceiptId:   receipt.ID,
+		CommandName: receipt.Name,
+		Status:      status,
+		TickHeight:  receipt.TickHeight,
+		Error:       receipt.Error,
+		Result:      receipt.Result,
 	}
 }
 
@@ -302,21 +516,20 @@ func (s *service) StartEventStream(
 	user := UserFromContext(ctx)
 	assert.That(user != nil, "user should exist in authenticated stream context")
 
-	subscriber, err := s.addSubscriber(ctx, user, stream)
+	subscriptions, err := s.parseSubscriptions(req.Msg.GetSubscriptions())
 	if err != nil {
-		return connect.NewError(connect.CodeFailedPrecondition, err)
+		return err
 	}
-	defer s.removeSubscriber(user)
 
-	for _, subscription := range req.Msg.GetSubscriptions() {
-		if micro.String(s.world.address) != micro.String(subscription.GetAddress()) {
-			return connect.NewError(connect.CodeInvalidArgument, eris.New("address doesn't match shard address"))
-		}
+	subscriber, replay, truncated, err := s.addSubscriber(ctx, user, stream, req.Msg, subscriptions)
+	if err != nil {
+		return err
 	}
-	s.subscribeEvents(user, req.Msg.GetSubscriptions())
+	defer s.removeSubscriber(user, subscriber)
 
-	if err := subscriber.send(&cardinalv1.StartEventStreamResponse{}); err != nil {
-		return connect.NewError(connect.CodeInternal, eris.Wrap(err, "failed to send initial empty event to client"))
+	// Live events published in the meantime wait in the queue, so the stream stays in sequence order.
+	if err := s.replayEvents(subscriber, replay, truncated); err != nil {
+		return connect.NewError(connect.CodeInternal, eris.Wrap(err, "failed to replay events to client"))
 	}
 
 	// Send periodic keepalive messages to prevent ALB idle timeouts.
@@ -331,14 +544,64 @@ func (s *service) StartEventStream(
 				return connect.NewError(connect.CodeCanceled, eris.Wrap(err, "stream cancelled"))
 			}
 			return nil
+		case <-subscriber.done:
+			return subscriber.closeErr
+		case response := <-subscriber.queue:
+			subscriber.metrics.queued.Add(ctx, -1)
+			if err := stream.Send(response); err != nil {
+				return err
+			}
 		case <-ticker.C:
-			if err := subscriber.send(&cardinalv1.StartEventStreamResponse{}); err != nil {
+			if err := stream.Send(&cardinalv1.StartEventStreamResponse{}); err != nil {
 				return err
 			}
 		}
 	}
 }
 
+// replayEvents sends the initial message with the stream ID, followed by the retained events the
+// client missed.
+func (s *service) replayEvents(subscriber *streamSubscriber, replay []historyEntry, truncated bool) error {
+	err := subscriber.stream.Send(&cardinalv1.StartEventStreamResponse{
+		HistoryTruncated: truncated,
+		StreamId:         subscriber.id,
+	})
+	if err != nil {
+		return eris.Wrap(err, "failed to send initial empty event to client")
+	}
+	if subscriber.batch {
+		return s.replayBatches(subscriber, replay)
+	}
+	for _, entry := range replay {
+		err := subscriber.stream.Send(&cardinalv1.StartEventStreamResponse{
+			Address:  s.world.address,
+			Event:    entry.event,
+			Sequence: entry.seq,
+		})
+		if err != nil {
+			return eris.Wrap(err, "failed to send event")
+		}
+	}
+	return nil
+}
+
+// replayBatches sends the replayed events of a batching stream in a batch per tick.
+func (s *service) replayBatches(subscriber *streamSubscriber, replay []historyEntry) error {
+	for start := 0; start < len(replay); {
+		tick := replay[start].tick
+		events := make([]*cardinalv1.BatchedEvent, 0)
+		end := start
+		for ; end < len(replay) && replay[end].tick.height == tick.height; end++ {
+			events = append(events, &cardinalv1.BatchedEvent{Event: replay[end].event, Sequence: replay[end].seq})
+		}
+		if err := subscriber.stream.Send(newBatchResponse(s.world.address, tick, events, 0)); err != nil {
+			return eris.Wrap(err, "failed to send event batch")
+		}
+		start = end
+	}
+	return nil
+}
+
 func (s *service) SubscribeEvents(
 	ctx context.Context,
 	req *connect.Request[cardinalv1.SubscribeEventsRequest],
@@ -346,16 +609,16 @@ func (s *service) SubscribeEvents(
 	user := UserFromContext(ctx)
 	assert.That(user != nil, "user should exist in authenticated request context")
 
-	if !s.hasSubscriber(user) {
-		return nil, connect.NewError(connect.CodeFailedPrecondition, eris.New("client has no established stream"))
+	subscriptions, err := s.parseSubscriptions(req.Msg.GetSubscriptions())
+	if err != nil {
+		return nil, err
 	}
-
-	for _, subscription := range req.Msg.GetSubscriptions() {
-		if micro.String(s.world.address) != micro.String(subscription.GetAddress()) {
-			return nil, connect.NewError(connect.CodeInvalidArgument, eris.New("address doesn't match shard address"))
-		}
+	err = s.updateSubscriptions(user, req.Msg.GetStreamId(), func(subscriber *streamSubscriber) {
+		maps.Copy(subscriber.events, subscriptions)
+	})
+	if err != nil {
+		return nil, connect.NewError(connect.CodeFailedPrecondition, err)
 	}
-	s.subscribeEvents(user, req.Msg.GetSubscriptions())
 
 	return connect.NewResponse(&cardinalv1.SubscribeEventsResponse{}), nil
 }
@@ -367,82 +630,147 @@ func (s *service) UnsubscribeEvents(
 	user := UserFromContext(ctx)
 	assert.That(user != nil, "user should exist in authenticated request context")
 
-	if !s.hasSubscriber(user) {
-		return nil, connect.NewError(connect.CodeFailedPrecondition, eris.New("client has no established stream"))
-	}
-
 	for _, subscription := range req.Msg.GetSubscriptions() {
 		if micro.String(s.world.address) != micro.String(subscription.GetAddress()) {
 			return nil, connect.NewError(connect.CodeInvalidArgument, eris.New("address doesn't match shard address"))
 		}
 	}
-	s.unsubscribeEvents(user, req.Msg.GetSubscriptions())
+	err := s.updateSubscriptions(user, req.Msg.GetStreamId(), func(subscriber *streamSubscriber) {
+		for _, subscription := range req.Msg.GetSubscriptions() {
+			for _, eventName := range subscription.GetEvents() {
+				delete(subscriber.events, eventName)
+			}
+		}
+	})
+	if err != nil {
+		return nil, connect.NewError(connect.CodeFailedPrecondition, err)
+	}
 
 	return connect.NewResponse(&cardinalv1.UnsubscribeEventsResponse{}), nil
 }
 
+// addSubscriber registers an event stream with its initial subscriptions. If the stream resumes a
+// previous one, it also returns the retained events published after the resume cursor. An open
+// stream with the same ID is closed and replaced.
 func (s *service) addSubscriber(
 	ctx context.Context,
 	user *User,
 	stream *connect.ServerStream[cardinalv1.StartEventStreamResponse],
-) (*streamSubscriber, error) {
+	req *cardinalv1.StartEventStreamRequest,
+	subscriptions map[string]*eventFilter,
+) (*streamSubscriber, []historyEntry, bool, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if _, exists := s.subscribers[user.ID]; exists {
-		return nil, eris.Errorf("user %s already has an open stream", user.ID)
+	streamID := req.GetStreamId()
+	if streamID == "" {
+		streamID = uuid.NewString()
 	}
 
-	subscriber := &streamSubscriber{
-		ctx:    ctx,
-		stream: stream,
-		events: make(map[string]struct{}),
+	streams := s.subscribers[user.ID]
+	if streams == nil {
+		streams = make(map[string]*streamSubscriber)
+		s.subscribers[user.ID] = streams
+	}
+	if previous, exists := streams[streamID]; exists {
+		previous.close(connect.NewError(connect.CodeAborted, eris.Errorf("stream %s was replaced", streamID)))
+		previous.discard()
+		delete(streams, streamID)
+	}
+	if len(streams) >= maxStreamsPerUser {
+		return nil, nil, false, connect.NewError(connect.CodeResourceExhausted,
+			eris.Errorf("user %s already has %d open streams", user.ID, len(streams)))
 	}
-	s.subscribers[user.ID] = subscriber
-	return subscriber, nil
-}
 
-func (s *service) removeSubscriber(user *User) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	subscriber := newStreamSubscriber(ctx, streamID, stream, s.queueSize, s.overflow, s.metrics)
+	subscriber.batch = req.GetBatchByTick()
+	// The stream starts caught up to the latest event, after replaying the events it missed, if any.
+	subscriber.lastSeq = s.history.latest()
+	maps.Copy(subscriber.events, subscriptions)
+
+	// Publishers record events in the history while holding the read lock, so every event is either
+	// in the replay or published to this subscriber, never both.
+	replay := make([]historyEntry, 0)
+	var truncated bool
+	if req.ResumeAfter != nil {
+		var retained []historyEntry
+		retained, truncated = s.history.since(user.ID, req.GetResumeAfter(), time.Now())
+		for _, entry := range retained {
+			// Area-of-interest broadcasts are replayed if the user is interested in them now.
+			if entry.position != nil && !s.world.interest.grid.Contains(user.ID, *entry.position) {
+				continue
+			}
+			if subscriber.matches(newEventMatch(entry.event.GetName(), entry.payload)) {
+				replay = append(replay, entry)
+			}
+		}
+	}
 
-	delete(s.subscribers, user.ID)
+	streams[streamID] = subscriber
+	return subscriber, replay, truncated, nil
 }
 
-func (s *service) subscribeEvents(user *User, subscriptions []*cardinalv1.EventSubscription) {
+// removeSubscriber unregisters an event stream, unless it has already been replaced.
+func (s *service) removeSubscriber(user *User, subscriber *streamSubscriber) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	subscriber := s.subscribers[user.ID]
-	assert.That(subscriber != nil, "subscriber should exist for authenticated stream")
-
-	for _, subscription := range subscriptions {
-		for _, eventName := range subscription.GetEvents() {
-			subscriber.events[eventName] = struct{}{}
-		}
+	streams := s.subscribers[user.ID]
+	if streams[subscriber.id] != subscriber {
+		return
+	}
+	subscriber.discard()
+	delete(streams, subscriber.id)
+	if len(streams) == 0 {
+		delete(s.subscribers, user.ID)
 	}
 }
 
-func (s *service) unsubscribeEvents(user *User, subscriptions []*cardinalv1.EventSubscription) {
+// updateSubscriptions applies update to the user's stream with the given ID, or to all of the user's
+// streams if streamID is empty.
+func (s *service) updateSubscriptions(user *User, streamID string, update func(*streamSubscriber)) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	subscriber := s.subscribers[user.ID]
-	assert.That(subscriber != nil, "subscriber should exist for authenticated stream")
+	streams := s.subscribers[user.ID]
+	if streamID == "" {
+		if len(streams) == 0 {
+			return eris.New("client has no established stream")
+		}
+		for _, subscriber := range streams {
+			update(subscriber)
+		}
+		return nil
+	}
+
+	subscriber, exists := streams[streamID]
+	if !exists {
+		return eris.Errorf("client has no established stream with ID %s", streamID)
+	}
+	update(subscriber)
+	return nil
+}
 
+// parseSubscriptions validates subscriptions and compiles their filters. It returns the filter for
+// each subscribed event name pattern, nil for subscriptions without a filter. Subscribing to a
+// pattern again replaces its filter.
+func (s *service) parseSubscriptions(
+	subscriptions []*cardinalv1.EventSubscription,
+) (map[string]*eventFilter, error) {
+	events := make(map[string]*eventFilter)
 	for _, subscription := range subscriptions {
+		if micro.String(s.world.address) != micro.String(subscription.GetAddress()) {
+			return nil, connect.NewError(connect.CodeInvalidArgument, eris.New("address doesn't match shard address"))
+		}
+		filter, err := s.filters.compile(subscription.GetFilter())
+		if err != nil {
+			return nil, connect.NewError(connect.CodeInvalidArgument, err)
+		}
 		for _, eventName := range subscription.GetEvents() {
-			delete(subscriber.events, eventName)
+			events[eventName] = filter
 		}
 	}
-}
-
-func (s *service) hasSubscriber(user *User) bool {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-
-	_, ok := s.subscribers[user.ID]
-	return ok
+	return events, nil
 }
 
 // -------------------------------------------------------------------------------------------------
@@ -451,7 +779,6 @@ func (s *service) hasSubscriber(user *User) bool {
 
 // TODO: move away from this centralized approach to a actor model for easier(?) synchronization.
 
-//nolint:gocognit // Put everything here so you can understand the logic in one place.
 func (s *service) publishDefaultEvent(evt event.Event) error {
 	payload, ok := evt.Payload.(event.Payload)
 	if !ok {
@@ -469,62 +796,87 @@ func (s *service) publishDefaultEvent(evt event.Event) error {
 		Name:    payload.Name(),
 		Payload: payloadPb,
 	}
+	s.sink.record(s.world.currentTick, evt.Recipient, eventPb)
 
 	s.mu.RLock()
-	var subscribers []*streamSubscriber
-	//nolint:nestif // It's fine
+	// Replies go to the SendCommandWithReply request waiting for them, and nowhere else. If the
+	// request is gone, e.g. it timed out, the reply falls through to the recipient's stream.
+	if waiter, exists := s.replyWaiters[evt.ReplyTo]; exists && evt.ReplyTo != 0 {
+		s.mu.RUnlock()
+		select {
+		case waiter <- eventPb:
+		default:
+			s.log.Warn().Str("event", eventPb.GetName()).Msg("dropped duplicate reply to command")
+		}
+		return nil
+	}
+
+	defer s.mu.RUnlock()
+
+	// Record the event while holding the lock, so streams that start concurrently either replay it or
+	// receive it below. Targeted events are retained for the recipient even if they have no stream.
+	seq := s.history.append(historyEntry{
+		recipient: evt.Recipient,
+		event:     eventPb,
+		payload:   evt.Payload,
+		position:  evt.Position,
+		tick:      s.world.currentTick,
+	}, time.Now())
+	response := &cardinalv1.StartEventStreamResponse{
+		Address:  s.world.address,
+		Event:    eventPb,
+		Sequence: seq,
+	}
+
+	// Queue the event for each subscribed stream. Enqueueing never blocks, so a slow client can't
+	// stall the tick loop. Each distinct filter is evaluated at most once for the event.
+	match := newEventMatch(eventPb.GetName(), evt.Payload)
 	if evt.Recipient != "" {
-		if subscriber, exists := s.subscribers[evt.Recipient]; exists {
-			for subscription := range subscriber.events {
-				if matchesEvent(subscription, eventPb.GetName()) {
-					subscribers = []*streamSubscriber{subscriber}
-					break
-				}
-			}
-		} else {
+		streams, exists := s.subscribers[evt.Recipient]
+		if !exists {
 			s.log.Debug().Str("recipient", evt.Recipient).Str("event", eventPb.GetName()).Msg("recipient has no open stream")
 		}
-	} else {
-		subscribers = make([]*streamSubscriber, 0, len(s.subscribers))
-		for _, subscriber := range s.subscribers {
-			for subscription := range subscriber.events {
-				if matchesEvent(subscription, eventPb.GetName()) {
-					subscribers = append(subscribers, subscriber)
-					break
+		// Targeted events go to all of the recipient's streams.
+		for _, subscriber := range streams {
+			if subscriber.matches(match) {
+				subscriber.deliver(response)
+			}
+		}
+		return nil
+	}
+	// Area-of-interest broadcasts go to the streams of the users whose interest region contains the
+	// event's position.
+	if evt.Position != nil {
+		for _, userID := range s.world.interest.grid.Query(*evt.Position) {
+			for _, subscriber := range s.subscribers[userID] {
+				if subscriber.matches(match) {
+					subscriber.deliver(response)
 				}
 			}
 		}
+		return nil
 	}
-	waiters := append([]chan *iscv1.Event(nil), s.replyWaiters[eventPb.GetName()]...)
-	s.mu.RUnlock()
-
-	// Send events for SendCommandWithReply channels.
-	for _, waiter := range waiters {
-		select {
-		case waiter <- eventPb:
-		default:
+	for _, streams := range s.subscribers {
+		for _, subscriber := range streams {
+			if subscriber.matches(match) {
+				subscriber.deliver(response)
+			}
 		}
 	}
+	return nil
+}
 
-	// Send events to stream subscribers.
-	for _, subscriber := range subscribers {
-		select {
-		case <-subscriber.ctx.Done():
-			continue
-		default:
-		}
+// flushEventBatches sends the batch of the tick's events to every stream that batches events by tick.
+// Called after the tick's events are dispatched.
+func (s *service) flushEventBatches(tick Tick) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 
-		err := subscriber.send(&cardinalv1.StartEventStreamResponse{
-			Address: s.world.address,
-			Event:   eventPb,
-		})
-		if err != nil {
-			s.log.Error().Err(err).Str("event", eventPb.GetName()).Msg("failed to send event to subscriber")
-			continue
+	for _, streams := range s.subscribers {
+		for _, subscriber := range streams {
+			subscriber.flush(s.world.address, tick)
 		}
 	}
-
-	return nil
 }
 
 func matchesEvent(subscription string, eventName string) bool {
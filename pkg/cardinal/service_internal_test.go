@@ -3,7 +3,9 @@ package cardinal
 import (
 	"context"
 	"math/rand/v2"
+	"reflect"
 	"testing"
+	"time"
 
 	"connectrpc.com/authn"
 	"connectrpc.com/connect"
@@ -19,6 +21,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.opentelemetry.io/otel/trace/noop"
+	"google.golang.org/protobuf/types/known/durationpb"
 )
 
 // -------------------------------------------------------------------------------------------------
@@ -86,11 +89,395 @@ func TestService_SendCommand(t *testing.T) {
 	})
 }
 
+// -------------------------------------------------------------------------------------------------
+// SendCommands smoke tests
+// -------------------------------------------------------------------------------------------------
+// Verifies that a batch of commands is enqueued into the same tick, and that a batch with an invalid
+// command is rejected as a whole with an error for each invalid command.
+// -------------------------------------------------------------------------------------------------
+
+func TestService_SendCommands(t *testing.T) {
+	t.Parallel()
+
+	newBatch := func(
+		t *testing.T, prng *rand.Rand, fixture *serviceFixture, count int,
+	) ([]*iscv1.Command, []testutils.SimpleCommand) {
+		t.Helper()
+		commands := make([]*iscv1.Command, count)
+		payloads := make([]testutils.SimpleCommand, count)
+		for i := range count {
+			payloads[i] = testutils.SimpleCommand{Value: prng.IntN(1_000_000)}
+			payloadBytes, err := payloads[i].MarshalWire()
+			require.NoError(t, err)
+			commands[i] = &iscv1.Command{
+				Name:    payloads[i].Name(),
+				Address: fixture.world.address,
+				Persona: &iscv1.Persona{Id: "client-provided-persona"},
+				Payload: payloadBytes,
+			}
+		}
+		return commands, payloads
+	}
+
+	t.Run("happy path", func(t *testing.T) {
+		t.Parallel()
+		prng := testutils.NewRand(t)
+		fixture := newServiceFixture(t, prng, false)
+
+		commands, payloads := newBatch(t, prng, fixture, prng.IntN(50)+1)
+		userID := testutils.RandString(prng, 8)
+
+		res, err := fixture.svc.SendCommands(
+			serviceTestContext(userID),
+			connect.NewRequest(&cardinalv1.SendCommandsRequest{Commands: commands}),
+		)
+		require.NoError(t, err)
+		assert.True(t, res.Msg.GetAccepted())
+		require.Len(t, res.Msg.GetResults(), len(commands))
+		for i, result := range res.Msg.GetResults() {
+			assert.Empty(t, result.GetError(), "unexpected error at index %d", i)
+			assert.NotZero(t, result.GetReceiptId(), "missing receipt at index %d", i)
+		}
+
+		fixture.world.commands.Drain()
+		cmds, err := fixture.world.commands.Get(fixture.commandID)
+		require.NoError(t, err)
+		require.Len(t, cmds, len(payloads))
+		for i, cmd := range cmds {
+			assert.Equal(t, payloads[i], cmd.Payload, "payload mismatch at index %d", i)
+			assert.Equal(t, userID, cmd.Persona, "persona mismatch at index %d", i)
+		}
+	})
+
+	t.Run("invalid command rejects batch", func(t *testing.T) {
+		t.Parallel()
+		prng := testutils.NewRand(t)
+		fixture := newServiceFixture(t, prng, false)
+
+		commands, _ := newBatch(t, prng, fixture, 10)
+		wrongAddress := prng.IntN(len(commands))
+		commands[wrongAddress].Address = RandServiceAddress(prng)
+		unregistered := (wrongAddress + 1) % len(commands)
+		commands[unregistered].Name = "unregistered"
+
+		res, err := fixture.svc.SendCommands(
+			serviceTestContext(testutils.RandString(prng, 8)),
+			connect.NewRequest(&cardinalv1.SendCommandsRequest{Commands: commands}),
+		)
+		require.NoError(t, err)
+		assert.False(t, res.Msg.GetAccepted())
+		require.Len(t, res.Msg.GetResults(), len(commands))
+		assert.Contains(t, res.Msg.GetResults()[wrongAddress].GetError(), "address")
+		for i, result := range res.Msg.GetResults() {
+			assert.Zero(t, result.GetReceiptId(), "unexpected receipt at index %d", i)
+			if i != wrongAddress && i != unregistered {
+				assert.Empty(t, result.GetError(), "unexpected error at index %d", i)
+			}
+		}
+
+		// Fix the address, the unregistered command alone still rejects the batch.
+		commands[wrongAddress].Address = fixture.world.address
+		res, err = fixture.svc.SendCommands(
+			serviceTestContext(testutils.RandString(prng, 8)),
+			connect.NewRequest(&cardinalv1.SendCommandsRequest{Commands: commands}),
+		)
+		require.NoError(t, err)
+		assert.False(t, res.Msg.GetAccepted())
+		assert.Contains(t, res.Msg.GetResults()[unregistered].GetError(), "unregistered")
+
+		fixture.world.commands.Drain()
+		cmds, err := fixture.world.commands.Get(fixture.commandID)
+		require.NoError(t, err)
+		assert.Empty(t, cmds)
+	})
+}
+
+// -------------------------------------------------------------------------------------------------
+// GetCommandReceipt smoke tests
+// -------------------------------------------------------------------------------------------------
+// Verifies that SendCommand returns a receipt that tracks the command through its tick, and that
+// receipts are only visible to the user that sent the command.
+// -------------------------------------------------------------------------------------------------
+
+func TestService_GetCommandReceipt(t *testing.T) {
+	t.Parallel()
+
+	prng := testutils.NewRand(t)
+	fixture := newServiceFixture(t, prng, false)
+
+	payload := testutils.SimpleCommand{Value: prng.IntN(1_000_000)}
+	payloadBytes, err := payload.MarshalWire()
+	require.NoError(t, err)
+	userID := testutils.RandString(prng, 8)
+
+	sent, err := fixture.svc.SendCommand(
+		serviceTestContext(userID),
+		connect.NewRequest(&cardinalv1.SendCommandRequest{Command: &iscv1.Command{
+			Name:    payload.Name(),
+			Address: fixture.world.address,
+			Persona: &iscv1.Persona{Id: "client-provided-persona"},
+			Payload: payloadBytes,
+		}}),
+	)
+	require.NoError(t, err)
+	assert.NotZero(t, sent.Msg.GetReceiptId())
+
+	getReceipt := func(userID string) (*cardinalv1.CommandReceipt, error) {
+		res, err := fixture.svc.GetCommandReceipt(
+			serviceTestContext(userID),
+			connect.NewRequest(&cardinalv1.GetCommandReceiptRequest{
+				Address:   fixture.world.address,
+				ReceiptId: sent.Msg.GetReceiptId(),
+			}),
+		)
+		if err != nil {
+			return nil, err
+		}
+		return res.Msg.GetReceipt(), nil
+	}
+
+	receipt, err := getReceipt(userID)
+	require.NoError(t, err)
+	assert.Equal(t, cardinalv1.CommandStatus_COMMAND_STATUS_PENDING, receipt.GetStatus())
+	assert.Equal(t, sent.Msg.GetTickHeight(), receipt.GetTickHeight())
+	assert.Equal(t, payload.Name(), receipt.GetCommandName())
+
+	_, err = getReceipt(userID + "-other")
+	require.Error(t, err)
+	assert.Equal(t, connect.CodeNotFound, connect.CodeOf(err))
+
+	fixture.world.commands.Drain()
+	fixture.world.commands.Settle()
+
+	receipt, err = getReceipt(userID)
+	require.NoError(t, err)
+	assert.Equal(t, cardinalv1.CommandStatus_COMMAND_STATUS_PROCESSED, receipt.GetStatus())
+}
+
+// -------------------------------------------------------------------------------------------------
+// ScheduleCommand smoke tests
+// -------------------------------------------------------------------------------------------------
+// Verifies that scheduled commands are released at their tick, can only be cancelled by the user
+// that scheduled them, and that commands addressed to the wrong shard are rejected.
+// -------------------------------------------------------------------------------------------------
+
+func TestService_ScheduleCommand(t *testing.T) {
+	t.Parallel()
+
+	newCommand := func(
+		t *testing.T, prng *rand.Rand, fixture *serviceFixture,
+	) (*iscv1.Command, testutils.SimpleCommand) {
+		t.Helper()
+		payload := testutils.SimpleCommand{Value: prng.IntN(1_000_000)}
+		payloadBytes, err := payload.MarshalWire()
+		require.NoError(t, err)
+		return &iscv1.Command{
+			Name:    payload.Name(),
+			Address: fixture.world.address,
+			Persona: &iscv1.Persona{Id: "client-provided-persona"},
+			Payload: payloadBytes,
+		}, payload
+	}
+
+	t.Run("released at tick", func(t *testing.T) {
+		t.Parallel()
+		prng := testutils.NewRand(t)
+		fixture := newServiceFixture(t, prng, false)
+
+		cmdPb, payload := newCommand(t, prng, fixture)
+		userID := testutils.RandString(prng, 8)
+		tick := uint64(prng.IntN(10) + 1)
+
+		res, err := fixture.svc.ScheduleCommand(
+			serviceTestContext(userID),
+			connect.NewRequest(&cardinalv1.ScheduleCommandRequest{
+				Command: cmdPb,
+				When:    &cardinalv1.ScheduleCommandRequest_TickHeight{TickHeight: tick},
+			}),
+		)
+		require.NoError(t, err)
+		assert.Equal(t, tick, res.Msg.GetTickHeight())
+
+		// Not released before its tick.
+		require.NoError(t, fixture.world.commands.Release(tick-1))
+		fixture.world.commands.Drain()
+		cmds, err := fixture.world.commands.Get(fixture.commandID)
+		require.NoError(t, err)
+		assert.Empty(t, cmds)
+
+		require.NoError(t, fixture.world.commands.Release(tick))
+		fixture.world.commands.Drain()
+		cmds, err = fixture.world.commands.Get(fixture.commandID)
+		require.NoError(t, err)
+		require.Len(t, cmds, 1)
+		assert.Equal(t, payload, cmds[0].Payload)
+		assert.Equal(t, userID, cmds[0].Persona)
+	})
+
+	t.Run("cancel by owner only", func(t *testing.T) {
+		t.Parallel()
+		prng := testutils.NewRand(t)
+		fixture := newServiceFixture(t, prng, false)
+
+		cmdPb, _ := newCommand(t, prng, fixture)
+		userID := testutils.RandString(prng, 8)
+
+		res, err := fixture.svc.ScheduleCommand(
+			serviceTestContext(userID),
+			connect.NewRequest(&cardinalv1.ScheduleCommandRequest{
+				Command: cmdPb,
+				When:    &cardinalv1.ScheduleCommandRequest_TickHeight{TickHeight: 5},
+			}),
+		)
+		require.NoError(t, err)
+
+		cancel := &cardinalv1.CancelScheduledCommandRequest{
+			Address: fixture.world.address,
+			Handle:  res.Msg.GetHandle(),
+		}
+		_, err = fixture.svc.CancelScheduledCommand(
+			serviceTestContext(userID+"-other"), connect.NewRequest(cancel))
+		require.Error(t, err)
+		assert.Equal(t, connect.CodeNotFound, connect.CodeOf(err))
+
+		_, err = fixture.svc.CancelScheduledCommand(serviceTestContext(userID), connect.NewRequest(cancel))
+		require.NoError(t, err)
+
+		require.NoError(t, fixture.world.commands.Release(5))
+		fixture.world.commands.Drain()
+		cmds, err := fixture.world.commands.Get(fixture.commandID)
+		require.NoError(t, err)
+		assert.Empty(t, cmds)
+	})
+
+	t.Run("wrong address rejected", func(t *testing.T) {
+		t.Parallel()
+		prng := testutils.NewRand(t)
+		fixture := newServiceFixture(t, prng, false)
+
+		cmdPb, _ := newCommand(t, prng, fixture)
+		cmdPb.Address = RandServiceAddress(prng)
+
+		_, err := fixture.svc.ScheduleCommand(
+			serviceTestContext(testutils.RandString(prng, 8)),
+			connect.NewRequest(&cardinalv1.ScheduleCommandRequest{
+				Command: cmdPb,
+				When:    &cardinalv1.ScheduleCommandRequest_TickHeight{TickHeight: 1},
+			}),
+		)
+		require.Error(t, err)
+		assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+		assert.Contains(t, err.Error(), "address")
+	})
+}
+
+// -------------------------------------------------------------------------------------------------
+// SendCommandWithReply smoke tests
+// -------------------------------------------------------------------------------------------------
+// Verifies that concurrent requests waiting on the same event name each receive the reply to their
+// own command, and that a request without a reply times out.
+// -------------------------------------------------------------------------------------------------
+
+func TestService_SendCommandWithReply(t *testing.T) {
+	t.Parallel()
+
+	t.Run("replies are correlated", func(t *testing.T) {
+		t.Parallel()
+		prng := testutils.NewRand(t)
+		fixture := newServiceFixture(t, prng, false)
+
+		const numRequests = 8
+		type result struct {
+			value int
+			event *iscv1.Event
+			err   error
+		}
+		results := make(chan result, numRequests)
+		for i := range numRequests {
+			userID := testutils.RandString(prng, 8)
+			go func(value int) {
+				payloadBytes, err := testutils.SimpleCommand{Value: value}.MarshalWire()
+				if err != nil {
+					results <- result{value: value, err: err}
+					return
+				}
+				res, err := fixture.svc.SendCommandWithReply(
+					serviceTestContext(userID),
+					connect.NewRequest(&cardinalv1.SendCommandWithReplyRequest{Command: &iscv1.Command{
+						Name:    testutils.SimpleCommand{}.Name(),
+						Address: fixture.world.address,
+						Persona: &iscv1.Persona{Id: "client-provided-persona"},
+						Payload: payloadBytes,
+					}}),
+				)
+				if err != nil {
+					results <- result{value: value, err: err}
+					return
+				}
+				results <- result{value: value, event: res.Msg.GetEvent()}
+			}(i)
+		}
+
+		// Reply to each command with its own value, like a system would, until all are collected.
+		for replied := 0; replied < numRequests; {
+			fixture.world.commands.Drain()
+			cmds, err := fixture.world.commands.Get(fixture.commandID)
+			require.NoError(t, err)
+			for _, cmd := range cmds {
+				require.NotZero(t, cmd.ReplyID)
+				err := fixture.svc.publishDefaultEvent(event.Event{
+					Kind:    event.KindDefault,
+					Payload: testutils.SimpleEvent{Value: cmd.Payload.(testutils.SimpleCommand).Value},
+					ReplyTo: cmd.ReplyID,
+				})
+				require.NoError(t, err)
+				replied++
+			}
+		}
+
+		for range numRequests {
+			res := <-results
+			require.NoError(t, res.err)
+			decoded, err := testutils.SimpleEvent{}.UnmarshalWire(res.event.GetPayload())
+			require.NoError(t, err)
+			assert.Equal(t, res.value, decoded.(testutils.SimpleEvent).Value, "got another command's reply")
+		}
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		t.Parallel()
+		prng := testutils.NewRand(t)
+		fixture := newServiceFixture(t, prng, false)
+
+		payloadBytes, err := testutils.SimpleCommand{Value: 42}.MarshalWire()
+		require.NoError(t, err)
+		_, err = fixture.svc.SendCommandWithReply(
+			serviceTestContext(testutils.RandString(prng, 8)),
+			connect.NewRequest(&cardinalv1.SendCommandWithReplyRequest{
+				Command: &iscv1.Command{
+					Name:    testutils.SimpleCommand{}.Name(),
+					Address: fixture.world.address,
+					Persona: &iscv1.Persona{Id: "client-provided-persona"},
+					Payload: payloadBytes,
+				},
+				Timeout: durationpb.New(10 * time.Millisecond),
+			}),
+		)
+		require.Error(t, err)
+		assert.Equal(t, connect.CodeDeadlineExceeded, connect.CodeOf(err))
+
+		// The waiter is removed, so a late reply goes nowhere.
+		fixture.svc.mu.RLock()
+		defer fixture.svc.mu.RUnlock()
+		assert.Empty(t, fixture.svc.replyWaiters)
+	})
+}
+
 // -------------------------------------------------------------------------------------------------
 // publishDefaultEvent smoke tests
 // -------------------------------------------------------------------------------------------------
-// Verifies that publishing a default event serializes the payload and delivers it to registered
-// ConnectRPC reply waiters with round-trip integrity.
+// Verifies that publishing a default event serializes the payload and delivers replies only to the
+// ConnectRPC reply waiter they're addressed to, with round-trip integrity.
 // -------------------------------------------------------------------------------------------------
 
 func TestService_PublishDefaultEvent(t *testing.T) {
@@ -102,12 +489,19 @@ func TestService_PublishDefaultEvent(t *testing.T) {
 		fixture := newServiceFixture(t, prng, false)
 
 		payload := testutils.SimpleEvent{Value: prng.Int()}
-		waiter := fixture.svc.addReplyWaiter(payload.Name())
-		defer fixture.svc.removeReplyWaiter(payload.Name(), waiter)
+		replyID, waiter := fixture.svc.addReplyWaiter()
+		defer fixture.svc.removeReplyWaiter(replyID)
+		otherID, other := fixture.svc.addReplyWaiter()
+		defer fixture.svc.removeReplyWaiter(otherID)
+
+		// An event that isn't a reply doesn't reach waiters, even if it has the same name.
+		err := fixture.svc.publishDefaultEvent(event.Event{Kind: event.KindDefault, Payload: payload})
+		require.NoError(t, err)
 
-		err := fixture.svc.publishDefaultEvent(event.Event{
+		err = fixture.svc.publishDefaultEvent(event.Event{
 			Kind:    event.KindDefault,
 			Payload: payload,
+			ReplyTo: replyID,
 		})
 		require.NoError(t, err)
 
@@ -116,6 +510,37 @@ func TestService_PublishDefaultEvent(t *testing.T) {
 		decoded, err := testutils.SimpleEvent{}.UnmarshalWire(eventPb.GetPayload())
 		require.NoError(t, err)
 		assert.Equal(t, payload, decoded)
+		assert.Empty(t, waiter)
+		assert.Empty(t, other)
+	})
+
+	t.Run("history", func(t *testing.T) {
+		t.Parallel()
+		prng := testutils.NewRand(t)
+		fixture := newServiceFixture(t, prng, false)
+
+		broadcast := testutils.SimpleEvent{Value: prng.Int()}
+		targeted := testutils.SimpleEvent{Value: prng.Int()}
+		err := fixture.svc.publishDefaultEvent(event.Event{Kind: event.KindDefault, Payload: broadcast})
+		require.NoError(t, err)
+		// The recipient has no open stream, but the event is retained for when they resume.
+		err = fixture.svc.publishDefaultEvent(event.Event{
+			Kind:      event.KindDefault,
+			Payload:   targeted,
+			Recipient: "player",
+		})
+		require.NoError(t, err)
+
+		entries, truncated := fixture.svc.history.since("player", 0, time.Now())
+		assert.False(t, truncated)
+		require.Len(t, entries, 2)
+		assert.Equal(t, uint64(1), entries[0].seq)
+		assert.Equal(t, uint64(2), entries[1].seq)
+		assert.Equal(t, "player", entries[1].recipient)
+
+		entries, _ = fixture.svc.history.since("other-player", 0, time.Now())
+		require.Len(t, entries, 1)
+		assert.Empty(t, entries[0].recipient)
 	})
 }
 
@@ -182,20 +607,27 @@ func newServiceFixture(t *testing.T, prng *rand.Rand, registerNATSEndpoints bool
 	}
 
 	w := &World{
-		world:    ecs.NewWorld(),
-		commands: command.NewManager(),
-		events:   event.NewManager(1024),
-		address:  address,
-		tel:      tel,
+		world:      ecs.NewWorld(),
+		commands:   command.NewManager(),
+		events:     event.NewManager(1024),
+		interest:   newInterestManager(newDefaultWorldOptions().InterestCellSize),
+		eventKinds: make(map[reflect.Type]event.Kind),
+		address:    address,
+		tel:        tel,
 	}
 
 	queue := command.NewQueue[testutils.SimpleCommand]()
 	cmdID, err := w.commands.Register(testutils.SimpleCommand{}.Name(), queue)
 	require.NoError(t, err)
 
-	svc := newService(w, AuthModeDev, "")
+	options := newDefaultWorldOptions()
+	svc := newService(w, options)
+	// Start sequence numbers at 1 instead of the clock, so tests can predict them.
+	svc.history = newEventHistory(options.EventHistorySize, options.TargetedEventTTL, 0)
 	svc.registerCommandHandler(testutils.SimpleCommand{}.Name())
 	w.service = svc
+	w.state.Store(&cardinalv1.Snapshot{WorldState: &cardinalv1.WorldState{}})
+	svc.publishState(w.state.Load())
 
 	fixture := &serviceFixture{
 		svc:       svc,
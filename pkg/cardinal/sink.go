@@ -0,0 +1,308 @@
+package cardinal
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/argus-labs/world-engine/pkg/micro"
+	cardinalv1 "github.com/argus-labs/world-engine/proto/gen/go/worldengine/cardinal/v1"
+	iscv1 "github.com/argus-labs/world-engine/proto/gen/go/worldengine/isc/v1"
+	"github.com/caarlos0/env/v11"
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/rotisserie/eris"
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// eventSink publishes every event the shard dispatches to a JetStream stream, for consumers that need
+// all of them regardless of who was subscribed, e.g. analytics and anti-cheat. Records are published
+// to <shard address>.events.<event name> on a background goroutine, so a slow or unavailable NATS
+// server doesn't stall the tick.
+//
+// Environment variables:
+//
+//	CARDINAL_EVENT_SINK=true                # Enables the event sink
+//	CARDINAL_EVENT_SINK_MAX_BYTES=<bytes>   # Maximum size of the stream, 0 for unlimited
+//	CARDINAL_EVENT_SINK_MAX_AGE=<duration>  # How long events are retained, 0 for forever
+//	CARDINAL_EVENT_SINK_QUEUE=<count>       # Number of events buffered for publishing
+//	CARDINAL_EVENT_SINK_RETRIES=<count>     # Publish retries before an event is dropped
+//	CARDINAL_EVENT_SINK_RETRY_WAIT=<dur>    # Wait before the first retry, doubled every retry
+type eventSink struct {
+	js        jetstream.JetStream
+	address   *micro.ServiceAddress
+	subject   string // Subject prefix of the published events
+	run       string // ID of the shard's run, which prefixes the message IDs
+	queue     chan sinkMessage
+	retries   int
+	retryWait time.Duration
+	log       zerolog.Logger
+	metrics   *eventSinkMetrics
+	ctx       context.Context // Canceled when close gives up, which aborts publishing
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+
+	// Only used by the tick.
+	tick    uint64 // Height of the tick of the last recorded event
+	index   uint32 // Index of the next event in the tick
+	seq     uint64 // Number of recorded events
+	dropped int    // Events dropped because the queue was full since the last reportDropped
+}
+
+// sinkMessage is a queued event record and its message ID.
+type sinkMessage struct {
+	id     string
+	record *cardinalv1.EventRecord
+}
+
+// newEventSink creates the sink's stream if it doesn't exist and starts publishing.
+func newEventSink(client *micro.Client, address *micro.ServiceAddress, log zerolog.Logger) (*eventSink, error) {
+	opts := eventSinkOptions{}
+	if err := env.Parse(&opts); err != nil {
+		return nil, eris.Wrap(err, "failed to parse env")
+	}
+	if err := opts.validate(); err != nil {
+		return nil, eris.Wrap(err, "invalid event sink options")
+	}
+
+	js, err := jetstream.New(client.Conn)
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to create JetStream client")
+	}
+
+	// Same format as the snapshot bucket, because stream names can't contain dots.
+	streamName := fmt.Sprintf("%s_%s_%s_events",
+		address.GetOrganization(), address.GetProject(), address.GetServiceId())
+	subject := micro.String(address) + ".events"
+	_, err = js.CreateOrUpdateStream(context.Background(), jetstream.StreamConfig{
+		Name:     streamName,
+		Subjects: []string{subject + ".>"},
+		MaxBytes: opts.MaxBytes,
+		MaxAge:   opts.MaxAge,
+	})
+	if err != nil {
+		return nil, eris.Wrapf(err, "failed to create event sink stream (stream=%s)", streamName)
+	}
+
+	metrics, err := newEventSinkMetrics()
+	if err != nil {
+		log.Warn().Err(err).Msg("event sink metrics may not be exported")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &eventSink{
+		js:        js,
+		address:   address,
+		subject:   subject,
+		run:       uuid.NewString(),
+		queue:     make(chan sinkMessage, opts.QueueSize),
+		retries:   opts.Retries,
+		retryWait: opts.RetryWait,
+		log:       log,
+		metrics:   metrics,
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+	s.wg.Add(1)
+	go s.publishAll()
+	return s, nil
+}
+
+// record queues a dispatched event for publishing. It never blocks, so if the queue is full because
+// NATS can't keep up, the event is dropped. Dropped events are counted, and reported once per tick by
+// reportDropped, so an outage doesn't log every event.
+func (s *eventSink) record(tick Tick, recipient string, evt *iscv1.Event) {
+	if s == nil {
+		return
+	}
+	if tick.height != s.tick {
+		s.tick = tick.height
+		s.index = 0
+	}
+	record := &cardinalv1.EventRecord{
+		Address:    s.address,
+		TickHeight: tick.height,
+		Timestamp:  timestamppb.New(tick.timestamp),
+		Recipient:  recipient,
+		Event:      evt,
+		Index:      s.index,
+	}
+	s.index++
+	// Ticks can repeat within a run, e.g. after a debug reset, and across runs, e.g. when a restore
+	// falls back to an earlier snapshot, so the tick and index don't identify an event. The message ID
+	// is unique, and stays the same for the retries of a publish.
+	s.seq++
+	msgID := fmt.Sprintf("%s-%d", s.run, s.seq)
+
+	select {
+	case s.queue <- sinkMessage{id: msgID, record: record}:
+	default:
+		s.dropped++
+		s.metrics.dropped.Add(context.Background(), 1, s.metrics.queueFull)
+	}
+}
+
+// reportDropped logs the number of events dropped because the queue was full since the last call.
+// Called at the end of every tick.
+func (s *eventSink) reportDropped(tick Tick) {
+	if s == nil || s.dropped == 0 {
+		return
+	}
+	s.log.Error().Uint64("tick", tick.height).Int("dropped", s.dropped).
+		Msg("event sink queue is full, dropped events")
+	s.dropped = 0
+}
+
+// publishAll publishes the queued events until the sink is closed. Once close gives up, the remaining
+// events are dropped.
+func (s *eventSink) publishAll() {
+	defer s.wg.Done()
+	for msg := range s.queue {
+		if s.ctx.Err() != nil {
+			continue
+		}
+		s.publish(msg)
+	}
+}
+
+// publish publishes an event, retrying with exponential backoff. The message ID identifies the event,
+// so JetStream discards the duplicates of retries whose earlier attempts were stored.
+func (s *eventSink) publish(msg sinkMessage) {
+	record := msg.record
+	data, err := proto.Marshal(record)
+	if err != nil {
+		s.log.Error().Err(err).Str("event", record.GetEvent().GetName()).Msg("failed to marshal event record")
+		return
+	}
+	subject := s.subject + "." + record.GetEvent().GetName()
+
+	wait := s.retryWait
+	for attempt := 0; ; attempt++ {
+		ctx, cancel := context.WithTimeout(s.ctx, 5*time.Second)
+		_, err = s.js.Publish(ctx, subject, data, jetstream.WithMsgID(msg.id))
+		cancel()
+		if err == nil {
+			return
+		}
+		if attempt >= s.retries || s.ctx.Err() != nil {
+			s.metrics.dropped.Add(context.Background(), 1, s.metrics.failed)
+			s.log.Error().Err(err).Uint64("tick", record.GetTickHeight()).Str("event", record.GetEvent().GetName()).
+				Msg("failed to publish event to sink, dropped event")
+			return
+		}
+		s.log.Warn().Err(err).Int("attempt", attempt+1).Msg("failed to publish event to sink, retrying")
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-s.ctx.Done():
+			timer.Stop()
+		}
+		wait *= 2
+	}
+}
+
+// close stops accepting events and waits for the queued ones to be published. If ctx is done first,
+// publishing is aborted and the unpublished events are dropped. Either way, the publishing goroutine
+// has exited when close returns.
+func (s *eventSink) close(ctx context.Context) error {
+	if s == nil {
+		return nil
+	}
+	close(s.queue)
+	defer s.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		unpublished := len(s.queue)
+		s.cancel()
+		<-done
+		return eris.Wrapf(ctx.Err(), "event sink closed with %d unpublished events", unpublished)
+	}
+}
+
+// -------------------------------------------------------------------------------------------------
+// Metrics
+// -------------------------------------------------------------------------------------------------
+
+// eventSinkMetrics are the OpenTelemetry instruments for the event sink. They are recorded through
+// the global meter provider, which is a no-op unless the application configures one.
+type eventSinkMetrics struct {
+	dropped   metric.Int64Counter // Events that weren't published
+	queueFull metric.AddOption    // Attribute for events dropped because the queue was full
+	failed    metric.AddOption    // Attribute for events whose publish failed after all retries
+}
+
+// newEventSinkMetrics creates the event sink instruments. The instruments are usable even if an error
+// is returned, they just may not be exported.
+func newEventSinkMetrics() (*eventSinkMetrics, error) {
+	meter := otel.Meter("github.com/argus-labs/world-engine/pkg/cardinal")
+
+	dropped, droppedErr := meter.Int64Counter("cardinal.event_sink.dropped",
+		metric.WithDescription("Number of events that weren't published to the event sink"),
+		metric.WithUnit("{event}"))
+
+	metrics := &eventSinkMetrics{
+		dropped:   dropped,
+		queueFull: metric.WithAttributes(attribute.String("reason", "queue_full")),
+		failed:    metric.WithAttributes(attribute.String("reason", "error")),
+	}
+	if droppedErr != nil {
+		return metrics, eris.Wrap(droppedErr, "failed to create event sink metrics")
+	}
+	return metrics, nil
+}
+
+// -------------------------------------------------------------------------------------------------
+// Options
+// -------------------------------------------------------------------------------------------------
+
+type eventSinkOptions struct {
+	// Maximum bytes of the stream. Required by some NATS providers like Synadia Cloud.
+	MaxBytes int64 `env:"CARDINAL_EVENT_SINK_MAX_BYTES" envDefault:"0"`
+
+	// How long the stream retains events, e.g. "72h".
+	MaxAge time.Duration `env:"CARDINAL_EVENT_SINK_MAX_AGE" envDefault:"0"`
+
+	// Number of events buffered for publishing.
+	QueueSize int `env:"CARDINAL_EVENT_SINK_QUEUE" envDefault:"16384"`
+
+	// Number of times a failed publish is retried before the event is dropped.
+	Retries int `env:"CARDINAL_EVENT_SINK_RETRIES" envDefault:"5"`
+
+	// Wait before the first retry, doubled after every retry.
+	RetryWait time.Duration `env:"CARDINAL_EVENT_SINK_RETRY_WAIT" envDefault:"100ms"`
+}
+
+func (opt *eventSinkOptions) validate() error {
+	// MaxBytes and MaxAge can be 0, which means unlimited.
+	if opt.MaxBytes < 0 {
+		return eris.New("event sink max bytes cannot be negative")
+	}
+	if opt.MaxAge < 0 {
+		return eris.New("event sink max age cannot be negative")
+	}
+	if opt.QueueSize <= 0 {
+		return eris.New("event sink queue size must be greater than 0")
+	}
+	if opt.Retries < 0 {
+		return eris.New("event sink retries cannot be negative")
+	}
+	if opt.RetryWait <= 0 {
+		return eris.New("event sink retry wait must be greater than 0")
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package cardinal
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/argus-labs/world-engine/pkg/micro"
+	cardinalv1 "github.com/argus-labs/world-engine/proto/gen/go/worldengine/cardinal/v1"
+	iscv1 "github.com/argus-labs/world-engine/proto/gen/go/worldengine/isc/v1"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats-server/v2/test"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+// -------------------------------------------------------------------------------------------------
+// Event sink smoke tests
+// -------------------------------------------------------------------------------------------------
+// Verifies that the event sink publishes every recorded event to the shard's JetStream stream in
+// order, with the tick and recipient they were dispatched with, including events of a tick that
+// repeats after a rewind, that closing the sink flushes the queued events, that events dropped by a
+// full queue are reported once per tick, and that a close that times out stops the publishing
+// goroutine.
+// -------------------------------------------------------------------------------------------------
+
+func TestEventSink(t *testing.T) {
+	t.Setenv("CARDINAL_EVENT_SINK_QUEUE", "64")
+
+	tempDir := filepath.Join(os.TempDir(), "nats-sink-"+strconv.Itoa(os.Getpid()))
+	srv := test.RunServer(&server.Options{
+		Host:                  "127.0.0.1",
+		Port:                  -1,
+		NoLog:                 true,
+		NoSigs:                true,
+		MaxControlLine:        4096,
+		DisableShortFirstPing: true,
+		JetStream:             true,
+		StoreDir:              tempDir,
+	})
+	t.Cleanup(func() {
+		srv.Shutdown()
+		_ = os.RemoveAll(tempDir)
+	})
+
+	client, err := micro.NewClient(
+		micro.WithNATSConfig(micro.NATSConfig{Name: "sink-test", URL: srv.ClientURL()}),
+		micro.WithLogger(zerolog.Nop()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(client.Close)
+
+	address := micro.GetAddress("local", micro.RealmWorld, "org", "project", "shard")
+	sink, err := newEventSink(client, address, zerolog.Nop())
+	require.NoError(t, err)
+
+	type dispatched struct {
+		tick      uint64
+		recipient string
+		name      string
+		index     uint32 // Indexes restart every tick
+	}
+	events := []dispatched{
+		{tick: 1, recipient: "", name: "spawned", index: 0},
+		{tick: 1, recipient: "alice", name: "reward", index: 1},
+		{tick: 2, recipient: "", name: "spawned", index: 0},
+		{tick: 4, recipient: "bob", name: "reward", index: 0},
+		{tick: 1, recipient: "", name: "spawned", index: 0}, // Rewound, e.g. by a debug reset
+	}
+	for _, evt := range events {
+		sink.record(Tick{height: evt.tick, timestamp: time.Now()}, evt.recipient,
+			&iscv1.Event{Name: evt.name, Payload: []byte(evt.name)})
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	require.NoError(t, sink.close(ctx))
+
+	js, err := jetstream.New(client.Conn)
+	require.NoError(t, err)
+	stream, err := js.Stream(ctx, "org_project_shard_events")
+	require.NoError(t, err)
+	consumer, err := stream.OrderedConsumer(ctx, jetstream.OrderedConsumerConfig{})
+	require.NoError(t, err)
+	batch, err := consumer.FetchNoWait(len(events) + 1)
+	require.NoError(t, err)
+
+	var records []*cardinalv1.EventRecord
+	for msg := range batch.Messages() {
+		record := &cardinalv1.EventRecord{}
+		require.NoError(t, proto.Unmarshal(msg.Data(), record))
+		assert.Equal(t, micro.String(address)+".events."+record.GetEvent().GetName(), msg.Subject())
+		records = append(records, record)
+	}
+	require.NoError(t, batch.Error())
+	require.Len(t, records, len(events))
+
+	for i, evt := range events {
+		assert.Equal(t, evt.tick, records[i].GetTickHeight())
+		assert.Equal(t, evt.recipient, records[i].GetRecipient())
+		assert.Equal(t, evt.name, records[i].GetEvent().GetName())
+		assert.Equal(t, evt.index, records[i].GetIndex())
+		assert.True(t, proto.Equal(address, records[i].GetAddress()))
+	}
+
+	// Once the server is gone, a close that times out aborts the retries instead of waiting for them,
+	// and close only returns after the publishing goroutine exited.
+	// Events dropped because the queue is full are reported once per tick instead of once per event.
+	t.Setenv("CARDINAL_EVENT_SINK_RETRY_WAIT", "1h")
+	t.Setenv("CARDINAL_EVENT_SINK_QUEUE", "1")
+	var logs bytes.Buffer
+	stuck, err := newEventSink(client, address, zerolog.New(&logs))
+	require.NoError(t, err)
+	srv.Shutdown()
+	for range 5 {
+		stuck.record(Tick{height: 5, timestamp: time.Now()}, "", &iscv1.Event{Name: "lost"})
+	}
+	assert.GreaterOrEqual(t, stuck.dropped, 3)
+	stuck.reportDropped(Tick{height: 5})
+	assert.Zero(t, stuck.dropped)
+
+	closeCtx, closeCancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer closeCancel()
+	start := time.Now()
+	require.Error(t, stuck.close(closeCtx))
+	assert.Less(t, time.Since(start), 10*time.Second)
+	assert.Equal(t, 1, strings.Count(logs.String(), "dropped events")) // Read once the goroutine exited
+}
@@ -0,0 +1,269 @@
+package cardinal
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/argus-labs/world-engine/pkg/cardinal/snapshot"
+	"github.com/rotisserie/eris"
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"google.golang.org/protobuf/proto"
+)
+
+// snapshotWriteTimeout bounds a single snapshot write to storage.
+const snapshotWriteTimeout = 10 * time.Second
+
+// snapshotJob is a serialized world state waiting to be written to snapshot storage.
+type snapshotJob struct {
+	tickHeight uint64
+	timestamp  time.Time
+	state      proto.Message // *cardinalv1.WorldState, or *cardinalv1.WorldStateDelta if delta is set
+	delta      bool
+	submitted  time.Time // When the job was submitted, to measure the writer's lag
+}
+
+// writeSnapshot marshals a snapshot job and writes it to storage.
+func writeSnapshot(ctx context.Context, storage snapshot.Storage, job *snapshotJob) error {
+	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(job.state)
+	if err != nil {
+		return eris.Wrap(err, "failed to marshal world state to bytes")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, snapshotWriteTimeout)
+	defer cancel()
+	snap := &snapshot.Snapshot{
+		TickHeight: job.tickHeight,
+		Timestamp:  job.timestamp,
+		Data:       data,
+		Version:    snapshot.CurrentVersion,
+		Delta:      job.delta,
+	}
+	if err := storage.Store(ctx, snap); err != nil {
+		return eris.Wrap(err, "failed to store snapshot")
+	}
+	return nil
+}
+
+// snapshotWriter writes snapshots to storage on a background goroutine, so a slow or unavailable
+// storage doesn't stall the tick. It holds at most one snapshot waiting to be written: if the writer
+// falls behind, a newer snapshot replaces the waiting one (latest wins).
+//
+// A delta snapshot can't replace a waiting snapshot, as it's chained to it. The world submits a full
+// snapshot instead if one is waiting. If a write fails, the delta snapshots chained to it are dropped
+// and the world is told to submit a full snapshot next.
+type snapshotWriter struct {
+	storage snapshot.Storage
+	log     zerolog.Logger
+	metrics *snapshotMetrics
+	wake    chan struct{} // Signals the goroutine that a job is waiting
+	wg      sync.WaitGroup
+
+	mu      sync.Mutex
+	pending *snapshotJob    // Job waiting to be written, nil if there's none
+	flushes []chan struct{} // Closed once the job waiting when they were added is written
+
+	// Set when a written or dropped snapshot breaks the delta chain, until the world takes it.
+	chainBroken atomic.Bool
+
+	// Only used by the writer goroutine. Set after a failed write until a full snapshot is stored, so
+	// the deltas chained to the failed snapshot are dropped.
+	broken bool
+}
+
+// newSnapshotWriter creates a snapshot writer and starts writing.
+func newSnapshotWriter(storage snapshot.Storage, log zerolog.Logger) *snapshotWriter {
+	metrics, err := newSnapshotMetrics()
+	if err != nil {
+		log.Warn().Err(err).Msg("snapshot writer metrics may not be exported")
+	}
+
+	s := &snapshotWriter{
+		storage: storage,
+		log:     log,
+		metrics: metrics,
+		wake:    make(chan struct{}, 1),
+	}
+	s.wg.Add(1)
+	go s.run()
+	return s
+}
+
+// submit queues a job for writing. It never blocks. If a job is still waiting, it's replaced.
+func (s *snapshotWriter) submit(job *snapshotJob) {
+	job.submitted = time.Now()
+
+	s.mu.Lock()
+	replaced := s.pending
+	s.pending = job
+	s.mu.Unlock()
+
+	if replaced != nil {
+		s.metrics.coalesced.Add(context.Background(), 1)
+		s.log.Warn().Uint64("dropped_tick", replaced.tickHeight).Uint64("tick", job.tickHeight).
+			Msg("snapshot writer is falling behind, replaced waiting snapshot")
+		if job.delta {
+			// The world doesn't do this, but the delta would be chained to the dropped snapshot.
+			s.chainBroken.Store(true)
+		}
+	}
+
+	select {
+	case s.wake <- struct{}{}:
+	default: // The goroutine is already signaled.
+	}
+}
+
+// busy returns whether a job is waiting to be written, in which case the next job replaces it.
+func (s *snapshotWriter) busy() bool {
+	if s == nil {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.pending != nil
+}
+
+// takeChainBroken returns whether the delta chain broke since it was last called, in which case the
+// next snapshot must be a full snapshot.
+func (s *snapshotWriter) takeChainBroken() bool {
+	if s == nil {
+		return false
+	}
+	return s.chainBroken.Swap(false)
+}
+
+// run writes the submitted jobs until the writer is closed.
+func (s *snapshotWriter) run() {
+	defer s.wg.Done()
+	for range s.wake {
+		s.mu.Lock()
+		job := s.pending
+		s.pending = nil
+		flushes := s.flushes
+		s.flushes = nil
+		s.mu.Unlock()
+
+		if job != nil {
+			s.write(job)
+		}
+		for _, flushed := range flushes {
+			close(flushed)
+		}
+	}
+}
+
+// write writes a job to storage, recording its lag and failures.
+func (s *snapshotWriter) write(job *snapshotJob) {
+	log := s.log.With().Uint64("tick", job.tickHeight).Bool("delta", job.delta).Logger()
+	if job.delta && s.broken {
+		s.metrics.failed.Add(context.Background(), 1, s.metrics.dropped)
+		log.Warn().Msg("dropped delta snapshot chained to a failed snapshot")
+		return
+	}
+
+	if err := writeSnapshot(context.Background(), s.storage, job); err != nil {
+		s.broken = true
+		s.chainBroken.Store(true)
+		s.metrics.failed.Add(context.Background(), 1, s.metrics.errored)
+		log.Warn().Err(err).Msg("failed to write snapshot")
+		return
+	}
+	if !job.delta {
+		s.broken = false
+	}
+
+	lag := time.Since(job.submitted)
+	s.metrics.lag.Record(context.Background(), lag.Seconds())
+	log.Debug().Dur("lag", lag).Msg("published snapshot")
+}
+
+// flush waits until the submitted jobs are written, or for ctx to be done. It must not be called
+// concurrently with submit or close.
+func (s *snapshotWriter) flush(ctx context.Context) error {
+	if s == nil {
+		return nil
+	}
+	flushed := make(chan struct{})
+	s.mu.Lock()
+	s.flushes = append(s.flushes, flushed)
+	s.mu.Unlock()
+
+	select {
+	case s.wake <- struct{}{}:
+	default: // The goroutine is already signaled.
+	}
+	select {
+	case <-flushed:
+		return nil
+	case <-ctx.Done():
+		return eris.Wrap(ctx.Err(), "snapshot writer wasn't flushed")
+	}
+}
+
+// close stops accepting jobs and waits for the waiting one to be written, or for ctx to be done.
+func (s *snapshotWriter) close(ctx context.Context) error {
+	if s == nil {
+		return nil
+	}
+	close(s.wake)
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return eris.Wrap(ctx.Err(), "snapshot writer closed before the waiting snapshot was written")
+	}
+}
+
+// -------------------------------------------------------------------------------------------------
+// Metrics
+// -------------------------------------------------------------------------------------------------
+
+// snapshotMetrics are the OpenTelemetry instruments for the snapshot writer. They are recorded through
+// the global meter provider, which is a no-op unless the application configures one.
+type snapshotMetrics struct {
+	lag       metric.Float64Histogram // Time from submitting a snapshot to it being written
+	coalesced metric.Int64Counter     // Waiting snapshots replaced by a newer one
+	failed    metric.Int64Counter     // Snapshots that weren't written
+	errored   metric.AddOption        // Attribute for snapshots whose write failed
+	dropped   metric.AddOption        // Attribute for delta snapshots chained to a failed snapshot
+}
+
+// newSnapshotMetrics creates the snapshot writer instruments. The instruments are usable even if an
+// error is returned, they just may not be exported.
+func newSnapshotMetrics() (*snapshotMetrics, error) {
+	meter := otel.Meter("github.com/argus-labs/world-engine/pkg/cardinal")
+
+	lag, lagErr := meter.Float64Histogram("cardinal.snapshot.write_lag",
+		metric.WithDescription("Time from a snapshot being taken to it being written to storage"),
+		metric.WithUnit("s"))
+	coalesced, coalescedErr := meter.Int64Counter("cardinal.snapshot.coalesced",
+		metric.WithDescription("Number of snapshots replaced by a newer one before they were written"),
+		metric.WithUnit("{snapshot}"))
+	failed, failedErr := meter.Int64Counter("cardinal.snapshot.failed",
+		metric.WithDescription("Number of snapshots that failed to be written to storage"),
+		metric.WithUnit("{snapshot}"))
+
+	metrics := &snapshotMetrics{
+		lag:       lag,
+		coalesced: coalesced,
+		failed:    failed,
+		errored:   metric.WithAttributes(attribute.String("reason", "error")),
+		dropped:   metric.WithAttributes(attribute.String("reason", "broken_chain")),
+	}
+	if err := errors.Join(lagErr, coalescedErr, failedErr); err != nil {
+		return metrics, eris.Wrap(err, "failed to create snapshot writer metrics")
+	}
+	return metrics, nil
+}
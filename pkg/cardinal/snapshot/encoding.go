@@ -0,0 +1,322 @@
+package snapshot
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"os"
+	"strings"
+
+	"buf.build/go/protovalidate"
+	cardinalv1 "github.com/argus-labs/world-engine/proto/gen/go/worldengine/cardinal/v1"
+	"github.com/klauspost/compress/zstd"
+	"github.com/rotisserie/eris"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	compressionNoneString = "NONE"
+	compressionZstdString = "ZSTD"
+)
+
+// versionEnvelope is the first version whose state is stored in a SnapshotPayload envelope instead of
+// the world_state and delta fields.
+const versionEnvelope uint32 = 2
+
+// maxStateSize is the largest serialized state a compressed payload decompresses to, so a corrupted or
+// malicious payload can't exhaust the memory.
+const maxStateSize = 1 << 30
+
+// codec encodes snapshots to the bytes a backend stores, and decodes them back. Snapshots are always
+// encoded in the current version's format, and the payload records the compression and encryption it
+// was encoded with, so snapshots encoded with other settings or in older versions still decode.
+type codec struct {
+	compression cardinalv1.SnapshotCompression
+	aead        cipher.AEAD // Encrypts the payloads, nil if encryption is disabled
+	keyID       []byte
+	encoder     *zstd.Encoder
+	decoder     *zstd.Decoder
+}
+
+// newCodec creates a codec with the given encoding options.
+func newCodec(opts EncodingOptions) (*codec, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	// The decoder is always created, to decode compressed snapshots after compression is disabled.
+	encoder, err := zstd.NewWriter(nil)
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to create zstd encoder")
+	}
+	decoder, err := zstd.NewReader(nil, zstd.WithDecoderMaxMemory(maxStateSize))
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to create zstd decoder")
+	}
+	c := &codec{
+		compression: cardinalv1.SnapshotCompression_SNAPSHOT_COMPRESSION_NONE,
+		encoder:     encoder,
+		decoder:     decoder,
+	}
+	if strings.ToUpper(opts.Compression) == compressionZstdString {
+		c.compression = cardinalv1.SnapshotCompression_SNAPSHOT_COMPRESSION_ZSTD
+	}
+
+	key, err := opts.encryptionKey()
+	if err != nil {
+		return nil, err
+	}
+	if key != nil {
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, eris.Wrap(err, "invalid snapshot encryption key")
+		}
+		c.aead, err = cipher.NewGCM(block)
+		if err != nil {
+			return nil, eris.Wrap(err, "failed to create AES-GCM cipher")
+		}
+		c.keyID = keyID(key)
+	}
+	return c, nil
+}
+
+// encode marshals a snapshot into its stored format.
+func (c *codec) encode(snapshot *Snapshot) ([]byte, error) {
+	payload, err := c.encodePayload(snapshot)
+	if err != nil {
+		return nil, err
+	}
+	data, err := proto.Marshal(&cardinalv1.Snapshot{
+		TickHeight: snapshot.TickHeight,
+		Timestamp:  timestamppb.New(snapshot.Timestamp),
+		Version:    CurrentVersion,
+		Payload:    payload,
+	})
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to marshal snapshot")
+	}
+	return data, nil
+}
+
+// encodePayload compresses and encrypts the snapshot's state. The checksum is of the stored data, so
+// it doesn't reveal anything about an encrypted state.
+func (c *codec) encodePayload(snapshot *Snapshot) (*cardinalv1.SnapshotPayload, error) {
+	// The state must be valid protobuf, so a corrupted state isn't stored with a valid checksum.
+	var state proto.Message = &cardinalv1.WorldState{}
+	if snapshot.Delta {
+		state = &cardinalv1.WorldStateDelta{}
+	}
+	if err := proto.Unmarshal(snapshot.Data, state); err != nil {
+		return nil, eris.Wrap(err, "failed to unmarshal world state")
+	}
+
+	payload := &cardinalv1.SnapshotPayload{
+		Delta:       snapshot.Delta,
+		Compression: c.compression,
+		Data:        snapshot.Data,
+	}
+	if c.compression == cardinalv1.SnapshotCompression_SNAPSHOT_COMPRESSION_ZSTD {
+		payload.Data = c.encoder.EncodeAll(payload.Data, nil)
+	}
+	if c.aead != nil {
+		payload.Encrypted = true
+		payload.KeyId = c.keyID
+		nonce := make([]byte, c.aead.NonceSize())
+		if _, err := rand.Read(nonce); err != nil {
+			return nil, eris.Wrap(err, "failed to generate nonce")
+		}
+		payload.Data = c.aead.Seal(nonce, nonce, payload.Data, additionalData(snapshot.TickHeight, payload))
+	}
+	checksum := sha256.Sum256(payload.Data)
+	payload.Checksum = checksum[:]
+	return payload, nil
+}
+
+// decode unmarshals, verifies, and validates a stored snapshot.
+func (c *codec) decode(data []byte) (*Snapshot, error) {
+	snapshotPb := cardinalv1.Snapshot{}
+	if err := proto.Unmarshal(data, &snapshotPb); err != nil {
+		return nil, eris.Wrap(err, "failed to unmarshal snapshot")
+	}
+	if err := protovalidate.Validate(&snapshotPb); err != nil {
+		return nil, eris.Wrap(err, "failed to validate snapshot")
+	}
+
+	version := snapshotPb.GetVersion()
+	if version > CurrentVersion {
+		return nil, eris.Errorf("unsupported snapshot version %d, newer than %d", version, CurrentVersion)
+	}
+
+	snap := &Snapshot{
+		TickHeight: snapshotPb.GetTickHeight(),
+		Timestamp:  snapshotPb.GetTimestamp().AsTime(),
+		Version:    version,
+	}
+	if version < versionEnvelope {
+		// Older snapshots store the state in place, already validated with the snapshot.
+		var state proto.Message = snapshotPb.GetWorldState()
+		if snapshotPb.GetDelta() != nil {
+			state = snapshotPb.GetDelta()
+		}
+		stateBytes, err := proto.Marshal(state)
+		if err != nil {
+			return nil, eris.Wrap(err, "failed to marshal world state")
+		}
+		snap.Data = stateBytes
+		snap.Delta = snapshotPb.GetDelta() != nil
+		return snap, nil
+	}
+
+	payload := snapshotPb.GetPayload()
+	if payload == nil {
+		return nil, eris.New("snapshot has no payload")
+	}
+	stateBytes, err := c.decodePayload(payload, snap.TickHeight)
+	if err != nil {
+		return nil, err
+	}
+	var state proto.Message = &cardinalv1.WorldState{}
+	if payload.GetDelta() {
+		state = &cardinalv1.WorldStateDelta{}
+	}
+	if err := proto.Unmarshal(stateBytes, state); err != nil {
+		return nil, eris.Wrap(err, "failed to unmarshal world state")
+	}
+	if err := protovalidate.Validate(state); err != nil {
+		return nil, eris.Wrap(err, "failed to validate world state")
+	}
+	snap.Data = stateBytes
+	snap.Delta = payload.GetDelta()
+	return snap, nil
+}
+
+// decodePayload verifies a snapshot's checksum, and decrypts and decompresses its state.
+func (c *codec) decodePayload(payload *cardinalv1.SnapshotPayload, tickHeight uint64) ([]byte, error) {
+	data := payload.GetData()
+	if checksum := sha256.Sum256(data); !bytes.Equal(checksum[:], payload.GetChecksum()) {
+		return nil, eris.New("snapshot checksum mismatch")
+	}
+
+	if payload.GetEncrypted() {
+		if c.aead == nil {
+			return nil, eris.New("snapshot is encrypted, but no encryption key is configured")
+		}
+		if !bytes.Equal(payload.GetKeyId(), c.keyID) {
+			return nil, eris.New("snapshot is encrypted with another key")
+		}
+		nonceSize := c.aead.NonceSize()
+		if len(data) < nonceSize {
+			return nil, eris.New("encrypted snapshot is too short")
+		}
+		var err error
+		data, err = c.aead.Open(nil, data[:nonceSize], data[nonceSize:], additionalData(tickHeight, payload))
+		if err != nil {
+			return nil, eris.Wrap(err, "failed to decrypt snapshot")
+		}
+	}
+
+	switch payload.GetCompression() {
+	case cardinalv1.SnapshotCompression_SNAPSHOT_COMPRESSION_NONE:
+	case cardinalv1.SnapshotCompression_SNAPSHOT_COMPRESSION_ZSTD:
+		var err error
+		data, err = c.decoder.DecodeAll(data, nil)
+		if err != nil {
+			return nil, eris.Wrap(err, "failed to decompress snapshot")
+		}
+	case cardinalv1.SnapshotCompression_SNAPSHOT_COMPRESSION_UNSPECIFIED:
+		fallthrough
+	default:
+		return nil, eris.Errorf("unsupported snapshot compression %s", payload.GetCompression())
+	}
+	return data, nil
+}
+
+// additionalData binds an encrypted payload to the snapshot's tick height and to how the payload says
+// it was encoded, so it can't be passed off as the payload of another snapshot, and its flags can't be
+// changed, e.g. to have a state decoded as a delta or decompressed differently.
+func additionalData(tickHeight uint64, payload *cardinalv1.SnapshotPayload) []byte {
+	data := binary.BigEndian.AppendUint64(nil, tickHeight)
+	delta := byte(0)
+	if payload.GetDelta() {
+		delta = 1
+	}
+	data = append(data, delta)
+	data = binary.BigEndian.AppendUint32(data, uint32(payload.GetCompression())) //nolint:gosec // enum values
+	data = binary.BigEndian.AppendUint32(data, uint32(len(payload.GetKeyId())))  //nolint:gosec // 8 bytes
+	return append(data, payload.GetKeyId()...)
+}
+
+// keyID returns the ID stored with the payloads encrypted with key.
+func keyID(key []byte) []byte {
+	hash := sha256.Sum256(key)
+	return hash[:8]
+}
+
+// -------------------------------------------------------------------------------------------------
+// Options
+// -------------------------------------------------------------------------------------------------
+
+// EncodingOptions configures how snapshots are encoded. Snapshots record how they were encoded, so
+// changing the options doesn't affect loading the stored snapshots, except that encrypted snapshots
+// need the key they were encrypted with.
+//
+// Environment variables:
+//
+//	CARDINAL_SNAPSHOT_COMPRESSION=ZSTD           # Compression, NONE (default) or ZSTD
+//	CARDINAL_SNAPSHOT_ENCRYPTION_KEY=<base64>    # AES key, 16, 24, or 32 bytes, enables encryption
+//	CARDINAL_SNAPSHOT_ENCRYPTION_KEY_FILE=<path> # File containing the base64 AES key, instead
+type EncodingOptions struct {
+	// Compression of the stored snapshots, NONE or ZSTD. Defaults to NONE.
+	Compression string `env:"CARDINAL_SNAPSHOT_COMPRESSION"`
+
+	// Base64-encoded AES key the snapshots are encrypted with using AES-GCM. Encryption is disabled if
+	// neither the key nor the key file is set.
+	EncryptionKey string `env:"CARDINAL_SNAPSHOT_ENCRYPTION_KEY"`
+
+	// Path to a file containing the base64-encoded AES key, instead of EncryptionKey.
+	EncryptionKeyFile string `env:"CARDINAL_SNAPSHOT_ENCRYPTION_KEY_FILE"`
+}
+
+func (opt *EncodingOptions) Validate() error {
+	switch strings.ToUpper(opt.Compression) {
+	case "", compressionNoneString, compressionZstdString:
+	default:
+		return eris.Errorf("invalid snapshot compression %q, must be %s or %s",
+			opt.Compression, compressionNoneString, compressionZstdString)
+	}
+	if opt.EncryptionKey != "" && opt.EncryptionKeyFile != "" {
+		return eris.New("only one of the snapshot encryption key and key file can be set")
+	}
+	return nil
+}
+
+// encryptionKey returns the configured encryption key, or nil if encryption is disabled.
+func (opt *EncodingOptions) encryptionKey() ([]byte, error) {
+	encoded := opt.EncryptionKey
+	if opt.EncryptionKeyFile != "" {
+		file, err := os.ReadFile(opt.EncryptionKeyFile)
+		if err != nil {
+			return nil, eris.Wrap(err, "failed to read snapshot encryption key file")
+		}
+		encoded = strings.TrimSpace(string(file))
+	}
+	if encoded == "" {
+		return nil, nil //nolint:nilnil // No key means encryption is disabled
+	}
+
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, eris.Wrap(err, "snapshot encryption key isn't valid base64")
+	}
+	switch len(key) {
+	case 16, 24, 32:
+		return key, nil
+	default:
+		return nil, eris.Errorf("snapshot encryption key must be 16, 24, or 32 bytes, got %d", len(key))
+	}
+}
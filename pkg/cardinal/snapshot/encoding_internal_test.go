@@ -0,0 +1,226 @@
+package snapshot
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/argus-labs/world-engine/pkg/testutils"
+	cardinalv1 "github.com/argus-labs/world-engine/proto/gen/go/worldengine/cardinal/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// -------------------------------------------------------------------------------------------------
+// Encoding smoke tests
+// -------------------------------------------------------------------------------------------------
+// Verifies that snapshots round-trip with every combination of compression and encryption, decode
+// regardless of the current settings, and fail to decode if their checksum doesn't match, they're
+// encrypted with another key, no key is configured, or the flags of an encrypted payload were changed.
+// Snapshots of version 1 must still decode.
+// -------------------------------------------------------------------------------------------------
+
+func TestCodec_RoundTrip(t *testing.T) {
+	t.Parallel()
+	prng := testutils.NewRand(t)
+
+	key := newTestEncryptionKey(prng)
+	options := map[string]EncodingOptions{
+		"plain":                {},
+		"zstd":                 {Compression: "ZSTD"},
+		"encrypted":            {EncryptionKey: key},
+		"zstd and encrypted":   {Compression: "zstd", EncryptionKey: key},
+		"explicit compression": {Compression: "NONE"},
+	}
+	states := []proto.Message{
+		&cardinalv1.WorldState{NextId: prng.Uint32(), FreeIds: []uint32{1, 2, 3}},
+		&cardinalv1.WorldStateDelta{ParentTickHeight: 1, NextId: prng.Uint32()},
+	}
+	// A codec with the key decodes the snapshots encoded with any of the options.
+	reader, err := newCodec(EncodingOptions{EncryptionKey: key})
+	require.NoError(t, err)
+
+	for name, opts := range options {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			c, err := newCodec(opts)
+			require.NoError(t, err)
+
+			for _, state := range states {
+				want := newTestStateSnapshot(t, 2, state)
+				data, err := c.encode(want)
+				require.NoError(t, err)
+
+				for _, dec := range []*codec{c, reader} {
+					got, err := dec.decode(data)
+					require.NoError(t, err)
+					assert.Equal(t, want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestCodec_Rejects(t *testing.T) {
+	t.Parallel()
+	prng := testutils.NewRand(t)
+
+	encrypted, err := newCodec(EncodingOptions{Compression: "ZSTD", EncryptionKey: newTestEncryptionKey(prng)})
+	require.NoError(t, err)
+	otherKey := newTestEncryptionKey(prng)
+	snap := newTestSnapshot(t, 1, 42)
+
+	t.Run("checksum mismatch", func(t *testing.T) {
+		t.Parallel()
+		c, err := newCodec(EncodingOptions{})
+		require.NoError(t, err)
+		data, err := c.encode(snap)
+		require.NoError(t, err)
+
+		var snapshotPb cardinalv1.Snapshot
+		require.NoError(t, proto.Unmarshal(data, &snapshotPb))
+		snapshotPb.GetPayload().Checksum[0] ^= 0xff
+		data, err = proto.Marshal(&snapshotPb)
+		require.NoError(t, err)
+
+		_, err = c.decode(data)
+		require.ErrorContains(t, err, "checksum mismatch")
+	})
+
+	t.Run("missing key", func(t *testing.T) {
+		t.Parallel()
+		data, err := encrypted.encode(snap)
+		require.NoError(t, err)
+		c, err := newCodec(EncodingOptions{})
+		require.NoError(t, err)
+		_, err = c.decode(data)
+		require.ErrorContains(t, err, "no encryption key")
+	})
+
+	t.Run("another key", func(t *testing.T) {
+		t.Parallel()
+		data, err := encrypted.encode(snap)
+		require.NoError(t, err)
+		c, err := newCodec(EncodingOptions{EncryptionKey: otherKey})
+		require.NoError(t, err)
+		_, err = c.decode(data)
+		require.ErrorContains(t, err, "another key")
+	})
+
+	t.Run("tampered ciphertext", func(t *testing.T) {
+		t.Parallel()
+		data, err := encrypted.encode(snap)
+		require.NoError(t, err)
+
+		var snapshotPb cardinalv1.Snapshot
+		require.NoError(t, proto.Unmarshal(data, &snapshotPb))
+		payload := snapshotPb.GetPayload()
+		payload.Data[len(payload.GetData())-1] ^= 0xff
+		checksum := sha256.Sum256(payload.GetData())
+		payload.Checksum = checksum[:]
+		data, err = proto.Marshal(&snapshotPb)
+		require.NoError(t, err)
+
+		_, err = encrypted.decode(data)
+		require.ErrorContains(t, err, "failed to decrypt")
+	})
+
+	t.Run("tampered flags", func(t *testing.T) {
+		t.Parallel()
+		tamper := map[string]func(payload *cardinalv1.SnapshotPayload){
+			"delta": func(payload *cardinalv1.SnapshotPayload) { payload.Delta = !payload.GetDelta() },
+			"compression": func(payload *cardinalv1.SnapshotPayload) {
+				payload.Compression = cardinalv1.SnapshotCompression_SNAPSHOT_COMPRESSION_NONE
+			},
+		}
+		for name, change := range tamper {
+			data, err := encrypted.encode(snap)
+			require.NoError(t, err)
+
+			var snapshotPb cardinalv1.Snapshot
+			require.NoError(t, proto.Unmarshal(data, &snapshotPb))
+			change(snapshotPb.GetPayload())
+			data, err = proto.Marshal(&snapshotPb)
+			require.NoError(t, err)
+
+			_, err = encrypted.decode(data)
+			require.ErrorContains(t, err, "failed to decrypt", "changed %s", name)
+		}
+	})
+
+	t.Run("newer version", func(t *testing.T) {
+		t.Parallel()
+		data, err := proto.Marshal(&cardinalv1.Snapshot{TickHeight: 1, Version: CurrentVersion + 1})
+		require.NoError(t, err)
+		_, err = encrypted.decode(data)
+		require.ErrorContains(t, err, "unsupported snapshot version")
+	})
+}
+
+func TestCodec_LegacyVersion(t *testing.T) {
+	t.Parallel()
+	prng := testutils.NewRand(t)
+
+	// Version 1 stored the world state in place, without an envelope.
+	timestamp := time.Unix(100, 0).UTC()
+	data, err := proto.Marshal(&cardinalv1.Snapshot{
+		TickHeight: 7,
+		Timestamp:  timestamppb.New(timestamp),
+		WorldState: &cardinalv1.WorldState{NextId: 42},
+		Version:    1,
+	})
+	require.NoError(t, err)
+
+	c, err := newCodec(EncodingOptions{Compression: "ZSTD", EncryptionKey: newTestEncryptionKey(prng)})
+	require.NoError(t, err)
+	got, err := c.decode(data)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(1), got.Version)
+	assertWorldState(t, 7, &cardinalv1.WorldState{NextId: 42}, got)
+}
+
+func TestEncodingOptions(t *testing.T) {
+	t.Parallel()
+	prng := testutils.NewRand(t)
+
+	key := newTestEncryptionKey(prng)
+	keyFile := filepath.Join(t.TempDir(), "key")
+	require.NoError(t, os.WriteFile(keyFile, []byte(key+"\n"), 0o600))
+
+	// The key file holds the same key as the env var, so each codec decodes the other's snapshots.
+	fromFile, err := newCodec(EncodingOptions{EncryptionKeyFile: keyFile})
+	require.NoError(t, err)
+	fromEnv, err := newCodec(EncodingOptions{EncryptionKey: key})
+	require.NoError(t, err)
+	data, err := fromFile.encode(newTestSnapshot(t, 1, 42))
+	require.NoError(t, err)
+	_, err = fromEnv.decode(data)
+	require.NoError(t, err)
+
+	invalid := map[string]EncodingOptions{
+		"unknown compression": {Compression: "gzip"},
+		"key and key file":    {EncryptionKey: key, EncryptionKeyFile: keyFile},
+		"key not base64":      {EncryptionKey: "not base64!"},
+		"key wrong size":      {EncryptionKey: base64.StdEncoding.EncodeToString([]byte("short"))},
+		"missing key file":    {EncryptionKeyFile: filepath.Join(t.TempDir(), "missing")},
+	}
+	for name, opts := range invalid {
+		_, err := newCodec(opts)
+		assert.Error(t, err, name)
+	}
+}
+
+// newTestEncryptionKey returns a random base64-encoded AES-256 key.
+func newTestEncryptionKey(prng *rand.Rand) string {
+	key := make([]byte, 32)
+	for i := range key {
+		key[i] = byte(prng.Uint32())
+	}
+	return base64.StdEncoding.EncodeToString(key)
+}
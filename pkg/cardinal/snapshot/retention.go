@@ -0,0 +1,148 @@
+package snapshot
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/rotisserie/eris"
+	"github.com/rs/zerolog"
+)
+
+// defaultGenerations is the number of newest snapshots kept if the retention policy doesn't set it.
+const defaultGenerations = 3
+
+// RetentionPolicy decides which snapshots a storage keeps after storing a new one. A snapshot is kept
+// if any of the rules keeps it, and the newest snapshot is always kept.
+//
+// A delta snapshot can only be loaded together with the snapshots it's chained to. If a rule keeps a
+// delta snapshot whose chain isn't kept, the delta is compacted into a full snapshot first, so the
+// rest of its chain can be removed.
+//
+// The hourly and daily rules count the hours and days that have snapshots, not the hours and days of
+// the clock: a shard that was stopped for a week still keeps the newest snapshot of each of the last
+// Hourly hours it was running in.
+//
+// Example, keeping the last 5 snapshots, and the newest snapshot of each of the 24 latest hours with
+// snapshots:
+//
+//	snapshot.RetentionPolicy{Generations: 5, Hourly: 24}
+type RetentionPolicy struct {
+	// Number of newest full snapshots kept, with the delta snapshots chained to them. Defaults to 3.
+	Generations int `env:"CARDINAL_SNAPSHOT_GENERATIONS"`
+
+	// Number of the latest hours with snapshots whose newest snapshot is kept. Hours without snapshots
+	// aren't counted.
+	Hourly int `env:"CARDINAL_SNAPSHOT_KEEP_HOURLY"`
+
+	// Number of the latest days with snapshots whose newest snapshot is kept. Days without snapshots
+	// aren't counted.
+	Daily int `env:"CARDINAL_SNAPSHOT_KEEP_DAILY"`
+}
+
+// setDefaults sets the defaults of the fields that aren't set.
+func (p *RetentionPolicy) setDefaults() {
+	if p.Generations == 0 {
+		p.Generations = defaultGenerations
+	}
+}
+
+// Validate checks that the policy is valid.
+func (p *RetentionPolicy) Validate() error {
+	if p.Generations <= 0 {
+		return eris.New("snapshot generations must be greater than 0")
+	}
+	if p.Hourly < 0 {
+		return eris.New("hourly snapshots kept cannot be negative")
+	}
+	if p.Daily < 0 {
+		return eris.New("daily snapshots kept cannot be negative")
+	}
+	return nil
+}
+
+// expired returns the snapshots the policy doesn't keep, and the kept delta snapshots that have to be
+// compacted into full snapshots before the expired ones are removed. infos must be ordered by tick
+// height, oldest first, like Storage.List returns them.
+func (p *RetentionPolicy) expired(infos []Info) (expired, compact []Info) {
+	kept := make(map[uint64]struct{}, p.Generations+p.Hourly+p.Daily)
+	hours := make(map[time.Time]struct{}, p.Hourly)
+	days := make(map[time.Time]struct{}, p.Daily)
+
+	// Newest first, so the first snapshot seen in an hour or day is its newest.
+	generations := 0
+	for _, info := range slices.Backward(infos) {
+		if generations < p.Generations {
+			kept[info.TickHeight] = struct{}{}
+		}
+		if !info.Delta {
+			generations++
+		}
+		if hour := info.Timestamp.Truncate(time.Hour); len(hours) < p.Hourly {
+			if _, seen := hours[hour]; !seen {
+				hours[hour] = struct{}{}
+				kept[info.TickHeight] = struct{}{}
+			}
+		}
+		if day := info.Timestamp.Truncate(24 * time.Hour); len(days) < p.Daily {
+			if _, seen := days[day]; !seen {
+				days[day] = struct{}{}
+				kept[info.TickHeight] = struct{}{}
+			}
+		}
+	}
+
+	// Oldest first, tracking whether the snapshots chained to the current one are all kept. Deltas
+	// before the first full snapshot can't be loaded, so they're never kept.
+	chainKept, chained := false, false
+	for _, info := range infos {
+		_, keep := kept[info.TickHeight]
+		switch {
+		case !info.Delta:
+			chainKept, chained = keep, true
+		case !chained:
+			keep = false
+		case keep && !chainKept:
+			compact = append(compact, info)
+			chainKept = true
+		case !keep:
+			chainKept = false
+		}
+		if !keep {
+			expired = append(expired, info)
+		}
+	}
+	return expired, compact
+}
+
+// applyRetention removes the snapshots in storage that the policy doesn't keep, after compacting the
+// kept delta snapshots whose chain would be removed. It also removes the objects superseded by another
+// object of the same tick.
+func applyRetention(ctx context.Context, b backend, policy RetentionPolicy, logger zerolog.Logger) error {
+	objects, err := b.objects(ctx)
+	if err != nil {
+		return eris.Wrap(err, "failed to list snapshots")
+	}
+	infos, superseded := dedupe(objects)
+
+	expired, compact := policy.expired(infos)
+	if len(compact) > 0 {
+		for _, info := range compact {
+			if err := compactAt(ctx, b, info); err != nil {
+				return eris.Wrapf(err, "failed to compact delta snapshot of tick %d", info.TickHeight)
+			}
+			logger.Debug().Uint64("tick", info.TickHeight).Msg("compacted delta snapshot")
+			i := slices.Index(infos, info)
+			infos[i].Delta = false
+		}
+		// The compacted snapshots are full snapshots now, so the rest of their chains expires.
+		expired, _ = policy.expired(infos)
+	}
+
+	for _, info := range slices.Concat(superseded, expired) {
+		if err := b.remove(ctx, info.objectName()); err != nil {
+			return eris.Wrapf(err, "failed to remove snapshot of tick %d", info.TickHeight)
+		}
+	}
+	return nil
+}
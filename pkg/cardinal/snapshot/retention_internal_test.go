@@ -0,0 +1,100 @@
+package snapshot
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/argus-labs/world-engine/pkg/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// -------------------------------------------------------------------------------------------------
+// Retention policy property tests
+// -------------------------------------------------------------------------------------------------
+// Verifies the retention rules against a model over random snapshot histories: the newest
+// generations are kept, so is the newest snapshot of each of the most recent hours and days, and
+// nothing else. Kept delta snapshots must stay loadable, either because the snapshots they're chained
+// to are kept too, or because they're compacted.
+// -------------------------------------------------------------------------------------------------
+
+func TestRetentionPolicy_Expired(t *testing.T) {
+	t.Parallel()
+	prng := testutils.NewRand(t)
+
+	for range 100 {
+		policy := RetentionPolicy{
+			Generations: 1 + prng.IntN(5),
+			Hourly:      prng.IntN(5),
+			Daily:       prng.IntN(3),
+		}
+
+		// Snapshots every 1 to 180 minutes, with increasing ticks, some of them deltas.
+		infos := make([]Info, prng.IntN(50))
+		storedAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		deltaRate := prng.Float64()
+		for i := range infos {
+			storedAt = storedAt.Add(time.Duration(1+prng.IntN(180)) * time.Minute)
+			infos[i] = Info{TickHeight: uint64(i) * 10, Timestamp: storedAt, Delta: prng.Float64() < deltaRate}
+		}
+
+		// Model: walk newest first, keeping every snapshot down to the Nth newest full snapshot, and the
+		// first snapshot of each new hour and day until the limits.
+		want := make(map[uint64]bool)
+		var hours, days []time.Time
+		fulls := 0
+		for _, info := range slices.Backward(infos) {
+			if fulls < policy.Generations {
+				want[info.TickHeight] = true
+			}
+			if !info.Delta {
+				fulls++
+			}
+			hour := info.Timestamp.Truncate(time.Hour)
+			if len(hours) < policy.Hourly && !slices.Contains(hours, hour) {
+				hours = append(hours, hour)
+				want[info.TickHeight] = true
+			}
+			day := info.Timestamp.Truncate(24 * time.Hour)
+			if len(days) < policy.Daily && !slices.Contains(days, day) {
+				days = append(days, day)
+				want[info.TickHeight] = true
+			}
+		}
+
+		// Deltas before the first full snapshot can't be loaded, so they're never kept.
+		first := slices.IndexFunc(infos, func(info Info) bool { return !info.Delta })
+		for i, info := range infos {
+			if first < 0 || i < first {
+				want[info.TickHeight] = false
+			}
+		}
+
+		expired, compact := policy.expired(infos)
+		for _, info := range infos {
+			assert.Equal(t, !want[info.TickHeight], slices.Contains(expired, info),
+				"tick %d with policy %+v", info.TickHeight, policy)
+		}
+		if first >= 0 {
+			require.NotContains(t, expired, infos[len(infos)-1], "newest snapshot must be kept")
+		}
+
+		// Property: after compaction, every kept delta snapshot is chained to a kept snapshot.
+		for i, info := range infos {
+			if !slices.Contains(compact, info) {
+				continue
+			}
+			assert.True(t, info.Delta && want[info.TickHeight],
+				"compacted tick %d must be a kept delta", info.TickHeight)
+			infos[i].Delta = false
+		}
+		for i, info := range infos {
+			if info.Delta && want[info.TickHeight] {
+				require.Positive(t, i)
+				assert.True(t, want[infos[i-1].TickHeight],
+					"tick %d is chained to an expired snapshot", info.TickHeight)
+			}
+		}
+	}
+}
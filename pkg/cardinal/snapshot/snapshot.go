@@ -16,22 +16,57 @@ type Snapshot struct {
 	Timestamp  time.Time
 	Data       []byte
 	Version    uint32
+
+	// Delta is true if Data is a serialized cardinalv1.WorldStateDelta with the changes since the
+	// previous stored snapshot, instead of a serialized cardinalv1.WorldState.
+	Delta bool
 }
 
-const CurrentVersion uint32 = 1
+// CurrentVersion is the version of the format snapshots are stored in. Version 2 stores the state in
+// an envelope with its checksum, compression, and encryption. Snapshots of older versions still load.
+const CurrentVersion uint32 = 2
 
 var ErrSnapshotNotFound = errors.New("snapshot not found")
 
+// Info describes a stored snapshot.
+type Info struct {
+	TickHeight uint64    // The tick height of the snapshot
+	Timestamp  time.Time // The timestamp of the snapshot's tick, to the millisecond
+	Delta      bool      // Whether the snapshot is a delta of the previous snapshot
+}
+
 // Storage provides persistence for shard snapshots.
-// Implementations handle atomic storage with automatic backup of previous snapshots.
+// Every stored snapshot is kept as a separate generation, identified by its tick height, until the
+// storage's retention policy removes it. This keeps earlier recovery points around if a bad state
+// gets persisted.
+//
+// A delta snapshot only contains the changes since the previous stored snapshot, which it is chained
+// to back to the last full snapshot. Load and LoadAt apply the chain's deltas to its full snapshot,
+// so they always return full snapshots.
 type Storage interface {
-	// Store saves the snapshot, atomically replacing any existing snapshot.
-	// The previous snapshot should be preserved as backup if possible.
+	// Store saves the snapshot as a new generation, replacing a stored snapshot of the same tick
+	// height, and then removes the generations its retention policy doesn't keep.
 	Store(ctx context.Context, snapshot *Snapshot) error
 
-	// Load retrieves the current snapshot.
-	// Returns an error if no snapshot exists.
+	// Load retrieves the newest snapshot that can be loaded, falling back to older generations if
+	// newer ones are invalid. Returns ErrSnapshotNotFound if no snapshot exists.
 	Load(ctx context.Context) (*Snapshot, error)
+
+	// LoadAt retrieves the snapshot of the given tick height.
+	// Returns ErrSnapshotNotFound if there is no snapshot of that tick.
+	LoadAt(ctx context.Context, tickHeight uint64) (*Snapshot, error)
+
+	// List returns the stored snapshots, ordered by tick height, oldest first.
+	List(ctx context.Context) ([]Info, error)
+
+	// Delete removes the snapshot of the given tick height. Deleting a snapshot that doesn't exist is
+	// not an error.
+	Delete(ctx context.Context, tickHeight uint64) error
+
+	// Quarantine moves the snapshot of the given tick height aside: it's no longer listed or loaded,
+	// but kept under a name prefixed with "quarantine-" for an operator to inspect, restore by
+	// removing the prefix, or delete. Quarantining a snapshot that doesn't exist is not an error.
+	Quarantine(ctx context.Context, tickHeight uint64) error
 }
 
 // StorageType defines the type of snapshot storage to use.
@@ -42,12 +77,14 @@ const (
 	StorageTypeNop
 	StorageTypeJetStream
 	StorageTypeS3
+	StorageTypeFile
 )
 
 const (
 	nopStorageString       = "NOP"
 	jetStreamStorageString = "JETSTREAM"
 	s3StorageString        = "S3"
+	fileStorageString      = "FILE"
 	undefinedStorageString = "UNDEFINED"
 )
 
@@ -61,13 +98,15 @@ func (s StorageType) String() string {
 		return jetStreamStorageString
 	case StorageTypeS3:
 		return s3StorageString
+	case StorageTypeFile:
+		return fileStorageString
 	default:
 		return undefinedStorageString
 	}
 }
 
 func (s StorageType) IsValid() bool {
-	return s == StorageTypeNop || s == StorageTypeJetStream || s == StorageTypeS3
+	return s == StorageTypeNop || s == StorageTypeJetStream || s == StorageTypeS3 || s == StorageTypeFile
 }
 
 func ParseStorageType(s string) (StorageType, error) {
@@ -78,6 +117,8 @@ func ParseStorageType(s string) (StorageType, error) {
 		return StorageTypeJetStream, nil
 	case s3StorageString:
 		return StorageTypeS3, nil
+	case fileStorageString:
+		return StorageTypeFile, nil
 	default:
 		return StorageTypeUndefined, eris.Errorf("invalid shard mode: %s", s)
 	}
@@ -0,0 +1,348 @@
+package snapshot
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	cardinalv1 "github.com/argus-labs/world-engine/proto/gen/go/worldengine/cardinal/v1"
+	"github.com/rotisserie/eris"
+	"github.com/rs/zerolog"
+	"google.golang.org/protobuf/proto"
+)
+
+// backend is the part of a Storage that is specific to where the snapshots are stored: it lists,
+// reads, writes, and removes the stored objects as they are. The storages share the handling of
+// delta chains and retention on top of it.
+type backend interface {
+	// objects returns the snapshot objects in storage, in any order.
+	objects(ctx context.Context) ([]Info, error)
+
+	// get returns the data of the named object. Returns ErrSnapshotNotFound if it doesn't exist.
+	get(ctx context.Context, name string) ([]byte, error)
+
+	// put writes the data to the named object, replacing it if it exists.
+	put(ctx context.Context, name string, data []byte) error
+
+	// remove removes the named object. Removing an object that doesn't exist is not an error.
+	remove(ctx context.Context, name string) error
+
+	// codec returns the codec the objects are encoded with.
+	codec() *codec
+}
+
+// store stores a snapshot and then applies the retention policy.
+func store(
+	ctx context.Context, b backend, snapshot *Snapshot, policy RetentionPolicy, logger zerolog.Logger,
+) error {
+	data, err := b.codec().encode(snapshot)
+	if err != nil {
+		return err
+	}
+	info := Info{TickHeight: snapshot.TickHeight, Timestamp: snapshot.Timestamp, Delta: snapshot.Delta}
+	if err := b.put(ctx, info.objectName(), data); err != nil {
+		return err
+	}
+
+	// Storing succeeded even if old generations can't be removed, they're removed on the next store.
+	if err := applyRetention(ctx, b, policy, logger); err != nil {
+		logger.Warn().Err(err).Msg("failed to remove old snapshot generations")
+	}
+	return nil
+}
+
+// list returns the snapshots in storage, ordered by tick height, oldest first.
+func list(ctx context.Context, b backend) ([]Info, error) {
+	objects, err := b.objects(ctx)
+	if err != nil {
+		return nil, err
+	}
+	infos, _ := dedupe(objects)
+	return infos, nil
+}
+
+// loadLatest loads the newest snapshot in storage that can be loaded, falling back to older
+// generations if newer ones are invalid.
+func loadLatest(ctx context.Context, b backend, logger zerolog.Logger) (*Snapshot, error) {
+	infos, err := list(ctx, b)
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to list snapshots")
+	}
+	if len(infos) == 0 {
+		return nil, eris.Wrap(ErrSnapshotNotFound, "no snapshot exists")
+	}
+
+	for i := len(infos) - 1; i >= 0; i-- {
+		snap, err := resolve(ctx, b, infos, i)
+		if err != nil {
+			logger.Warn().Err(err).Uint64("tick", infos[i].TickHeight).Msg("skipping invalid snapshot")
+			continue
+		}
+		return snap, nil
+	}
+	return nil, eris.Errorf("none of the %d stored snapshots are valid", len(infos))
+}
+
+// loadAt loads the snapshot of a tick, applying its chain of deltas if it's a delta snapshot.
+func loadAt(ctx context.Context, b backend, tickHeight uint64) (*Snapshot, error) {
+	infos, err := list(ctx, b)
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to list snapshots")
+	}
+	i := slices.IndexFunc(infos, func(info Info) bool { return info.TickHeight == tickHeight })
+	if i < 0 {
+		return nil, eris.Wrapf(ErrSnapshotNotFound, "no snapshot of tick %d exists", tickHeight)
+	}
+	return resolve(ctx, b, infos, i)
+}
+
+// deleteAt removes the snapshot objects of a tick.
+func deleteAt(ctx context.Context, b backend, tickHeight uint64) error {
+	objects, err := b.objects(ctx)
+	if err != nil {
+		return eris.Wrap(err, "failed to list snapshots")
+	}
+	for _, info := range objects {
+		if info.TickHeight != tickHeight {
+			continue
+		}
+		if err := b.remove(ctx, info.objectName()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// quarantineAt moves the snapshot objects of a tick to names with the quarantine prefix, which aren't
+// snapshot object names, so they're kept as they are but no longer listed, loaded, or removed by the
+// retention policy.
+func quarantineAt(ctx context.Context, b backend, tickHeight uint64) error {
+	objects, err := b.objects(ctx)
+	if err != nil {
+		return eris.Wrap(err, "failed to list snapshots")
+	}
+	for _, info := range objects {
+		if info.TickHeight != tickHeight {
+			continue
+		}
+		data, err := b.get(ctx, info.objectName())
+		if err != nil {
+			return err
+		}
+		// Write the copy before removing the snapshot, so it's never lost.
+		if err := b.put(ctx, quarantineObjectNamePrefix+info.objectName(), data); err != nil {
+			return err
+		}
+		if err := b.remove(ctx, info.objectName()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// compactAt replaces a delta snapshot with the full snapshot its chain resolves to. The full snapshot
+// keeps the delta's tick and timestamp, so the retention policy treats it the same.
+func compactAt(ctx context.Context, b backend, info Info) error {
+	snap, err := loadAt(ctx, b, info.TickHeight)
+	if err != nil {
+		return err
+	}
+	data, err := b.codec().encode(snap)
+	if err != nil {
+		return err
+	}
+	// Write the full snapshot before removing the delta, so the tick is never missing. The full snapshot
+	// supersedes the delta while both exist.
+	full := Info{TickHeight: snap.TickHeight, Timestamp: snap.Timestamp}
+	if err := b.put(ctx, full.objectName(), data); err != nil {
+		return err
+	}
+	return b.remove(ctx, info.objectName())
+}
+
+// resolve loads the snapshot infos[i]. If it's a delta snapshot, the deltas chained to it are applied
+// to the full snapshot they start from, oldest first.
+func resolve(ctx context.Context, b backend, infos []Info, i int) (*Snapshot, error) {
+	base := i
+	for base >= 0 && infos[base].Delta {
+		base--
+	}
+	if base < 0 {
+		return nil, eris.Errorf("delta snapshot of tick %d isn't chained to a full snapshot",
+			infos[i].TickHeight)
+	}
+
+	snap, err := loadObject(ctx, b, infos[base])
+	if err != nil {
+		return nil, err
+	}
+	if base == i {
+		return snap, nil
+	}
+
+	var worldState cardinalv1.WorldState
+	if err := proto.Unmarshal(snap.Data, &worldState); err != nil {
+		return nil, eris.Wrap(err, "failed to unmarshal world state")
+	}
+	for _, info := range infos[base+1 : i+1] {
+		delta, err := loadObject(ctx, b, info)
+		if err != nil {
+			return nil, err
+		}
+		var deltaPb cardinalv1.WorldStateDelta
+		if err := proto.Unmarshal(delta.Data, &deltaPb); err != nil {
+			return nil, eris.Wrap(err, "failed to unmarshal world state delta")
+		}
+		if deltaPb.GetParentTickHeight() != snap.TickHeight {
+			return nil, eris.Errorf("delta snapshot of tick %d applies to tick %d, not to the previous tick %d",
+				info.TickHeight, deltaPb.GetParentTickHeight(), snap.TickHeight)
+		}
+		if err := applyDelta(&worldState, &deltaPb); err != nil {
+			return nil, eris.Wrapf(err, "failed to apply delta snapshot of tick %d", info.TickHeight)
+		}
+		snap = delta
+	}
+
+	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(&worldState)
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to marshal world state")
+	}
+	return &Snapshot{
+		TickHeight: snap.TickHeight,
+		Timestamp:  snap.Timestamp,
+		Data:       data,
+		Version:    snap.Version,
+	}, nil
+}
+
+// loadObject loads a stored snapshot as it is.
+func loadObject(ctx context.Context, b backend, info Info) (*Snapshot, error) {
+	data, err := b.get(ctx, info.objectName())
+	if err != nil {
+		return nil, err
+	}
+	snap, err := b.codec().decode(data)
+	if err != nil {
+		return nil, err
+	}
+	if snap.Delta != info.Delta {
+		return nil, eris.Errorf("snapshot %s has the wrong kind", info.objectName())
+	}
+	return snap, nil
+}
+
+// applyDelta applies the changes of a delta to a world state.
+func applyDelta(worldState *cardinalv1.WorldState, delta *cardinalv1.WorldStateDelta) error {
+	worldState.NextId = delta.GetNextId()
+	worldState.FreeIds = delta.GetFreeIds()
+	worldState.EntityArch = delta.GetEntityArch()
+	worldState.CommandSchedule = delta.GetCommandSchedule()
+	worldState.ComponentVersions = delta.GetComponentVersions()
+	worldState.Seed = delta.GetSeed()
+
+	for _, archetype := range delta.GetArchetypes() {
+		id := int(archetype.GetId())
+		switch {
+		case id == len(worldState.GetArchetypes()):
+			// New archetypes are included whole.
+			worldState.Archetypes = append(worldState.Archetypes, archetype)
+		case id >= 0 && id < len(worldState.GetArchetypes()):
+			current := worldState.GetArchetypes()[id]
+			if !bytes.Equal(current.GetComponentsBitmap(), archetype.GetComponentsBitmap()) {
+				return eris.Errorf("archetype %d has different components", id)
+			}
+			current.Rows = archetype.GetRows()
+			current.Entities = archetype.GetEntities()
+			for _, column := range archetype.GetColumns() {
+				j := slices.IndexFunc(current.GetColumns(), func(c *cardinalv1.Column) bool {
+					return c.GetComponentName() == column.GetComponentName()
+				})
+				if j < 0 {
+					return eris.Errorf("archetype %d has no column %s", id, column.GetComponentName())
+				}
+				current.Columns[j] = column
+			}
+		default:
+			return eris.Errorf("archetype %d doesn't follow the %d existing archetypes", id,
+				len(worldState.GetArchetypes()))
+		}
+	}
+	return nil
+}
+
+// -------------------------------------------------------------------------------------------------
+// Encoding
+// -------------------------------------------------------------------------------------------------
+
+const (
+	objectNamePrefix      = "snapshot-"
+	deltaObjectNameSuffix = "-delta"
+
+	// Prefixes the names of quarantined snapshot objects, see Storage.Quarantine.
+	quarantineObjectNamePrefix = "quarantine-"
+)
+
+// objectName returns the name the snapshot is stored under: its tick height, zero-padded so the names
+// sort by tick height, and its timestamp in Unix milliseconds, with a suffix for delta snapshots.
+func (info Info) objectName() string {
+	name := fmt.Sprintf("%s%020d-%d", objectNamePrefix, info.TickHeight, info.Timestamp.UnixMilli())
+	if info.Delta {
+		name += deltaObjectNameSuffix
+	}
+	return name
+}
+
+// parseObjectName returns the snapshot a snapshot object name describes, or false if it isn't a
+// snapshot object name.
+func parseObjectName(name string) (Info, bool) {
+	rest, ok := strings.CutPrefix(name, objectNamePrefix)
+	if !ok {
+		return Info{}, false
+	}
+	rest, delta := strings.CutSuffix(rest, deltaObjectNameSuffix)
+	tickDigits, millisDigits, ok := strings.Cut(rest, "-")
+	if !ok {
+		return Info{}, false
+	}
+	tick, err := strconv.ParseUint(tickDigits, 10, 64)
+	if err != nil {
+		return Info{}, false
+	}
+	millis, err := strconv.ParseInt(millisDigits, 10, 64)
+	if err != nil {
+		return Info{}, false
+	}
+	return Info{TickHeight: tick, Timestamp: time.UnixMilli(millis).UTC(), Delta: delta}, true
+}
+
+// dedupe orders snapshot objects by tick height, oldest first, and splits off the objects superseded by
+// another object of the same tick: a delta snapshot is superseded by a full snapshot of its tick, left
+// behind if compacting it was interrupted, and a snapshot by a newer one of the same tick, stored after
+// the world went back to an earlier tick.
+func dedupe(objects []Info) (infos, superseded []Info) {
+	slices.SortFunc(objects, func(a, b Info) int {
+		if c := cmp.Compare(a.TickHeight, b.TickHeight); c != 0 {
+			return c
+		}
+		if a.Delta != b.Delta {
+			if a.Delta {
+				return 1
+			}
+			return -1
+		}
+		return b.Timestamp.Compare(a.Timestamp)
+	})
+	for i, info := range objects {
+		if i > 0 && objects[i-1].TickHeight == info.TickHeight {
+			superseded = append(superseded, info)
+			continue
+		}
+		infos = append(infos, info)
+	}
+	return infos, superseded
+}
@@ -0,0 +1,274 @@
+package snapshot
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/caarlos0/env/v11"
+	"github.com/rotisserie/eris"
+	"github.com/rs/zerolog"
+)
+
+const (
+	fileSnapshotSuffix     = ".pb"
+	defaultFileSnapshotDir = "snapshots"
+)
+
+// fileSnapshotMagic starts every snapshot file, followed by the CRC-32C checksum of the snapshot.
+var fileSnapshotMagic = []byte("CSNP")
+
+var crc32c = crc32.MakeTable(crc32.Castagnoli)
+
+// FileStorage implements Storage on the local filesystem, for local development and single-node
+// deployments.
+//
+// Environment variables:
+//
+//	CARDINAL_SNAPSHOT_STORAGE_TYPE=FILE  # Selects the filesystem as the snapshot backend
+//	CARDINAL_SNAPSHOT_DIR=<path>         # Directory of the snapshot files, defaults to ./snapshots
+//	CARDINAL_SNAPSHOT_GENERATIONS=<n>    # Number of newest full snapshots kept, defaults to 3
+//	CARDINAL_SNAPSHOT_KEEP_HOURLY=<n>    # Number of latest hours with snapshots whose newest one is kept
+//	CARDINAL_SNAPSHOT_KEEP_DAILY=<n>     # Number of latest days with snapshots whose newest one is kept
+//
+// See EncodingOptions for the environment variables that configure compression and encryption.
+//
+// Every snapshot is written to its own file, snapshot-<tick height>-<timestamp>.pb, with a -delta
+// suffix before the extension for delta snapshots, and the files the retention policy doesn't keep
+// are removed. Files are written to a temporary file that is synced and then renamed, so a crash never
+// leaves a partially written snapshot behind. Load returns the newest snapshot that passes its
+// checksum and validation, so a corrupted file falls back to the previous generation.
+type FileStorage struct {
+	dir       string
+	retention RetentionPolicy
+	enc       *codec
+	logger    zerolog.Logger
+}
+
+var (
+	_ Storage = (*FileStorage)(nil)
+	_ backend = (*FileStorage)(nil)
+)
+
+// NewFileStorage creates a new filesystem-based snapshot storage, creating the snapshot directory if
+// it doesn't exist.
+func NewFileStorage(opts FileStorageOptions) (*FileStorage, error) {
+	if err := env.Parse(&opts); err != nil {
+		return nil, eris.Wrap(err, "failed to parse env")
+	}
+	if opts.Dir == "" {
+		opts.Dir = defaultFileSnapshotDir
+	}
+	opts.Retention.setDefaults()
+	if err := opts.Validate(); err != nil {
+		return nil, eris.Wrap(err, "invalid options passed")
+	}
+
+	enc, err := newCodec(opts.Encoding)
+	if err != nil {
+		return nil, eris.Wrap(err, "invalid snapshot encoding")
+	}
+
+	if err := os.MkdirAll(opts.Dir, 0o755); err != nil {
+		return nil, eris.Wrapf(err, "failed to create snapshot directory %s", opts.Dir)
+	}
+
+	return &FileStorage{
+		dir:       opts.Dir,
+		retention: opts.Retention,
+		enc:       enc,
+		logger:    opts.Logger,
+	}, nil
+}
+
+func (f *FileStorage) Store(ctx context.Context, snapshot *Snapshot) error {
+	return store(ctx, f, snapshot, f.retention, f.logger)
+}
+
+func (f *FileStorage) Load(ctx context.Context) (*Snapshot, error) {
+	return loadLatest(ctx, f, f.logger)
+}
+
+func (f *FileStorage) LoadAt(ctx context.Context, tickHeight uint64) (*Snapshot, error) {
+	return loadAt(ctx, f, tickHeight)
+}
+
+func (f *FileStorage) List(ctx context.Context) ([]Info, error) {
+	return list(ctx, f)
+}
+
+func (f *FileStorage) Delete(ctx context.Context, tickHeight uint64) error {
+	return deleteAt(ctx, f, tickHeight)
+}
+
+// Quarantine renames the files instead of copying them through get and put like the other storages,
+// because get rejects the files that fail their checksum, which are the ones mostly quarantined.
+func (f *FileStorage) Quarantine(ctx context.Context, tickHeight uint64) error {
+	objects, err := f.objects(ctx)
+	if err != nil {
+		return eris.Wrap(err, "failed to list snapshots")
+	}
+	for _, info := range objects {
+		if info.TickHeight != tickHeight {
+			continue
+		}
+		from := filepath.Join(f.dir, fileName(info.objectName()))
+		to := filepath.Join(f.dir, fileName(quarantineObjectNamePrefix+info.objectName()))
+		if err := os.Rename(from, to); err != nil {
+			return eris.Wrapf(err, "failed to quarantine snapshot file %s", fileName(info.objectName()))
+		}
+	}
+	return nil
+}
+
+func (f *FileStorage) objects(_ context.Context) ([]Info, error) {
+	entries, err := os.ReadDir(f.dir)
+	if err != nil {
+		return nil, eris.Wrapf(err, "failed to read snapshot directory %s", f.dir)
+	}
+
+	infos := make([]Info, 0, len(entries))
+	for _, entry := range entries {
+		if info, ok := parseFileName(entry.Name()); ok && !entry.IsDir() {
+			infos = append(infos, info)
+		}
+	}
+	return infos, nil
+}
+
+func (f *FileStorage) get(_ context.Context, name string) ([]byte, error) {
+	data, err := os.ReadFile(filepath.Join(f.dir, fileName(name)))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, eris.Wrapf(ErrSnapshotNotFound, "snapshot %s doesn't exist", name)
+		}
+		return nil, eris.Wrap(err, "failed to read snapshot file")
+	}
+	return decodeSnapshotFile(data)
+}
+
+func (f *FileStorage) put(_ context.Context, name string, data []byte) error {
+	return f.writeFile(fileName(name), encodeSnapshotFile(data))
+}
+
+func (f *FileStorage) remove(_ context.Context, name string) error {
+	err := os.Remove(filepath.Join(f.dir, fileName(name)))
+	if err != nil && !os.IsNotExist(err) {
+		return eris.Wrapf(err, "failed to remove snapshot file %s", fileName(name))
+	}
+	return nil
+}
+
+func (f *FileStorage) codec() *codec {
+	return f.enc
+}
+
+// writeFile atomically writes data to the named file in the snapshot directory.
+func (f *FileStorage) writeFile(name string, data []byte) error {
+	tmp, err := os.CreateTemp(f.dir, "."+name+".tmp-*")
+	if err != nil {
+		return eris.Wrap(err, "failed to create temporary snapshot file")
+	}
+	// Remove the temporary file if anything below fails. After the rename, this is a no-op.
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return eris.Wrap(err, "failed to write snapshot file")
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return eris.Wrap(err, "failed to sync snapshot file")
+	}
+	if err := tmp.Close(); err != nil {
+		return eris.Wrap(err, "failed to close snapshot file")
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(f.dir, name)); err != nil {
+		return eris.Wrap(err, "failed to rename snapshot file")
+	}
+
+	// Sync the directory so the rename survives a crash.
+	dir, err := os.Open(f.dir)
+	if err != nil {
+		return eris.Wrap(err, "failed to open snapshot directory")
+	}
+	defer func() {
+		_ = dir.Close()
+	}()
+	if err := dir.Sync(); err != nil {
+		return eris.Wrap(err, "failed to sync snapshot directory")
+	}
+	return nil
+}
+
+// fileName returns the name of the file a snapshot object is stored in.
+func fileName(name string) string {
+	return name + fileSnapshotSuffix
+}
+
+// parseFileName returns the snapshot a snapshot file name describes, or false if it isn't a snapshot
+// file name.
+func parseFileName(name string) (Info, bool) {
+	name, ok := strings.CutSuffix(name, fileSnapshotSuffix)
+	if !ok {
+		return Info{}, false
+	}
+	return parseObjectName(name)
+}
+
+// encodeSnapshotFile prefixes the snapshot with the file header: the magic bytes and the snapshot's
+// checksum.
+func encodeSnapshotFile(data []byte) []byte {
+	file := make([]byte, 0, len(fileSnapshotMagic)+4+len(data))
+	file = append(file, fileSnapshotMagic...)
+	file = binary.BigEndian.AppendUint32(file, crc32.Checksum(data, crc32c))
+	return append(file, data...)
+}
+
+// decodeSnapshotFile checks the file header and returns the snapshot.
+func decodeSnapshotFile(file []byte) ([]byte, error) {
+	headerLen := len(fileSnapshotMagic) + 4
+	if len(file) < headerLen || !bytes.Equal(file[:len(fileSnapshotMagic)], fileSnapshotMagic) {
+		return nil, eris.New("snapshot file has an invalid header")
+	}
+	data := file[headerLen:]
+	if binary.BigEndian.Uint32(file[len(fileSnapshotMagic):headerLen]) != crc32.Checksum(data, crc32c) {
+		return nil, eris.New("snapshot file checksum mismatch")
+	}
+	return data, nil
+}
+
+// -------------------------------------------------------------------------------------------------
+// Options
+// -------------------------------------------------------------------------------------------------
+
+// FileStorageOptions configures the filesystem snapshot storage. Dir and Retention are loaded from
+// environment variables via env tags. Dir defaults to ./snapshots if it isn't set.
+type FileStorageOptions struct {
+	Logger zerolog.Logger
+
+	// Directory of the snapshot files. Created if it doesn't exist.
+	Dir string `env:"CARDINAL_SNAPSHOT_DIR"`
+
+	// Which snapshots are kept after a new one is stored.
+	Retention RetentionPolicy
+
+	// How snapshots are compressed and encrypted.
+	Encoding EncodingOptions
+}
+
+func (opt *FileStorageOptions) Validate() error {
+	if opt.Dir == "" {
+		return eris.New("snapshot directory cannot be empty")
+	}
+	if err := opt.Retention.Validate(); err != nil {
+		return err
+	}
+	return opt.Encoding.Validate()
+}
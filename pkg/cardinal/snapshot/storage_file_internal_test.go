@@ -0,0 +1,352 @@
+package snapshot
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/argus-labs/world-engine/pkg/testutils"
+	cardinalv1 "github.com/argus-labs/world-engine/proto/gen/go/worldengine/cardinal/v1"
+	"github.com/rotisserie/eris"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+// -------------------------------------------------------------------------------------------------
+// File storage smoke tests
+// -------------------------------------------------------------------------------------------------
+// Verifies that the file storage round-trips snapshots, keeps only the configured number of
+// generations, falls back to the newest valid generation when newer files are corrupt, keeps
+// quarantined snapshots out of loading and retention, applies delta chains when loading, and compacts
+// kept deltas whose chain expires.
+// -------------------------------------------------------------------------------------------------
+
+func TestFileStorage(t *testing.T) {
+	t.Parallel()
+
+	t.Run("round trip and generations", func(t *testing.T) {
+		t.Parallel()
+		prng := testutils.NewRand(t)
+		storage := newTestFileStorage(t, 3)
+
+		_, err := storage.Load(context.Background())
+		require.ErrorIs(t, err, ErrSnapshotNotFound)
+
+		count := 3 + prng.IntN(10)
+		var last *Snapshot
+		for tick := range uint64(count) {
+			last = newTestSnapshot(t, tick*10, uint32(prng.Uint32()))
+			require.NoError(t, storage.Store(context.Background(), last))
+		}
+
+		loaded, err := storage.Load(context.Background())
+		require.NoError(t, err)
+		assertSnapshotEqual(t, last, loaded)
+
+		infos, err := storage.List(context.Background())
+		require.NoError(t, err)
+		require.Len(t, infos, 3)
+		for i, info := range infos {
+			assert.Equal(t, uint64(count-3+i)*10, info.TickHeight)
+		}
+
+		// Older generations can still be loaded by their tick.
+		older, err := storage.LoadAt(context.Background(), infos[0].TickHeight)
+		require.NoError(t, err)
+		assert.Equal(t, infos[0].TickHeight, older.TickHeight)
+		_, err = storage.LoadAt(context.Background(), 1)
+		require.ErrorIs(t, err, ErrSnapshotNotFound)
+
+		entries, err := os.ReadDir(storage.dir)
+		require.NoError(t, err)
+		assert.Len(t, entries, 3, "temporary files should be removed")
+	})
+
+	t.Run("same tick replaces", func(t *testing.T) {
+		t.Parallel()
+		storage := newTestFileStorage(t, 3)
+
+		// A world that went back to an earlier tick stores a newer snapshot of a stored tick.
+		require.NoError(t, storage.Store(context.Background(), newTestSnapshot(t, 1, 42)))
+		newer := newTestSnapshot(t, 1, 43)
+		newer.Timestamp = newer.Timestamp.Add(time.Minute)
+		require.NoError(t, storage.Store(context.Background(), newer))
+
+		loaded, err := storage.Load(context.Background())
+		require.NoError(t, err)
+		assertSnapshotEqual(t, newer, loaded)
+
+		entries, err := os.ReadDir(storage.dir)
+		require.NoError(t, err)
+		assert.Len(t, entries, 1, "the replaced snapshot should be removed")
+	})
+
+	t.Run("falls back to valid generation", func(t *testing.T) {
+		t.Parallel()
+		storage := newTestFileStorage(t, 3)
+
+		valid := newTestSnapshot(t, 1, 42)
+		require.NoError(t, storage.Store(context.Background(), valid))
+		require.NoError(t, storage.Store(context.Background(), newTestSnapshot(t, 2, 43)))
+		require.NoError(t, storage.Store(context.Background(), newTestSnapshot(t, 3, 44)))
+
+		// Truncate the newest file and flip a byte in the one before it.
+		newest := snapshotFilePath(t, storage, 3)
+		data, err := os.ReadFile(newest)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(newest, data[:len(data)/2], 0o600))
+
+		previous := snapshotFilePath(t, storage, 2)
+		data, err = os.ReadFile(previous)
+		require.NoError(t, err)
+		data[len(data)-1] ^= 0xff
+		require.NoError(t, os.WriteFile(previous, data, 0o600))
+
+		loaded, err := storage.Load(context.Background())
+		require.NoError(t, err)
+		assertSnapshotEqual(t, valid, loaded)
+
+		// If no file is valid, loading fails instead of starting from an empty state.
+		require.NoError(t, os.WriteFile(snapshotFilePath(t, storage, 1), []byte("garbage"), 0o600))
+		_, err = storage.Load(context.Background())
+		require.Error(t, err)
+		assert.False(t, eris.Is(err, ErrSnapshotNotFound))
+	})
+
+	t.Run("quarantine", func(t *testing.T) {
+		t.Parallel()
+		storage := newTestFileStorage(t, 2)
+
+		kept := newTestSnapshot(t, 1, 42)
+		require.NoError(t, storage.Store(context.Background(), kept))
+		quarantined := newTestSnapshot(t, 2, 43)
+		require.NoError(t, storage.Store(context.Background(), quarantined))
+		path := snapshotFilePath(t, storage, 2)
+		require.NoError(t, storage.Quarantine(context.Background(), 2))
+		require.NoError(t, storage.Quarantine(context.Background(), 100), "a missing snapshot isn't an error")
+
+		loaded, err := storage.Load(context.Background())
+		require.NoError(t, err)
+		assertSnapshotEqual(t, kept, loaded)
+
+		// The retention policy leaves the quarantined snapshot alone, and it's intact.
+		for tick := range uint64(3) {
+			require.NoError(t, storage.Store(context.Background(), newTestSnapshot(t, 10+tick, 44)))
+		}
+		quarantinedPath := filepath.Join(filepath.Dir(path), quarantineObjectNamePrefix+filepath.Base(path))
+		file, err := os.ReadFile(quarantinedPath)
+		require.NoError(t, err)
+		data, err := decodeSnapshotFile(file)
+		require.NoError(t, err)
+		decoded, err := storage.enc.decode(data)
+		require.NoError(t, err)
+		assertSnapshotEqual(t, quarantined, decoded)
+	})
+}
+
+func TestFileStorage_Deltas(t *testing.T) {
+	t.Parallel()
+
+	bitmap := []byte{1, 0, 0, 0, 0, 0, 0, 0}
+	column := func(name string, values ...string) *cardinalv1.Column {
+		components := make([][]byte, len(values))
+		for i, value := range values {
+			components[i] = []byte(value)
+		}
+		return &cardinalv1.Column{ComponentName: name, Components: components}
+	}
+
+	// A full snapshot at tick 1, and two deltas: one changing a column and creating an archetype, and
+	// one creating an entity.
+	base := &cardinalv1.WorldState{
+		NextId:     1,
+		EntityArch: []int64{1},
+		Archetypes: []*cardinalv1.Archetype{
+			{Id: 0},
+			{Id: 1, ComponentsBitmap: bitmap, Rows: []int64{0}, Entities: []uint32{0},
+				Columns: []*cardinalv1.Column{column("a", "a0"), column("b", "b0")}},
+		},
+	}
+	delta2 := &cardinalv1.WorldStateDelta{
+		ParentTickHeight: 1,
+		NextId:           1,
+		EntityArch:       []int64{1},
+		Archetypes: []*cardinalv1.Archetype{
+			{Id: 1, ComponentsBitmap: bitmap, Rows: []int64{0}, Entities: []uint32{0},
+				Columns: []*cardinalv1.Column{column("b", "b1")}},
+			{Id: 2, ComponentsBitmap: bitmap},
+		},
+	}
+	delta3 := &cardinalv1.WorldStateDelta{
+		ParentTickHeight: 2,
+		NextId:           2,
+		EntityArch:       []int64{1, 0},
+		Archetypes:       []*cardinalv1.Archetype{{Id: 0, Rows: []int64{-1, 0}, Entities: []uint32{1}}},
+	}
+	state2 := &cardinalv1.WorldState{
+		NextId:     1,
+		EntityArch: []int64{1},
+		Archetypes: []*cardinalv1.Archetype{
+			{Id: 0},
+			{Id: 1, ComponentsBitmap: bitmap, Rows: []int64{0}, Entities: []uint32{0},
+				Columns: []*cardinalv1.Column{column("a", "a0"), column("b", "b1")}},
+			{Id: 2, ComponentsBitmap: bitmap},
+		},
+	}
+	state3 := proto.CloneOf(state2)
+	state3.NextId = 2
+	state3.EntityArch = []int64{1, 0}
+	state3.Archetypes[0] = &cardinalv1.Archetype{Id: 0, Rows: []int64{-1, 0}, Entities: []uint32{1}}
+
+	storeChain := func(t *testing.T, storage *FileStorage) {
+		t.Helper()
+		require.NoError(t, storage.Store(context.Background(), newTestStateSnapshot(t, 1, base)))
+		require.NoError(t, storage.Store(context.Background(), newTestStateSnapshot(t, 2, delta2)))
+		require.NoError(t, storage.Store(context.Background(), newTestStateSnapshot(t, 3, delta3)))
+	}
+
+	t.Run("load applies deltas", func(t *testing.T) {
+		t.Parallel()
+		storage := newTestFileStorage(t, 3)
+		storeChain(t, storage)
+
+		infos, err := storage.List(context.Background())
+		require.NoError(t, err)
+		require.Len(t, infos, 3)
+		assert.Equal(t, []bool{false, true, true}, []bool{infos[0].Delta, infos[1].Delta, infos[2].Delta})
+
+		loaded, err := storage.Load(context.Background())
+		require.NoError(t, err)
+		assert.False(t, loaded.Delta)
+		assertWorldState(t, 3, state3, loaded)
+
+		loaded, err = storage.LoadAt(context.Background(), 2)
+		require.NoError(t, err)
+		assertWorldState(t, 2, state2, loaded)
+
+		// A corrupt delta breaks the rest of its chain, so loading falls back to the delta before it.
+		require.NoError(t, os.WriteFile(snapshotFilePath(t, storage, 3), []byte("garbage"), 0o600))
+		loaded, err = storage.Load(context.Background())
+		require.NoError(t, err)
+		assertWorldState(t, 2, state2, loaded)
+	})
+
+	t.Run("delta of another parent", func(t *testing.T) {
+		t.Parallel()
+		storage := newTestFileStorage(t, 3)
+		require.NoError(t, storage.Store(context.Background(), newTestStateSnapshot(t, 1, base)))
+		require.NoError(t, storage.Store(context.Background(), newTestStateSnapshot(t, 3, delta3)))
+
+		_, err := storage.LoadAt(context.Background(), 3)
+		require.Error(t, err)
+	})
+
+	t.Run("compacts kept delta", func(t *testing.T) {
+		t.Parallel()
+		storage, err := NewFileStorage(FileStorageOptions{
+			Logger:    zerolog.Nop(),
+			Dir:       t.TempDir(),
+			Retention: RetentionPolicy{Generations: 1, Hourly: 2},
+		})
+		require.NoError(t, err)
+		storeChain(t, storage)
+
+		// A full snapshot two hours after the chain, so tick 3 is the newest snapshot of its hour.
+		next := newTestSnapshot(t, 4, 7)
+		next.Timestamp = next.Timestamp.Add(2 * time.Hour)
+		require.NoError(t, storage.Store(context.Background(), next))
+
+		infos, err := storage.List(context.Background())
+		require.NoError(t, err)
+		require.Len(t, infos, 2)
+		assert.Equal(t, uint64(3), infos[0].TickHeight)
+		assert.False(t, infos[0].Delta, "tick 3 should be compacted")
+		assert.Equal(t, uint64(4), infos[1].TickHeight)
+
+		entries, err := os.ReadDir(storage.dir)
+		require.NoError(t, err)
+		assert.Len(t, entries, 2, "the compacted chain should be removed")
+
+		loaded, err := storage.LoadAt(context.Background(), 3)
+		require.NoError(t, err)
+		assertWorldState(t, 3, state3, loaded)
+	})
+}
+
+func newTestFileStorage(t *testing.T, generations int) *FileStorage {
+	t.Helper()
+	storage, err := NewFileStorage(FileStorageOptions{
+		Logger:    zerolog.Nop(),
+		Dir:       t.TempDir(),
+		Retention: RetentionPolicy{Generations: generations},
+	})
+	require.NoError(t, err)
+	return storage
+}
+
+func newTestSnapshot(t *testing.T, tick uint64, nextID uint32) *Snapshot {
+	t.Helper()
+	data, err := proto.Marshal(&cardinalv1.WorldState{NextId: nextID})
+	require.NoError(t, err)
+	return &Snapshot{
+		TickHeight: tick,
+		Timestamp:  time.Unix(int64(tick), 0).UTC(),
+		Data:       data,
+		Version:    CurrentVersion,
+	}
+}
+
+// snapshotFilePath returns the path of the file the snapshot of a tick is stored in.
+func snapshotFilePath(t *testing.T, storage *FileStorage, tick uint64) string {
+	t.Helper()
+	infos, err := storage.List(context.Background())
+	require.NoError(t, err)
+	for _, info := range infos {
+		if info.TickHeight == tick {
+			return filepath.Join(storage.dir, fileName(info.objectName()))
+		}
+	}
+	require.FailNow(t, "no snapshot file", "tick %d", tick)
+	return ""
+}
+
+// newTestStateSnapshot returns a full snapshot of a world state, or a delta snapshot of a world state
+// delta.
+func newTestStateSnapshot(t *testing.T, tick uint64, state proto.Message) *Snapshot {
+	t.Helper()
+	data, err := proto.Marshal(state)
+	require.NoError(t, err)
+	_, delta := state.(*cardinalv1.WorldStateDelta)
+	return &Snapshot{
+		TickHeight: tick,
+		Timestamp:  time.Unix(int64(tick), 0).UTC(),
+		Data:       data,
+		Version:    CurrentVersion,
+		Delta:      delta,
+	}
+}
+
+// assertWorldState checks that a snapshot is the full snapshot of a world state at a tick.
+func assertWorldState(t *testing.T, tick uint64, want *cardinalv1.WorldState, got *Snapshot) {
+	t.Helper()
+	assert.Equal(t, tick, got.TickHeight)
+	var state cardinalv1.WorldState
+	require.NoError(t, proto.Unmarshal(got.Data, &state))
+	assert.True(t, proto.Equal(want, &state), "want %v, got %v", want, &state)
+}
+
+func assertSnapshotEqual(t *testing.T, want, got *Snapshot) {
+	t.Helper()
+	assert.Equal(t, want.TickHeight, got.TickHeight)
+	assert.True(t, want.Timestamp.Equal(got.Timestamp))
+	assert.Equal(t, want.Version, got.Version)
+
+	var wantState, gotState cardinalv1.WorldState
+	require.NoError(t, proto.Unmarshal(want.Data, &wantState))
+	require.NoError(t, proto.Unmarshal(got.Data, &gotState))
+	assert.True(t, proto.Equal(&wantState, &gotState))
+}
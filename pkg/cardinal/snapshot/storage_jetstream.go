@@ -6,25 +6,30 @@ import (
 	"io"
 	"math"
 
-	"buf.build/go/protovalidate"
 	"github.com/argus-labs/world-engine/pkg/micro"
-	cardinalv1 "github.com/argus-labs/world-engine/proto/gen/go/worldengine/cardinal/v1"
 	"github.com/caarlos0/env/v11"
 	"github.com/nats-io/nats.go/jetstream"
 	"github.com/rotisserie/eris"
 	"github.com/rs/zerolog"
-	"google.golang.org/protobuf/proto"
-	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
-const defaultObjectName = "snapshot"
+// legacyObjectName is the object the snapshot was stored in before snapshots were kept as generations.
+const legacyObjectName = "snapshot"
 
 // JetStreamStorage implements SnapshotStorage using NATS JetStream ObjectStore.
+// Every snapshot is stored as its own object, snapshot-<tick height>-<timestamp>, with a -delta suffix for
+// delta snapshots, in the shard's bucket.
 type JetStreamStorage struct {
-	os jetstream.ObjectStore
+	os        jetstream.ObjectStore
+	retention RetentionPolicy
+	enc       *codec
+	logger    zerolog.Logger
 }
 
-var _ Storage = (*JetStreamStorage)(nil)
+var (
+	_ Storage = (*JetStreamStorage)(nil)
+	_ backend = (*JetStreamStorage)(nil)
+)
 
 // NewJetStreamStorage creates a new JetStream ObjectStore-based snapshot storage.
 // It creates its own NATS client using the default configuration from environment variables.
@@ -38,6 +43,14 @@ func NewJetStreamStorage(opts JetStreamStorageOptions) (*JetStreamStorage, error
 	if err := env.Parse(&opts); err != nil {
 		return nil, eris.Wrap(err, "failed to parse env")
 	}
+	opts.Retention.setDefaults()
+	if err := opts.Retention.Validate(); err != nil {
+		return nil, eris.Wrap(err, "invalid retention policy")
+	}
+	enc, err := newCodec(opts.Encoding)
+	if err != nil {
+		return nil, eris.Wrap(err, "invalid snapshot encoding")
+	}
 
 	clientOpts := []micro.ClientOption{micro.WithLogger(opts.Logger)}
 	if opts.NATSConfig != nil {
@@ -81,38 +94,65 @@ func NewJetStreamStorage(opts JetStreamStorageOptions) (*JetStreamStorage, error
 		}
 	}
 
-	return &JetStreamStorage{os: os}, nil
+	return &JetStreamStorage{os: os, retention: opts.Retention, enc: enc, logger: opts.Logger}, nil
 }
 
 func (j *JetStreamStorage) Store(ctx context.Context, snapshot *Snapshot) error {
-	var worldState cardinalv1.WorldState
-	if err := proto.Unmarshal(snapshot.Data, &worldState); err != nil {
-		return eris.Wrap(err, "failed to unmarshal world state")
-	}
-	snapshotPb := &cardinalv1.Snapshot{
-		TickHeight: snapshot.TickHeight,
-		Timestamp:  timestamppb.New(snapshot.Timestamp),
-		WorldState: &worldState,
-		Version:    snapshot.Version,
+	return store(ctx, j, snapshot, j.retention, j.logger)
+}
+
+func (j *JetStreamStorage) Load(ctx context.Context) (*Snapshot, error) {
+	snap, err := loadLatest(ctx, j, j.logger)
+	if eris.Is(err, ErrSnapshotNotFound) {
+		// Fall back to the snapshot stored before generations, if any.
+		data, err := j.get(ctx, legacyObjectName)
+		if err != nil {
+			return nil, err
+		}
+		return j.enc.decode(data)
 	}
-	data, err := proto.Marshal(snapshotPb)
+	return snap, err
+}
+
+func (j *JetStreamStorage) LoadAt(ctx context.Context, tickHeight uint64) (*Snapshot, error) {
+	return loadAt(ctx, j, tickHeight)
+}
+
+func (j *JetStreamStorage) List(ctx context.Context) ([]Info, error) {
+	return list(ctx, j)
+}
+
+func (j *JetStreamStorage) Delete(ctx context.Context, tickHeight uint64) error {
+	return deleteAt(ctx, j, tickHeight)
+}
+
+func (j *JetStreamStorage) Quarantine(ctx context.Context, tickHeight uint64) error {
+	return quarantineAt(ctx, j, tickHeight)
+}
+
+func (j *JetStreamStorage) objects(ctx context.Context) ([]Info, error) {
+	objects, err := j.os.List(ctx)
 	if err != nil {
-		return eris.Wrap(err, "failed to marshal snapshot")
+		if eris.Is(err, jetstream.ErrNoObjectsFound) {
+			return nil, nil
+		}
+		return nil, eris.Wrap(err, "failed to list objects in ObjectStore")
 	}
 
-	// Overwrite the existing snapshot if any.
-	if _, err = j.os.PutBytes(ctx, defaultObjectName, data); err != nil {
-		return eris.Wrap(err, "failed to store snapshot in ObjectStore")
+	infos := make([]Info, 0, len(objects))
+	for _, object := range objects {
+		if info, ok := parseObjectName(object.Name); ok {
+			infos = append(infos, info)
+		}
 	}
-
-	return nil
+	return infos, nil
 }
 
-func (j *JetStreamStorage) Load(ctx context.Context) (*Snapshot, error) {
-	object, err := j.os.Get(ctx, defaultObjectName)
+func (j *JetStreamStorage) get(ctx context.Context, name string) ([]byte, error) {
+	object, err := j.os.Get(ctx, name)
 	if err != nil {
 		if eris.Is(err, jetstream.ErrObjectNotFound) {
-			return nil, eris.Wrap(ErrSnapshotNotFound, "no snapshot exists")
+			return nil, eris.Wrapf(ErrSnapshotNotFound, "snapshot %s doesn't exist", name)
 		}
 		return nil, eris.Wrap(err, "failed to get snapshot from ObjectStore")
 	}
@@ -124,26 +164,26 @@ func (j *JetStreamStorage) Load(ctx context.Context) (*Snapshot, error) {
 	if err != nil {
 		return nil, eris.Wrap(err, "failed to read from object")
 	}
+	return data, nil
+}
 
-	snapshotPb := cardinalv1.Snapshot{}
-	if err = proto.Unmarshal(data, &snapshotPb); err != nil {
-		return nil, eris.Wrap(err, "failed to unmarshal snapshot")
-	}
-	if err = protovalidate.Validate(&snapshotPb); err != nil {
-		return nil, eris.Wrap(err, "failed to validate snapshot")
+func (j *JetStreamStorage) put(ctx context.Context, name string, data []byte) error {
+	if _, err := j.os.PutBytes(ctx, name, data); err != nil {
+		return eris.Wrap(err, "failed to store snapshot in ObjectStore")
 	}
+	return nil
+}
 
-	worldStateBytes, err := proto.Marshal(snapshotPb.GetWorldState())
-	if err != nil {
-		return nil, eris.Wrap(err, "failed to marshal world state")
+func (j *JetStreamStorage) remove(ctx context.Context, name string) error {
+	err := j.os.Delete(ctx, name)
+	if err != nil && !eris.Is(err, jetstream.ErrObjectNotFound) {
+		return eris.Wrapf(err, "failed to delete snapshot %s from ObjectStore", name)
 	}
+	return nil
+}
 
-	return &Snapshot{
-		TickHeight: snapshotPb.GetTickHeight(),
-		Timestamp:  snapshotPb.GetTimestamp().AsTime(),
-		Data:       worldStateBytes,
-		Version:    snapshotPb.GetVersion(),
-	}, nil
+func (j *JetStreamStorage) codec() *codec {
+	return j.enc
 }
 
 // -------------------------------------------------------------------------------------------------
@@ -157,6 +197,12 @@ type JetStreamStorageOptions struct {
 
 	// Maximum bytes for snapshot storage (ObjectStore). Required by some NATS providers like Synadia Cloud.
 	SnapshotStorageMaxBytes uint64 `env:"CARDINAL_SNAPSHOT_STORAGE_MAX_BYTES" envDefault:"0"`
+
+	// Which snapshots are kept after a new one is stored.
+	Retention RetentionPolicy
+
+	// How snapshots are compressed and encrypted.
+	Encoding EncodingOptions
 }
 
 func (opt *JetStreamStorageOptions) Validate() error {
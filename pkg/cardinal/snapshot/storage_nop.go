@@ -24,3 +24,19 @@ func (n *NopStorage) Store(_ context.Context, _ *Snapshot) error {
 func (n *NopStorage) Load(_ context.Context) (*Snapshot, error) {
 	return nil, eris.Wrap(ErrSnapshotNotFound, "no snapshots available (using no-op storage)")
 }
+
+func (n *NopStorage) LoadAt(_ context.Context, _ uint64) (*Snapshot, error) {
+	return nil, eris.Wrap(ErrSnapshotNotFound, "no snapshots available (using no-op storage)")
+}
+
+func (n *NopStorage) List(_ context.Context) ([]Info, error) {
+	return nil, nil
+}
+
+func (n *NopStorage) Delete(_ context.Context, _ uint64) error {
+	return nil
+}
+
+func (n *NopStorage) Quarantine(_ context.Context, _ uint64) error {
+	return nil
+}
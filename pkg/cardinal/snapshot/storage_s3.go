@@ -5,10 +5,9 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"strings"
 
-	"buf.build/go/protovalidate"
 	"github.com/argus-labs/world-engine/pkg/micro"
-	cardinalv1 "github.com/argus-labs/world-engine/proto/gen/go/worldengine/cardinal/v1"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	awsconfig "github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
@@ -17,12 +16,8 @@ import (
 	"github.com/caarlos0/env/v11"
 	"github.com/rotisserie/eris"
 	"github.com/rs/zerolog"
-	"google.golang.org/protobuf/proto"
-	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
-const defaultS3ObjectKey = "snapshot"
-
 // S3Storage implements Storage using AWS S3 (or S3-compatible services like MinIO, R2).
 //
 // Required environment variables:
@@ -38,19 +33,27 @@ const defaultS3ObjectKey = "snapshot"
 //	CARDINAL_S3_ENDPOINT=<url>         # Custom endpoint for S3-compatible services
 //	AWS_SESSION_TOKEN=<token>          # Session token for temporary credentials (STS/IRSA)
 //
-// Snapshots are stored at the key: {org}/{project}/{serviceId}/snapshot
+// See EncodingOptions for the environment variables that configure compression and encryption.
+//
+// Snapshots are stored at the key: {org}/{project}/{serviceId}/snapshot-{tick height}-{timestamp}, with
+// a -delta suffix for delta snapshots.
 // A single shared bucket can serve all orgs/projects; key prefixes prevent collisions.
 //
-// The bucket must already exist. The IAM principal needs s3:PutObject and s3:GetObject permissions.
-// Enable S3 versioning on the bucket for automatic backup retention of previous snapshots.
+// The bucket must already exist. The IAM principal needs s3:PutObject, s3:GetObject, s3:ListBucket,
+// and s3:DeleteObject permissions.
 type S3Storage struct {
-	client *s3.Client
-	bucket string
-	key    string
-	logger zerolog.Logger
+	client    *s3.Client
+	bucket    string
+	prefix    string // Key prefix of the shard's snapshots, ending with a slash
+	retention RetentionPolicy
+	enc       *codec
+	logger    zerolog.Logger
 }
 
-var _ Storage = (*S3Storage)(nil)
+var (
+	_ Storage = (*S3Storage)(nil)
+	_ backend = (*S3Storage)(nil)
+)
 
 // NewS3Storage creates a new S3-based snapshot storage.
 // It loads AWS credentials from the default credential chain (env vars, IRSA, instance roles).
@@ -59,17 +62,22 @@ func NewS3Storage(opts S3StorageOptions) (*S3Storage, error) {
 		return nil, eris.Wrap(err, "failed to parse env")
 	}
 
+	opts.Retention.setDefaults()
 	if err := opts.Validate(); err != nil {
 		return nil, eris.Wrap(err, "invalid options passed")
 	}
 
-	// Build the S3 key. Region scoping is handled at the bucket level (one bucket per region),
+	enc, err := newCodec(opts.Encoding)
+	if err != nil {
+		return nil, eris.Wrap(err, "invalid snapshot encoding")
+	}
+
+	// Build the S3 key prefix. Region scoping is handled at the bucket level (one bucket per region),
 	// so the key only needs org/project/serviceId to be unique within a region.
-	objectKey := fmt.Sprintf("%s/%s/%s/%s",
+	prefix := fmt.Sprintf("%s/%s/%s/",
 		opts.Address.GetOrganization(),
 		opts.Address.GetProject(),
 		opts.Address.GetServiceId(),
-		defaultS3ObjectKey,
 	)
 
 	// Load AWS config from environment (reads AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, AWS_REGION).
@@ -93,57 +101,84 @@ func NewS3Storage(opts S3StorageOptions) (*S3Storage, error) {
 	client := s3.NewFromConfig(cfg, s3Opts...)
 
 	return &S3Storage{
-		client: client,
-		bucket: opts.Bucket,
-		key:    objectKey,
-		logger: opts.Logger,
+		client:    client,
+		bucket:    opts.Bucket,
+		prefix:    prefix,
+		retention: opts.Retention,
+		enc:       enc,
+		logger:    opts.Logger,
 	}, nil
 }
 
 func (s *S3Storage) Store(ctx context.Context, snapshot *Snapshot) error {
-	var worldState cardinalv1.WorldState
-	if err := proto.Unmarshal(snapshot.Data, &worldState); err != nil {
-		return eris.Wrap(err, "failed to unmarshal world state")
-	}
-	snapshotPb := &cardinalv1.Snapshot{
-		TickHeight: snapshot.TickHeight,
-		Timestamp:  timestamppb.New(snapshot.Timestamp),
-		WorldState: &worldState,
-		Version:    snapshot.Version,
-	}
-	data, err := proto.Marshal(snapshotPb)
-	if err != nil {
-		return eris.Wrap(err, "failed to marshal snapshot")
-	}
+	return store(ctx, s, snapshot, s.retention, s.logger)
+}
 
-	// Overwrite the existing snapshot if any.
-	if _, err = s.client.PutObject(ctx, &s3.PutObjectInput{
-		Bucket:      aws.String(s.bucket),
-		Key:         aws.String(s.key),
-		Body:        bytes.NewReader(data),
-		ContentType: aws.String("application/x-protobuf"),
-	}); err != nil {
-		return eris.Wrap(err, "failed to store snapshot in S3")
+func (s *S3Storage) Load(ctx context.Context) (*Snapshot, error) {
+	snap, err := loadLatest(ctx, s, s.logger)
+	if eris.Is(err, ErrSnapshotNotFound) {
+		// Fall back to the snapshot stored before generations, if any.
+		data, err := s.get(ctx, legacyObjectName)
+		if err != nil {
+			return nil, err
+		}
+		return s.enc.decode(data)
 	}
+	return snap, err
+}
 
-	return nil
+func (s *S3Storage) LoadAt(ctx context.Context, tickHeight uint64) (*Snapshot, error) {
+	return loadAt(ctx, s, tickHeight)
 }
 
-func (s *S3Storage) Load(ctx context.Context) (*Snapshot, error) {
+func (s *S3Storage) List(ctx context.Context) ([]Info, error) {
+	return list(ctx, s)
+}
+
+func (s *S3Storage) Delete(ctx context.Context, tickHeight uint64) error {
+	return deleteAt(ctx, s, tickHeight)
+}
+
+func (s *S3Storage) Quarantine(ctx context.Context, tickHeight uint64) error {
+	return quarantineAt(ctx, s, tickHeight)
+}
+
+func (s *S3Storage) objects(ctx context.Context) ([]Info, error) {
+	var infos []Info
+	paginator := s3.NewListObjectsV2Paginator(s.client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.bucket),
+		Prefix: aws.String(s.prefix + objectNamePrefix),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, eris.Wrap(err, "failed to list snapshots in S3")
+		}
+		for _, object := range page.Contents {
+			if info, ok := parseObjectName(strings.TrimPrefix(aws.ToString(object.Key), s.prefix)); ok {
+				infos = append(infos, info)
+			}
+		}
+	}
+	return infos, nil
+}
+
+func (s *S3Storage) get(ctx context.Context, name string) ([]byte, error) {
+	key := s.prefix + name
 	result, err := s.client.GetObject(ctx, &s3.GetObjectInput{
 		Bucket: aws.String(s.bucket),
-		Key:    aws.String(s.key),
+		Key:    aws.String(key),
 	})
 	if err != nil {
 		// Check for "not found" errors (NoSuchKey).
 		var noSuchKey *types.NoSuchKey
 		if eris.As(err, &noSuchKey) {
-			return nil, eris.Wrap(ErrSnapshotNotFound, "no snapshot exists")
+			return nil, eris.Wrapf(ErrSnapshotNotFound, "snapshot %s doesn't exist", key)
 		}
 		// Fallback for S3-compatible services that may return a generic smithy error.
 		var apiErr smithy.APIError
 		if eris.As(err, &apiErr) && apiErr.ErrorCode() == "NoSuchKey" {
-			return nil, eris.Wrap(ErrSnapshotNotFound, "no snapshot exists")
+			return nil, eris.Wrapf(ErrSnapshotNotFound, "snapshot %s doesn't exist", key)
 		}
 		return nil, eris.Wrap(err, "failed to get snapshot from S3")
 	}
@@ -155,26 +190,34 @@ func (s *S3Storage) Load(ctx context.Context) (*Snapshot, error) {
 	if err != nil {
 		return nil, eris.Wrap(err, "failed to read from S3 object")
 	}
+	return data, nil
+}
 
-	snapshotPb := cardinalv1.Snapshot{}
-	if err = proto.Unmarshal(data, &snapshotPb); err != nil {
-		return nil, eris.Wrap(err, "failed to unmarshal snapshot")
-	}
-	if err = protovalidate.Validate(&snapshotPb); err != nil {
-		return nil, eris.Wrap(err, "failed to validate snapshot")
+func (s *S3Storage) put(ctx context.Context, name string, data []byte) error {
+	if _, err := s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(s.bucket),
+		Key:         aws.String(s.prefix + name),
+		Body:        bytes.NewReader(data),
+		ContentType: aws.String("application/x-protobuf"),
+	}); err != nil {
+		return eris.Wrap(err, "failed to store snapshot in S3")
 	}
+	return nil
+}
 
-	worldStateBytes, err := proto.Marshal(snapshotPb.GetWorldState())
-	if err != nil {
-		return nil, eris.Wrap(err, "failed to marshal world state")
+func (s *S3Storage) remove(ctx context.Context, name string) error {
+	// Deleting a key that doesn't exist succeeds.
+	if _, err := s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.prefix + name),
+	}); err != nil {
+		return eris.Wrapf(err, "failed to delete snapshot %s from S3", name)
 	}
+	return nil
+}
 
-	return &Snapshot{
-		TickHeight: snapshotPb.GetTickHeight(),
-		Timestamp:  snapshotPb.GetTimestamp().AsTime(),
-		Data:       worldStateBytes,
-		Version:    snapshotPb.GetVersion(),
-	}, nil
+func (s *S3Storage) codec() *codec {
+	return s.enc
 }
 
 // -------------------------------------------------------------------------------------------------
@@ -197,6 +240,12 @@ type S3StorageOptions struct {
 	// Set this to use MinIO (e.g. "http://localhost:9000"), Cloudflare R2, DigitalOcean Spaces, etc.
 	// When set, path-style addressing is enabled automatically.
 	Endpoint string `env:"CARDINAL_S3_ENDPOINT"`
+
+	// Which snapshots are kept after a new one is stored.
+	Retention RetentionPolicy
+
+	// How snapshots are compressed and encrypted.
+	Encoding EncodingOptions
 }
 
 func (opt *S3StorageOptions) Validate() error {
@@ -206,5 +255,8 @@ func (opt *S3StorageOptions) Validate() error {
 	if opt.Bucket == "" {
 		return eris.New("CARDINAL_S3_BUCKET environment variable is required")
 	}
-	return nil
+	if err := opt.Retention.Validate(); err != nil {
+		return err
+	}
+	return opt.Encoding.Validate()
 }
@@ -0,0 +1,453 @@
+package cardinal
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/argus-labs/world-engine/pkg/cardinal/internal/ecs"
+	"github.com/argus-labs/world-engine/pkg/cardinal/snapshot"
+	"github.com/argus-labs/world-engine/pkg/testutils"
+	cardinalv1 "github.com/argus-labs/world-engine/proto/gen/go/worldengine/cardinal/v1"
+	iscv1 "github.com/argus-labs/world-engine/proto/gen/go/worldengine/isc/v1"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+// -------------------------------------------------------------------------------------------------
+// Point-in-time restore smoke tests
+// -------------------------------------------------------------------------------------------------
+// Verifies that a world restores the snapshot of the requested tick instead of the latest one, with
+// the seed of the world it was taken of, quarantines the snapshots of later ticks instead of deleting
+// them, and refuses to start if the requested snapshot is missing.
+// -------------------------------------------------------------------------------------------------
+
+func TestWorld_RestoreTick(t *testing.T) {
+	t.Parallel()
+	prng := testutils.NewRand(t)
+
+	dir := t.TempDir()
+	storage, err := snapshot.NewFileStorage(snapshot.FileStorageOptions{
+		Logger:    zerolog.Nop(),
+		Dir:       dir,
+		Retention: snapshot.RetentionPolicy{Generations: 10},
+	})
+	require.NoError(t, err)
+
+	// Store snapshots of ticks 0 to 4, each with one more entity.
+	source := newSnapshotTestWorld(t, prng, storage)
+	source.seed = prng.Uint64() | 1
+	for tick := range uint64(5) {
+		eid := ecs.Create(source.world)
+		require.NoError(t, ecs.Set(source.world, eid, testutils.ComponentA{X: float64(tick)}))
+		source.currentTick.height = tick
+		state, err := source.stateToProto()
+		require.NoError(t, err)
+		source.snapshot(context.Background(), time.Now(), state)
+	}
+
+	restoreTick := uint64(2)
+	restored := newSnapshotTestWorld(t, prng, storage)
+	restored.options.RestoreTick = &restoreTick
+	require.NoError(t, restored.restore(context.Background()))
+
+	assert.Equal(t, restoreTick+1, restored.currentTick.height)
+	assert.Equal(t, source.seed, restored.seed)
+	assert.Equal(t, restoreTick, restored.state.Load().GetTickHeight())
+	entities := 0
+	for _, archetype := range restored.state.Load().GetWorldState().GetArchetypes() {
+		entities += len(archetype.GetEntities())
+	}
+	assert.Equal(t, int(restoreTick)+1, entities)
+
+	infos, err := storage.List(context.Background())
+	require.NoError(t, err)
+	require.NotEmpty(t, infos)
+	assert.Equal(t, restoreTick, infos[len(infos)-1].TickHeight, "later snapshots should be quarantined")
+	quarantined, err := filepath.Glob(filepath.Join(dir, "quarantine-*"))
+	require.NoError(t, err)
+	assert.Len(t, quarantined, 2, "later snapshots should be kept in quarantine")
+
+	missing := uint64(100)
+	refused := newSnapshotTestWorld(t, prng, storage)
+	refused.options.RestoreTick = &missing
+	assert.ErrorIs(t, refused.restore(context.Background()), snapshot.ErrSnapshotNotFound)
+}
+
+// -------------------------------------------------------------------------------------------------
+// Corrupted snapshot fallback
+// -------------------------------------------------------------------------------------------------
+// Verifies that a world whose latest snapshot has a state violating the ECS invariants restores the
+// latest earlier valid snapshot and quarantines the invalid ones, and refuses to start if restores are
+// strict or no valid snapshot is left. Any other restore error, such as a command schedule that doesn't
+// deserialize, refuses to start instead of falling back. Snapshots skipped by loading because they fail
+// their checksum are quarantined too, so they aren't counted as generations by the retention policy.
+// -------------------------------------------------------------------------------------------------
+
+func TestWorld_RestoreFallback(t *testing.T) {
+	t.Parallel()
+	prng := testutils.NewRand(t)
+
+	storage, err := snapshot.NewFileStorage(snapshot.FileStorageOptions{
+		Logger:    zerolog.Nop(),
+		Dir:       t.TempDir(),
+		Retention: snapshot.RetentionPolicy{Generations: 10},
+	})
+	require.NoError(t, err)
+
+	// Store valid snapshots of ticks 0 and 1, and snapshots of ticks 2 and 3 with an ID gap, which
+	// unmarshal and deserialize fine but violate the invariants.
+	source := newSnapshotTestWorld(t, prng, storage)
+	for tick := range uint64(4) {
+		eid := ecs.Create(source.world)
+		require.NoError(t, ecs.Set(source.world, eid, testutils.ComponentA{X: float64(tick)}))
+		source.currentTick.height = tick
+		state, err := source.stateToProto()
+		require.NoError(t, err)
+		if tick >= 2 {
+			state.NextId += 10
+		}
+		source.snapshot(context.Background(), time.Now(), state)
+	}
+
+	strict := true
+	refused := newSnapshotTestWorld(t, prng, storage)
+	refused.options.RestoreStrict = &strict
+	err = refused.restore(context.Background())
+	require.ErrorContains(t, err, "refusing to start")
+	require.ErrorContains(t, err, "snapshot of tick 3 is invalid")
+
+	restored := newSnapshotTestWorld(t, prng, storage)
+	require.NoError(t, restored.restore(context.Background()))
+	assert.Equal(t, uint64(2), restored.currentTick.height)
+	require.NoError(t, restored.world.CheckInvariants())
+
+	infos, err := storage.List(context.Background())
+	require.NoError(t, err)
+	require.NotEmpty(t, infos)
+	assert.Equal(t, uint64(1), infos[len(infos)-1].TickHeight, "invalid snapshots should be quarantined")
+
+	// With only invalid snapshots left, the world refuses to start.
+	require.NoError(t, storage.Delete(context.Background(), 0))
+	require.NoError(t, storage.Delete(context.Background(), 1))
+	state, err := source.stateToProto()
+	require.NoError(t, err)
+	state.NextId += 10
+	source.snapshot(context.Background(), time.Now(), state)
+	empty := newSnapshotTestWorld(t, prng, storage)
+	require.ErrorContains(t, empty.restore(context.Background()), "no valid snapshot")
+
+	// A snapshot whose command schedule doesn't deserialize, here a handle that was never assigned,
+	// doesn't fall back to the earlier one.
+	require.NoError(t, storage.Delete(context.Background(), source.currentTick.height))
+	state, err = source.stateToProto()
+	require.NoError(t, err)
+	source.snapshot(context.Background(), time.Now(), state)
+	state = proto.CloneOf(state)
+	state.CommandSchedule = &cardinalv1.CommandSchedule{
+		NextHandle: 2,
+		Commands: []*cardinalv1.ScheduledCommand{{
+			Handle:     5,
+			TickHeight: source.currentTick.height + 2,
+			Command: &iscv1.Command{
+				Name:    testutils.SimpleCommand{}.Name(),
+				Address: source.address,
+				Persona: &iscv1.Persona{Id: testutils.RandString(prng, 8)},
+			},
+		}},
+	}
+	source.currentTick.height++
+	source.snapshot(context.Background(), time.Now(), state)
+	broken := newSnapshotTestWorld(t, prng, storage)
+	err = broken.restore(context.Background())
+	require.ErrorContains(t, err, "refusing to start")
+	require.ErrorContains(t, err, "failed to restore command schedule")
+	infos, err = storage.List(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, infos, 2, "no snapshot should be quarantined")
+}
+
+func TestWorld_RestoreSkipsCorrupted(t *testing.T) {
+	t.Parallel()
+	prng := testutils.NewRand(t)
+
+	const generations = 2
+	dir := t.TempDir()
+	newStorage := func(generations int) *snapshot.FileStorage {
+		storage, err := snapshot.NewFileStorage(snapshot.FileStorageOptions{
+			Logger:    zerolog.Nop(),
+			Dir:       dir,
+			Retention: snapshot.RetentionPolicy{Generations: generations},
+		})
+		require.NoError(t, err)
+		return storage
+	}
+
+	// Store a valid snapshot of tick 1, and snapshots of the newest generations after it, whose files are
+	// corrupted afterwards. They're stored with a larger retention, so the valid snapshot is kept.
+	source := newSnapshotTestWorld(t, prng, newStorage(generations+1))
+	store := func(tick uint64) {
+		source.currentTick.height = tick
+		state, err := source.stateToProto()
+		require.NoError(t, err)
+		source.snapshot(context.Background(), time.Now(), state)
+	}
+	store(1)
+	valid, err := filepath.Glob(filepath.Join(dir, "*"))
+	require.NoError(t, err)
+	for tick := range uint64(generations) {
+		store(10 + tick)
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*"))
+	require.NoError(t, err)
+	for _, file := range files {
+		if slices.Contains(valid, file) {
+			continue
+		}
+		data, err := os.ReadFile(file)
+		require.NoError(t, err)
+		data[len(data)/2] ^= 0xff
+		require.NoError(t, os.WriteFile(file, data, 0o600))
+	}
+
+	storage := newStorage(generations)
+	restored := newSnapshotTestWorld(t, prng, storage)
+	require.NoError(t, restored.restore(context.Background()))
+	assert.Equal(t, uint64(2), restored.currentTick.height)
+	quarantined, err := filepath.Glob(filepath.Join(dir, "quarantine-*"))
+	require.NoError(t, err)
+	assert.Len(t, quarantined, generations, "corrupted snapshots should be quarantined")
+
+	// The snapshots the restored world takes are the newest generations, so the next restart restores
+	// the latest of them.
+	state, err := restored.stateToProto()
+	require.NoError(t, err)
+	restored.snapshot(context.Background(), time.Now(), state)
+	restarted := newSnapshotTestWorld(t, prng, storage)
+	require.NoError(t, restarted.restore(context.Background()))
+	assert.Equal(t, restored.currentTick.height+1, restarted.currentTick.height)
+}
+
+// -------------------------------------------------------------------------------------------------
+// Delta snapshot fuzzing
+// -------------------------------------------------------------------------------------------------
+// Applies random entity operations over many ticks while storing full and delta snapshots, and
+// verifies that the latest snapshot loaded from storage always equals the live world state.
+// -------------------------------------------------------------------------------------------------
+
+func TestWorld_SnapshotDeltas(t *testing.T) {
+	t.Parallel()
+	prng := testutils.NewRand(t)
+
+	const ticks = 200
+
+	storage, err := snapshot.NewFileStorage(snapshot.FileStorageOptions{
+		Logger:    zerolog.Nop(),
+		Dir:       t.TempDir(),
+		Retention: snapshot.RetentionPolicy{Generations: 2},
+	})
+	require.NoError(t, err)
+
+	w := newSnapshotTestWorld(t, prng, storage)
+	w.options.SnapshotRate = uint32(1 + prng.IntN(3))
+	w.options.SnapshotDeltas = uint32(1 + prng.IntN(5))
+	_, err = ecs.RegisterComponent[testutils.ComponentB](w.world)
+	require.NoError(t, err)
+
+	var entities []ecs.EntityID
+	for tick := range uint64(ticks) {
+		for range prng.IntN(5) {
+			switch {
+			case len(entities) > 0 && prng.IntN(4) == 0:
+				i := prng.IntN(len(entities))
+				ecs.Destroy(w.world, entities[i])
+				entities = append(entities[:i], entities[i+1:]...)
+			case len(entities) > 0 && prng.IntN(2) == 0:
+				// Setting ComponentB moves the entity to another archetype, which may be new.
+				eid := entities[prng.IntN(len(entities))]
+				if prng.IntN(2) == 0 {
+					require.NoError(t, ecs.Set(w.world, eid, testutils.ComponentB{ID: prng.Uint64()}))
+				} else {
+					require.NoError(t, ecs.Set(w.world, eid, testutils.ComponentA{X: prng.Float64()}))
+				}
+			default:
+				eid := ecs.Create(w.world)
+				require.NoError(t, ecs.Set(w.world, eid, testutils.ComponentA{X: prng.Float64()}))
+				entities = append(entities, eid)
+			}
+		}
+
+		w.currentTick.height = tick
+		w.persistState(context.Background(), time.Now())
+		if tick%uint64(w.options.SnapshotRate) != 0 {
+			continue
+		}
+
+		snap, err := storage.Load(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, tick, snap.TickHeight)
+		var loaded cardinalv1.WorldState
+		require.NoError(t, proto.Unmarshal(snap.Data, &loaded))
+		live, err := w.stateToProto()
+		require.NoError(t, err)
+		// Property: load(store(deltas)) == live world state.
+		require.True(t, proto.Equal(live, &loaded), "loaded snapshot of tick %d differs from the world", tick)
+	}
+}
+
+// -------------------------------------------------------------------------------------------------
+// Debug reset smoke tests
+// -------------------------------------------------------------------------------------------------
+// Verifies that a debug reset deletes the stored snapshots, including the ones still waiting in the
+// background writer, so a restart doesn't restore the history the reset discarded.
+// -------------------------------------------------------------------------------------------------
+
+func TestWorld_DebugReset(t *testing.T) {
+	t.Parallel()
+	prng := testutils.NewRand(t)
+
+	storage, err := snapshot.NewFileStorage(snapshot.FileStorageOptions{
+		Logger:    zerolog.Nop(),
+		Dir:       t.TempDir(),
+		Retention: snapshot.RetentionPolicy{Generations: 10},
+	})
+	require.NoError(t, err)
+
+	w := newSnapshotTestWorld(t, prng, storage)
+	w.snapshots = newSnapshotWriter(storage, zerolog.Nop())
+	for tick := range uint64(5) {
+		eid := ecs.Create(w.world)
+		require.NoError(t, ecs.Set(w.world, eid, testutils.ComponentA{X: float64(tick)}))
+		w.currentTick.height = tick
+		state, err := w.stateToProto()
+		require.NoError(t, err)
+		w.snapshot(context.Background(), time.Now(), state)
+	}
+
+	w.debugReset(context.Background())
+	require.NoError(t, w.snapshots.close(context.Background()))
+	infos, err := storage.List(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, infos, "snapshots of the discarded history should be deleted")
+
+	// A restart starts from the reset world.
+	restarted := newSnapshotTestWorld(t, prng, storage)
+	require.NoError(t, restarted.restore(context.Background()))
+	assert.Equal(t, uint64(0), restarted.currentTick.height)
+}
+
+// -------------------------------------------------------------------------------------------------
+// Snapshot writer smoke tests
+// -------------------------------------------------------------------------------------------------
+// Verifies that the background writer replaces a waiting snapshot with a newer one when storage is
+// slow, writes the waiting snapshot when it's closed, and drops the deltas chained to a snapshot
+// whose write failed until a full snapshot is written.
+// -------------------------------------------------------------------------------------------------
+
+func TestSnapshotWriter_Coalesces(t *testing.T) {
+	t.Parallel()
+
+	storage := newFakeSnapshotStorage()
+	writer := newSnapshotWriter(storage, zerolog.Nop())
+
+	// The first snapshot blocks in storage, so the second one waits and is replaced by the third.
+	storage.block = make(chan struct{})
+	writer.submit(newTestSnapshotJob(t, 1, false))
+	require.Eventually(t, func() bool { return !writer.busy() }, time.Second, time.Millisecond)
+	writer.submit(newTestSnapshotJob(t, 2, false))
+	assert.True(t, writer.busy())
+	writer.submit(newTestSnapshotJob(t, 3, false))
+	close(storage.block)
+
+	require.NoError(t, writer.close(context.Background()))
+	assert.Equal(t, []uint64{1, 3}, storage.storedTicks())
+	assert.False(t, writer.takeChainBroken())
+}
+
+func TestSnapshotWriter_FailureBreaksChain(t *testing.T) {
+	t.Parallel()
+
+	storage := newFakeSnapshotStorage()
+	storage.fail[2] = true
+	writer := newSnapshotWriter(storage, zerolog.Nop())
+
+	// Wait for every job, so none of them is replaced.
+	submit := func(tick uint64, delta bool) {
+		writer.submit(newTestSnapshotJob(t, tick, delta))
+		require.Eventually(t, func() bool { return !writer.busy() }, time.Second, time.Millisecond)
+	}
+	submit(1, false)
+	submit(2, true) // Fails
+	submit(3, true) // Chained to the failed snapshot, dropped
+	submit(4, false)
+	submit(5, true)
+
+	require.NoError(t, writer.close(context.Background()))
+	assert.Equal(t, []uint64{1, 4, 5}, storage.storedTicks())
+	assert.True(t, writer.takeChainBroken())
+	assert.False(t, writer.takeChainBroken(), "the broken chain should only be reported once")
+}
+
+func newTestSnapshotJob(t *testing.T, tick uint64, delta bool) *snapshotJob {
+	t.Helper()
+	job := &snapshotJob{tickHeight: tick, timestamp: time.Now(), delta: delta}
+	if delta {
+		job.state = &cardinalv1.WorldStateDelta{ParentTickHeight: tick - 1}
+	} else {
+		job.state = &cardinalv1.WorldState{NextId: uint32(tick)}
+	}
+	return job
+}
+
+// fakeSnapshotStorage records the snapshots stored in it. Stores block while block is open, and
+// fail for the ticks in fail. Only Store is implemented.
+type fakeSnapshotStorage struct {
+	snapshot.Storage
+
+	block chan struct{}
+	fail  map[uint64]bool
+
+	mu     sync.Mutex
+	stored []uint64
+}
+
+func newFakeSnapshotStorage() *fakeSnapshotStorage {
+	return &fakeSnapshotStorage{fail: make(map[uint64]bool)}
+}
+
+func (f *fakeSnapshotStorage) Store(_ context.Context, snap *snapshot.Snapshot) error {
+	if f.block != nil {
+		<-f.block
+	}
+	if f.fail[snap.TickHeight] {
+		return errors.New("storage unavailable")
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.stored = append(f.stored, snap.TickHeight)
+	return nil
+}
+
+func (f *fakeSnapshotStorage) storedTicks() []uint64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return slices.Clone(f.stored)
+}
+
+// newSnapshotTestWorld returns a world with ComponentA registered that stores snapshots in storage.
+func newSnapshotTestWorld(t *testing.T, prng *rand.Rand, storage snapshot.Storage) *World {
+	t.Helper()
+	w := newServiceFixture(t, prng, false).world
+	_, err := ecs.RegisterComponent[testutils.ComponentA](w.world)
+	require.NoError(t, err)
+	w.snapshotStorage = storage
+	return w
+}
@@ -0,0 +1,536 @@
+package cardinal
+
+import (
+	"bytes"
+	"context"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/argus-labs/world-engine/pkg/assert"
+	"github.com/argus-labs/world-engine/pkg/cardinal/internal/ecs"
+	cardinalv1 "github.com/argus-labs/world-engine/proto/gen/go/worldengine/cardinal/v1"
+	"github.com/goccy/go-json"
+	"github.com/rotisserie/eris"
+)
+
+// SubscribeState streams the state of the entities matching the request's query. The first message
+// is a snapshot of the matching entities in the latest published world state, and every following
+// message is the changes to them since the previous message, computed from the world state the
+// shard publishes every tick while it has state subscribers.
+func (s *service) SubscribeState(
+	ctx context.Context,
+	req *connect.Request[cardinalv1.SubscribeStateRequest],
+	stream *connect.ServerStream[cardinalv1.SubscribeStateResponse],
+) error {
+	user := UserFromContext(ctx)
+	assert.That(user != nil, "user should exist in authenticated stream context")
+
+	subscriber, initial, err := s.addStateSubscriber(user, newStateQuery(req.Msg, user.ID))
+	if err != nil {
+		return err
+	}
+	defer s.removeStateSubscriber(user, subscriber)
+
+	if err := stream.Send(initial); err != nil {
+		return connect.NewError(connect.CodeInternal, eris.Wrap(err, "failed to send state snapshot to client"))
+	}
+
+	// Send periodic keepalive messages to prevent ALB idle timeouts, like event streams.
+	ticker := time.NewTicker(30 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			if err := ctx.Err(); !eris.Is(err, context.Canceled) {
+				return connect.NewError(connect.CodeCanceled, eris.Wrap(err, "stream cancelled"))
+			}
+			return nil
+		case <-subscriber.done:
+			return subscriber.closeErr
+		case response := <-subscriber.queue:
+			if err := stream.Send(response); err != nil {
+				return err
+			}
+		case <-ticker.C:
+			if err := stream.Send(&cardinalv1.SubscribeStateResponse{}); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// addStateSubscriber registers a state stream, and returns its initial snapshot built from the
+// latest published world state.
+func (s *service) addStateSubscriber(
+	user *User, query stateQuery,
+) (*stateSubscriber, *cardinalv1.SubscribeStateResponse, error) {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+
+	subscribers := s.stateSubscribers[user.ID]
+	if len(subscribers) >= maxStreamsPerUser {
+		return nil, nil, connect.NewError(connect.CodeResourceExhausted,
+			eris.Errorf("user %s already has %d open state streams", user.ID, len(subscribers)))
+	}
+	if subscribers == nil {
+		subscribers = make(map[*stateSubscriber]struct{})
+		s.stateSubscribers[user.ID] = subscribers
+	}
+
+	// Publishing holds the lock, so the snapshot and the deltas that follow it line up.
+	index := s.index.Load()
+	query.authorizer = s.authorizer
+	subscriber := newStateSubscriber(query, s.queueSize)
+	subscriber.view = index.view(query)
+	subscribers[subscriber] = struct{}{}
+
+	return subscriber, &cardinalv1.SubscribeStateResponse{
+		TickHeight: index.snap.GetTickHeight(),
+		Timestamp:  index.snap.GetTimestamp(),
+		State:      &cardinalv1.SubscribeStateResponse_Snapshot{Snapshot: subscriber.view.toProto()},
+	}, nil
+}
+
+// removeStateSubscriber unregisters a state stream.
+func (s *service) removeStateSubscriber(user *User, subscriber *stateSubscriber) {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+
+	subscribers := s.stateSubscribers[user.ID]
+	delete(subscribers, subscriber)
+	if len(subscribers) == 0 {
+		delete(s.stateSubscribers, user.ID)
+	}
+}
+
+// hasStateSubscribers reports whether any state stream is open, in which case the world state has to
+// be published every tick.
+func (s *service) hasStateSubscribers() bool {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+
+	return len(s.stateSubscribers) > 0
+}
+
+// publishState indexes a newly published world state for QueryEntities and state streams, and sends
+// its changes to every state stream. Only the entities of archetypes that changed since the previous
+// state are compared, and streams whose state didn't change don't receive a message. It must be
+// called from the tick goroutine, as it reads the ECS world.
+func (s *service) publishState(snap *cardinalv1.Snapshot) {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+
+	prev := s.index.Load()
+	index := newStateIndex(s.world.world, snap, prev)
+	s.index.Store(index)
+	if len(s.stateSubscribers) == 0 {
+		return
+	}
+
+	changed := index.changedSince(prev)
+	for _, subscribers := range s.stateSubscribers {
+		for subscriber := range subscribers {
+			delta := index.update(subscriber.view, subscriber.query, changed)
+			if delta == nil {
+				continue
+			}
+			subscriber.enqueue(&cardinalv1.SubscribeStateResponse{
+				TickHeight: snap.GetTickHeight(),
+				Timestamp:  snap.GetTimestamp(),
+				State:      &cardinalv1.SubscribeStateResponse_Delta{Delta: delta},
+			})
+		}
+	}
+}
+
+// -------------------------------------------------------------------------------------------------
+// State subscribers
+// -------------------------------------------------------------------------------------------------
+
+// stateSubscriber is an open state stream. Like event streams, publishing enqueues messages into a
+// bounded outbound queue that the stream's handler drains.
+type stateSubscriber struct {
+	query     stateQuery
+	view      stateView // The state the client has, i.e. the state of the last message
+	queue     chan *cardinalv1.SubscribeStateResponse
+	done      chan struct{} // Closed when the server closes the stream
+	closeErr  error         // Why the server closed the stream
+	closeOnce sync.Once
+}
+
+func newStateSubscriber(query stateQuery, queueSize int) *stateSubscriber {
+	assert.That(queueSize > 0, "stream queue size must be positive")
+	return &stateSubscriber{
+		query: query,
+		queue: make(chan *cardinalv1.SubscribeStateResponse, queueSize),
+		done:  make(chan struct{}),
+	}
+}
+
+// enqueue adds a message to the outbound queue without blocking. Deltas can't be dropped without the
+// client's state diverging, so a full queue closes the stream, and the client subscribes again to get
+// a new snapshot.
+func (s *stateSubscriber) enqueue(response *cardinalv1.SubscribeStateResponse) {
+	select {
+	case s.queue <- response:
+	default:
+		s.closeOnce.Do(func() {
+			s.closeErr = connect.NewError(connect.CodeResourceExhausted,
+				eris.New("client isn't keeping up with state changes, subscribe again"))
+			close(s.done)
+		})
+	}
+}
+
+// -------------------------------------------------------------------------------------------------
+// State queries
+// -------------------------------------------------------------------------------------------------
+
+// stateQuery selects the entities and components a state stream receives.
+type stateQuery struct {
+	components     map[string]struct{} // Names of the streamed components
+	entities       map[uint32]struct{} // IDs of the streamed entities, nil for all entities
+	ownerComponent string              // Component holding the entity's owner, empty for no filter
+	ownerField     string              // JSON name of the field of ownerComponent with the owner ID
+	owner          string              // The user the entities have to be owned by
+	authorizer     QueryAuthorizer     // Filters the components the user may see, nil to show all
+}
+
+func newStateQuery(req *cardinalv1.SubscribeStateRequest, userID string) stateQuery {
+	query := stateQuery{
+		components: make(map[string]struct{}, len(req.GetComponents())),
+		owner:      userID,
+	}
+	for _, name := range req.GetComponents() {
+		query.components[name] = struct{}{}
+	}
+	if len(req.GetEntityIds()) > 0 {
+		query.entities = make(map[uint32]struct{}, len(req.GetEntityIds()))
+		for _, eid := range req.GetEntityIds() {
+			query.entities[eid] = struct{}{}
+		}
+	}
+	// The request validation guarantees the path has exactly one dot if it's set.
+	if component, field, ok := strings.Cut(req.GetOwnerField(), "."); ok {
+		query.ownerComponent = component
+		query.ownerField = field
+	}
+	return query
+}
+
+// stateView is the state a client has: entity ID -> component name -> serialized component.
+type stateView map[uint32]map[string][]byte
+
+// toProto converts the view to a snapshot message, in entity ID order.
+func (v stateView) toProto() *cardinalv1.StateSnapshot {
+	entities := make([]*cardinalv1.EntityState, 0, len(v))
+	for _, eid := range slices.Sorted(maps.Keys(v)) {
+		entities = append(entities, &cardinalv1.EntityState{EntityId: eid, Components: v[eid]})
+	}
+	return &cardinalv1.StateSnapshot{Entities: entities}
+}
+
+// stateIndex indexes a published world state by archetype, so the views of all state streams and the
+// results of QueryEntities can be built from it without decoding the state again. It is built on the
+// tick goroutine when a state is published, and not modified afterwards, so request handlers can read
+// it concurrently. Archetypes that didn't change since the previous index are shared with it instead
+// of being indexed again.
+type stateIndex struct {
+	world      *ecs.World
+	snap       *cardinalv1.Snapshot // The indexed state
+	archetypes []*archetypeIndex    // Archetype ID -> archetype
+	entityArch []int64              // Entity ID -> archetype ID, negative if the entity doesn't exist
+}
+
+// newStateIndex indexes snap, a serialization of the world's current state. Archetypes whose hash
+// didn't change since prev, which can be nil, are reused from it.
+func newStateIndex(world *ecs.World, snap *cardinalv1.Snapshot, prev *stateIndex) *stateIndex {
+	archetypes := snap.GetWorldState().GetArchetypes()
+	hashes, err := world.ArchetypeHashes()
+	if err != nil || len(hashes) != len(archetypes) {
+		hashes = nil // Index every archetype again
+	}
+
+	index := &stateIndex{
+		world:      world,
+		snap:       snap,
+		archetypes: make([]*archetypeIndex, len(archetypes)),
+		entityArch: snap.GetWorldState().GetEntityArch(),
+	}
+	for aid, archetype := range archetypes {
+		if hashes != nil && prev != nil && aid < len(prev.archetypes) {
+			if previous := prev.archetypes[aid]; previous.hashed && previous.hash == hashes[aid] {
+				index.archetypes[aid] = previous
+				continue
+			}
+		}
+		index.archetypes[aid] = newArchetypeIndex(archetype)
+		if hashes != nil {
+			index.archetypes[aid].hash, index.archetypes[aid].hashed = hashes[aid], true
+		}
+	}
+	return index
+}
+
+// changedSince returns the IDs of the entities whose state may differ from prev: the entities of the
+// archetypes in either index that aren't shared by both. With a nil prev, every entity changed.
+func (i *stateIndex) changedSince(prev *stateIndex) map[uint32]struct{} {
+	changed := make(map[uint32]struct{})
+	addChanged := func(archetypes, other []*archetypeIndex) {
+		for aid, archetype := range archetypes {
+			if aid < len(other) && other[aid] == archetype {
+				continue
+			}
+			for _, eid := range archetype.entities {
+				changed[eid] = struct{}{}
+			}
+		}
+	}
+	var previous []*archetypeIndex
+	if prev != nil {
+		previous = prev.archetypes
+	}
+	addChanged(i.archetypes, previous)
+	addChanged(previous, i.archetypes)
+	return changed
+}
+
+// view returns the state matching query.
+func (i *stateIndex) view(query stateQuery) stateView {
+	view := make(stateView)
+	if query.entities != nil {
+		for eid := range query.entities {
+			if components := i.entityView(eid, query); components != nil {
+				view[eid] = components
+			}
+		}
+		return view
+	}
+	for _, archetype := range i.archetypes {
+		if !archetype.hasAny(query.components) {
+			continue
+		}
+		for row, eid := range archetype.entities {
+			if components := i.rowView(archetype, row, eid, query); components != nil {
+				view[eid] = components
+			}
+		}
+	}
+	return view
+}
+
+// update brings view, built from an earlier index, up to date with this index, and returns the
+// changes, or nil if there are none. Only the changed entities, see changedSince, are compared.
+func (i *stateIndex) update(view stateView, query stateQuery, changed map[uint32]struct{}) *cardinalv1.StateDelta {
+	candidates := make([]uint32, 0)
+	if query.entities != nil && len(query.entities) < len(changed) {
+		for eid := range query.entities {
+			if _, ok := changed[eid]; ok {
+				candidates = append(candidates, eid)
+			}
+		}
+	} else {
+		for eid := range changed {
+			if _, ok := query.entities[eid]; query.entities == nil || ok {
+				candidates = append(candidates, eid)
+			}
+		}
+	}
+	slices.Sort(candidates)
+
+	delta := &cardinalv1.StateDelta{}
+	for _, eid := range candidates {
+		components := i.entityView(eid, query)
+		previous, existed := view[eid]
+		switch {
+		case components == nil:
+			if existed {
+				delta.Removed = append(delta.Removed, eid)
+				delete(view, eid)
+			}
+			continue
+		case !existed:
+			delta.Added = append(delta.Added, &cardinalv1.EntityState{EntityId: eid, Components: components})
+			view[eid] = components
+			continue
+		}
+		view[eid] = components
+
+		update := &cardinalv1.EntityState{EntityId: eid}
+		for name, data := range components {
+			if old, ok := previous[name]; !ok || !bytes.Equal(old, data) {
+				if update.Components == nil {
+					update.Components = make(map[string][]byte)
+				}
+				update.Components[name] = data
+			}
+		}
+		for name := range previous {
+			if _, ok := components[name]; !ok {
+				update.RemovedComponents = append(update.RemovedComponents, name)
+			}
+		}
+		if len(update.GetComponents()) > 0 || len(update.GetRemovedComponents()) > 0 {
+			slices.Sort(update.RemovedComponents)
+			delta.Updated = append(delta.Updated, update)
+		}
+	}
+
+	if len(delta.GetAdded()) == 0 && len(delta.GetUpdated()) == 0 && len(delta.GetRemoved()) == 0 {
+		return nil
+	}
+	return delta
+}
+
+// entity returns the archetype and row of an entity, or false if it doesn't exist.
+func (i *stateIndex) entity(eid uint32) (*archetypeIndex, int, bool) {
+	if int(eid) >= len(i.entityArch) {
+		return nil, 0, false
+	}
+	aid := i.entityArch[eid]
+	if aid < 0 || aid >= int64(len(i.archetypes)) {
+		return nil, 0, false
+	}
+	archetype := i.archetypes[aid]
+	row, ok := archetype.rows[eid]
+	return archetype, row, ok
+}
+
+// component returns the serialized component of an entity, or false if the entity doesn't have it.
+func (i *stateIndex) component(eid uint32, name string) ([]byte, bool) {
+	archetype, row, ok := i.entity(eid)
+	if !ok {
+		return nil, false
+	}
+	return archetype.component(name, row)
+}
+
+// entityView returns the components of an entity matching query, or nil if there are none.
+func (i *stateIndex) entityView(eid uint32, query stateQuery) map[string][]byte {
+	archetype, row, ok := i.entity(eid)
+	if !ok {
+		return nil
+	}
+	return i.rowView(archetype, row, eid, query)
+}
+
+// rowView returns the components of the entity in a row of archetype matching query that the query's
+// user may see, or nil if there are none.
+func (i *stateIndex) rowView(archetype *archetypeIndex, row int, eid uint32, query stateQuery) map[string][]byte {
+	if query.ownerComponent != "" &&
+		archetype.owner(i.world, row, eid, query.ownerComponent, query.ownerField) != query.owner {
+		return nil
+	}
+
+	var matched map[string][]byte
+	for name := range query.components {
+		data, ok := archetype.component(name, row)
+		if !ok {
+			continue
+		}
+		if allowed, err := i.authorized(query.authorizer, query.owner, eid, name, data); err != nil || !allowed {
+			continue
+		}
+		if matched == nil {
+			matched = make(map[string][]byte)
+		}
+		matched[name] = data
+	}
+	return matched
+}
+
+// authorized reports whether authorizer lets persona see a serialized component of an entity. A nil
+// authorizer allows everything.
+func (i *stateIndex) authorized(
+	authorizer QueryAuthorizer, persona string, eid uint32, name string, data []byte,
+) (bool, error) {
+	if authorizer == nil {
+		return true, nil
+	}
+	component, err := ecs.DecodeComponent(i.world, name, data)
+	if err != nil {
+		return false, eris.Wrapf(err, "failed to decode component %s for authorization", name)
+	}
+	return authorizer(persona, EntityID(eid), component), nil
+}
+
+// archetypeIndex is an archetype of an indexed world state. It's shared by the indexes of consecutive
+// states as long as the archetype doesn't change.
+type archetypeIndex struct {
+	hash     uint64                       // The ECS hash of the archetype
+	hashed   bool                         // Whether hash is known, otherwise the archetype is never reused
+	entities []uint32                     // Entity IDs in row order
+	rows     map[uint32]int               // Entity ID -> row
+	columns  map[string][][]byte          // Component name -> serialized components in row order
+	owners   map[string]map[uint32]string // Owner field path -> entity ID -> owner, decoded lazily
+	ownersMu sync.Mutex
+}
+
+func newArchetypeIndex(pb *cardinalv1.Archetype) *archetypeIndex {
+	archetype := &archetypeIndex{
+		entities: pb.GetEntities(),
+		rows:     make(map[uint32]int, len(pb.GetEntities())),
+		columns:  make(map[string][][]byte, len(pb.GetColumns())),
+		owners:   make(map[string]map[uint32]string),
+	}
+	for row, eid := range pb.GetEntities() {
+		archetype.rows[eid] = row
+	}
+	for _, column := range pb.GetColumns() {
+		archetype.columns[column.GetComponentName()] = column.GetComponents()
+	}
+	return archetype
+}
+
+// component returns the serialized component in a row, or false if the archetype doesn't have it.
+func (a *archetypeIndex) component(name string, row int) ([]byte, bool) {
+	column, ok := a.columns[name]
+	if !ok || row >= len(column) {
+		return nil, false
+	}
+	return column[row], true
+}
+
+// hasAny reports whether the archetype has any of the components.
+func (a *archetypeIndex) hasAny(components map[string]struct{}) bool {
+	for name := range components {
+		if _, ok := a.columns[name]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// owner returns the owner of the entity in a row, the value of the field of its owner component, or
+// an empty string if the archetype doesn't have the component or the field isn't a string.
+func (a *archetypeIndex) owner(world *ecs.World, row int, eid uint32, component, field string) string {
+	a.ownersMu.Lock()
+	defer a.ownersMu.Unlock()
+
+	path := component + "." + field
+	owners := a.owners[path]
+	if owners == nil {
+		owners = make(map[uint32]string)
+		a.owners[path] = owners
+	}
+	if owner, ok := owners[eid]; ok {
+		return owner
+	}
+
+	owner := ""
+	if data, ok := a.component(component, row); ok {
+		if decoded, err := ecs.DecodeComponent(world, component, data); err == nil {
+			var fields map[string]any
+			if encoded, err := json.Marshal(decoded); err == nil && json.Unmarshal(encoded, &fields) == nil {
+				owner, _ = fields[field].(string)
+			}
+		}
+	}
+	owners[eid] = owner
+	return owner
+}
@@ -0,0 +1,328 @@
+package cardinal
+
+import (
+	"maps"
+	"math/rand/v2"
+	"testing"
+
+	"connectrpc.com/connect"
+	"github.com/argus-labs/world-engine/pkg/cardinal/internal/ecs"
+	"github.com/argus-labs/world-engine/pkg/testutils"
+	cardinalv1 "github.com/argus-labs/world-engine/proto/gen/go/worldengine/cardinal/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// -------------------------------------------------------------------------------------------------
+// Model-based fuzzing state deltas
+// -------------------------------------------------------------------------------------------------
+// This test verifies that applying the deltas of a state stream to its initial snapshot always
+// reproduces the state matching the query. It applies random sequences of ECS operations, and after
+// each simulated tick indexes the world state incrementally, updates the stream's view, and applies
+// the delta to a client-side copy of the state. The model is the view of a fresh index of the current
+// world state, which both must equal.
+// -------------------------------------------------------------------------------------------------
+
+func TestStateStream_ModelFuzz(t *testing.T) {
+	t.Parallel()
+	prng := testutils.NewRand(t)
+
+	const (
+		opsMax   = 1 << 12 // 4096 iterations
+		opCreate = "create"
+		opSet    = "set"
+		opRemove = "remove"
+		opDelete = "destroy"
+		opTick   = "tick"
+	)
+
+	world := ecs.NewWorld()
+	_, err := ecs.RegisterComponent[testutils.ComponentA](world)
+	require.NoError(t, err)
+	_, err = ecs.RegisterComponent[testutils.ComponentB](world)
+	require.NoError(t, err)
+	_, err = ecs.RegisterComponent[testutils.ComponentC](world)
+	require.NoError(t, err)
+
+	// Random query over a random subset of the components, optionally restricted to some entities and
+	// to entities owned by the user.
+	names := []string{
+		testutils.ComponentA{}.Name(), testutils.ComponentB{}.Name(), testutils.ComponentC{}.Name(),
+	}
+	owners := []string{"alice", "bob"}
+	req := &cardinalv1.SubscribeStateRequest{}
+	for _, name := range names {
+		if prng.IntN(2) == 0 {
+			req.Components = append(req.Components, name)
+		}
+	}
+	if len(req.GetComponents()) == 0 {
+		req.Components = names
+	}
+	if prng.IntN(3) == 0 {
+		for eid := range uint32(64) {
+			if prng.IntN(2) == 0 {
+				req.EntityIds = append(req.EntityIds, eid)
+			}
+		}
+	}
+	if prng.IntN(3) == 0 {
+		req.OwnerField = testutils.ComponentB{}.Name() + ".Label"
+	}
+	query := newStateQuery(req, owners[0])
+
+	index := newStateIndex(world, &cardinalv1.Snapshot{WorldState: mustWorldStateToProto(t, world)}, nil)
+	server := index.view(query)
+	client := index.view(query)
+	alive := make([]ecs.EntityID, 0)
+
+	operations := []string{opCreate, opSet, opRemove, opDelete, opTick}
+	weights := testutils.RandOpWeights(prng, operations)
+	weights[opTick] = max(weights[opTick], 1)
+
+	for range opsMax {
+		op := testutils.RandWeightedOp(prng, weights)
+		switch op {
+		case opCreate:
+			eid := ecs.Create(world)
+			alive = append(alive, eid)
+			for range prng.IntN(3) {
+				setRandomComponent(t, prng, world, eid, owners)
+			}
+
+		case opSet:
+			if len(alive) == 0 {
+				continue
+			}
+			setRandomComponent(t, prng, world, alive[prng.IntN(len(alive))], owners)
+
+		case opRemove:
+			if len(alive) == 0 {
+				continue
+			}
+			eid := alive[prng.IntN(len(alive))]
+			switch prng.IntN(3) {
+			case 0:
+				_ = ecs.Remove[testutils.ComponentA](world, eid)
+			case 1:
+				_ = ecs.Remove[testutils.ComponentB](world, eid)
+			default:
+				_ = ecs.Remove[testutils.ComponentC](world, eid)
+			}
+
+		case opDelete:
+			if len(alive) == 0 {
+				continue
+			}
+			i := prng.IntN(len(alive))
+			require.True(t, ecs.Destroy(world, alive[i]))
+			alive = append(alive[:i], alive[i+1:]...)
+
+		case opTick:
+			snap := &cardinalv1.Snapshot{WorldState: mustWorldStateToProto(t, world)}
+			next := newStateIndex(world, snap, index)
+			delta := next.update(server, query, next.changedSince(index))
+			index = next
+			model := newStateIndex(world, snap, nil).view(query)
+
+			// Property: there is a delta iff the state changed.
+			assert.Equal(t, !stateViewsEqual(client, model), delta != nil, "delta presence mismatch")
+
+			// Property: the incrementally updated view equals the view of the full state.
+			require.True(t, stateViewsEqual(model, server), "server state diverged")
+
+			// Property: applying the delta to the client's state reproduces the current state.
+			client = applyStateDelta(client, delta)
+			require.True(t, stateViewsEqual(model, client), "client state diverged")
+
+		default:
+			panic("unreachable")
+		}
+	}
+}
+
+// -------------------------------------------------------------------------------------------------
+// State stream smoke tests
+// -------------------------------------------------------------------------------------------------
+// Verifies that a state stream starts with a snapshot of the latest published state, receives
+// deltas only for ticks that change its state, hides what the query authorizer doesn't allow, and is
+// closed if it doesn't keep up.
+// -------------------------------------------------------------------------------------------------
+
+func TestService_SubscribeState(t *testing.T) {
+	t.Parallel()
+
+	t.Run("snapshot and deltas", func(t *testing.T) {
+		t.Parallel()
+		prng := testutils.NewRand(t)
+		fixture := newServiceFixture(t, prng, false)
+		world := fixture.world.world
+		_, err := ecs.RegisterComponent[testutils.ComponentB](world)
+		require.NoError(t, err)
+
+		mine := ecs.Create(world)
+		require.NoError(t, ecs.Set(world, mine, testutils.ComponentB{Label: "player"}))
+		theirs := ecs.Create(world)
+		require.NoError(t, ecs.Set(world, theirs, testutils.ComponentB{Label: "other-player"}))
+		publishTestState(t, fixture, 1)
+
+		subscriber, initial, err := fixture.svc.addStateSubscriber(&User{ID: "player"},
+			newStateQuery(&cardinalv1.SubscribeStateRequest{
+				Components: []string{testutils.ComponentB{}.Name()},
+				OwnerField: testutils.ComponentB{}.Name() + ".Label",
+			}, "player"))
+		require.NoError(t, err)
+		assert.Equal(t, uint64(1), initial.GetTickHeight())
+		require.Len(t, initial.GetSnapshot().GetEntities(), 1)
+		assert.Equal(t, uint32(mine), initial.GetSnapshot().GetEntities()[0].GetEntityId())
+
+		// Changes to entities the user doesn't own aren't sent.
+		require.NoError(t, ecs.Set(world, theirs, testutils.ComponentB{Label: "other-player", ID: 1}))
+		publishTestState(t, fixture, 2)
+		assert.Empty(t, subscriber.queue)
+
+		require.NoError(t, ecs.Set(world, theirs, testutils.ComponentB{Label: "player"}))
+		require.True(t, ecs.Destroy(world, mine))
+		publishTestState(t, fixture, 3)
+		require.Len(t, subscriber.queue, 1)
+		response := <-subscriber.queue
+		assert.Equal(t, uint64(3), response.GetTickHeight())
+		require.Len(t, response.GetDelta().GetAdded(), 1)
+		assert.Equal(t, uint32(theirs), response.GetDelta().GetAdded()[0].GetEntityId())
+		assert.Equal(t, []uint32{uint32(mine)}, response.GetDelta().GetRemoved())
+
+		fixture.svc.removeStateSubscriber(&User{ID: "player"}, subscriber)
+		assert.False(t, fixture.svc.hasStateSubscribers())
+	})
+
+	t.Run("authorizer", func(t *testing.T) {
+		t.Parallel()
+		prng := testutils.NewRand(t)
+		fixture := newServiceFixture(t, prng, false)
+		fixture.svc.authorizer = func(persona string, entity EntityID, component ecs.Component) bool {
+			b, ok := component.(testutils.ComponentB)
+			return !ok || b.Label != "secret"
+		}
+		world := fixture.world.world
+		_, err := ecs.RegisterComponent[testutils.ComponentB](world)
+		require.NoError(t, err)
+
+		visible := ecs.Create(world)
+		require.NoError(t, ecs.Set(world, visible, testutils.ComponentB{Label: "public"}))
+		denied := ecs.Create(world)
+		require.NoError(t, ecs.Set(world, denied, testutils.ComponentB{Label: "secret"}))
+		publishTestState(t, fixture, 1)
+
+		subscriber, initial, err := fixture.svc.addStateSubscriber(&User{ID: "player"},
+			newStateQuery(&cardinalv1.SubscribeStateRequest{
+				Components: []string{testutils.ComponentB{}.Name()},
+			}, "player"))
+		require.NoError(t, err)
+		require.Len(t, initial.GetSnapshot().GetEntities(), 1)
+		assert.Equal(t, uint32(visible), initial.GetSnapshot().GetEntities()[0].GetEntityId())
+
+		// Changes to the denied entity aren't sent.
+		require.NoError(t, ecs.Set(world, denied, testutils.ComponentB{Label: "secret", ID: 1}))
+		publishTestState(t, fixture, 2)
+		assert.Empty(t, subscriber.queue)
+
+		// Neither is a new denied entity, and an entity that becomes denied is removed.
+		created := ecs.Create(world)
+		require.NoError(t, ecs.Set(world, created, testutils.ComponentB{Label: "secret"}))
+		require.NoError(t, ecs.Set(world, visible, testutils.ComponentB{Label: "secret"}))
+		publishTestState(t, fixture, 3)
+		require.Len(t, subscriber.queue, 1)
+		response := <-subscriber.queue
+		assert.Empty(t, response.GetDelta().GetAdded())
+		assert.Empty(t, response.GetDelta().GetUpdated())
+		assert.Equal(t, []uint32{uint32(visible)}, response.GetDelta().GetRemoved())
+	})
+
+	t.Run("slow client is disconnected", func(t *testing.T) {
+		t.Parallel()
+		prng := testutils.NewRand(t)
+		fixture := newServiceFixture(t, prng, false)
+		world := fixture.world.world
+		_, err := ecs.RegisterComponent[testutils.ComponentA](world)
+		require.NoError(t, err)
+		eid := ecs.Create(world)
+
+		subscriber, _, err := fixture.svc.addStateSubscriber(&User{ID: "player"},
+			newStateQuery(&cardinalv1.SubscribeStateRequest{
+				Components: []string{testutils.ComponentA{}.Name()},
+			}, "player"))
+		require.NoError(t, err)
+
+		// Every tick changes the state, and nothing drains the queue.
+		for tick := range uint64(fixture.svc.queueSize + 1) {
+			require.NoError(t, ecs.Set(world, eid, testutils.ComponentA{X: float64(tick)}))
+			publishTestState(t, fixture, tick)
+		}
+		<-subscriber.done
+		assert.Equal(t, connect.CodeResourceExhausted, connect.CodeOf(subscriber.closeErr))
+	})
+}
+
+// publishTestState publishes the fixture world's state like persistState does at the end of a tick.
+func publishTestState(t *testing.T, fixture *serviceFixture, tick uint64) {
+	t.Helper()
+	snap := &cardinalv1.Snapshot{
+		TickHeight: tick,
+		Timestamp:  timestamppb.Now(),
+		WorldState: mustWorldStateToProto(t, fixture.world.world),
+	}
+	fixture.world.state.Store(snap)
+	fixture.svc.publishState(snap)
+}
+
+func mustWorldStateToProto(t *testing.T, world *ecs.World) *cardinalv1.WorldState {
+	t.Helper()
+	state, err := world.ToProto()
+	require.NoError(t, err)
+	return state
+}
+
+// setRandomComponent sets a random component with a value from a small range on the entity, so
+// some sets don't change the state.
+func setRandomComponent(t *testing.T, prng *rand.Rand, world *ecs.World, eid ecs.EntityID, owners []string) {
+	t.Helper()
+	var err error
+	switch prng.IntN(3) {
+	case 0:
+		err = ecs.Set(world, eid, testutils.ComponentA{X: float64(prng.IntN(4))})
+	case 1:
+		err = ecs.Set(world, eid, testutils.ComponentB{Label: owners[prng.IntN(len(owners))], ID: prng.Uint64N(4)})
+	default:
+		err = ecs.Set(world, eid, testutils.ComponentC{Counter: uint16(prng.UintN(4))})
+	}
+	require.NoError(t, err)
+}
+
+// applyStateDelta applies a delta to a client's state, like a client would.
+func applyStateDelta(view stateView, delta *cardinalv1.StateDelta) stateView {
+	next := make(stateView, len(view))
+	for eid, components := range view {
+		next[eid] = maps.Clone(components)
+	}
+	for _, entity := range delta.GetAdded() {
+		next[entity.GetEntityId()] = maps.Clone(entity.GetComponents())
+	}
+	for _, entity := range delta.GetUpdated() {
+		components := next[entity.GetEntityId()]
+		maps.Copy(components, entity.GetComponents())
+		for _, name := range entity.GetRemovedComponents() {
+			delete(components, name)
+		}
+	}
+	for _, eid := range delta.GetRemoved() {
+		delete(next, eid)
+	}
+	return next
+}
+
+func stateViewsEqual(a, b stateView) bool {
+	return maps.EqualFunc(a, b, func(x, y map[string][]byte) bool {
+		return maps.EqualFunc(x, y, func(p, q []byte) bool { return string(p) == string(q) })
+	})
+}
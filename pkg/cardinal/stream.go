@@ -0,0 +1,259 @@
+package cardinal
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+
+	"connectrpc.com/connect"
+	"github.com/argus-labs/world-engine/pkg/assert"
+	"github.com/argus-labs/world-engine/pkg/micro"
+	cardinalv1 "github.com/argus-labs/world-engine/proto/gen/go/worldengine/cardinal/v1"
+	"github.com/rotisserie/eris"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// streamSubscriber is an open event stream. Publishers enqueue events into its bounded outbound
+// queue without blocking, and the stream's handler goroutine drains the queue to the client, so a
+// slow client never stalls the tick loop.
+type streamSubscriber struct {
+	ctx       context.Context
+	id        string // Stream ID, unique among the user's streams
+	stream    *connect.ServerStream[cardinalv1.StartEventStreamResponse]
+	events    map[string]*eventFilter                   // Event name pattern -> filter, nil if unfiltered
+	queue     chan *cardinalv1.StartEventStreamResponse // Outbound events, drained by the handler
+	overflow  OverflowPolicy                            // What to do when the queue is full
+	done      chan struct{}                             // Closed when the server closes the stream
+	closeErr  error                                     // Why the server closed the stream
+	closeOnce sync.Once
+	metrics   *streamMetrics
+
+	batch     bool                       // Whether events are sent in a batch per tick
+	pending   []*cardinalv1.BatchedEvent // Events of the current tick, sent in the next batch
+	lastSeq   uint64                     // Sequence the last batch carried, so empty batches repeat it
+	pendingMu sync.Mutex
+}
+
+func newStreamSubscriber(
+	ctx context.Context,
+	id string,
+	stream *connect.ServerStream[cardinalv1.StartEventStreamResponse],
+	queueSize int,
+	overflow OverflowPolicy,
+	metrics *streamMetrics,
+) *streamSubscriber {
+	assert.That(queueSize > 0, "stream queue size must be positive")
+	assert.That(overflow.IsValid(), "stream overflow policy must be valid")
+
+	return &streamSubscriber{
+		ctx:      ctx,
+		id:       id,
+		stream:   stream,
+		events:   make(map[string]*eventFilter),
+		queue:    make(chan *cardinalv1.StartEventStreamResponse, queueSize),
+		overflow: overflow,
+		done:     make(chan struct{}),
+		metrics:  metrics,
+	}
+}
+
+// matches reports whether the subscriber is subscribed to the event, i.e. whether the event matches
+// a subscription's name pattern and passes its filter. Expects the caller to hold the service lock.
+func (s *streamSubscriber) matches(event *eventMatch) bool {
+	for subscription, filter := range s.events {
+		if matchesEvent(subscription, event.name) && event.passes(filter) {
+			return true
+		}
+	}
+	return false
+}
+
+// deliver sends an event to the stream. Events of batching streams are held until the tick's batch is
+// flushed, other events are enqueued right away. Expects the caller to hold the service lock.
+func (s *streamSubscriber) deliver(response *cardinalv1.StartEventStreamResponse) {
+	if !s.batch {
+		s.enqueue(response)
+		return
+	}
+	s.pendingMu.Lock()
+	defer s.pendingMu.Unlock()
+	s.pending = append(s.pending, &cardinalv1.BatchedEvent{Event: response.GetEvent(), Sequence: response.GetSequence()})
+}
+
+// flush enqueues the batch of the tick's events, even if there are none, so clients can follow the
+// tick clock. It is a no-op for streams that don't batch. Expects the caller to hold the service lock.
+func (s *streamSubscriber) flush(address *micro.ServiceAddress, tick Tick) {
+	if !s.batch {
+		return
+	}
+	s.pendingMu.Lock()
+	events := s.pending
+	s.pending = nil
+	response := newBatchResponse(address, tick, events, s.lastSeq)
+	s.lastSeq = response.GetSequence()
+	s.pendingMu.Unlock()
+
+	s.enqueue(response)
+}
+
+// newBatchResponse creates the stream message of a tick's batch of events. The batch's sequence is
+// its last event's, or lastSeq, the sequence the stream was caught up to before, if it's empty. So a
+// client can always resume the stream after the sequence of the last batch it received.
+func newBatchResponse(
+	address *micro.ServiceAddress, tick Tick, events []*cardinalv1.BatchedEvent, lastSeq uint64,
+) *cardinalv1.StartEventStreamResponse {
+	seq := lastSeq
+	if len(events) > 0 {
+		seq = events[len(events)-1].GetSequence()
+	}
+	return &cardinalv1.StartEventStreamResponse{
+		Address:  address,
+		Sequence: seq,
+		Batch: &cardinalv1.EventBatch{
+			TickHeight: tick.height,
+			Timestamp:  timestamppb.New(tick.timestamp),
+			Events:     events,
+		},
+	}
+}
+
+// enqueue adds an event to the outbound queue without blocking. If the queue is full, the overflow
+// policy decides whether the oldest queued event is dropped or the stream is closed. Expects the
+// caller to hold the service lock, so the subscriber isn't removed concurrently.
+func (s *streamSubscriber) enqueue(response *cardinalv1.StartEventStreamResponse) {
+	for {
+		select {
+		case s.queue <- response:
+			s.metrics.queued.Add(s.ctx, 1)
+			return
+		default:
+		}
+
+		switch s.overflow {
+		case OverflowDropOldest:
+			select {
+			case <-s.queue:
+				s.metrics.queued.Add(s.ctx, -1)
+				s.metrics.dropped.Add(s.ctx, 1, s.metrics.dropOldest)
+			default:
+			}
+		case OverflowDisconnect, OverflowUndefined:
+			s.metrics.dropped.Add(s.ctx, 1, s.metrics.disconnect)
+			s.close(connect.NewError(connect.CodeResourceExhausted,
+				eris.New("client isn't keeping up with events, resume the stream from the last received event")))
+			return
+		}
+	}
+}
+
+// close makes the stream's handler end the stream with err. Only the first call has an effect.
+func (s *streamSubscriber) close(err error) {
+	s.closeOnce.Do(func() {
+		s.closeErr = err
+		close(s.done)
+	})
+}
+
+// discard empties the outbound queue after the stream is removed, so the queue depth metric only
+// counts events of open streams. Expects the caller to hold the service lock.
+func (s *streamSubscriber) discard() {
+	for {
+		select {
+		case <-s.queue:
+			s.metrics.queued.Add(context.Background(), -1)
+		default:
+			return
+		}
+	}
+}
+
+// -------------------------------------------------------------------------------------------------
+// Metrics
+// -------------------------------------------------------------------------------------------------
+
+// streamMetrics are the OpenTelemetry instruments for event stream queues. They are recorded through
+// the global meter provider, which is a no-op unless the application configures one.
+type streamMetrics struct {
+	queued     metric.Int64UpDownCounter // Events waiting in outbound queues across all streams
+	dropped    metric.Int64Counter       // Events dropped because an outbound queue was full
+	dropOldest metric.AddOption          // Attribute for events dropped by OverflowDropOldest
+	disconnect metric.AddOption          // Attribute for events dropped by OverflowDisconnect
+}
+
+// newStreamMetrics creates the event stream instruments. The instruments are usable even if an error
+// is returned, they just may not be exported.
+func newStreamMetrics() (*streamMetrics, error) {
+	meter := otel.Meter("github.com/argus-labs/world-engine/pkg/cardinal")
+
+	queued, queuedErr := meter.Int64UpDownCounter("cardinal.event_stream.queued_events",
+		metric.WithDescription("Number of events waiting in event stream outbound queues"),
+		metric.WithUnit("{event}"))
+	dropped, droppedErr := meter.Int64Counter("cardinal.event_stream.dropped_events",
+		metric.WithDescription("Number of events dropped because an event stream outbound queue was full"),
+		metric.WithUnit("{event}"))
+
+	metrics := &streamMetrics{
+		queued:     queued,
+		dropped:    dropped,
+		dropOldest: metric.WithAttributes(attribute.String("policy", OverflowDropOldest.String())),
+		disconnect: metric.WithAttributes(attribute.String("policy", OverflowDisconnect.String())),
+	}
+	if err := errors.Join(queuedErr, droppedErr); err != nil {
+		return metrics, eris.Wrap(err, "failed to create event stream metrics")
+	}
+	return metrics, nil
+}
+
+// -------------------------------------------------------------------------------------------------
+// Overflow policy
+// -------------------------------------------------------------------------------------------------
+
+// OverflowPolicy selects what happens when an event stream's outbound queue is full.
+type OverflowPolicy uint8
+
+const (
+	OverflowUndefined OverflowPolicy = iota
+	// OverflowDropOldest drops the oldest queued event to make room for the new one.
+	OverflowDropOldest
+	// OverflowDisconnect closes the stream of the slow client, which can resume it from the last
+	// event it received.
+	OverflowDisconnect
+)
+
+const (
+	dropOldestOverflowString = "DROP_OLDEST"
+	disconnectOverflowString = "DISCONNECT"
+	undefinedOverflowString  = "UNDEFINED"
+)
+
+func (o OverflowPolicy) String() string {
+	switch o {
+	case OverflowUndefined:
+		return undefinedOverflowString
+	case OverflowDropOldest:
+		return dropOldestOverflowString
+	case OverflowDisconnect:
+		return disconnectOverflowString
+	default:
+		return undefinedOverflowString
+	}
+}
+
+func (o OverflowPolicy) IsValid() bool {
+	return o == OverflowDropOldest || o == OverflowDisconnect
+}
+
+func ParseOverflowPolicy(s string) (OverflowPolicy, error) {
+	switch strings.ToUpper(s) {
+	case dropOldestOverflowString:
+		return OverflowDropOldest, nil
+	case disconnectOverflowString:
+		return OverflowDisconnect, nil
+	default:
+		return OverflowUndefined, eris.Errorf("invalid overflow policy: %s", s)
+	}
+}
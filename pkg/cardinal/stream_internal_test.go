@@ -0,0 +1,357 @@
+package cardinal
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/argus-labs/world-engine/pkg/cardinal/internal/event"
+	"github.com/argus-labs/world-engine/pkg/testutils"
+	cardinalv1 "github.com/argus-labs/world-engine/proto/gen/go/worldengine/cardinal/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// -------------------------------------------------------------------------------------------------
+// Outbound queue smoke tests
+// -------------------------------------------------------------------------------------------------
+// Verifies that publishing never blocks on a stream that isn't being drained, and that a full
+// outbound queue either drops its oldest events or closes the stream, depending on the policy.
+// -------------------------------------------------------------------------------------------------
+
+func TestStreamSubscriber_Overflow(t *testing.T) {
+	t.Parallel()
+
+	t.Run("drop oldest", func(t *testing.T) {
+		t.Parallel()
+		prng := testutils.NewRand(t)
+		fixture := newServiceFixture(t, prng, false)
+
+		queueSize := prng.IntN(16) + 1
+		subscriber := fixture.addTestSubscriber(t, "player", "stream", queueSize, OverflowDropOldest)
+
+		// Nothing drains the queue, so publishing would block forever if it sent synchronously.
+		count := queueSize + prng.IntN(32) + 1
+		for i := range count {
+			err := fixture.svc.publishDefaultEvent(event.Event{
+				Kind:    event.KindDefault,
+				Payload: testutils.SimpleEvent{Value: i},
+			})
+			require.NoError(t, err)
+		}
+
+		// The queue holds the newest events in order.
+		require.Len(t, subscriber.queue, queueSize)
+		for i := count - queueSize; i < count; i++ {
+			response := <-subscriber.queue
+			decoded, err := testutils.SimpleEvent{}.UnmarshalWire(response.GetEvent().GetPayload())
+			require.NoError(t, err)
+			assert.Equal(t, testutils.SimpleEvent{Value: i}, decoded)
+			assert.Equal(t, uint64(i+1), response.GetSequence())
+		}
+		select {
+		case <-subscriber.done:
+			t.Fatal("drop oldest policy closed the stream")
+		default:
+		}
+	})
+
+	t.Run("disconnect", func(t *testing.T) {
+		t.Parallel()
+		prng := testutils.NewRand(t)
+		fixture := newServiceFixture(t, prng, false)
+
+		queueSize := prng.IntN(16) + 1
+		subscriber := fixture.addTestSubscriber(t, "player", "stream", queueSize, OverflowDisconnect)
+
+		for i := range queueSize {
+			subscriber.enqueue(&cardinalv1.StartEventStreamResponse{Sequence: uint64(i + 1)})
+		}
+		select {
+		case <-subscriber.done:
+			t.Fatal("stream closed before the queue overflowed")
+		default:
+		}
+
+		// Overflowing more than once must not panic on closing the channel twice.
+		subscriber.enqueue(&cardinalv1.StartEventStreamResponse{})
+		subscriber.enqueue(&cardinalv1.StartEventStreamResponse{})
+		<-subscriber.done
+		assert.Len(t, subscriber.queue, queueSize, "overflowing events should be dropped")
+	})
+}
+
+// -------------------------------------------------------------------------------------------------
+// Multiple streams per user smoke tests
+// -------------------------------------------------------------------------------------------------
+// Verifies that a user can have several streams open, that targeted events reach all of them, that
+// subscriptions can target a single stream, and that reusing a stream ID replaces the open stream.
+// -------------------------------------------------------------------------------------------------
+
+func TestService_MultipleStreams(t *testing.T) {
+	t.Parallel()
+
+	t.Run("targeted events reach all streams", func(t *testing.T) {
+		t.Parallel()
+		prng := testutils.NewRand(t)
+		fixture := newServiceFixture(t, prng, false)
+
+		phone := fixture.addTestSubscriber(t, "player", "phone", 8, OverflowDropOldest)
+		web := fixture.addTestSubscriber(t, "player", "web", 8, OverflowDropOldest)
+		other := fixture.addTestSubscriber(t, "other-player", "phone", 8, OverflowDropOldest)
+
+		err := fixture.svc.publishDefaultEvent(event.Event{
+			Kind:      event.KindDefault,
+			Payload:   testutils.SimpleEvent{Value: prng.Int()},
+			Recipient: "player",
+		})
+		require.NoError(t, err)
+
+		assert.Len(t, phone.queue, 1)
+		assert.Len(t, web.queue, 1)
+		assert.Empty(t, other.queue)
+	})
+
+	t.Run("subscriptions target a stream", func(t *testing.T) {
+		t.Parallel()
+		prng := testutils.NewRand(t)
+		fixture := newServiceFixture(t, prng, false)
+
+		phone := fixture.addTestSubscriber(t, "player", "phone", 8, OverflowDropOldest)
+		web := fixture.addTestSubscriber(t, "player", "web", 8, OverflowDropOldest)
+		subscription := []*cardinalv1.EventSubscription{{
+			Address: fixture.world.address,
+			Events:  []string{"*"},
+		}}
+
+		_, err := fixture.svc.UnsubscribeEvents(serviceTestContext("player"),
+			connect.NewRequest(&cardinalv1.UnsubscribeEventsRequest{Subscriptions: subscription, StreamId: "web"}))
+		require.NoError(t, err)
+		err = fixture.svc.publishDefaultEvent(event.Event{Kind: event.KindDefault, Payload: testutils.SimpleEvent{}})
+		require.NoError(t, err)
+		assert.Len(t, phone.queue, 1)
+		assert.Empty(t, web.queue)
+
+		// Without a stream ID, the subscriptions apply to all of the user's streams.
+		_, err = fixture.svc.UnsubscribeEvents(serviceTestContext("player"),
+			connect.NewRequest(&cardinalv1.UnsubscribeEventsRequest{Subscriptions: subscription}))
+		require.NoError(t, err)
+		err = fixture.svc.publishDefaultEvent(event.Event{Kind: event.KindDefault, Payload: testutils.SimpleEvent{}})
+		require.NoError(t, err)
+		assert.Len(t, phone.queue, 1)
+		assert.Empty(t, web.queue)
+
+		_, err = fixture.svc.SubscribeEvents(serviceTestContext("player"),
+			connect.NewRequest(&cardinalv1.SubscribeEventsRequest{Subscriptions: subscription, StreamId: "tablet"}))
+		require.Error(t, err)
+		assert.Equal(t, connect.CodeFailedPrecondition, connect.CodeOf(err))
+	})
+
+	t.Run("stream ID reuse replaces stream", func(t *testing.T) {
+		t.Parallel()
+		prng := testutils.NewRand(t)
+		fixture := newServiceFixture(t, prng, false)
+		user := &User{ID: "player"}
+
+		first, _, _, err := fixture.svc.addSubscriber(context.Background(), user, nil,
+			&cardinalv1.StartEventStreamRequest{StreamId: "phone"}, nil)
+		require.NoError(t, err)
+		second, _, _, err := fixture.svc.addSubscriber(context.Background(), user, nil,
+			&cardinalv1.StartEventStreamRequest{StreamId: "phone"}, nil)
+		require.NoError(t, err)
+
+		<-first.done
+		assert.Equal(t, connect.CodeAborted, connect.CodeOf(first.closeErr))
+
+		// The replaced stream's handler exiting must not remove the new stream.
+		fixture.svc.removeSubscriber(user, first)
+		fixture.svc.mu.RLock()
+		assert.Same(t, second, fixture.svc.subscribers["player"]["phone"])
+		fixture.svc.mu.RUnlock()
+	})
+
+	t.Run("stream limit", func(t *testing.T) {
+		t.Parallel()
+		prng := testutils.NewRand(t)
+		fixture := newServiceFixture(t, prng, false)
+		user := &User{ID: "player"}
+
+		for range maxStreamsPerUser {
+			subscriber, _, _, err := fixture.svc.addSubscriber(context.Background(), user, nil,
+				&cardinalv1.StartEventStreamRequest{}, nil)
+			require.NoError(t, err)
+			assert.NotEmpty(t, subscriber.id, "stream ID should be generated")
+		}
+		_, _, _, err := fixture.svc.addSubscriber(context.Background(), user, nil,
+			&cardinalv1.StartEventStreamRequest{}, nil)
+		require.Error(t, err)
+		assert.Equal(t, connect.CodeResourceExhausted, connect.CodeOf(err))
+	})
+}
+
+// -------------------------------------------------------------------------------------------------
+// Subscription filter smoke tests
+// -------------------------------------------------------------------------------------------------
+// Verifies that streams only receive events that pass their subscription filters, that invalid
+// filters are rejected, and that a filter shared by several streams is evaluated once per event.
+// -------------------------------------------------------------------------------------------------
+
+func TestService_SubscriptionFilters(t *testing.T) {
+	t.Parallel()
+
+	t.Run("filter on event fields", func(t *testing.T) {
+		t.Parallel()
+		prng := testutils.NewRand(t)
+		fixture := newServiceFixture(t, prng, false)
+
+		value := prng.IntN(1000)
+		equal := fixture.addTestSubscriber(t, "alice", "stream", 8, OverflowDropOldest)
+		greater := fixture.addTestSubscriber(t, "bob", "stream", 8, OverflowDropOldest)
+		missing := fixture.addTestSubscriber(t, "carol", "stream", 8, OverflowDropOldest)
+		_, err := fixture.svc.SubscribeEvents(serviceTestContext("alice"), connect.NewRequest(
+			&cardinalv1.SubscribeEventsRequest{Subscriptions: []*cardinalv1.EventSubscription{{
+				Address: fixture.world.address,
+				Events:  []string{"*"},
+				Filter:  fmt.Sprintf("event.Value == %d", value),
+			}}}))
+		require.NoError(t, err)
+		greater.events["*"], err = fixture.svc.filters.compile(fmt.Sprintf("event.Value > %d", value))
+		require.NoError(t, err)
+		missing.events["*"], err = fixture.svc.filters.compile("event.zone_id == 3")
+		require.NoError(t, err)
+
+		for _, v := range []int{value, value + 1} {
+			err := fixture.svc.publishDefaultEvent(event.Event{
+				Kind:    event.KindDefault,
+				Payload: testutils.SimpleEvent{Value: v},
+			})
+			require.NoError(t, err)
+		}
+
+		require.Len(t, equal.queue, 1)
+		assert.Equal(t, uint64(1), (<-equal.queue).GetSequence())
+		require.Len(t, greater.queue, 1)
+		assert.Equal(t, uint64(2), (<-greater.queue).GetSequence())
+		assert.Empty(t, missing.queue, "events without the filtered field should not match")
+	})
+
+	t.Run("invalid filters are rejected", func(t *testing.T) {
+		t.Parallel()
+		prng := testutils.NewRand(t)
+		fixture := newServiceFixture(t, prng, false)
+		fixture.addTestSubscriber(t, "player", "stream", 8, OverflowDropOldest)
+
+		for _, filter := range []string{"event.Value ==", "event.Value + 1", "unknown == 3"} {
+			_, err := fixture.svc.SubscribeEvents(serviceTestContext("player"), connect.NewRequest(
+				&cardinalv1.SubscribeEventsRequest{Subscriptions: []*cardinalv1.EventSubscription{{
+					Address: fixture.world.address,
+					Events:  []string{"*"},
+					Filter:  filter,
+				}}}))
+			require.Error(t, err, "filter %q", filter)
+			assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err), "filter %q", filter)
+		}
+	})
+
+	t.Run("shared filter is evaluated once", func(t *testing.T) {
+		t.Parallel()
+		prng := testutils.NewRand(t)
+		fixture := newServiceFixture(t, prng, false)
+
+		first, err := fixture.svc.filters.compile("event.Value >= 0")
+		require.NoError(t, err)
+		second, err := fixture.svc.filters.compile("event.Value >= 0")
+		require.NoError(t, err)
+		require.Same(t, first, second, "the same expression should compile to the same filter")
+
+		match := newEventMatch(testutils.SimpleEvent{}.Name(), testutils.SimpleEvent{Value: prng.IntN(1000)})
+		for i := range prng.IntN(16) + 2 {
+			subscriber := fixture.addTestSubscriber(t, fmt.Sprintf("player-%d", i), "stream", 8, OverflowDropOldest)
+			subscriber.events["*"] = first
+			assert.True(t, subscriber.matches(match))
+		}
+		assert.Len(t, match.results, 1)
+	})
+}
+
+// -------------------------------------------------------------------------------------------------
+// Per-tick batching smoke tests
+// -------------------------------------------------------------------------------------------------
+// Verifies that batching streams receive the events of a tick in a single message with the tick's
+// height and timestamp, sent every tick even without events, and that other streams are unaffected.
+// -------------------------------------------------------------------------------------------------
+
+func TestService_EventBatching(t *testing.T) {
+	t.Parallel()
+	prng := testutils.NewRand(t)
+	fixture := newServiceFixture(t, prng, false)
+
+	// Events published before the stream started count as received.
+	seq := uint64(prng.IntN(4))
+	for range seq {
+		err := fixture.svc.publishDefaultEvent(event.Event{Kind: event.KindDefault, Payload: testutils.SimpleEvent{}})
+		require.NoError(t, err)
+	}
+	batched := fixture.addTestSubscriber(t, "player", "batched", 8, OverflowDropOldest)
+	batched.batch = true
+	single := fixture.addTestSubscriber(t, "player", "single", 64, OverflowDropOldest)
+
+	for height := range uint64(prng.IntN(4) + 2) {
+		tick := Tick{height: height, timestamp: time.Unix(prng.Int64N(1<<32), 0).UTC()}
+		fixture.world.currentTick = tick
+
+		count := prng.IntN(8)
+		for i := range count {
+			err := fixture.svc.publishDefaultEvent(event.Event{
+				Kind:    event.KindDefault,
+				Payload: testutils.SimpleEvent{Value: i},
+			})
+			require.NoError(t, err)
+		}
+		assert.Empty(t, batched.queue, "batched events should wait for the end of the tick")
+		assert.Len(t, single.queue, count)
+		for range count {
+			<-single.queue
+		}
+
+		fixture.svc.flushEventBatches(tick)
+		require.Len(t, batched.queue, 1)
+		response := <-batched.queue
+		batch := response.GetBatch()
+		require.NotNil(t, batch)
+		assert.Equal(t, tick.height, batch.GetTickHeight())
+		assert.True(t, tick.timestamp.Equal(batch.GetTimestamp().AsTime()))
+		require.Len(t, batch.GetEvents(), count)
+		for i, batchedEvent := range batch.GetEvents() {
+			seq++
+			assert.Equal(t, seq, batchedEvent.GetSequence())
+			decoded, err := testutils.SimpleEvent{}.UnmarshalWire(batchedEvent.GetEvent().GetPayload())
+			require.NoError(t, err)
+			assert.Equal(t, testutils.SimpleEvent{Value: i}, decoded)
+		}
+		// Empty batches carry the sequence of the stream's latest event, so clients can resume after it.
+		assert.Equal(t, seq, response.GetSequence(), "batch sequence should be its last event's")
+		assert.Empty(t, single.queue, "streams that don't batch shouldn't receive batches")
+	}
+}
+
+// addTestSubscriber registers a stream subscribed to all events, without a connection behind it.
+func (f *serviceFixture) addTestSubscriber(
+	t *testing.T, userID, streamID string, queueSize int, overflow OverflowPolicy,
+) *streamSubscriber {
+	t.Helper()
+
+	subscriber := newStreamSubscriber(context.Background(), streamID, nil, queueSize, overflow, f.svc.metrics)
+	subscriber.events["*"] = nil
+
+	f.svc.mu.Lock()
+	defer f.svc.mu.Unlock()
+	subscriber.lastSeq = f.svc.history.latest()
+	if f.svc.subscribers[userID] == nil {
+		f.svc.subscribers[userID] = make(map[string]*streamSubscriber)
+	}
+	f.svc.subscribers[userID][streamID] = subscriber
+	return subscriber
+}
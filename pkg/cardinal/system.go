@@ -3,15 +3,19 @@ package cardinal
 import (
 	"fmt"
 	"iter"
+	"math"
 	"reflect"
+	"runtime"
 	"time"
 
 	"github.com/argus-labs/world-engine/pkg/assert"
 	"github.com/argus-labs/world-engine/pkg/cardinal/internal/command"
 	"github.com/argus-labs/world-engine/pkg/cardinal/internal/ecs"
 	"github.com/argus-labs/world-engine/pkg/cardinal/internal/event"
+	"github.com/argus-labs/world-engine/pkg/cardinal/internal/interest"
 	"github.com/argus-labs/world-engine/pkg/cardinal/internal/performance"
 	"github.com/argus-labs/world-engine/pkg/micro"
+	iscv1 "github.com/argus-labs/world-engine/proto/gen/go/worldengine/isc/v1"
 	"github.com/kelindar/bitmap"
 	"github.com/rotisserie/eris"
 	"github.com/rs/zerolog"
@@ -40,13 +44,21 @@ func RegisterSystem[T any](world *World, system func(*T), opts ...SystemOption)
 	}
 
 	name := fmt.Sprintf("%T", system)
-	fn := func() { system(state) }
+
+	// Every run of the system starts a new stream of random numbers, seeded from the system's name.
+	base := reflect.ValueOf(state).Elem().FieldByName("BaseSystemState").Addr().Interface().(*BaseSystemState)
+	base.system = systemSeedName(world, system, cfg.name)
+	fn := func() {
+		base.rand = nil
+		system(state)
+	}
 
 	// If debug is enabled, wrap the system function with performance instrumentation.
 	if world.debug != nil {
 		fn = func() {
 			ts := world.currentTick.timestamp
 			startTime := ts.Add(time.Since(ts))
+			base.rand = nil
 			system(state)
 			endTime := ts.Add(time.Since(ts))
 			world.debug.recordSpan(performance.TickSpan{
@@ -65,6 +77,31 @@ func RegisterSystem[T any](world *World, system func(*T), opts ...SystemOption)
 	}
 }
 
+// systemSeedName returns the name that seeds a system's random numbers: the name set with
+// WithSystemName, or else the system's function name. Later registrations of the same function, e.g.
+// closures returned by the same function, are numbered to get their own random numbers. Panics if the
+// name set with WithSystemName is already used.
+func systemSeedName(world *World, system any, name string) string {
+	if world.systemNames == nil {
+		world.systemNames = make(map[string]int)
+	}
+	if name != "" {
+		if world.systemNames[name] > 0 {
+			panic(eris.Errorf("system name %q is already registered", name))
+		}
+		world.systemNames[name] = 1
+		return name
+	}
+
+	name = runtime.FuncForPC(reflect.ValueOf(system).Pointer()).Name()
+	n := world.systemNames[name]
+	world.systemNames[name]++
+	if n > 0 {
+		return fmt.Sprintf("%s#%d", name, n)
+	}
+	return name
+}
+
 func initSystemFields[T any](state *T, world *World) error {
 	meta := systemInitMetadata{
 		world:        world,
@@ -117,6 +154,8 @@ type systemField interface {
 var _ systemField = (*BaseSystemState)(nil)
 var _ systemField = (*WithCommand[Command])(nil)
 var _ systemField = (*WithEvent[Event])(nil)
+var _ systemField = (*WithCustomEvent[any])(nil)
+var _ systemField = (*WithInterest[Positioned])(nil)
 var _ systemField = (*WithSystemEventReceiver[ecs.Component])(nil)
 var _ systemField = (*WithSystemEventEmitter[ecs.Component])(nil)
 var _ systemField = (*search[ecs.Component])(nil)
@@ -133,6 +172,9 @@ var _ systemField = (*Exact[ecs.Component])(nil)
 type systemConfig struct {
 	// The hook that determines when the system should be executed.
 	hook ecs.SystemHook
+
+	// The name that seeds the system's random numbers, see WithSystemName.
+	name string
 }
 
 // newSystemConfig creates a new system config with default values.
@@ -164,12 +206,23 @@ func WithHook(hook SystemHook) SystemOption {
 	return func(cfg *systemConfig) { cfg.hook = hook }
 }
 
+// WithSystemName returns an option to set the name that seeds the system's random numbers, see
+// BaseSystemState.Rand. Names must be unique. Without one, the system's function name is used, e.g.
+// main.spawnSystem, which changes when the function is renamed or moved to another package, and for
+// closures, when a closure is added before it. Recorded tick logs only replay if the names stay the same,
+// so systems that use Rand should be named.
+func WithSystemName(name string) SystemOption {
+	return func(cfg *systemConfig) { cfg.name = name }
+}
+
 // -------------------------------------------------------------------------------------------------
 // Base
 // -------------------------------------------------------------------------------------------------
 
 type BaseSystemState struct {
-	world *World
+	world  *World
+	system string // Name that seeds the system's random numbers, see WithSystemName
+	rand   *Rand  // Random numbers of the current run of the system, nil until Rand is called
 }
 
 func (b *BaseSystemState) init(meta *systemInitMetadata) error {
@@ -194,6 +247,23 @@ func (b *BaseSystemState) Timestamp() time.Time {
 	return b.world.currentTick.timestamp
 }
 
+// Rand returns the random numbers of the system in the current tick, seeded from the world's seed, the
+// tick height, and the system's name (see WithSystemName). Every call in the same tick returns the same
+// stream, which continues where the last call left off. Use the substreams of Rand.Entity and
+// Rand.Command for numbers that don't depend on the order entities and commands are processed in.
+//
+// The world's seed is set with WorldOptions.Seed or CARDINAL_SEED, and stored in the snapshots.
+//
+// Example:
+//
+//	damage := 10 + state.Rand().IntN(5)
+func (b *BaseSystemState) Rand() *Rand {
+	if b.rand == nil {
+		b.rand = newRand(deriveSeed(b.world.seed, "system:"+b.system, b.world.currentTick.height))
+	}
+	return b.rand
+}
+
 // -------------------------------------------------------------------------------------------------
 // Commands
 // -------------------------------------------------------------------------------------------------
@@ -238,8 +308,8 @@ func (c *WithCommand[T]) Iter() iter.Seq[CommandContext[T]] {
 	assert.That(err == nil, "command not automatically registered %s", zero.Name())
 
 	return func(yield func(CommandContext[T]) bool) {
-		for _, cmd := range commands {
-			if !yield(newCommandContext[T](cmd)) {
+		for i, cmd := range commands {
+			if !yield(newCommandContext[T](c.manager, cmd, uint64(i))) {
 				return
 			}
 		}
@@ -249,9 +319,14 @@ func (c *WithCommand[T]) Iter() iter.Seq[CommandContext[T]] {
 type CommandContext[T Command] struct {
 	Payload T
 	Persona string
+
+	manager *command.Manager
+	receipt command.ReceiptID
+	replyID command.ReplyID
+	index   uint64 // Position among the commands of its type in the tick
 }
 
-func newCommandContext[T Command](cmd command.Command) CommandContext[T] {
+func newCommandContext[T Command](manager *command.Manager, cmd command.Command, index uint64) CommandContext[T] {
 	// The queue stores the decoded value as a Payload; recover the concrete type. Value semantics —
 	// no pointer, because Serializable is satisfied by the value type (all value receivers).
 	payload, ok := cmd.Payload.(T)
@@ -260,7 +335,48 @@ func newCommandContext[T Command](cmd command.Command) CommandContext[T] {
 	return CommandContext[T]{
 		Payload: payload,
 		Persona: cmd.Persona,
+		manager: manager,
+		receipt: cmd.Receipt,
+		replyID: cmd.ReplyID,
+		index:   index,
+	}
+}
+
+func (c CommandContext[T]) replyTarget() (string, command.ReplyID) {
+	return c.Persona, c.replyID
+}
+
+func (c CommandContext[T]) randKey() (string, uint64) {
+	return c.Payload.Name(), c.index
+}
+
+// Fail marks the command as failed in its receipt, which the sender can look up with the
+// GetCommandReceipt RPC. Commands that aren't failed are marked as processed at the end of the tick.
+// It is a no-op for commands sent without a receipt, e.g. scheduled or inter-shard commands.
+//
+// Example:
+//
+//	if player.Gold < cmdCtx.Payload.Cost {
+//	    cmdCtx.Fail(eris.New("not enough gold"))
+//	    continue
+//	}
+func (c CommandContext[T]) Fail(err error) {
+	assert.That(err != nil, "failure reason must not be nil")
+	c.manager.Fail(c.receipt, err.Error())
+}
+
+// SetResult records result in the command's receipt. Like Fail, it is a no-op for commands sent
+// without a receipt. Calling it again replaces the previous result.
+func (c CommandContext[T]) SetResult(result Event) error {
+	if c.receipt == 0 {
+		return nil
+	}
+	payload, err := result.MarshalWire()
+	if err != nil {
+		return eris.Wrapf(err, "failed to marshal command result %s", result.Name())
 	}
+	c.manager.SetResult(c.receipt, &iscv1.Event{Name: result.Name(), Payload: payload})
+	return nil
 }
 
 // -------------------------------------------------------------------------------------------------
@@ -303,6 +419,57 @@ func (b *BaseSystemState) SendToShard(to OtherWorld, cmd command.Payload) {
 	})
 }
 
+// -------------------------------------------------------------------------------------------------
+// Scheduled Commands
+// -------------------------------------------------------------------------------------------------
+
+// ScheduleHandle identifies a scheduled command. It is used to cancel the command before it runs.
+type ScheduleHandle = command.ScheduleHandle
+
+// Schedule schedules cmd to be processed by this shard after delay, rounded up to whole ticks. It is
+// the in-engine counterpart of the ScheduleCommand RPC and is useful for timers, e.g. cooldowns or
+// building upgrades. The command is sent by this shard (the persona is the shard address) and is
+// processed by whichever system has a WithCommand field for it. Scheduled commands are persisted in
+// snapshots, so they survive restarts.
+//
+// Example:
+//
+//	handle, err := state.Schedule(5*time.Second, UpgradeComplete{BuildingID: id})
+//	if err != nil {
+//	    state.Logger().Error().Err(err).Msg("failed to schedule upgrade")
+//	}
+func (b *BaseSystemState) Schedule(delay time.Duration, cmd Command) (ScheduleHandle, error) {
+	commandPb, err := b.world.selfCommand(cmd)
+	if err != nil {
+		return 0, err
+	}
+	handle, _, err := b.world.commands.ScheduleIn(commandPb, b.world.delayTicks(delay))
+	if err != nil {
+		return 0, eris.Wrapf(err, "failed to schedule command %s", cmd.Name())
+	}
+	return handle, nil
+}
+
+// ScheduleAt is like Schedule, but processes cmd at the given tick. A tick that has already been
+// processed is treated as the next tick.
+func (b *BaseSystemState) ScheduleAt(tick uint64, cmd Command) (ScheduleHandle, error) {
+	commandPb, err := b.world.selfCommand(cmd)
+	if err != nil {
+		return 0, err
+	}
+	handle, _, err := b.world.commands.Schedule(commandPb, tick)
+	if err != nil {
+		return 0, eris.Wrapf(err, "failed to schedule command %s", cmd.Name())
+	}
+	return handle, nil
+}
+
+// CancelScheduled cancels a scheduled command, including ones scheduled by clients. Returns false if
+// the command has already been processed or cancelled.
+func (b *BaseSystemState) CancelScheduled(handle ScheduleHandle) bool {
+	return b.world.commands.Cancel(handle) == nil
+}
+
 // -------------------------------------------------------------------------------------------------
 // Events
 // -------------------------------------------------------------------------------------------------
@@ -311,6 +478,7 @@ type Event = event.Payload
 
 type WithEvent[T Event] struct {
 	manager *event.Manager
+	logger  zerolog.Logger
 }
 
 func (e *WithEvent[T]) init(meta *systemInitMetadata) error {
@@ -328,6 +496,7 @@ func (e *WithEvent[T]) init(meta *systemInitMetadata) error {
 	meta.events[name] = struct{}{} // Add to system events set for duplicate field check
 
 	e.manager = &meta.world.events
+	e.logger = meta.world.tel.GetLogger("system")
 	return nil
 }
 
@@ -338,6 +507,54 @@ func (e *WithEvent[T]) Broadcast(evt T) {
 	})
 }
 
+// BroadcastAt enqueues an event that is delivered only to the clients whose interest region contains
+// pos, instead of to every subscriber like Broadcast. A client's interest region is set by the entity
+// its persona controls, see WithInterest. Clients without an interest region don't receive the event.
+// Events at a NaN or infinite position, or with a coordinate beyond ±2^40, are dropped.
+//
+// Example:
+//
+//	state.Explosions.BroadcastAt(cardinal.Position{X: pos.X, Y: pos.Y}, Explosion{Damage: 10})
+func (e *WithEvent[T]) BroadcastAt(pos Position, evt T) {
+	if !interest.ValidPosition(pos) {
+		e.logger.Warn().Str("event", evt.Name()).Float64("x", pos.X).Float64("y", pos.Y).
+			Msg("dropped event broadcast at an invalid position")
+		return
+	}
+	e.manager.Enqueue(event.Event{
+		Kind:     event.KindDefault,
+		Payload:  evt,
+		Position: &pos,
+	})
+}
+
+// Replyable is a command that can be replied to with WithEvent.Reply. It is implemented by
+// CommandContext.
+type Replyable interface {
+	replyTarget() (persona string, replyID command.ReplyID)
+}
+
+// Reply sends evt as the reply to cmd. If cmd was sent with SendCommandWithReply, the event is
+// returned to that request only, and isn't delivered to any event stream. Otherwise, e.g. the command
+// was sent with SendCommand or the request has already timed out, the event is sent to the command's
+// sender like SendTo.
+//
+// Example:
+//
+//	for cmd := range state.BuyItemCmds.Iter() {
+//	    state.BuyItemResults.Reply(cmd, BuyItemResult{OK: true})
+//	}
+func (e *WithEvent[T]) Reply(cmd Replyable, evt T) {
+	persona, replyID := cmd.replyTarget()
+	assert.That(persona != "", "command has empty persona")
+	e.manager.Enqueue(event.Event{
+		Kind:      event.KindDefault,
+		Payload:   evt,
+		Recipient: persona,
+		ReplyTo:   replyID,
+	})
+}
+
 // SendTo enqueues a targeted event that is delivered only to the named recipient (a user ID),
 // provided they have an open event stream subscribed to this event. If the recipient has no open
 // stream, the event is silently dropped.
@@ -354,6 +571,180 @@ func (e *WithEvent[T]) SendTo(recipient string, evt T) {
 	})
 }
 
+// -------------------------------------------------------------------------------------------------
+// Custom Events
+// -------------------------------------------------------------------------------------------------
+
+// EventHandler handles the events of a custom event kind. It is called on the tick goroutine at the
+// end of every tick, after the systems run, with the height of the tick that emitted evt. Handlers
+// that do slow work, e.g. calling a webhook, should hand it off to another goroutine to avoid
+// stalling the tick. Returned errors are logged and don't stop other events from being dispatched.
+type EventHandler[T any] func(tick uint64, evt T) error
+
+// RegisterEventKind registers a custom event kind with payload type T, whose events are dispatched to
+// handler instead of to event streams. Systems emit them with a WithCustomEvent[T] field, so it must
+// be called before the systems that use the field are registered. Panics if T is already registered
+// or there are no event kinds left, consistent with other registration functions.
+//
+// Example:
+//
+//	type AuditEntry struct{ Persona, Action string }
+//
+//	func (p *AuditPlugin) Register(world *cardinal.World) {
+//	    cardinal.RegisterEventKind(world, func(tick uint64, entry AuditEntry) error {
+//	        p.log.Info().Uint64("tick", tick).Str("persona", entry.Persona).Msg(entry.Action)
+//	        return nil
+//	    })
+//	    cardinal.RegisterSystem(world, AuditedSystem)
+//	}
+func RegisterEventKind[T any](world *World, handler EventHandler[T]) {
+	assert.That(handler != nil, "event handler must not be nil")
+
+	payloadType := reflect.TypeFor[T]()
+	if _, ok := world.eventKinds[payloadType]; ok {
+		panic(eris.Errorf("event kind %s is already registered", payloadType))
+	}
+	if int(event.KindCustom)+len(world.eventKinds) > math.MaxUint8 {
+		panic(eris.Errorf("cannot register event kind %s, all event kinds are in use", payloadType))
+	}
+	kind := event.KindCustom + event.Kind(len(world.eventKinds))
+	world.eventKinds[payloadType] = kind
+
+	world.events.RegisterHandler(kind, func(evt event.Event) error {
+		payload, ok := evt.Payload.(T)
+		assert.That(ok, "custom event payload doesn't match its kind")
+		if err := handler(world.currentTick.height, payload); err != nil {
+			return eris.Wrapf(err, "failed to handle %s event", payloadType)
+		}
+		return nil
+	})
+}
+
+// WithCustomEvent is a system state field that emits events of a custom event kind, which are
+// dispatched to the handler registered with RegisterEventKind at the end of the tick.
+//
+// Example:
+//
+//	type TradeSystemState struct {
+//	    cardinal.BaseSystemState
+//	    Audit cardinal.WithCustomEvent[AuditEntry]
+//	    // Other fields...
+//	}
+//
+//	func TradeSystem(state *TradeSystemState) {
+//	    state.Audit.Emit(AuditEntry{Persona: persona, Action: "trade"})
+//	}
+type WithCustomEvent[T any] struct {
+	manager *event.Manager
+	kind    event.Kind
+}
+
+func (e *WithCustomEvent[T]) init(meta *systemInitMetadata) error {
+	payloadType := reflect.TypeFor[T]()
+	kind, ok := meta.world.eventKinds[payloadType]
+	if !ok {
+		return eris.Errorf("event kind %s isn't registered, call RegisterEventKind before RegisterSystem",
+			payloadType)
+	}
+	e.manager = &meta.world.events
+	e.kind = kind
+	return nil
+}
+
+// Emit enqueues an event for the kind's handler.
+func (e *WithCustomEvent[T]) Emit(evt T) {
+	e.manager.Enqueue(event.Event{
+		Kind:    e.kind,
+		Payload: evt,
+	})
+}
+
+// -------------------------------------------------------------------------------------------------
+// Interest
+// -------------------------------------------------------------------------------------------------
+
+// WithInterest is a system state field that ties a persona's interest region to an entity it
+// controls, for area-of-interest events sent with WithEvent.BroadcastAt. The region is a circle around
+// the entity's T component position, and follows the entity as it moves, updated every tick. A world
+// can only use one position component type for interest management.
+//
+// Controlled entities aren't part of the world's snapshot, so systems should take control again
+// after a restart, e.g. when the player reconnects.
+//
+// Example:
+//
+//	type SpawnSystemState struct {
+//	    cardinal.BaseSystemState
+//	    SpawnCmds cardinal.WithCommand[Spawn]
+//	    Interest  cardinal.WithInterest[Transform]
+//	    // Other fields...
+//	}
+//
+//	func SpawnSystem(state *SpawnSystemState) {
+//	    for cmd := range state.SpawnCmds.Iter() {
+//	        entity, player := state.Players.Create()
+//	        // Set up the player...
+//	        state.Interest.Control(cmd.Persona, entity, 50)
+//	    }
+//	}
+type WithInterest[T Positioned] struct {
+	manager *interestManager
+	logger  zerolog.Logger
+}
+
+func (i *WithInterest[T]) init(meta *systemInitMetadata) error {
+	var zero T
+	name := zero.Name()
+
+	manager := meta.world.interest
+	if manager.component != "" && manager.component != name {
+		return eris.Errorf("interest is already tracked by component %s, cannot track %s", manager.component, name)
+	}
+	if _, err := ecs.RegisterComponent[T](meta.world.world); err != nil {
+		return eris.Wrapf(err, "failed to register component %s", name)
+	}
+
+	world := meta.world.world
+	manager.component = name
+	manager.position = func(eid EntityID) (Position, bool) {
+		component, err := ecs.Get[T](world, eid)
+		if err != nil {
+			return Position{}, false
+		}
+		return component.WorldPosition(), true
+	}
+	i.manager = manager
+	i.logger = meta.world.tel.GetLogger("system")
+	return nil
+}
+
+// Control makes the entity the one persona controls, with an interest region of the given radius
+// around the entity's position. It replaces the entity the persona controlled before, if any. The
+// region takes effect at the end of the current tick. The radius can be at most 16 times the world's
+// InterestCellSize, larger radii are capped with a warning. A radius that isn't positive gives the
+// persona no interest region.
+func (i *WithInterest[T]) Control(persona string, entity EntityID, radius float64) {
+	assert.That(persona != "", "persona must not be empty")
+	maxRadius := i.manager.grid.MaxRadius()
+	switch {
+	case !(radius > 0):
+		i.logger.Warn().Str("persona", persona).Float64("radius", radius).
+			Msg("interest radius isn't positive, the persona has no interest region")
+	case radius > maxRadius:
+		i.logger.Warn().Str("persona", persona).Float64("radius", radius).Float64("max_radius", maxRadius).
+			Msg("interest radius is too large, capping it")
+		radius = maxRadius
+	}
+	i.manager.controls[persona] = interestControl{entity: entity, radius: radius}
+}
+
+// Release removes the persona's controlled entity and interest region, so it stops receiving
+// area-of-interest events.
+func (i *WithInterest[T]) Release(persona string) {
+	delete(i.manager.controls, persona)
+	i.manager.grid.Remove(persona)
+}
+
 // -------------------------------------------------------------------------------------------------
 // System Events
 // -------------------------------------------------------------------------------------------------
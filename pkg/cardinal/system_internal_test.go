@@ -1,14 +1,19 @@
 package cardinal
 
 import (
+	"reflect"
+	"strconv"
 	"testing"
+	"time"
 
 	"github.com/argus-labs/world-engine/pkg/cardinal/internal/command"
 	"github.com/argus-labs/world-engine/pkg/cardinal/internal/ecs"
 	"github.com/argus-labs/world-engine/pkg/cardinal/internal/event"
+	"github.com/argus-labs/world-engine/pkg/cardinal/snapshot"
 	"github.com/argus-labs/world-engine/pkg/testutils"
 	iscv1 "github.com/argus-labs/world-engine/proto/gen/go/worldengine/isc/v1"
 	microv1 "github.com/argus-labs/world-engine/proto/gen/go/worldengine/micro/v1"
+	"github.com/rotisserie/eris"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -85,6 +90,40 @@ func TestWithCommand_Smoke(t *testing.T) {
 		}
 		assert.Equal(t, 1, count)
 	})
+
+	t.Run("receipts", func(t *testing.T) {
+		t.Parallel()
+		prng := testutils.NewRand(t)
+		fixture := newCommandFixture(t)
+
+		failed := fixture.enqueueCommandWithReceipt(t, testutils.SimpleCommand{Value: 1}, "player")
+		processed := fixture.enqueueCommandWithReceipt(t, testutils.SimpleCommand{Value: 2}, "player")
+		fixture.world.commands.Drain()
+
+		result := testutils.SimpleEvent{Value: prng.Int()}
+		for ctx := range fixture.Command.Iter() {
+			if ctx.Payload.Value == 1 {
+				ctx.Fail(eris.New("rejected"))
+				continue
+			}
+			require.NoError(t, ctx.SetResult(result))
+		}
+		fixture.world.commands.Settle()
+
+		receipt, err := fixture.world.commands.Receipt(failed, "player")
+		require.NoError(t, err)
+		assert.Equal(t, command.ReceiptFailed, receipt.Status)
+		assert.Equal(t, "rejected", receipt.Error)
+
+		receipt, err = fixture.world.commands.Receipt(processed, "player")
+		require.NoError(t, err)
+		assert.Equal(t, command.ReceiptProcessed, receipt.Status)
+		require.NotNil(t, receipt.Result)
+		assert.Equal(t, result.Name(), receipt.Result.GetName())
+		decoded, err := testutils.SimpleEvent{}.UnmarshalWire(receipt.Result.GetPayload())
+		require.NoError(t, err)
+		assert.Equal(t, result, decoded)
+	})
 }
 
 type commandFixture struct {
@@ -98,7 +137,7 @@ func newCommandFixture(t *testing.T) *commandFixture {
 	world := &World{
 		commands: command.NewManager(),
 	}
-	world.service = newService(world, AuthModeDev, "")
+	world.service = newService(world, newDefaultWorldOptions())
 
 	fixture := &commandFixture{world: world}
 
@@ -127,6 +166,25 @@ func (f *commandFixture) enqueueCommand(t *testing.T, payload command.Payload, p
 	require.NoError(t, err)
 }
 
+// enqueueCommandWithReceipt is like enqueueCommand, but also issues a receipt for the command.
+func (f *commandFixture) enqueueCommandWithReceipt(
+	t *testing.T, payload command.Payload, persona string,
+) command.ReceiptID {
+	t.Helper()
+
+	bytes, err := payload.MarshalWire()
+	require.NoError(t, err)
+
+	receipt, _, err := f.world.commands.EnqueueWithReceipt(&iscv1.Command{
+		Name:    payload.Name(),
+		Address: &microv1.ServiceAddress{},
+		Persona: &iscv1.Persona{Id: persona},
+		Payload: bytes,
+	})
+	require.NoError(t, err)
+	return receipt
+}
+
 // -------------------------------------------------------------------------------------------------
 // WithEvent smoke tests
 // -------------------------------------------------------------------------------------------------
@@ -169,6 +227,29 @@ func TestWithEvent_Smoke(t *testing.T) {
 		}
 	})
 
+	t.Run("reply", func(t *testing.T) {
+		t.Parallel()
+		prng := testutils.NewRand(t)
+		fixture := newEventFixture(t)
+
+		cmd := CommandContext[testutils.SimpleCommand]{Persona: "player", replyID: command.ReplyID(prng.Uint64())}
+		evt := testutils.SimpleEvent{Value: prng.Int()}
+		fixture.Event.Reply(cmd, evt)
+
+		var collected []event.Event
+		fixture.world.events.RegisterHandler(event.KindDefault, func(evt event.Event) error {
+			collected = append(collected, evt)
+			return nil
+		})
+		err := fixture.world.events.Dispatch()
+		require.NoError(t, err)
+
+		require.Len(t, collected, 1)
+		assert.Equal(t, evt, collected[0].Payload)
+		assert.Equal(t, "player", collected[0].Recipient)
+		assert.Equal(t, cmd.replyID, collected[0].ReplyTo)
+	})
+
 	t.Run("emit empty", func(t *testing.T) {
 		t.Parallel()
 		fixture := newEventFixture(t)
@@ -206,6 +287,76 @@ func newEventFixture(t *testing.T) *eventFixture {
 	return fixture
 }
 
+// -------------------------------------------------------------------------------------------------
+// Custom event smoke tests
+// -------------------------------------------------------------------------------------------------
+// Custom event kinds reuse event.Manager, which is already tested. Here, we check that each
+// registered kind is dispatched to its own handler with the emitting tick, and that fields of
+// unregistered kinds fail to initialize.
+// -------------------------------------------------------------------------------------------------
+
+func TestWithCustomEvent_Smoke(t *testing.T) {
+	t.Parallel()
+
+	type auditEntry struct{ Action string }
+	type metricSample struct{ Value float64 }
+
+	t.Run("dispatched to handler", func(t *testing.T) {
+		t.Parallel()
+		prng := testutils.NewRand(t)
+		world := &World{
+			events:      event.NewManager(1024),
+			eventKinds:  make(map[reflect.Type]event.Kind),
+			currentTick: Tick{height: prng.Uint64()},
+		}
+
+		var audits []auditEntry
+		var samples []metricSample
+		RegisterEventKind(world, func(tick uint64, entry auditEntry) error {
+			assert.Equal(t, world.currentTick.height, tick)
+			audits = append(audits, entry)
+			return nil
+		})
+		RegisterEventKind(world, func(_ uint64, sample metricSample) error {
+			samples = append(samples, sample)
+			return nil
+		})
+		assert.Panics(t, func() {
+			RegisterEventKind(world, func(uint64, auditEntry) error { return nil })
+		}, "registering a kind twice should panic")
+
+		state := &struct {
+			Audit   WithCustomEvent[auditEntry]
+			Metrics WithCustomEvent[metricSample]
+		}{}
+		meta := &systemInitMetadata{world: world}
+		require.NoError(t, state.Audit.init(meta))
+		require.NoError(t, state.Metrics.init(meta))
+
+		model := make([]auditEntry, prng.IntN(100))
+		for i := range model {
+			model[i] = auditEntry{Action: strconv.Itoa(prng.Int())}
+			state.Audit.Emit(model[i])
+		}
+		state.Metrics.Emit(metricSample{Value: prng.Float64()})
+
+		require.NoError(t, world.events.Dispatch())
+		assert.Equal(t, len(model), len(audits))
+		for i := range audits {
+			assert.Equal(t, model[i], audits[i], "event mismatch at index %d", i)
+		}
+		assert.Len(t, samples, 1)
+	})
+
+	t.Run("unregistered kind", func(t *testing.T) {
+		t.Parallel()
+		world := &World{events: event.NewManager(1024), eventKinds: make(map[reflect.Type]event.Kind)}
+
+		var field WithCustomEvent[auditEntry]
+		assert.Error(t, field.init(&systemInitMetadata{world: world}))
+	})
+}
+
 // -------------------------------------------------------------------------------------------------
 // WithSystemEvent smoke tests
 // -------------------------------------------------------------------------------------------------
@@ -540,3 +691,149 @@ func newSearchFixture(t *testing.T) *searchFixture {
 
 	return fixture
 }
+
+// -------------------------------------------------------------------------------------------------
+// Rand smoke tests
+// -------------------------------------------------------------------------------------------------
+// Verifies that the random numbers of systems only depend on the world's seed, the tick, and the
+// system, and that the substreams of entities and commands don't depend on the numbers taken from
+// the system's stream before them. Systems with the same state type, and closures of the same function,
+// must draw different numbers, and a system named with WithSystemName draws the same numbers whatever
+// its function is.
+// -------------------------------------------------------------------------------------------------
+
+type randTestState struct {
+	BaseSystemState
+	Command WithCommand[testutils.SimpleCommand]
+}
+
+// randTestDraws are the numbers the systems of a rand test world took in a tick.
+type randTestDraws struct {
+	System   uint64    // First number of the system's stream
+	Other    uint64    // First number of the stream of the other system with the same state type
+	Closures [2]uint64 // First number of the streams of two closures of the same function
+	Named    uint64    // First number of the stream of the named system, whose function differs with extra
+	Entity   uint64    // First number of the substream of entity 7
+	Commands []uint64  // First number of the substream of each command
+}
+
+func TestBaseSystemState_Rand(t *testing.T) {
+	t.Setenv("LOG_LEVEL", "disabled")
+	prng := testutils.NewRand(t)
+
+	seed := prng.Uint64()>>1 + 1 // Seeds can't be 0
+	values := make([][]int, 10)
+	for tick := range values {
+		for range prng.IntN(4) {
+			values[tick] = append(values[tick], prng.IntN(1000))
+		}
+	}
+
+	draws := runRandTest(t, seed, values, false)
+	assert.Equal(t, draws, runRandTest(t, seed, values, false), "same seed should draw the same numbers")
+	assert.Equal(t, draws, runRandTest(t, seed, values, true),
+		"substreams shouldn't depend on the numbers taken before them")
+
+	other := runRandTest(t, seed+1, values, false)
+	for tick := range draws {
+		assert.NotEqual(t, draws[tick].System, other[tick].System, "tick %d", tick)
+		assert.NotEqual(t, draws[tick].System, draws[tick].Other, "tick %d", tick)
+		assert.NotEqual(t, draws[tick].Closures[0], draws[tick].Closures[1], "tick %d", tick)
+		if tick > 0 {
+			assert.NotEqual(t, draws[tick-1].System, draws[tick].System, "tick %d", tick)
+		}
+	}
+
+	// Seed 0 marks snapshots without a seed, so it can't be configured.
+	zero := uint64(0)
+	_, err := NewWorld(WorldOptions{
+		Region:              "rand",
+		Organization:        "rand",
+		Project:             "rand",
+		ShardID:             "0",
+		TickRate:            1,
+		SnapshotStorageType: snapshot.StorageTypeNop,
+		SnapshotRate:        1,
+		Seed:                &zero,
+	})
+	require.ErrorContains(t, err, "seed cannot be 0")
+}
+
+// runRandTest runs a world with the given seed for a tick per element of values, sending a command
+// with each value, and returns the numbers its systems drew in each tick. If extra is set, the system
+// takes a number from its stream before the substreams instead of after them, and takes the numbers of
+// the commands in reverse order.
+func runRandTest(t *testing.T, seed uint64, values [][]int, extra bool) []randTestDraws {
+	t.Helper()
+	ctx := t.Context()
+
+	debug := false
+	w, err := NewWorld(WorldOptions{
+		Region:              "rand",
+		Organization:        "rand",
+		Project:             "rand",
+		ShardID:             "0",
+		TickRate:            1,
+		SnapshotStorageType: snapshot.StorageTypeNop,
+		SnapshotRate:        1,
+		Debug:               &debug,
+		Seed:                &seed,
+	})
+	require.NoError(t, err)
+
+	draws := make([]randTestDraws, len(values))
+	RegisterSystem(w, func(state *randTestState) {
+		d := &draws[state.Tick()]
+		if extra {
+			d.System = state.Rand().Uint64()
+		}
+		var commands []CommandContext[testutils.SimpleCommand]
+		for cmd := range state.Command.Iter() {
+			commands = append(commands, cmd)
+		}
+		d.Commands = make([]uint64, len(commands))
+		for i := range commands {
+			if extra {
+				i = len(commands) - 1 - i
+			}
+			d.Commands[i] = state.Rand().Command(commands[i]).Uint64()
+		}
+		d.Entity = state.Rand().Entity(7).Uint64()
+		if !extra {
+			d.System = state.Rand().Uint64()
+		}
+	})
+	RegisterSystem(w, func(state *randTestState) {
+		draws[state.Tick()].Other = state.Rand().Uint64()
+	})
+	closure := func(i int) func(state *randTestState) {
+		return func(state *randTestState) {
+			draws[state.Tick()].Closures[i] = state.Rand().Uint64()
+		}
+	}
+	RegisterSystem(w, closure(0))
+	RegisterSystem(w, closure(1))
+	if extra {
+		RegisterSystem(w, func(state *randTestState) {
+			draws[state.Tick()].Named = state.Rand().Uint64()
+		}, WithSystemName("named"))
+	} else {
+		RegisterSystem(w, func(state *randTestState) {
+			draws[state.Tick()].Named = state.Rand().Uint64()
+		}, WithSystemName("named"))
+	}
+	assert.Panics(t, func() {
+		RegisterSystem(w, func(*randTestState) {}, WithSystemName("named"))
+	}, "system names must be unique")
+	w.world.Init()
+
+	for tick, tickValues := range values {
+		for _, value := range tickValues {
+			cmd, err := w.selfCommand(testutils.SimpleCommand{Value: value})
+			require.NoError(t, err)
+			require.NoError(t, w.commands.Enqueue(cmd))
+		}
+		w.Tick(ctx, time.Unix(int64(tick), 0))
+	}
+	return draws
+}
@@ -0,0 +1,279 @@
+package cardinal
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/argus-labs/world-engine/pkg/cardinal/internal/command"
+	"github.com/argus-labs/world-engine/pkg/cardinal/ticklog"
+	iscv1 "github.com/argus-labs/world-engine/proto/gen/go/worldengine/isc/v1"
+	"github.com/caarlos0/env/v11"
+	"github.com/rotisserie/eris"
+	"github.com/rs/zerolog"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// tickLog records the header and the ordered commands of every tick to the tick log store. Ticks are
+// grouped into epochs of consecutive ticks, which are hash-chained and appended to the store on a
+// background goroutine, so a slow store doesn't stall the tick. Epochs are never dropped, as a gap in
+// the chain would make every later tick unreplayable: failed appends are retried until they succeed,
+// and if the queue fills up while the store is unavailable, the tick waits for it.
+//
+// Environment variables:
+//
+//	CARDINAL_TICK_LOG_EPOCH_TICKS=<count>   # Number of ticks per epoch
+//	CARDINAL_TICK_LOG_QUEUE=<count>         # Number of epochs buffered for appending
+//	CARDINAL_TICK_LOG_RETRY_WAIT=<dur>      # Wait before the first retry, doubled every retry
+//	CARDINAL_TICK_LOG_MAX_RETRY_WAIT=<dur>  # Maximum wait between retries
+type tickLog struct {
+	store        ticklog.Store
+	epochTicks   int
+	queue        chan *iscv1.Epoch
+	retryWait    time.Duration
+	maxRetryWait time.Duration
+	log          zerolog.Logger
+	wg           sync.WaitGroup
+	ctx          context.Context // Canceled when close gives up, which aborts appending
+	cancel       context.CancelFunc
+
+	// Only used by the tick.
+	nextEpoch uint64        // Height of the next epoch
+	pending   []*iscv1.Tick // Ticks of the next epoch
+
+	// Only used by the writer goroutine.
+	prevHash []byte // Hash of the last epoch
+}
+
+// newTickLog continues the epoch chain of the store and starts appending.
+func newTickLog(ctx context.Context, store ticklog.Store, log zerolog.Logger) (*tickLog, error) {
+	opts := tickLogOptions{}
+	if err := env.Parse(&opts); err != nil {
+		return nil, eris.Wrap(err, "failed to parse env")
+	}
+	if err := opts.validate(); err != nil {
+		return nil, eris.Wrap(err, "invalid tick log options")
+	}
+
+	l := &tickLog{
+		store:        store,
+		epochTicks:   opts.EpochTicks,
+		queue:        make(chan *iscv1.Epoch, opts.QueueSize),
+		retryWait:    opts.RetryWait,
+		maxRetryWait: opts.MaxRetryWait,
+		log:          log,
+	}
+	last, err := store.Last(ctx)
+	switch {
+	case err == nil:
+		l.nextEpoch = last.GetEpochHeight() + 1
+		l.prevHash = last.GetHash()
+	case eris.Is(err, ticklog.ErrEpochNotFound):
+	default:
+		return nil, eris.Wrap(err, "failed to load the last epoch of the tick log")
+	}
+
+	l.ctx, l.cancel = context.WithCancel(context.Background())
+	l.wg.Add(1)
+	go l.run()
+	return l, nil
+}
+
+// record adds a tick, the commands drained for it, the changes clients made to the schedule before
+// it, and the hash of the state after it to the next epoch.
+func (l *tickLog) record(
+	tick Tick, commands []command.Command, scheduleChanges []*iscv1.ScheduleChange, stateHash uint64,
+) {
+	if l == nil {
+		return
+	}
+
+	// Epochs only hold consecutive ticks, so the epoch is sealed if the tick height jumped, e.g. after
+	// a reset.
+	if n := len(l.pending); n > 0 && l.pending[n-1].GetHeader().GetTickHeight()+1 != tick.height {
+		l.sealBlocking()
+	}
+
+	data := &iscv1.TickData{
+		Commands:        make([]*iscv1.Command, 0, len(commands)),
+		ScheduleChanges: scheduleChanges,
+	}
+	for i := range commands {
+		pb, err := commands[i].ToProto()
+		if err != nil {
+			// The command was decoded from its payload, so this only fails for a broken codec. The tick
+			// is marked, so replaying it fails instead of silently diverging.
+			l.log.Error().Err(err).Uint64("tick", tick.height).Str("command", commands[i].Name).
+				Msg("failed to record command in tick log, the tick can't be replayed")
+			data.UnrecordedCommands = append(data.UnrecordedCommands, commands[i].Name)
+			continue
+		}
+		data.Commands = append(data.Commands, pb)
+	}
+	l.pending = append(l.pending, &iscv1.Tick{
+		Header: &iscv1.TickHeader{
+			TickHeight: tick.height,
+			Timestamp:  timestamppb.New(tick.timestamp),
+			StateHash:  stateHash,
+		},
+		Data: data,
+	})
+	if len(l.pending) >= l.epochTicks {
+		l.sealBlocking()
+	}
+}
+
+// flush seals the pending ticks before their epoch is full. It's called when a snapshot is taken, so
+// the ticks up to the snapshot are appended even if the shard crashes before the epoch fills up, and
+// the ticks after the snapshot can be replayed from it.
+func (l *tickLog) flush() {
+	if l == nil {
+		return
+	}
+	l.sealBlocking()
+}
+
+// sealBlocking seals the pending ticks, waiting for the store as long as it takes. See seal.
+func (l *tickLog) sealBlocking() {
+	_ = l.seal(context.Background()) // Never fails, as the context is never done
+}
+
+// seal queues the pending ticks as the next epoch. If the queue is full because the store can't keep
+// up, it blocks until the store catches up, which holds back the tick, or until ctx is done, in which
+// case the epoch is lost and an error is returned.
+func (l *tickLog) seal(ctx context.Context) error {
+	if len(l.pending) == 0 {
+		return nil
+	}
+	epoch := &iscv1.Epoch{EpochHeight: l.nextEpoch, Ticks: l.pending}
+	l.nextEpoch++
+	l.pending = nil
+
+	select {
+	case l.queue <- epoch:
+		return nil
+	default:
+	}
+	l.log.Warn().Uint64("epoch", epoch.GetEpochHeight()).
+		Msg("tick log queue is full, waiting for the store to catch up")
+	select {
+	case l.queue <- epoch:
+		return nil
+	case <-ctx.Done():
+		return eris.Wrapf(ctx.Err(), "epoch %d wasn't queued", epoch.GetEpochHeight())
+	}
+}
+
+// run appends the queued epochs until the tick log is closed. Once appending is aborted, the rest are
+// skipped, as appending an epoch after one that wasn't appended would leave a gap in the chain.
+func (l *tickLog) run() {
+	defer l.wg.Done()
+	for epoch := range l.queue {
+		if l.ctx.Err() != nil {
+			continue
+		}
+		l.append(epoch)
+	}
+}
+
+// append chains an epoch to the previous one and appends it, retrying with exponential backoff until
+// it's appended or the tick log is closed. An attempt that timed out may have stored the epoch anyway,
+// so the same epoch may be stored twice, which readers skip (see ticklog.Verify).
+func (l *tickLog) append(epoch *iscv1.Epoch) {
+	hash, err := ticklog.Hash(l.prevHash, epoch)
+	if err != nil {
+		// Hashing only fails for an epoch that doesn't marshal, which no retry fixes.
+		l.log.Error().Err(err).Uint64("epoch", epoch.GetEpochHeight()).Msg("failed to hash epoch")
+		return
+	}
+	epoch.Hash = hash
+	l.prevHash = hash
+
+	wait := l.retryWait
+	for attempt := 1; ; attempt++ {
+		ctx, cancel := context.WithTimeout(l.ctx, 5*time.Second)
+		err = l.store.Append(ctx, epoch)
+		cancel()
+		if err == nil {
+			return
+		}
+		l.log.Warn().Err(err).Int("attempt", attempt).Uint64("epoch", epoch.GetEpochHeight()).
+			Msg("failed to append epoch to tick log, retrying")
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-l.ctx.Done():
+			timer.Stop()
+			l.log.Error().Uint64("epoch", epoch.GetEpochHeight()).
+				Msg("tick log closed before the epoch was appended")
+			return
+		}
+		wait = min(wait*2, l.maxRetryWait)
+	}
+}
+
+// close seals the pending ticks, stops accepting epochs, and waits for the queued ones to be appended.
+// If ctx is done first, including while the pending ticks wait for room in the queue, the appends are
+// aborted. The epochs that weren't appended are lost, but the chain stays intact, as the next tick log
+// continues from the last stored epoch.
+func (l *tickLog) close(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+	sealErr := l.seal(ctx)
+	close(l.queue)
+	if sealErr != nil {
+		l.cancel()
+		return eris.Wrapf(sealErr, "tick log closed with %d unappended epochs", len(l.queue)+1)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		l.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		l.cancel()
+		return nil
+	case <-ctx.Done():
+		l.cancel()
+		return eris.Wrapf(ctx.Err(), "tick log closed with %d unappended epochs", len(l.queue))
+	}
+}
+
+// -------------------------------------------------------------------------------------------------
+// Options
+// -------------------------------------------------------------------------------------------------
+
+type tickLogOptions struct {
+	// Number of ticks per epoch. The ticks of an unfinished epoch are lost if the shard crashes, except
+	// that taking a snapshot seals the epoch early.
+	EpochTicks int `env:"CARDINAL_TICK_LOG_EPOCH_TICKS" envDefault:"10"`
+
+	// Number of epochs buffered for appending. The tick waits for the store when the buffer is full.
+	QueueSize int `env:"CARDINAL_TICK_LOG_QUEUE" envDefault:"1024"`
+
+	// Wait before the first retry, doubled after every retry.
+	RetryWait time.Duration `env:"CARDINAL_TICK_LOG_RETRY_WAIT" envDefault:"100ms"`
+
+	// Maximum wait between retries.
+	MaxRetryWait time.Duration `env:"CARDINAL_TICK_LOG_MAX_RETRY_WAIT" envDefault:"10s"`
+}
+
+func (opt *tickLogOptions) validate() error {
+	if opt.EpochTicks <= 0 {
+		return eris.New("tick log epoch ticks must be greater than 0")
+	}
+	if opt.QueueSize <= 0 {
+		return eris.New("tick log queue size must be greater than 0")
+	}
+	if opt.RetryWait <= 0 {
+		return eris.New("tick log retry wait must be greater than 0")
+	}
+	if opt.MaxRetryWait < opt.RetryWait {
+		return eris.New("tick log max retry wait cannot be less than the retry wait")
+	}
+	return nil
+}
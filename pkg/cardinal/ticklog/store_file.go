@@ -0,0 +1,259 @@
+package ticklog
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"iter"
+	"os"
+	"path/filepath"
+	"sync"
+
+	iscv1 "github.com/argus-labs/world-engine/proto/gen/go/worldengine/isc/v1"
+	"github.com/caarlos0/env/v11"
+	"github.com/rotisserie/eris"
+	"github.com/rs/zerolog"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	defaultFileLogDir = "ticklog"
+	fileLogName       = "epochs.log"
+
+	// recordHeaderSize is the size of the header of every record: the length of the epoch, followed by
+	// its CRC-32C checksum, both big-endian uint32.
+	recordHeaderSize = 8
+
+	// maxRecordSize bounds the length read from a record header, so a corrupted header isn't trusted to
+	// allocate gigabytes.
+	maxRecordSize = 256 << 20
+)
+
+var crc32c = crc32.MakeTable(crc32.Castagnoli)
+
+// FileStore implements Store on the local filesystem, for local development and single-node
+// deployments.
+//
+// Environment variables:
+//
+//	CARDINAL_TICK_LOG_STORE_TYPE=FILE  # Selects the filesystem as the tick log backend
+//	CARDINAL_TICK_LOG_DIR=<path>       # Directory of the tick log, defaults to ./ticklog
+//
+// Epochs are appended to a single file as records of the epoch's length and checksum followed by the
+// epoch, and every append is synced. A crash during an append leaves a partial record at the end of
+// the file, which is truncated when the store is opened again.
+type FileStore struct {
+	mu     sync.Mutex
+	path   string
+	file   *os.File
+	last   *iscv1.Epoch // Last stored epoch, nil if the log is empty
+	logger zerolog.Logger
+}
+
+var _ Store = (*FileStore)(nil)
+
+// NewFileStore opens the tick log file, creating the directory and file if they don't exist.
+func NewFileStore(opts FileStoreOptions) (*FileStore, error) {
+	if err := env.Parse(&opts); err != nil {
+		return nil, eris.Wrap(err, "failed to parse env")
+	}
+	if opts.Dir == "" {
+		opts.Dir = defaultFileLogDir
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, eris.Wrap(err, "invalid options passed")
+	}
+
+	if err := os.MkdirAll(opts.Dir, 0o755); err != nil {
+		return nil, eris.Wrapf(err, "failed to create tick log directory %s", opts.Dir)
+	}
+	path := filepath.Join(opts.Dir, fileLogName)
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, eris.Wrapf(err, "failed to open tick log %s", path)
+	}
+
+	f := &FileStore{path: path, file: file, logger: opts.Logger}
+	if err := f.recover(); err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+// recover finds the last epoch in the file, and truncates the partial or corrupted records after it.
+func (f *FileStore) recover() error {
+	var end int64
+	for epoch, err := range readRecords(f.file) {
+		if err != nil {
+			break
+		}
+		f.last = epoch.epoch
+		end = epoch.end
+	}
+
+	info, err := f.file.Stat()
+	if err != nil {
+		return eris.Wrap(err, "failed to stat tick log")
+	}
+	if info.Size() > end {
+		f.logger.Warn().Int64("offset", end).Int64("size", info.Size()).
+			Msg("truncating partial or corrupted tick log records")
+		if err := f.file.Truncate(end); err != nil {
+			return eris.Wrap(err, "failed to truncate tick log")
+		}
+		if err := f.file.Sync(); err != nil {
+			return eris.Wrap(err, "failed to sync tick log")
+		}
+	}
+	if _, err := f.file.Seek(end, io.SeekStart); err != nil {
+		return eris.Wrap(err, "failed to seek tick log")
+	}
+	return nil
+}
+
+func (f *FileStore) Append(_ context.Context, epoch *iscv1.Epoch) error {
+	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(epoch)
+	if err != nil {
+		return eris.Wrap(err, "failed to marshal epoch")
+	}
+	record := make([]byte, recordHeaderSize, recordHeaderSize+len(data))
+	binary.BigEndian.PutUint32(record[0:4], uint32(len(data))) //nolint:gosec // bounded by maxRecordSize
+	binary.BigEndian.PutUint32(record[4:8], crc32.Checksum(data, crc32c))
+	record = append(record, data...)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, err := f.file.Write(record); err != nil {
+		return eris.Wrap(err, "failed to write epoch to tick log")
+	}
+	if err := f.file.Sync(); err != nil {
+		return eris.Wrap(err, "failed to sync tick log")
+	}
+	f.last = epoch
+	return nil
+}
+
+func (f *FileStore) Last(_ context.Context) (*iscv1.Epoch, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.last == nil {
+		return nil, eris.Wrap(ErrEpochNotFound, "tick log is empty")
+	}
+	return f.last, nil
+}
+
+func (f *FileStore) Read(ctx context.Context, from uint64) iter.Seq2[*iscv1.Epoch, error] {
+	return func(yield func(*iscv1.Epoch, error) bool) {
+		// Read the file through its own handle, so appends can go on. Only the records that were
+		// complete when reading started are read.
+		f.mu.Lock()
+		end, err := f.file.Seek(0, io.SeekCurrent)
+		f.mu.Unlock()
+		if err != nil {
+			yield(nil, eris.Wrap(err, "failed to seek tick log"))
+			return
+		}
+		file, err := os.Open(f.path)
+		if err != nil {
+			yield(nil, eris.Wrap(err, "failed to open tick log"))
+			return
+		}
+		defer func() {
+			_ = file.Close()
+		}()
+
+		for record, err := range readRecords(io.LimitReader(file, end)) {
+			if err == nil {
+				err = ctx.Err()
+			}
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if record.epoch.GetEpochHeight() < from {
+				continue
+			}
+			if !yield(record.epoch, nil) {
+				return
+			}
+		}
+	}
+}
+
+func (f *FileStore) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.file.Close(); err != nil {
+		return eris.Wrap(err, "failed to close tick log")
+	}
+	return nil
+}
+
+// fileRecord is an epoch read from the tick log file, with the offset its record ends at.
+type fileRecord struct {
+	epoch *iscv1.Epoch
+	end   int64
+}
+
+// readRecords reads the records of a tick log file from its start. It stops at the end of the file, or
+// with an error at the first partial or corrupted record.
+func readRecords(r io.Reader) iter.Seq2[fileRecord, error] {
+	return func(yield func(fileRecord, error) bool) {
+		reader := bufio.NewReader(r)
+		var offset int64
+		header := make([]byte, recordHeaderSize)
+		for {
+			if _, err := io.ReadFull(reader, header); err != nil {
+				if err != io.EOF { //nolint:errorlint // io.ReadFull returns io.EOF unwrapped
+					yield(fileRecord{}, eris.Wrapf(err, "partial record header at offset %d", offset))
+				}
+				return
+			}
+			length := binary.BigEndian.Uint32(header[0:4])
+			if length > maxRecordSize {
+				yield(fileRecord{}, eris.Errorf("record at offset %d has invalid length %d", offset, length))
+				return
+			}
+			data := make([]byte, length)
+			if _, err := io.ReadFull(reader, data); err != nil {
+				yield(fileRecord{}, eris.Wrapf(err, "partial record at offset %d", offset))
+				return
+			}
+			if crc32.Checksum(data, crc32c) != binary.BigEndian.Uint32(header[4:8]) {
+				yield(fileRecord{}, eris.Errorf("checksum mismatch of record at offset %d", offset))
+				return
+			}
+			epoch := &iscv1.Epoch{}
+			if err := proto.Unmarshal(data, epoch); err != nil {
+				yield(fileRecord{}, eris.Wrapf(err, "failed to unmarshal record at offset %d", offset))
+				return
+			}
+
+			offset += recordHeaderSize + int64(length)
+			if !yield(fileRecord{epoch: epoch, end: offset}, nil) {
+				return
+			}
+		}
+	}
+}
+
+// -------------------------------------------------------------------------------------------------
+// Options
+// -------------------------------------------------------------------------------------------------
+
+type FileStoreOptions struct {
+	Logger zerolog.Logger
+
+	// Directory of the tick log. Created if it doesn't exist.
+	Dir string `env:"CARDINAL_TICK_LOG_DIR"`
+}
+
+func (opt *FileStoreOptions) Validate() error {
+	if opt.Dir == "" {
+		return eris.New("tick log directory cannot be empty")
+	}
+	return nil
+}
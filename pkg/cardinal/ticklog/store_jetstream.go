@@ -0,0 +1,207 @@
+package ticklog
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"strconv"
+	"time"
+
+	"github.com/argus-labs/world-engine/pkg/micro"
+	iscv1 "github.com/argus-labs/world-engine/proto/gen/go/worldengine/isc/v1"
+	"github.com/caarlos0/env/v11"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/rotisserie/eris"
+	"github.com/rs/zerolog"
+	"google.golang.org/protobuf/proto"
+)
+
+// readBatchSize is the number of epochs fetched at a time when reading the stream.
+const readBatchSize = 256
+
+// JetStreamStore implements Store using a NATS JetStream stream.
+// Every epoch is published as a message to <shard address>.ticks in the shard's stream, with its
+// epoch height as the message ID, so JetStream discards the duplicates of retried appends.
+//
+// Environment variables:
+//
+//	CARDINAL_TICK_LOG_STORE_TYPE=JETSTREAM  # Selects JetStream as the tick log backend
+//	CARDINAL_TICK_LOG_MAX_BYTES=<bytes>     # Maximum size of the stream, 0 for unlimited
+//	CARDINAL_TICK_LOG_MAX_AGE=<duration>    # How long epochs are retained, 0 for forever
+type JetStreamStore struct {
+	client  *micro.Client
+	stream  jetstream.Stream
+	js      jetstream.JetStream
+	subject string
+	logger  zerolog.Logger
+}
+
+var _ Store = (*JetStreamStore)(nil)
+
+// NewJetStreamStore creates the shard's tick log stream if it doesn't exist.
+// It creates its own NATS client using the default configuration from environment variables.
+func NewJetStreamStore(opts JetStreamStoreOptions) (*JetStreamStore, error) {
+	// env.Parse fills the fields of the NATS config with their env defaults, overriding the caller's.
+	natsConfig := opts.NATSConfig
+	opts.NATSConfig = nil
+	if err := env.Parse(&opts); err != nil {
+		return nil, eris.Wrap(err, "failed to parse env")
+	}
+	opts.NATSConfig = natsConfig
+	if err := opts.Validate(); err != nil {
+		return nil, eris.Wrap(err, "invalid options passed")
+	}
+
+	clientOpts := []micro.ClientOption{micro.WithLogger(opts.Logger)}
+	if opts.NATSConfig != nil {
+		clientOpts = append(clientOpts, micro.WithNATSConfig(*opts.NATSConfig))
+	}
+	client, err := micro.NewClient(clientOpts...)
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to create micro client")
+	}
+
+	js, err := jetstream.New(client.Conn)
+	if err != nil {
+		client.Close()
+		return nil, eris.Wrap(err, "failed to create JetStream client")
+	}
+
+	// Same format as the snapshot bucket, because stream names can't contain dots.
+	streamName := fmt.Sprintf("%s_%s_%s_ticks",
+		opts.Address.GetOrganization(), opts.Address.GetProject(), opts.Address.GetServiceId())
+	subject := micro.String(opts.Address) + ".ticks"
+	stream, err := js.CreateOrUpdateStream(context.Background(), jetstream.StreamConfig{
+		Name:     streamName,
+		Subjects: []string{subject},
+		MaxBytes: opts.MaxBytes,
+		MaxAge:   opts.MaxAge,
+	})
+	if err != nil {
+		client.Close()
+		return nil, eris.Wrapf(err, "failed to create tick log stream (stream=%s)", streamName)
+	}
+
+	return &JetStreamStore{client: client, stream: stream, js: js, subject: subject, logger: opts.Logger}, nil
+}
+
+func (j *JetStreamStore) Append(ctx context.Context, epoch *iscv1.Epoch) error {
+	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(epoch)
+	if err != nil {
+		return eris.Wrap(err, "failed to marshal epoch")
+	}
+	msgID := strconv.FormatUint(epoch.GetEpochHeight(), 10)
+	if _, err := j.js.Publish(ctx, j.subject, data, jetstream.WithMsgID(msgID)); err != nil {
+		return eris.Wrap(err, "failed to publish epoch to tick log stream")
+	}
+	return nil
+}
+
+func (j *JetStreamStore) Last(ctx context.Context) (*iscv1.Epoch, error) {
+	msg, err := j.stream.GetLastMsgForSubject(ctx, j.subject)
+	if err != nil {
+		if eris.Is(err, jetstream.ErrMsgNotFound) {
+			return nil, eris.Wrap(ErrEpochNotFound, "tick log is empty")
+		}
+		return nil, eris.Wrap(err, "failed to get last epoch from tick log stream")
+	}
+	epoch := &iscv1.Epoch{}
+	if err := proto.Unmarshal(msg.Data, epoch); err != nil {
+		return nil, eris.Wrapf(err, "failed to unmarshal epoch (seq=%d)", msg.Sequence)
+	}
+	return epoch, nil
+}
+
+func (j *JetStreamStore) Read(ctx context.Context, from uint64) iter.Seq2[*iscv1.Epoch, error] {
+	return func(yield func(*iscv1.Epoch, error) bool) {
+		// Only the epochs stored when reading started are read.
+		info, err := j.stream.Info(ctx)
+		if err != nil {
+			yield(nil, eris.Wrap(err, "failed to get tick log stream info"))
+			return
+		}
+		last := info.State.LastSeq
+		if info.State.Msgs == 0 {
+			return
+		}
+
+		consumer, err := j.stream.OrderedConsumer(ctx, jetstream.OrderedConsumerConfig{
+			FilterSubjects: []string{j.subject},
+		})
+		if err != nil {
+			yield(nil, eris.Wrap(err, "failed to create tick log consumer"))
+			return
+		}
+		for {
+			batch, err := consumer.Fetch(readBatchSize, jetstream.FetchMaxWait(5*time.Second))
+			if err != nil {
+				yield(nil, eris.Wrap(err, "failed to fetch epochs from tick log stream"))
+				return
+			}
+			received := 0
+			for msg := range batch.Messages() {
+				received++
+				meta, err := msg.Metadata()
+				if err != nil {
+					yield(nil, eris.Wrap(err, "failed to get epoch message metadata"))
+					return
+				}
+				epoch := &iscv1.Epoch{}
+				if err := proto.Unmarshal(msg.Data(), epoch); err != nil {
+					yield(nil, eris.Wrapf(err, "failed to unmarshal epoch (seq=%d)", meta.Sequence.Stream))
+					return
+				}
+				if epoch.GetEpochHeight() >= from && !yield(epoch, nil) {
+					return
+				}
+				if meta.Sequence.Stream >= last {
+					return
+				}
+			}
+			if err := batch.Error(); err != nil {
+				yield(nil, eris.Wrap(err, "failed to fetch epochs from tick log stream"))
+				return
+			}
+			if received == 0 {
+				yield(nil, eris.Errorf("tick log stream ended before sequence %d", last))
+				return
+			}
+		}
+	}
+}
+
+func (j *JetStreamStore) Close() error {
+	j.client.Close()
+	return nil
+}
+
+// -------------------------------------------------------------------------------------------------
+// Options
+// -------------------------------------------------------------------------------------------------
+
+type JetStreamStoreOptions struct {
+	Address    *micro.ServiceAddress
+	Logger     zerolog.Logger
+	NATSConfig *micro.NATSConfig // Optional NATS config override (nil = use env/defaults)
+
+	// Maximum bytes of the stream. Required by some NATS providers like Synadia Cloud.
+	MaxBytes int64 `env:"CARDINAL_TICK_LOG_MAX_BYTES" envDefault:"0"`
+
+	// How long the stream retains epochs, e.g. "720h". Replay needs the epochs since the snapshot it
+	// starts from.
+	MaxAge time.Duration `env:"CARDINAL_TICK_LOG_MAX_AGE" envDefault:"0"`
+}
+
+func (opt *JetStreamStoreOptions) Validate() error {
+	if opt.Address == nil {
+		return eris.New("address cannot be nil")
+	}
+	// MaxBytes and MaxAge can be 0, which means unlimited.
+	if opt.MaxBytes < 0 {
+		return eris.New("tick log max bytes cannot be negative")
+	}
+	if opt.MaxAge < 0 {
+		return eris.New("tick log max age cannot be negative")
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package ticklog
+
+import (
+	"context"
+	"iter"
+
+	iscv1 "github.com/argus-labs/world-engine/proto/gen/go/worldengine/isc/v1"
+	"github.com/rotisserie/eris"
+)
+
+// NopStore is a no-op implementation of Store.
+// It's used when the tick log is not needed (e.g., development, testing).
+type NopStore struct{}
+
+var _ Store = (*NopStore)(nil)
+
+// NewNopStore creates a new no-op tick log store.
+func NewNopStore() *NopStore {
+	return &NopStore{}
+}
+
+func (n *NopStore) Append(_ context.Context, _ *iscv1.Epoch) error {
+	return nil
+}
+
+func (n *NopStore) Last(_ context.Context) (*iscv1.Epoch, error) {
+	return nil, eris.Wrap(ErrEpochNotFound, "no epochs available (using no-op store)")
+}
+
+func (n *NopStore) Read(_ context.Context, _ uint64) iter.Seq2[*iscv1.Epoch, error] {
+	return func(func(*iscv1.Epoch, error) bool) {}
+}
+
+func (n *NopStore) Close() error {
+	return nil
+}
@@ -0,0 +1,143 @@
+package ticklog
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"iter"
+	"strings"
+
+	iscv1 "github.com/argus-labs/world-engine/proto/gen/go/worldengine/isc/v1"
+	"github.com/rotisserie/eris"
+	"google.golang.org/protobuf/proto"
+)
+
+var ErrEpochNotFound = errors.New("epoch not found")
+
+// Store persists the tick log of a shard: the header and ordered commands of every tick, grouped into
+// epochs. Epochs are appended in order of their epoch height, and every epoch's hash chains it to the
+// previous one (see Hash), so the log can be audited and replayed.
+//
+// The log is append-only. If the shard continues from an earlier tick, e.g. after restoring an earlier
+// snapshot, the next epochs start at that tick again, and the ticks they contain supersede the ones of
+// the same height in earlier epochs.
+//
+// An append that's retried after it timed out may store the same epoch twice in a row. Readers skip
+// the second copy, see Duplicate.
+type Store interface {
+	// Append stores an epoch after the last stored one.
+	Append(ctx context.Context, epoch *iscv1.Epoch) error
+
+	// Last returns the last stored epoch. Returns ErrEpochNotFound if the log is empty.
+	Last(ctx context.Context) (*iscv1.Epoch, error)
+
+	// Read returns the stored epochs with an epoch height of at least from, in the order they were
+	// stored. Iteration stops at the first error.
+	Read(ctx context.Context, from uint64) iter.Seq2[*iscv1.Epoch, error]
+
+	// Close releases the resources of the store.
+	Close() error
+}
+
+// Hash returns the hash of an epoch chained to the hash of the previous epoch, which is empty for the
+// first epoch. It's the SHA-256 hash of the previous hash followed by the deterministic serialization
+// of the epoch without its hash.
+func Hash(prev []byte, epoch *iscv1.Epoch) ([]byte, error) {
+	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(&iscv1.Epoch{
+		EpochHeight: epoch.GetEpochHeight(),
+		Ticks:       epoch.GetTicks(),
+	})
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to marshal epoch")
+	}
+	h := sha256.New()
+	h.Write(prev)
+	h.Write(data)
+	return h.Sum(nil), nil
+}
+
+// Duplicate reports whether epoch is a copy of the epoch stored before it, stored again by a retried
+// append.
+func Duplicate(prev, epoch *iscv1.Epoch) bool {
+	return prev != nil && proto.Equal(prev, epoch)
+}
+
+// Verify checks that the epochs read from a store are consecutive and hash-chained, skipping duplicates.
+// prev is the hash of the epoch before the first one, which is empty if the first epoch starts the log.
+// Returns the hash of the last epoch, to verify the epochs that follow it.
+func Verify(prev []byte, epochs iter.Seq2[*iscv1.Epoch, error]) ([]byte, error) {
+	var height uint64
+	var last *iscv1.Epoch
+	first := true
+	for epoch, err := range epochs {
+		if err != nil {
+			return nil, err
+		}
+		if Duplicate(last, epoch) {
+			continue
+		}
+		last = epoch
+		if !first && epoch.GetEpochHeight() != height+1 {
+			return nil, eris.Errorf("epoch %d follows epoch %d", epoch.GetEpochHeight(), height)
+		}
+		hash, err := Hash(prev, epoch)
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(hash, epoch.GetHash()) {
+			return nil, eris.Errorf("hash mismatch of epoch %d", epoch.GetEpochHeight())
+		}
+		prev, height, first = hash, epoch.GetEpochHeight(), false
+	}
+	return prev, nil
+}
+
+// StoreType defines the type of tick log store to use.
+type StoreType uint8
+
+const (
+	StoreTypeUndefined StoreType = iota
+	StoreTypeNop
+	StoreTypeFile
+	StoreTypeJetStream
+)
+
+const (
+	nopStoreString       = "NOP"
+	fileStoreString      = "FILE"
+	jetStreamStoreString = "JETSTREAM"
+	undefinedStoreString = "UNDEFINED"
+)
+
+func (s StoreType) String() string {
+	switch s {
+	case StoreTypeUndefined:
+		return undefinedStoreString
+	case StoreTypeNop:
+		return nopStoreString
+	case StoreTypeFile:
+		return fileStoreString
+	case StoreTypeJetStream:
+		return jetStreamStoreString
+	default:
+		return undefinedStoreString
+	}
+}
+
+func (s StoreType) IsValid() bool {
+	return s == StoreTypeNop || s == StoreTypeFile || s == StoreTypeJetStream
+}
+
+func ParseStoreType(s string) (StoreType, error) {
+	switch strings.ToUpper(s) {
+	case nopStoreString:
+		return StoreTypeNop, nil
+	case fileStoreString:
+		return StoreTypeFile, nil
+	case jetStreamStoreString:
+		return StoreTypeJetStream, nil
+	default:
+		return StoreTypeUndefined, eris.Errorf("invalid tick log store type: %s", s)
+	}
+}
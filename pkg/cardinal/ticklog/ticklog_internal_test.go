@@ -0,0 +1,269 @@
+package ticklog
+
+import (
+	"context"
+	"iter"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/argus-labs/world-engine/pkg/micro"
+	"github.com/argus-labs/world-engine/pkg/testutils"
+	iscv1 "github.com/argus-labs/world-engine/proto/gen/go/worldengine/isc/v1"
+	microv1 "github.com/argus-labs/world-engine/proto/gen/go/worldengine/micro/v1"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats-server/v2/test"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// -------------------------------------------------------------------------------------------------
+// Hash chain tests
+// -------------------------------------------------------------------------------------------------
+// Verifies that a chain of epochs verifies, also with an epoch stored twice by a retried append, and
+// that changing, reordering, or dropping an epoch breaks the chain.
+// -------------------------------------------------------------------------------------------------
+
+func TestVerify(t *testing.T) {
+	t.Parallel()
+	prng := testutils.NewRand(t)
+
+	epochs := newTestChain(t, prng, nil, 0, 5)
+	last, err := Verify(nil, seq(epochs))
+	require.NoError(t, err)
+	assert.Equal(t, epochs[len(epochs)-1].GetHash(), last)
+
+	// The epochs that follow verify from the hash of the last one.
+	next := newTestChain(t, prng, last, 5, 2)
+	_, err = Verify(last, seq(next))
+	require.NoError(t, err)
+
+	// A retried append stored an epoch twice.
+	duplicated := slices.Insert(slices.Clone(epochs), 3, proto.CloneOf(epochs[2]))
+	verified, err := Verify(nil, seq(duplicated))
+	require.NoError(t, err)
+	assert.Equal(t, last, verified)
+
+	tampered := map[string]func(epochs []*iscv1.Epoch) []*iscv1.Epoch{
+		"changed command": func(epochs []*iscv1.Epoch) []*iscv1.Epoch {
+			epochs[2].GetTicks()[0].GetData().GetCommands()[0].Payload = []byte("forged")
+			return epochs
+		},
+		"changed timestamp": func(epochs []*iscv1.Epoch) []*iscv1.Epoch {
+			epochs[1].GetTicks()[0].GetHeader().Timestamp = timestamppb.New(time.Unix(0, 0))
+			return epochs
+		},
+		"reordered": func(epochs []*iscv1.Epoch) []*iscv1.Epoch {
+			epochs[1], epochs[2] = epochs[2], epochs[1]
+			return epochs
+		},
+		"dropped": func(epochs []*iscv1.Epoch) []*iscv1.Epoch {
+			return slices.Delete(epochs, 2, 3)
+		},
+	}
+	for name, tamper := range tampered {
+		clone := make([]*iscv1.Epoch, len(epochs))
+		for i, epoch := range epochs {
+			clone[i] = proto.Clone(epoch).(*iscv1.Epoch)
+		}
+		_, err := Verify(nil, seq(tamper(clone)))
+		assert.Error(t, err, name)
+	}
+}
+
+// -------------------------------------------------------------------------------------------------
+// Store smoke tests
+// -------------------------------------------------------------------------------------------------
+// Verifies that the file and JetStream stores return the appended epochs in order, from a given
+// epoch height, and the last appended epoch, also after the store is reopened.
+// -------------------------------------------------------------------------------------------------
+
+func TestFileStore(t *testing.T) {
+	t.Parallel()
+	prng := testutils.NewRand(t)
+
+	opts := FileStoreOptions{Logger: zerolog.Nop(), Dir: t.TempDir()}
+	testStore(t, prng, func() Store {
+		store, err := NewFileStore(opts)
+		require.NoError(t, err)
+		return store
+	})
+}
+
+func TestJetStreamStore(t *testing.T) {
+	t.Parallel()
+	prng := testutils.NewRand(t)
+
+	tempDir := filepath.Join(os.TempDir(), "nats-ticklog-"+strconv.Itoa(os.Getpid()))
+	srv := test.RunServer(&server.Options{
+		Host:                  "127.0.0.1",
+		Port:                  -1,
+		NoLog:                 true,
+		NoSigs:                true,
+		MaxControlLine:        4096,
+		DisableShortFirstPing: true,
+		JetStream:             true,
+		StoreDir:              tempDir,
+	})
+	t.Cleanup(func() {
+		srv.Shutdown()
+		_ = os.RemoveAll(tempDir)
+	})
+
+	opts := JetStreamStoreOptions{
+		Address:    micro.GetAddress("local", micro.RealmWorld, "org", "project", "shard"),
+		Logger:     zerolog.Nop(),
+		NATSConfig: &micro.NATSConfig{Name: "ticklog-test", URL: srv.ClientURL()},
+	}
+	testStore(t, prng, func() Store {
+		store, err := NewJetStreamStore(opts)
+		require.NoError(t, err)
+		return store
+	})
+}
+
+// testStore runs the store smoke test against the stores opened by open.
+func testStore(t *testing.T, prng *rand.Rand, open func() Store) {
+	t.Helper()
+	ctx := context.Background()
+
+	store := open()
+	_, err := store.Last(ctx)
+	require.ErrorIs(t, err, ErrEpochNotFound)
+	assert.Empty(t, collect(t, store.Read(ctx, 0)))
+
+	epochs := newTestChain(t, prng, nil, 0, 4)
+	for _, epoch := range epochs[:3] {
+		require.NoError(t, store.Append(ctx, epoch))
+	}
+	require.NoError(t, store.Close())
+
+	// The reopened store continues after the last epoch.
+	store = open()
+	defer func() {
+		require.NoError(t, store.Close())
+	}()
+	last, err := store.Last(ctx)
+	require.NoError(t, err)
+	assert.True(t, proto.Equal(epochs[2], last))
+	require.NoError(t, store.Append(ctx, epochs[3]))
+
+	got := collect(t, store.Read(ctx, 0))
+	require.Len(t, got, len(epochs))
+	for i := range epochs {
+		assert.True(t, proto.Equal(epochs[i], got[i]), "epoch %d differs", i)
+	}
+	_, err = Verify(nil, store.Read(ctx, 0))
+	require.NoError(t, err)
+
+	from := collect(t, store.Read(ctx, 2))
+	require.Len(t, from, 2)
+	assert.Equal(t, uint64(2), from[0].GetEpochHeight())
+}
+
+// -------------------------------------------------------------------------------------------------
+// File store crash recovery
+// -------------------------------------------------------------------------------------------------
+// Verifies that a partial record left by a crash during an append is truncated when the file store
+// is opened, so the epochs before it are kept and new epochs are appended after them.
+// -------------------------------------------------------------------------------------------------
+
+func TestFileStore_TruncatesPartialRecord(t *testing.T) {
+	t.Parallel()
+	prng := testutils.NewRand(t)
+	ctx := context.Background()
+
+	opts := FileStoreOptions{Logger: zerolog.Nop(), Dir: t.TempDir()}
+	store, err := NewFileStore(opts)
+	require.NoError(t, err)
+	epochs := newTestChain(t, prng, nil, 0, 3)
+	require.NoError(t, store.Append(ctx, epochs[0]))
+	require.NoError(t, store.Append(ctx, epochs[1]))
+	require.NoError(t, store.Close())
+
+	// Cut the last record in half.
+	path := filepath.Join(opts.Dir, fileLogName)
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.NoError(t, os.Truncate(path, info.Size()-5))
+
+	store, err = NewFileStore(opts)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, store.Close())
+	}()
+	last, err := store.Last(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0), last.GetEpochHeight())
+
+	// Appending the lost epoch again restores the chain.
+	require.NoError(t, store.Append(ctx, epochs[1]))
+	require.NoError(t, store.Append(ctx, epochs[2]))
+	_, err = Verify(nil, store.Read(ctx, 0))
+	require.NoError(t, err)
+	assert.Len(t, collect(t, store.Read(ctx, 0)), 3)
+}
+
+// newTestChain returns count hash-chained epochs, starting at epoch height first and chained to prev,
+// each with two ticks of random commands.
+func newTestChain(t *testing.T, prng *rand.Rand, prev []byte, first uint64, count int) []*iscv1.Epoch {
+	t.Helper()
+	epochs := make([]*iscv1.Epoch, count)
+	for i := range epochs {
+		height := first + uint64(i) //nolint:gosec // count is small
+		epoch := &iscv1.Epoch{EpochHeight: height}
+		for j := range uint64(2) {
+			tick := &iscv1.Tick{
+				Header: &iscv1.TickHeader{
+					TickHeight: height*2 + j,
+					Timestamp:  timestamppb.New(time.UnixMilli(prng.Int64N(1 << 40))),
+				},
+				Data: &iscv1.TickData{},
+			}
+			for range 1 + prng.IntN(3) {
+				tick.Data.Commands = append(tick.Data.Commands, &iscv1.Command{
+					Name:    testutils.RandString(prng, 8),
+					Address: &microv1.ServiceAddress{ServiceId: "shard"},
+					Persona: &iscv1.Persona{Id: testutils.RandString(prng, 8)},
+					Payload: []byte(testutils.RandString(prng, 16)),
+				})
+			}
+			epoch.Ticks = append(epoch.Ticks, tick)
+		}
+		hash, err := Hash(prev, epoch)
+		require.NoError(t, err)
+		epoch.Hash = hash
+		prev = hash
+		epochs[i] = epoch
+	}
+	return epochs
+}
+
+// seq returns an iterator over epochs, like the ones stores return.
+func seq(epochs []*iscv1.Epoch) iter.Seq2[*iscv1.Epoch, error] {
+	return func(yield func(*iscv1.Epoch, error) bool) {
+		for _, epoch := range epochs {
+			if !yield(epoch, nil) {
+				return
+			}
+		}
+	}
+}
+
+// collect reads all epochs of an iterator, failing the test on an error.
+func collect(t *testing.T, epochs iter.Seq2[*iscv1.Epoch, error]) []*iscv1.Epoch {
+	t.Helper()
+	var all []*iscv1.Epoch
+	for epoch, err := range epochs {
+		require.NoError(t, err)
+		all = append(all, epoch)
+	}
+	return all
+}
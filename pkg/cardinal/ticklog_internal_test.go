@@ -0,0 +1,266 @@
+package cardinal
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/argus-labs/world-engine/pkg/cardinal/internal/command"
+	"github.com/argus-labs/world-engine/pkg/cardinal/ticklog"
+	"github.com/argus-labs/world-engine/pkg/micro"
+	"github.com/argus-labs/world-engine/pkg/testutils"
+	iscv1 "github.com/argus-labs/world-engine/proto/gen/go/worldengine/isc/v1"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// -------------------------------------------------------------------------------------------------
+// Tick log tests
+// -------------------------------------------------------------------------------------------------
+// Verifies that the tick log groups the recorded ticks into hash-chained epochs of consecutive ticks
+// with their commands in order, flushes the unfinished epoch on close, continues the chain of the
+// store when it's opened again, and marks the ticks with commands that fail to encode as unreplayable.
+// -------------------------------------------------------------------------------------------------
+
+func TestTickLog(t *testing.T) {
+	t.Setenv("CARDINAL_TICK_LOG_EPOCH_TICKS", "4")
+	prng := testutils.NewRand(t)
+	ctx := context.Background()
+
+	opts := ticklog.FileStoreOptions{Logger: zerolog.Nop(), Dir: t.TempDir()}
+	store, err := ticklog.NewFileStore(opts)
+	require.NoError(t, err)
+
+	address := micro.GetAddress("local", micro.RealmWorld, "org", "project", "shard")
+	start := time.UnixMilli(1_700_000_000_000)
+	recorded := make(map[uint64][]int) // Tick height -> values of the last recorded commands
+	record := func(l *tickLog, height uint64) {
+		var commands []command.Command
+		recorded[height] = nil
+		for range prng.IntN(3) {
+			value := prng.Int()
+			commands = append(commands, command.Command{
+				Name:    testutils.SimpleCommand{}.Name(),
+				Address: address,
+				Persona: "persona",
+				Payload: testutils.SimpleCommand{Value: value},
+			})
+			recorded[height] = append(recorded[height], value)
+		}
+		tick := Tick{height: height, timestamp: start.Add(time.Duration(height) * time.Second)}
+		l.record(tick, commands, nil, height+1)
+	}
+
+	l, err := newTickLog(ctx, store, zerolog.Nop())
+	require.NoError(t, err)
+	for height := range uint64(10) {
+		record(l, height)
+	}
+	require.NoError(t, l.close(ctx))
+	require.NoError(t, store.Close())
+
+	// The reopened tick log continues the chain, and seals the epoch when the tick height jumps.
+	store, err = ticklog.NewFileStore(opts)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, store.Close())
+	}()
+	l, err = newTickLog(ctx, store, zerolog.Nop())
+	require.NoError(t, err)
+	for _, height := range []uint64{10, 11, 5, 6} {
+		record(l, height)
+	}
+	require.NoError(t, l.close(ctx))
+
+	_, err = ticklog.Verify(nil, store.Read(ctx, 0))
+	require.NoError(t, err)
+
+	var epochs []*iscv1.Epoch
+	for epoch, err := range store.Read(ctx, 0) {
+		require.NoError(t, err)
+		epochs = append(epochs, epoch)
+	}
+	wantHeights := [][]uint64{{0, 1, 2, 3}, {4, 5, 6, 7}, {8, 9}, {10, 11}, {5, 6}}
+	require.Len(t, epochs, len(wantHeights))
+	for i, epoch := range epochs {
+		assert.Equal(t, uint64(i), epoch.GetEpochHeight()) //nolint:gosec // i is small
+		var heights []uint64
+		for _, tick := range epoch.GetTicks() {
+			height := tick.GetHeader().GetTickHeight()
+			heights = append(heights, height)
+			assert.True(t, start.Add(time.Duration(height)*time.Second).Equal(tick.GetHeader().GetTimestamp().AsTime()))
+			assert.Equal(t, height+1, tick.GetHeader().GetStateHash())
+
+			// Ticks 5 and 6 were recorded twice, the second time in the last epoch.
+			want := recorded[height]
+			if i < len(epochs)-1 && (height == 5 || height == 6) {
+				continue
+			}
+			require.Len(t, tick.GetData().GetCommands(), len(want))
+			for j, pb := range tick.GetData().GetCommands() {
+				assert.Equal(t, testutils.SimpleCommand{}.Name(), pb.GetName())
+				assert.Equal(t, "persona", pb.GetPersona().GetId())
+				payload, err := testutils.SimpleCommand{}.UnmarshalWire(pb.GetPayload())
+				require.NoError(t, err)
+				assert.Equal(t, testutils.SimpleCommand{Value: want[j]}, payload)
+			}
+		}
+		assert.Equal(t, wantHeights[i], heights, "epoch %d", i)
+	}
+}
+
+// unencodableCommand is a command payload that fails to encode.
+type unencodableCommand struct {
+	testutils.SimpleCommand
+}
+
+func (unencodableCommand) MarshalWire() ([]byte, error) {
+	return nil, errors.New("broken codec")
+}
+
+func TestTickLog_UnrecordedCommand(t *testing.T) {
+	t.Setenv("CARDINAL_TICK_LOG_EPOCH_TICKS", "1")
+	prng := testutils.NewRand(t)
+	ctx := context.Background()
+
+	store, err := ticklog.NewFileStore(ticklog.FileStoreOptions{Logger: zerolog.Nop(), Dir: t.TempDir()})
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, store.Close())
+	}()
+
+	// A command that fails to encode is left out of the tick, which is marked as unreplayable.
+	l, err := newTickLog(ctx, store, zerolog.Nop())
+	require.NoError(t, err)
+	commands := []command.Command{
+		{Name: testutils.SimpleCommand{}.Name(), Persona: "persona", Payload: testutils.SimpleCommand{Value: 1}},
+		{Name: "broken", Persona: "persona", Payload: unencodableCommand{}},
+	}
+	l.record(Tick{height: 0, timestamp: time.Now()}, commands, nil, 1)
+	require.NoError(t, l.close(ctx))
+
+	epoch, err := store.Last(ctx)
+	require.NoError(t, err)
+	require.Len(t, epoch.GetTicks(), 1)
+	tick := epoch.GetTicks()[0]
+	assert.Len(t, tick.GetData().GetCommands(), 1)
+	assert.Equal(t, []string{"broken"}, tick.GetData().GetUnrecordedCommands())
+
+	fixture := newServiceFixture(t, prng, false)
+	require.ErrorContains(t, fixture.world.replayTick(ctx, tick), "can't be replayed")
+}
+
+// -------------------------------------------------------------------------------------------------
+// Unavailable store tests
+// -------------------------------------------------------------------------------------------------
+// Verifies that the tick log doesn't drop epochs while the store is unavailable: the appends are
+// retried and the tick waits when the queue is full, so the chain has no gaps once the store is back,
+// and an append aborted by close stops the epochs after it from being appended. A close that times out
+// while the queue is full returns instead of waiting for room.
+// -------------------------------------------------------------------------------------------------
+
+// flakyStore is a tick log store whose appends fail while failing is set.
+type flakyStore struct {
+	ticklog.Store
+	failing atomic.Bool
+}
+
+func (s *flakyStore) Append(ctx context.Context, epoch *iscv1.Epoch) error {
+	if s.failing.Load() {
+		return errors.New("store unavailable")
+	}
+	return s.Store.Append(ctx, epoch)
+}
+
+func TestTickLog_Unavailable(t *testing.T) {
+	t.Setenv("CARDINAL_TICK_LOG_EPOCH_TICKS", "1")
+	t.Setenv("CARDINAL_TICK_LOG_QUEUE", "1")
+	t.Setenv("CARDINAL_TICK_LOG_RETRY_WAIT", "1ms")
+	t.Setenv("CARDINAL_TICK_LOG_MAX_RETRY_WAIT", "5ms")
+	ctx := context.Background()
+
+	fileStore, err := ticklog.NewFileStore(ticklog.FileStoreOptions{Logger: zerolog.Nop(), Dir: t.TempDir()})
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, fileStore.Close())
+	}()
+	store := &flakyStore{Store: fileStore}
+	store.failing.Store(true)
+
+	l, err := newTickLog(ctx, store, zerolog.Nop())
+	require.NoError(t, err)
+	recorded := make(chan uint64)
+	go func() {
+		defer close(recorded)
+		for height := range uint64(5) {
+			l.record(Tick{height: height, timestamp: time.Now()}, nil, nil, height+1)
+			recorded <- height
+		}
+	}()
+
+	// One epoch is being appended and one is queued, so recording the third one waits for the store.
+	assert.Equal(t, uint64(0), <-recorded)
+	assert.Equal(t, uint64(1), <-recorded)
+	select {
+	case height := <-recorded:
+		t.Fatalf("recorded tick %d while the store is unavailable", height)
+	case <-time.After(50 * time.Millisecond):
+	}
+	store.failing.Store(false)
+	for height := uint64(2); height < 5; height++ {
+		assert.Equal(t, height, <-recorded)
+	}
+	require.NoError(t, l.close(ctx))
+
+	var heights []uint64
+	for epoch, err := range fileStore.Read(ctx, 0) {
+		require.NoError(t, err)
+		heights = append(heights, epoch.GetEpochHeight())
+	}
+	assert.Equal(t, []uint64{0, 1, 2, 3, 4}, heights)
+	last, err := ticklog.Verify(nil, fileStore.Read(ctx, 0))
+	require.NoError(t, err)
+
+	// Closing while the store is unavailable aborts the append, and the epoch queued after it isn't
+	// appended either, even if the store comes back, so the chain stays intact.
+	store.failing.Store(true)
+	l, err = newTickLog(ctx, store, zerolog.Nop())
+	require.NoError(t, err)
+	l.record(Tick{height: 5, timestamp: time.Now()}, nil, nil, 6)
+	l.record(Tick{height: 6, timestamp: time.Now()}, nil, nil, 7)
+	closeCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	require.Error(t, l.close(closeCtx))
+	store.failing.Store(false)
+	l.wg.Wait()
+
+	lastEpoch, err := fileStore.Last(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(4), lastEpoch.GetEpochHeight())
+	assert.Equal(t, last, lastEpoch.GetHash())
+
+	// Closing while the queue is full doesn't wait for room for the pending ticks beyond ctx.
+	t.Setenv("CARDINAL_TICK_LOG_EPOCH_TICKS", "10")
+	store.failing.Store(true)
+	l, err = newTickLog(ctx, store, zerolog.Nop())
+	require.NoError(t, err)
+	for height := uint64(5); height < 8; height++ {
+		l.record(Tick{height: height, timestamp: time.Now()}, nil, nil, height+1)
+		if height < 7 {
+			l.flush() // One epoch is being appended and one is queued
+		}
+	}
+	closeCtx, cancel = context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	require.ErrorContains(t, l.close(closeCtx), "wasn't queued")
+	assert.Less(t, time.Since(start), time.Second)
+	l.wg.Wait()
+
+	lastEpoch, err = fileStore.Last(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(4), lastEpoch.GetEpochHeight())
+}
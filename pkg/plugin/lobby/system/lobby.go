@@ -315,8 +315,10 @@ type PlayerPassthroughUpdatedEvent struct {
 func (PlayerPassthroughUpdatedEvent) Name() string { return "lobby_player_passthrough_updated" }
 
 // -----------------------------------------------------------------------------
-// CommandResult (Shard → Client, persona-prefixed)
+// CommandResult (Shard → Client, replies to the sender)
 // -----------------------------------------------------------------------------
+// Results are named after the request, e.g. req-42_join_lobby_result, so clients can subscribe to
+// the result of a command sent without SendCommandWithReply.
 
 // CreateLobbyResult is sent back to the client after CreateLobbyCommand.
 type CreateLobbyResult struct {
@@ -399,6 +401,8 @@ func (r TransferLeaderResult) Name() string { return r.RequestID + "_transfer_le
 // once the orchestrator assigns a game shard via AssignShardCommand.
 // On success, GameWorld holds the assigned shard address so the client
 // can connect without an extra query. Zero-valued on failure.
+// Rejections are replies to the command; results emitted after the
+// command's tick are sent to the lobby leader, which is who started it.
 type StartSessionResult struct {
 	RequestID string                 `json:"request_id"`
 	IsSuccess bool                   `json:"is_success"`
@@ -705,7 +709,7 @@ type LobbySystemState struct {
 	SessionPassthroughUpdatedEvents cardinal.WithEvent[SessionPassthroughUpdatedEvent]
 	PlayerPassthroughUpdatedEvents  cardinal.WithEvent[PlayerPassthroughUpdatedEvent]
 
-	// CommandResult (request-prefixed responses)
+	// CommandResult (replies to the sender)
 	CreateLobbyResults              cardinal.WithEvent[CreateLobbyResult]
 	JoinLobbyResults                cardinal.WithEvent[JoinLobbyResult]
 	JoinTeamResults                 cardinal.WithEvent[JoinTeamResult]
@@ -867,19 +871,19 @@ func isLeaderInList(leaderID string, players []timedOutPlayer) bool {
 	return false
 }
 
-// emitJoinLobbyFailure emits a failure result for JoinLobby command.
-func emitJoinLobbyFailure(state *LobbySystemState, requestID, message string) {
-	state.JoinLobbyResults.Broadcast(JoinLobbyResult{
-		RequestID: requestID,
+// emitJoinLobbyFailure replies to a JoinLobby command with a failure result.
+func emitJoinLobbyFailure(state *LobbySystemState, cmd cardinal.CommandContext[JoinLobbyCommand], message string) {
+	state.JoinLobbyResults.Reply(cmd, JoinLobbyResult{
+		RequestID: cmd.Payload.RequestID,
 		IsSuccess: false,
 		Message:   message,
 	})
 }
 
-// emitCreateLobbyFailure emits a failure result for CreateLobby command.
-func emitCreateLobbyFailure(state *LobbySystemState, requestID, message string) {
-	state.CreateLobbyResults.Broadcast(CreateLobbyResult{
-		RequestID: requestID,
+// emitCreateLobbyFailure replies to a CreateLobby command with a failure result.
+func emitCreateLobbyFailure(state *LobbySystemState, cmd cardinal.CommandContext[CreateLobbyCommand], message string) {
+	state.CreateLobbyResults.Reply(cmd, CreateLobbyResult{
+		RequestID: cmd.Payload.RequestID,
 		IsSuccess: false,
 		Message:   message,
 	})
@@ -1182,7 +1186,7 @@ func processCreateLobbyCommands(
 		// Check if player is already in a lobby
 		if _, exists := lobbyIndex.GetPlayerLobby(playerID); exists {
 			state.Logger().Warn().Str("player_id", playerID).Msg("player already in a lobby")
-			emitCreateLobbyFailure(state, payload.RequestID, "player already in a lobby")
+			emitCreateLobbyFailure(state, cmd, "player already in a lobby")
 			continue
 		}
 
@@ -1211,7 +1215,7 @@ func processCreateLobbyCommands(
 				Str("player_id", playerID).
 				Str("preset", payload.Preset).
 				Msg("create lobby rejected: " + errMsg)
-			emitCreateLobbyFailure(state, payload.RequestID, errMsg)
+			emitCreateLobbyFailure(state, cmd, errMsg)
 			continue
 		}
 		for _, tc := range presetTeams {
@@ -1227,7 +1231,7 @@ func processCreateLobbyCommands(
 		)
 		if !ok {
 			state.Logger().Warn().Str("lobby_id", lobbyID).Msg("invite code collision after retries")
-			emitCreateLobbyFailure(state, payload.RequestID, "invite code collision")
+			emitCreateLobbyFailure(state, cmd, "invite code collision")
 			continue
 		}
 		lobby.InviteCode = inviteCode
@@ -1268,7 +1272,7 @@ func processCreateLobbyCommands(
 		})
 
 		// Emit success result
-		state.CreateLobbyResults.Broadcast(CreateLobbyResult{
+		state.CreateLobbyResults.Reply(cmd, CreateLobbyResult{
 			RequestID: payload.RequestID,
 			IsSuccess: true,
 			Message:   "lobby created",
@@ -1289,9 +1293,10 @@ func resolveInviteCode(
 	state *LobbySystemState,
 	lobbyIndex *component.LobbyIndexComponent,
 	playerID string,
-	payload JoinLobbyCommand,
+	cmd cardinal.CommandContext[JoinLobbyCommand],
 ) (string, cardinal.Ref[component.LobbyComponent], bool) {
 	var none cardinal.Ref[component.LobbyComponent]
+	payload := cmd.Payload
 
 	lobbyID, exists := lobbyIndex.GetLobbyByInviteCode(payload.InviteCode)
 	if !exists {
@@ -1303,7 +1308,7 @@ func resolveInviteCode(
 			Str("request_id", payload.RequestID).
 			Int("known_codes", lobbyIndex.InviteCodeCount()).
 			Msg("invalid invite code")
-		emitJoinLobbyFailure(state, payload.RequestID, "invalid invite code")
+		emitJoinLobbyFailure(state, cmd, "invalid invite code")
 		return "", none, false
 	}
 
@@ -1315,7 +1320,7 @@ func resolveInviteCode(
 			Str("player_id", playerID).
 			Str("request_id", payload.RequestID).
 			Msg("invite code maps to a lobby with no entity")
-		emitJoinLobbyFailure(state, payload.RequestID, "lobby not found")
+		emitJoinLobbyFailure(state, cmd, "lobby not found")
 		return "", none, false
 	}
 
@@ -1327,7 +1332,7 @@ func resolveInviteCode(
 			Str("player_id", playerID).
 			Str("request_id", payload.RequestID).
 			Msg("invite code maps to a lobby entity that no longer exists")
-		emitJoinLobbyFailure(state, payload.RequestID, "lobby not found")
+		emitJoinLobbyFailure(state, cmd, "lobby not found")
 		return "", none, false
 	}
 
@@ -1347,8 +1352,9 @@ func admitToTeam(
 	state *LobbySystemState,
 	lobby *component.LobbyComponent,
 	lobbyID, playerID string,
-	payload JoinLobbyCommand,
+	cmd cardinal.CommandContext[JoinLobbyCommand],
 ) (*component.Team, bool) {
+	payload := cmd.Payload
 	if lobby.Session.State == component.SessionStateInSession {
 		state.Logger().Warn().
 			Str("lobby_id", lobbyID).
@@ -1356,7 +1362,7 @@ func admitToTeam(
 			Str("player_id", playerID).
 			Str("request_id", payload.RequestID).
 			Msg("lobby is in session")
-		emitJoinLobbyFailure(state, payload.RequestID, "lobby is in session")
+		emitJoinLobbyFailure(state, cmd, "lobby is in session")
 		return nil, false
 	}
 
@@ -1368,7 +1374,7 @@ func admitToTeam(
 			Str("player_id", playerID).
 			Str("reason", reason).
 			Msg("join rejected by provider")
-		emitJoinLobbyFailure(state, payload.RequestID, reason)
+		emitJoinLobbyFailure(state, cmd, reason)
 		return nil, false
 	}
 
@@ -1380,7 +1386,7 @@ func admitToTeam(
 			Str("player_id", playerID).
 			Str("team_id", payload.TeamID).
 			Msg(errMsg)
-		emitJoinLobbyFailure(state, payload.RequestID, errMsg)
+		emitJoinLobbyFailure(state, cmd, errMsg)
 		return nil, false
 	}
 
@@ -1390,7 +1396,7 @@ func admitToTeam(
 			Str("invite_code", payload.InviteCode).
 			Str("player_id", playerID).
 			Msg("failed to join team")
-		emitJoinLobbyFailure(state, payload.RequestID, "failed to join team")
+		emitJoinLobbyFailure(state, cmd, "failed to join team")
 		return nil, false
 	}
 
@@ -1416,17 +1422,17 @@ func processJoinLobbyCommands(
 				Str("lobby_id", existingLobbyID).
 				Str("request_id", payload.RequestID).
 				Msg("player already in a lobby")
-			emitJoinLobbyFailure(state, payload.RequestID, "player already in a lobby")
+			emitJoinLobbyFailure(state, cmd, "player already in a lobby")
 			continue
 		}
 
-		lobbyID, lobbyRef, found := resolveInviteCode(state, lobbyIndex, playerID, payload)
+		lobbyID, lobbyRef, found := resolveInviteCode(state, lobbyIndex, playerID, cmd)
 		if !found {
 			continue
 		}
 		lobby := lobbyRef.Get()
 
-		targetTeam, admitted := admitToTeam(state, &lobby, lobbyID, playerID, payload)
+		targetTeam, admitted := admitToTeam(state, &lobby, lobbyID, playerID, cmd)
 		if !admitted {
 			continue
 		}
@@ -1462,7 +1468,7 @@ func processJoinLobbyCommands(
 		playersList := gatherLobbyPlayers(state, lobbyIndex, &lobby)
 
 		// Emit success result
-		state.JoinLobbyResults.Broadcast(JoinLobbyResult{
+		state.JoinLobbyResults.Reply(cmd, JoinLobbyResult{
 			RequestID:   payload.RequestID,
 			IsSuccess:   true,
 			Message:     "joined lobby",
@@ -1479,7 +1485,7 @@ func processJoinTeamCommands(state *LobbySystemState, lobbyIndex *component.Lobb
 
 		result := getPlayerLobby(playerID, lobbyIndex, &state.Lobbies)
 		if result == nil {
-			state.JoinTeamResults.Broadcast(JoinTeamResult{
+			state.JoinTeamResults.Reply(cmd, JoinTeamResult{
 				RequestID: payload.RequestID,
 				IsSuccess: false,
 				Message:   "player not in a lobby",
@@ -1491,7 +1497,7 @@ func processJoinTeamCommands(state *LobbySystemState, lobbyIndex *component.Lobb
 
 		// Can't change team during session
 		if lobby.Session.State == component.SessionStateInSession {
-			state.JoinTeamResults.Broadcast(JoinTeamResult{
+			state.JoinTeamResults.Reply(cmd, JoinTeamResult{
 				RequestID: payload.RequestID,
 				IsSuccess: false,
 				Message:   "cannot change team during session",
@@ -1502,7 +1508,7 @@ func processJoinTeamCommands(state *LobbySystemState, lobbyIndex *component.Lobb
 		// Get current team
 		oldTeam := lobby.GetPlayerTeam(playerID)
 		if oldTeam == nil {
-			state.JoinTeamResults.Broadcast(JoinTeamResult{
+			state.JoinTeamResults.Reply(cmd, JoinTeamResult{
 				RequestID: payload.RequestID,
 				IsSuccess: false,
 				Message:   "player not in any team",
@@ -1515,7 +1521,7 @@ func processJoinTeamCommands(state *LobbySystemState, lobbyIndex *component.Lobb
 		newTeam := lobby.GetTeam(payload.TeamID)
 		if newTeam == nil {
 			state.Logger().Warn().Str("lobby_id", lobbyID).Str("team_id", payload.TeamID).Msg("team not found")
-			state.JoinTeamResults.Broadcast(JoinTeamResult{
+			state.JoinTeamResults.Reply(cmd, JoinTeamResult{
 				RequestID: payload.RequestID,
 				IsSuccess: false,
 				Message:   "team not found",
@@ -1526,7 +1532,7 @@ func processJoinTeamCommands(state *LobbySystemState, lobbyIndex *component.Lobb
 		// Move to new team
 		if !lobby.MovePlayerToTeam(playerID, newTeam.TeamID) {
 			state.Logger().Warn().Str("lobby_id", lobbyID).Msg("failed to change team")
-			state.JoinTeamResults.Broadcast(JoinTeamResult{
+			state.JoinTeamResults.Reply(cmd, JoinTeamResult{
 				RequestID: payload.RequestID,
 				IsSuccess: false,
 				Message:   "failed to change team (team may be full)",
@@ -1565,7 +1571,7 @@ func processJoinTeamCommands(state *LobbySystemState, lobbyIndex *component.Lobb
 			Player:    playerComp,
 		})
 
-		state.JoinTeamResults.Broadcast(JoinTeamResult{
+		state.JoinTeamResults.Reply(cmd, JoinTeamResult{
 			RequestID: payload.RequestID,
 			IsSuccess: true,
 			Message:   "changed team",
@@ -1581,7 +1587,7 @@ func processLeaveLobbyCommands(state *LobbySystemState, lobbyIndex *component.Lo
 
 		result := getPlayerLobby(playerID, lobbyIndex, &state.Lobbies)
 		if result == nil {
-			state.LeaveLobbyResults.Broadcast(LeaveLobbyResult{
+			state.LeaveLobbyResults.Reply(cmd, LeaveLobbyResult{
 				RequestID: payload.RequestID,
 				IsSuccess: false,
 				Message:   "player not in a lobby",
@@ -1667,7 +1673,7 @@ func processLeaveLobbyCommands(state *LobbySystemState, lobbyIndex *component.Lo
 			result.lobbyRef.Set(lobby)
 		}
 
-		state.LeaveLobbyResults.Broadcast(LeaveLobbyResult{
+		state.LeaveLobbyResults.Reply(cmd, LeaveLobbyResult{
 			RequestID: payload.RequestID,
 			IsSuccess: true,
 			Message:   "left lobby",
@@ -1682,7 +1688,7 @@ func processSetReadyCommands(state *LobbySystemState, lobbyIndex *component.Lobb
 
 		result := getPlayerLobby(playerID, lobbyIndex, &state.Lobbies)
 		if result == nil {
-			state.SetReadyResults.Broadcast(SetReadyResult{
+			state.SetReadyResults.Reply(cmd, SetReadyResult{
 				RequestID: payload.RequestID,
 				IsSuccess: false,
 				Message:   "player not in a lobby",
@@ -1694,7 +1700,7 @@ func processSetReadyCommands(state *LobbySystemState, lobbyIndex *component.Lobb
 
 		// Can't change ready during session
 		if lobby.Session.State == component.SessionStateInSession {
-			state.SetReadyResults.Broadcast(SetReadyResult{
+			state.SetReadyResults.Reply(cmd, SetReadyResult{
 				RequestID: payload.RequestID,
 				IsSuccess: false,
 				Message:   "cannot change ready status during session",
@@ -1705,7 +1711,7 @@ func processSetReadyCommands(state *LobbySystemState, lobbyIndex *component.Lobb
 		// Update player entity's IsReady
 		playerEntityID, exists := lobbyIndex.GetPlayerEntityID(playerID)
 		if !exists {
-			state.SetReadyResults.Broadcast(SetReadyResult{
+			state.SetReadyResults.Reply(cmd, SetReadyResult{
 				RequestID: payload.RequestID,
 				IsSuccess: false,
 				Message:   "player entity not found",
@@ -1714,7 +1720,7 @@ func processSetReadyCommands(state *LobbySystemState, lobbyIndex *component.Lobb
 		}
 		playerEntity, err := state.Players.GetByID(cardinal.EntityID(playerEntityID))
 		if err != nil {
-			state.SetReadyResults.Broadcast(SetReadyResult{
+			state.SetReadyResults.Reply(cmd, SetReadyResult{
 				RequestID: payload.RequestID,
 				IsSuccess: false,
 				Message:   "player entity not found",
@@ -1739,7 +1745,7 @@ func processSetReadyCommands(state *LobbySystemState, lobbyIndex *component.Lobb
 			Player:  playerComp,
 		})
 
-		state.SetReadyResults.Broadcast(SetReadyResult{
+		state.SetReadyResults.Reply(cmd, SetReadyResult{
 			RequestID: payload.RequestID,
 			IsSuccess: true,
 			Message:   "ready status updated",
@@ -1755,7 +1761,7 @@ func processKickPlayerCommands(state *LobbySystemState, lobbyIndex *component.Lo
 
 		result := getPlayerLobby(playerID, lobbyIndex, &state.Lobbies)
 		if result == nil {
-			state.KickPlayerResults.Broadcast(KickPlayerResult{
+			state.KickPlayerResults.Reply(cmd, KickPlayerResult{
 				RequestID: payload.RequestID,
 				IsSuccess: false,
 				Message:   "player not in a lobby",
@@ -1768,7 +1774,7 @@ func processKickPlayerCommands(state *LobbySystemState, lobbyIndex *component.Lo
 		// Only leader can kick
 		if !lobby.IsLeader(playerID) {
 			state.Logger().Warn().Str("lobby_id", lobbyID).Str("player_id", playerID).Msg("only leader can kick players")
-			state.KickPlayerResults.Broadcast(KickPlayerResult{
+			state.KickPlayerResults.Reply(cmd, KickPlayerResult{
 				RequestID: payload.RequestID,
 				IsSuccess: false,
 				Message:   "only leader can kick players",
@@ -1778,7 +1784,7 @@ func processKickPlayerCommands(state *LobbySystemState, lobbyIndex *component.Lo
 
 		// Can't kick self
 		if payload.TargetPlayerID == playerID {
-			state.KickPlayerResults.Broadcast(KickPlayerResult{
+			state.KickPlayerResults.Reply(cmd, KickPlayerResult{
 				RequestID: payload.RequestID,
 				IsSuccess: false,
 				Message:   "cannot kick yourself",
@@ -1788,7 +1794,7 @@ func processKickPlayerCommands(state *LobbySystemState, lobbyIndex *component.Lo
 
 		// Check if target is in lobby
 		if !lobby.HasPlayer(payload.TargetPlayerID) {
-			state.KickPlayerResults.Broadcast(KickPlayerResult{
+			state.KickPlayerResults.Reply(cmd, KickPlayerResult{
 				RequestID: payload.RequestID,
 				IsSuccess: false,
 				Message:   "target player not in lobby",
@@ -1823,7 +1829,7 @@ func processKickPlayerCommands(state *LobbySystemState, lobbyIndex *component.Lo
 			KickerID: playerID,
 		})
 
-		state.KickPlayerResults.Broadcast(KickPlayerResult{
+		state.KickPlayerResults.Reply(cmd, KickPlayerResult{
 			RequestID: payload.RequestID,
 			IsSuccess: true,
 			Message:   "player kicked",
@@ -1838,7 +1844,7 @@ func processTransferLeaderCommands(state *LobbySystemState, lobbyIndex *componen
 
 		result := getPlayerLobby(playerID, lobbyIndex, &state.Lobbies)
 		if result == nil {
-			state.TransferLeaderResults.Broadcast(TransferLeaderResult{
+			state.TransferLeaderResults.Reply(cmd, TransferLeaderResult{
 				RequestID: payload.RequestID,
 				IsSuccess: false,
 				Message:   "player not in a lobby",
@@ -1851,7 +1857,7 @@ func processTransferLeaderCommands(state *LobbySystemState, lobbyIndex *componen
 		// Only leader can transfer
 		if !lobby.IsLeader(playerID) {
 			state.Logger().Warn().Str("lobby_id", lobbyID).Str("player_id", playerID).Msg("only leader can transfer leadership")
-			state.TransferLeaderResults.Broadcast(TransferLeaderResult{
+			state.TransferLeaderResults.Reply(cmd, TransferLeaderResult{
 				RequestID: payload.RequestID,
 				IsSuccess: false,
 				Message:   "only leader can transfer leadership",
@@ -1863,7 +1869,7 @@ func processTransferLeaderCommands(state *LobbySystemState, lobbyIndex *componen
 		if !lobby.HasPlayer(payload.TargetPlayerID) {
 			state.Logger().Warn().Str("lobby_id", lobbyID).Str("target", payload.TargetPlayerID).
 				Msg("target player not in lobby")
-			state.TransferLeaderResults.Broadcast(TransferLeaderResult{
+			state.TransferLeaderResults.Reply(cmd, TransferLeaderResult{
 				RequestID: payload.RequestID,
 				IsSuccess: false,
 				Message:   "target player not in lobby",
@@ -1890,7 +1896,7 @@ func processTransferLeaderCommands(state *LobbySystemState, lobbyIndex *componen
 			NewLeaderID: payload.TargetPlayerID,
 		})
 
-		state.TransferLeaderResults.Broadcast(TransferLeaderResult{
+		state.TransferLeaderResults.Reply(cmd, TransferLeaderResult{
 			RequestID: payload.RequestID,
 			IsSuccess: true,
 			Message:   "leadership transferred",
@@ -1908,7 +1914,7 @@ func processStartSessionCommands(
 
 		result := getPlayerLobby(playerID, lobbyIndex, &state.Lobbies)
 		if result == nil {
-			state.StartSessionResults.Broadcast(StartSessionResult{
+			state.StartSessionResults.Reply(cmd, StartSessionResult{
 				RequestID: payload.RequestID,
 				IsSuccess: false,
 				Message:   "player not in a lobby",
@@ -1921,7 +1927,7 @@ func processStartSessionCommands(
 		// Only leader can start
 		if !lobby.IsLeader(playerID) {
 			state.Logger().Warn().Str("lobby_id", lobbyID).Str("player_id", playerID).Msg("only leader can start session")
-			state.StartSessionResults.Broadcast(StartSessionResult{
+			state.StartSessionResults.Reply(cmd, StartSessionResult{
 				RequestID: payload.RequestID,
 				IsSuccess: false,
 				Message:   "only leader can start session",
@@ -1931,7 +1937,7 @@ func processStartSessionCommands(
 
 		// Already in session or awaiting assignment
 		if lobby.Session.State == component.SessionStateInSession {
-			state.StartSessionResults.Broadcast(StartSessionResult{
+			state.StartSessionResults.Reply(cmd, StartSessionResult{
 				RequestID: payload.RequestID,
 				IsSuccess: false,
 				Message:   "session already in progress",
@@ -1939,7 +1945,7 @@ func processStartSessionCommands(
 			continue
 		}
 		if lobby.Session.State == component.SessionStateAwaitingAllocation {
-			state.StartSessionResults.Broadcast(StartSessionResult{
+			state.StartSessionResults.Reply(cmd, StartSessionResult{
 				RequestID: payload.RequestID,
 				IsSuccess: false,
 				Message:   "session already pending shard assignment",
@@ -1950,7 +1956,7 @@ func processStartSessionCommands(
 		// Check all ready
 		if !areAllPlayersReady(state, lobbyIndex, &lobby) {
 			state.Logger().Warn().Str("lobby_id", lobbyID).Msg("not all players are ready")
-			state.StartSessionResults.Broadcast(StartSessionResult{
+			state.StartSessionResults.Reply(cmd, StartSessionResult{
 				RequestID: payload.RequestID,
 				IsSuccess: false,
 				Message:   "not all players are ready",
@@ -2030,7 +2036,7 @@ func failPendingAssignment(
 	if lobby.Session.PendingRequestID == "" {
 		return
 	}
-	results.Broadcast(StartSessionResult{
+	results.SendTo(lobby.LeaderID, StartSessionResult{
 		RequestID: lobby.Session.PendingRequestID,
 		IsSuccess: false,
 		Message:   reason,
@@ -2053,7 +2059,7 @@ func abortAwaitingAllocation(
 		return
 	}
 	if lobby.Session.PendingRequestID != "" {
-		results.Broadcast(StartSessionResult{
+		results.SendTo(lobby.LeaderID, StartSessionResult{
 			RequestID: lobby.Session.PendingRequestID,
 			IsSuccess: false,
 			Message:   reason,
@@ -2167,7 +2173,7 @@ func processAssignShardCommands(
 
 		dispatchSessionStart(state, config, &lobby, payload.LobbyID)
 
-		state.StartSessionResults.Broadcast(StartSessionResult{
+		state.StartSessionResults.SendTo(lobby.LeaderID, StartSessionResult{
 			RequestID: requestID,
 			IsSuccess: true,
 			Message:   "session started",
@@ -2256,7 +2262,7 @@ func processGenerateInviteCodeCommands(state *LobbySystemState, lobbyIndex *comp
 
 		result := getPlayerLobby(playerID, lobbyIndex, &state.Lobbies)
 		if result == nil {
-			state.GenerateInviteCodeResults.Broadcast(GenerateInviteCodeResult{
+			state.GenerateInviteCodeResults.Reply(cmd, GenerateInviteCodeResult{
 				RequestID: payload.RequestID,
 				IsSuccess: false,
 				Message:   "player not in a lobby",
@@ -2269,7 +2275,7 @@ func processGenerateInviteCodeCommands(state *LobbySystemState, lobbyIndex *comp
 		// Only leader can generate
 		if !lobby.IsLeader(playerID) {
 			state.Logger().Warn().Str("lobby_id", lobbyID).Str("player_id", playerID).Msg("only leader can generate invite code")
-			state.GenerateInviteCodeResults.Broadcast(GenerateInviteCodeResult{
+			state.GenerateInviteCodeResults.Reply(cmd, GenerateInviteCodeResult{
 				RequestID: payload.RequestID,
 				IsSuccess: false,
 				Message:   "only leader can generate invite code",
@@ -2285,7 +2291,7 @@ func processGenerateInviteCodeCommands(state *LobbySystemState, lobbyIndex *comp
 		)
 		if !newCodeValid {
 			state.Logger().Warn().Str("lobby_id", lobbyID).Msg("invite code collision after retries")
-			state.GenerateInviteCodeResults.Broadcast(GenerateInviteCodeResult{
+			state.GenerateInviteCodeResults.Reply(cmd, GenerateInviteCodeResult{
 				RequestID: payload.RequestID,
 				IsSuccess: false,
 				Message:   "invite code collision",
@@ -2314,7 +2320,7 @@ func processGenerateInviteCodeCommands(state *LobbySystemState, lobbyIndex *comp
 			InviteCode: newCode,
 		})
 
-		state.GenerateInviteCodeResults.Broadcast(GenerateInviteCodeResult{
+		state.GenerateInviteCodeResults.Reply(cmd, GenerateInviteCodeResult{
 			RequestID:  payload.RequestID,
 			IsSuccess:  true,
 			Message:    "invite code generated",
@@ -2330,7 +2336,7 @@ func processUpdateSessionPassthroughCommands(state *LobbySystemState, lobbyIndex
 
 		result := getPlayerLobby(playerID, lobbyIndex, &state.Lobbies)
 		if result == nil {
-			state.UpdateSessionPassthroughResults.Broadcast(UpdateSessionPassthroughResult{
+			state.UpdateSessionPassthroughResults.Reply(cmd, UpdateSessionPassthroughResult{
 				RequestID: payload.RequestID,
 				IsSuccess: false,
 				Message:   "player not in a lobby",
@@ -2344,7 +2350,7 @@ func processUpdateSessionPassthroughCommands(state *LobbySystemState, lobbyIndex
 		if !lobby.IsLeader(playerID) {
 			state.Logger().Warn().Str("lobby_id", lobbyID).Str("player_id", playerID).
 				Msg("only leader can update session passthrough data")
-			state.UpdateSessionPassthroughResults.Broadcast(UpdateSessionPassthroughResult{
+			state.UpdateSessionPassthroughResults.Reply(cmd, UpdateSessionPassthroughResult{
 				RequestID: payload.RequestID,
 				IsSuccess: false,
 				Message:   "only leader can update session passthrough data",
@@ -2371,7 +2377,7 @@ func processUpdateSessionPassthroughCommands(state *LobbySystemState, lobbyIndex
 			PassthroughData: lobby.Session.PassthroughData,
 		})
 
-		state.UpdateSessionPassthroughResults.Broadcast(UpdateSessionPassthroughResult{
+		state.UpdateSessionPassthroughResults.Reply(cmd, UpdateSessionPassthroughResult{
 			RequestID: payload.RequestID,
 			IsSuccess: true,
 			Message:   "session passthrough data updated",
@@ -2386,7 +2392,7 @@ func processUpdatePlayerPassthroughCommands(state *LobbySystemState, lobbyIndex
 
 		result := getPlayerLobby(playerID, lobbyIndex, &state.Lobbies)
 		if result == nil {
-			state.UpdatePlayerPassthroughResults.Broadcast(UpdatePlayerPassthroughResult{
+			state.UpdatePlayerPassthroughResults.Reply(cmd, UpdatePlayerPassthroughResult{
 				RequestID: payload.RequestID,
 				IsSuccess: false,
 				Message:   "player not in a lobby",
@@ -2398,7 +2404,7 @@ func processUpdatePlayerPassthroughCommands(state *LobbySystemState, lobbyIndex
 		// Update player entity's passthrough data
 		playerEntityID, exists := lobbyIndex.GetPlayerEntityID(playerID)
 		if !exists {
-			state.UpdatePlayerPassthroughResults.Broadcast(UpdatePlayerPassthroughResult{
+			state.UpdatePlayerPassthroughResults.Reply(cmd, UpdatePlayerPassthroughResult{
 				RequestID: payload.RequestID,
 				IsSuccess: false,
 				Message:   "player entity not found",
@@ -2407,7 +2413,7 @@ func processUpdatePlayerPassthroughCommands(state *LobbySystemState, lobbyIndex
 		}
 		playerEntity, err := state.Players.GetByID(cardinal.EntityID(playerEntityID))
 		if err != nil {
-			state.UpdatePlayerPassthroughResults.Broadcast(UpdatePlayerPassthroughResult{
+			state.UpdatePlayerPassthroughResults.Reply(cmd, UpdatePlayerPassthroughResult{
 				RequestID: payload.RequestID,
 				IsSuccess: false,
 				Message:   "player entity not found",
@@ -2435,7 +2441,7 @@ func processUpdatePlayerPassthroughCommands(state *LobbySystemState, lobbyIndex
 			Player:  playerComp,
 		})
 
-		state.UpdatePlayerPassthroughResults.Broadcast(UpdatePlayerPassthroughResult{
+		state.UpdatePlayerPassthroughResults.Reply(cmd, UpdatePlayerPassthroughResult{
 			RequestID: payload.RequestID,
 			IsSuccess: true,
 			Message:   "player passthrough data updated",
@@ -2458,7 +2464,7 @@ func processGetPlayerCommands(state *LobbySystemState, lobbyIndex *component.Lob
 		// Check if target player exists
 		playerEntityID, exists := lobbyIndex.GetPlayerEntityID(targetPlayerID)
 		if !exists {
-			state.GetPlayerResults.Broadcast(GetPlayerResult{
+			state.GetPlayerResults.Reply(cmd, GetPlayerResult{
 				RequestID: payload.RequestID,
 				IsSuccess: false,
 				Message:   "player not found",
@@ -2468,7 +2474,7 @@ func processGetPlayerCommands(state *LobbySystemState, lobbyIndex *component.Lob
 
 		playerEntity, err := state.Players.GetByID(cardinal.EntityID(playerEntityID))
 		if err != nil {
-			state.GetPlayerResults.Broadcast(GetPlayerResult{
+			state.GetPlayerResults.Reply(cmd, GetPlayerResult{
 				RequestID: payload.RequestID,
 				IsSuccess: false,
 				Message:   "player entity not found",
@@ -2478,7 +2484,7 @@ func processGetPlayerCommands(state *LobbySystemState, lobbyIndex *component.Lob
 
 		playerComp := playerEntity.Player.Get()
 
-		state.GetPlayerResults.Broadcast(GetPlayerResult{
+		state.GetPlayerResults.Reply(cmd, GetPlayerResult{
 			RequestID: payload.RequestID,
 			IsSuccess: true,
 			Message:   "player found",
@@ -2494,7 +2500,7 @@ func processGetLobbyCommands(state *LobbySystemState, lobbyIndex *component.Lobb
 
 		result := getPlayerLobby(playerID, lobbyIndex, &state.Lobbies)
 		if result == nil {
-			state.GetLobbyResults.Broadcast(GetLobbyResult{
+			state.GetLobbyResults.Reply(cmd, GetLobbyResult{
 				RequestID: payload.RequestID,
 				IsSuccess: false,
 				Message:   "player not in a lobby",
@@ -2502,7 +2508,7 @@ func processGetLobbyCommands(state *LobbySystemState, lobbyIndex *component.Lobb
 			continue
 		}
 
-		state.GetLobbyResults.Broadcast(GetLobbyResult{
+		state.GetLobbyResults.Reply(cmd, GetLobbyResult{
 			RequestID: payload.RequestID,
 			IsSuccess: true,
 			Message:   "lobby found",
@@ -2519,7 +2525,7 @@ func processGetAllPlayersCommands(state *LobbySystemState, lobbyIndex *component
 		// Get caller's lobby
 		result := getPlayerLobby(playerID, lobbyIndex, &state.Lobbies)
 		if result == nil {
-			state.GetAllPlayersResults.Broadcast(GetAllPlayersResult{
+			state.GetAllPlayersResults.Reply(cmd, GetAllPlayersResult{
 				RequestID: payload.RequestID,
 				IsSuccess: false,
 				Message:   "player not in a lobby",
@@ -2543,7 +2549,7 @@ func processGetAllPlayersCommands(state *LobbySystemState, lobbyIndex *component
 			players = append(players, playerEntity.Player.Get())
 		}
 
-		state.GetAllPlayersResults.Broadcast(GetAllPlayersResult{
+		state.GetAllPlayersResults.Reply(cmd, GetAllPlayersResult{
 			RequestID: payload.RequestID,
 			IsSuccess: true,
 			Message:   "players found",
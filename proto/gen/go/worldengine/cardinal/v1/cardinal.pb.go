@@ -12,6 +12,8 @@ import (
 	v11 "github.com/argus-labs/world-engine/proto/gen/go/worldengine/micro/v1"
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
 	reflect "reflect"
 	sync "sync"
 	unsafe "unsafe"
@@ -24,6 +26,114 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+// CommandStatus is the processing status of a command.
+type CommandStatus int32
+
+const (
+	CommandStatus_COMMAND_STATUS_UNSPECIFIED CommandStatus = 0
+	// The command is queued and hasn't been processed yet.
+	CommandStatus_COMMAND_STATUS_PENDING CommandStatus = 1
+	// The command was processed by the shard.
+	CommandStatus_COMMAND_STATUS_PROCESSED CommandStatus = 2
+	// The command was processed, but the system handling it reported a failure.
+	CommandStatus_COMMAND_STATUS_FAILED CommandStatus = 3
+)
+
+// Enum value maps for CommandStatus.
+var (
+	CommandStatus_name = map[int32]string{
+		0: "COMMAND_STATUS_UNSPECIFIED",
+		1: "COMMAND_STATUS_PENDING",
+		2: "COMMAND_STATUS_PROCESSED",
+		3: "COMMAND_STATUS_FAILED",
+	}
+	CommandStatus_value = map[string]int32{
+		"COMMAND_STATUS_UNSPECIFIED": 0,
+		"COMMAND_STATUS_PENDING":     1,
+		"COMMAND_STATUS_PROCESSED":   2,
+		"COMMAND_STATUS_FAILED":      3,
+	}
+)
+
+func (x CommandStatus) Enum() *CommandStatus {
+	p := new(CommandStatus)
+	*p = x
+	return p
+}
+
+func (x CommandStatus) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (CommandStatus) Descriptor() protoreflect.EnumDescriptor {
+	return file_worldengine_cardinal_v1_cardinal_proto_enumTypes[0].Descriptor()
+}
+
+func (CommandStatus) Type() protoreflect.EnumType {
+	return &file_worldengine_cardinal_v1_cardinal_proto_enumTypes[0]
+}
+
+func (x CommandStatus) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use CommandStatus.Descriptor instead.
+func (CommandStatus) EnumDescriptor() ([]byte, []int) {
+	return file_worldengine_cardinal_v1_cardinal_proto_rawDescGZIP(), []int{0}
+}
+
+// QueryMatch selects how a query's components are matched against an entity's components.
+type QueryMatch int32
+
+const (
+	QueryMatch_QUERY_MATCH_UNSPECIFIED QueryMatch = 0
+	// Matches entities that have all of the query's components, and possibly others.
+	QueryMatch_QUERY_MATCH_CONTAINS QueryMatch = 1
+	// Matches entities that have exactly the query's components.
+	QueryMatch_QUERY_MATCH_EXACT QueryMatch = 2
+)
+
+// Enum value maps for QueryMatch.
+var (
+	QueryMatch_name = map[int32]string{
+		0: "QUERY_MATCH_UNSPECIFIED",
+		1: "QUERY_MATCH_CONTAINS",
+		2: "QUERY_MATCH_EXACT",
+	}
+	QueryMatch_value = map[string]int32{
+		"QUERY_MATCH_UNSPECIFIED": 0,
+		"QUERY_MATCH_CONTAINS":    1,
+		"QUERY_MATCH_EXACT":       2,
+	}
+)
+
+func (x QueryMatch) Enum() *QueryMatch {
+	p := new(QueryMatch)
+	*p = x
+	return p
+}
+
+func (x QueryMatch) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (QueryMatch) Descriptor() protoreflect.EnumDescriptor {
+	return file_worldengine_cardinal_v1_cardinal_proto_enumTypes[1].Descriptor()
+}
+
+func (QueryMatch) Type() protoreflect.EnumType {
+	return &file_worldengine_cardinal_v1_cardinal_proto_enumTypes[1]
+}
+
+func (x QueryMatch) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use QueryMatch.Descriptor instead.
+func (QueryMatch) EnumDescriptor() ([]byte, []int) {
+	return file_worldengine_cardinal_v1_cardinal_proto_rawDescGZIP(), []int{1}
+}
+
 // SendCommandRequest represents a request to execute a command on a specific shard.
 type SendCommandRequest struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
@@ -70,9 +180,13 @@ func (x *SendCommandRequest) GetCommand() *v1.Command {
 	return nil
 }
 
-// SendCommandResponse is returned upon successful command execution.
+// SendCommandResponse is returned when the command is accepted by the shard.
 type SendCommandResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// ID of the command's receipt, used to look up its status with GetCommandReceipt.
+	ReceiptId uint64 `protobuf:"varint,1,opt,name=receipt_id,json=receiptId,proto3" json:"receipt_id,omitempty"`
+	// The tick the command is expected to be processed at.
+	TickHeight    uint64 `protobuf:"varint,2,opt,name=tick_height,json=tickHeight,proto3" json:"tick_height,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -107,31 +221,43 @@ func (*SendCommandResponse) Descriptor() ([]byte, []int) {
 	return file_worldengine_cardinal_v1_cardinal_proto_rawDescGZIP(), []int{1}
 }
 
-// SendCommandWithReplyRequest represents a request to execute a command and wait for an event response.
-type SendCommandWithReplyRequest struct {
+func (x *SendCommandResponse) GetReceiptId() uint64 {
+	if x != nil {
+		return x.ReceiptId
+	}
+	return 0
+}
+
+func (x *SendCommandResponse) GetTickHeight() uint64 {
+	if x != nil {
+		return x.TickHeight
+	}
+	return 0
+}
+
+// SendCommandsRequest represents a request to execute a batch of commands on a specific shard.
+type SendCommandsRequest struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
-	// The command to execute on the shard.
-	Command *v1.Command `protobuf:"bytes,1,opt,name=command,proto3" json:"command,omitempty"`
-	// The name of the event to wait for after the command is executed.
-	EventName     string `protobuf:"bytes,2,opt,name=event_name,json=eventName,proto3" json:"event_name,omitempty"`
+	// The commands to execute, in order. All commands must be addressed to the same shard.
+	Commands      []*v1.Command `protobuf:"bytes,1,rep,name=commands,proto3" json:"commands,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *SendCommandWithReplyRequest) Reset() {
-	*x = SendCommandWithReplyRequest{}
+func (x *SendCommandsRequest) Reset() {
+	*x = SendCommandsRequest{}
 	mi := &file_worldengine_cardinal_v1_cardinal_proto_msgTypes[2]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *SendCommandWithReplyRequest) String() string {
+func (x *SendCommandsRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SendCommandWithReplyRequest) ProtoMessage() {}
+func (*SendCommandsRequest) ProtoMessage() {}
 
-func (x *SendCommandWithReplyRequest) ProtoReflect() protoreflect.Message {
+func (x *SendCommandsRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_worldengine_cardinal_v1_cardinal_proto_msgTypes[2]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -143,48 +269,46 @@ func (x *SendCommandWithReplyRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SendCommandWithReplyRequest.ProtoReflect.Descriptor instead.
-func (*SendCommandWithReplyRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use SendCommandsRequest.ProtoReflect.Descriptor instead.
+func (*SendCommandsRequest) Descriptor() ([]byte, []int) {
 	return file_worldengine_cardinal_v1_cardinal_proto_rawDescGZIP(), []int{2}
 }
 
-func (x *SendCommandWithReplyRequest) GetCommand() *v1.Command {
+func (x *SendCommandsRequest) GetCommands() []*v1.Command {
 	if x != nil {
-		return x.Command
+		return x.Commands
 	}
 	return nil
 }
 
-func (x *SendCommandWithReplyRequest) GetEventName() string {
-	if x != nil {
-		return x.EventName
-	}
-	return ""
-}
-
-// SendCommandWithReplyResponse is returned with the event from the command execution.
-type SendCommandWithReplyResponse struct {
+// SendCommandsResponse is returned when the batch is handled. If any command is invalid, no command
+// is enqueued and the results of the invalid commands contain the reason.
+type SendCommandsResponse struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
-	// The event received from the shard after command execution.
-	Event         *v1.Event `protobuf:"bytes,1,opt,name=event,proto3" json:"event,omitempty"`
+	// Whether the commands were enqueued.
+	Accepted bool `protobuf:"varint,1,opt,name=accepted,proto3" json:"accepted,omitempty"`
+	// The tick the commands are expected to be processed at, set if accepted.
+	TickHeight uint64 `protobuf:"varint,2,opt,name=tick_height,json=tickHeight,proto3" json:"tick_height,omitempty"`
+	// The result of each command, in request order.
+	Results       []*SendCommandResult `protobuf:"bytes,3,rep,name=results,proto3" json:"results,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *SendCommandWithReplyResponse) Reset() {
-	*x = SendCommandWithReplyResponse{}
+func (x *SendCommandsResponse) Reset() {
+	*x = SendCommandsResponse{}
 	mi := &file_worldengine_cardinal_v1_cardinal_proto_msgTypes[3]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *SendCommandWithReplyResponse) String() string {
+func (x *SendCommandsResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SendCommandWithReplyResponse) ProtoMessage() {}
+func (*SendCommandsResponse) ProtoMessage() {}
 
-func (x *SendCommandWithReplyResponse) ProtoReflect() protoreflect.Message {
+func (x *SendCommandsResponse) ProtoReflect() protoreflect.Message {
 	mi := &file_worldengine_cardinal_v1_cardinal_proto_msgTypes[3]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -196,43 +320,57 @@ func (x *SendCommandWithReplyResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SendCommandWithReplyResponse.ProtoReflect.Descriptor instead.
-func (*SendCommandWithReplyResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use SendCommandsResponse.ProtoReflect.Descriptor instead.
+func (*SendCommandsResponse) Descriptor() ([]byte, []int) {
 	return file_worldengine_cardinal_v1_cardinal_proto_rawDescGZIP(), []int{3}
 }
 
-func (x *SendCommandWithReplyResponse) GetEvent() *v1.Event {
+func (x *SendCommandsResponse) GetAccepted() bool {
 	if x != nil {
-		return x.Event
+		return x.Accepted
+	}
+	return false
+}
+
+func (x *SendCommandsResponse) GetTickHeight() uint64 {
+	if x != nil {
+		return x.TickHeight
+	}
+	return 0
+}
+
+func (x *SendCommandsResponse) GetResults() []*SendCommandResult {
+	if x != nil {
+		return x.Results
 	}
 	return nil
 }
 
-type EventSubscription struct {
+// SendCommandResult is the result of a single command in a batch.
+type SendCommandResult struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
-	// Specifies the target shard address from which to stream events.
-	Address *v11.ServiceAddress `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
-	// A list of events to subscribe to. If the event belongs to a group, use the `<group>.<event>`
-	// format. This also supports the '*' and '>' wildcards.
-	Events        []string `protobuf:"bytes,2,rep,name=events,proto3" json:"events,omitempty"`
+	// ID of the command's receipt, set if the batch is accepted.
+	ReceiptId uint64 `protobuf:"varint,1,opt,name=receipt_id,json=receiptId,proto3" json:"receipt_id,omitempty"`
+	// Why the command was rejected, empty if the command is valid.
+	Error         string `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *EventSubscription) Reset() {
-	*x = EventSubscription{}
+func (x *SendCommandResult) Reset() {
+	*x = SendCommandResult{}
 	mi := &file_worldengine_cardinal_v1_cardinal_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *EventSubscription) String() string {
+func (x *SendCommandResult) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*EventSubscription) ProtoMessage() {}
+func (*SendCommandResult) ProtoMessage() {}
 
-func (x *EventSubscription) ProtoReflect() protoreflect.Message {
+func (x *SendCommandResult) ProtoReflect() protoreflect.Message {
 	mi := &file_worldengine_cardinal_v1_cardinal_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -244,49 +382,55 @@ func (x *EventSubscription) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use EventSubscription.ProtoReflect.Descriptor instead.
-func (*EventSubscription) Descriptor() ([]byte, []int) {
+// Deprecated: Use SendCommandResult.ProtoReflect.Descriptor instead.
+func (*SendCommandResult) Descriptor() ([]byte, []int) {
 	return file_worldengine_cardinal_v1_cardinal_proto_rawDescGZIP(), []int{4}
 }
 
-func (x *EventSubscription) GetAddress() *v11.ServiceAddress {
+func (x *SendCommandResult) GetReceiptId() uint64 {
 	if x != nil {
-		return x.Address
+		return x.ReceiptId
 	}
-	return nil
+	return 0
 }
 
-func (x *EventSubscription) GetEvents() []string {
+func (x *SendCommandResult) GetError() string {
 	if x != nil {
-		return x.Events
+		return x.Error
 	}
-	return nil
+	return ""
 }
 
-// StartEventStreamRequest represents a request to establish a stream of events from Cardinal.
-// This enables real-time monitoring of system events through a server-side streaming connection.
-type StartEventStreamRequest struct {
+// SendCommandWithReplyRequest represents a request to execute a command and wait for an event response.
+type SendCommandWithReplyRequest struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
-	// TODO: replace this with bidi streams.
-	Subscriptions []*EventSubscription `protobuf:"bytes,1,rep,name=subscriptions,proto3" json:"subscriptions,omitempty"`
+	// The command to execute on the shard.
+	Command *v1.Command `protobuf:"bytes,1,opt,name=command,proto3" json:"command,omitempty"`
+	// Deprecated: replies are matched to the command they answer, not by event name. This field is
+	// ignored.
+	//
+	// Deprecated: Marked as deprecated in worldengine/cardinal/v1/cardinal.proto.
+	EventName string `protobuf:"bytes,2,opt,name=event_name,json=eventName,proto3" json:"event_name,omitempty"`
+	// How long to wait for the reply. Defaults to 30 seconds.
+	Timeout       *durationpb.Duration `protobuf:"bytes,3,opt,name=timeout,proto3" json:"timeout,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *StartEventStreamRequest) Reset() {
-	*x = StartEventStreamRequest{}
+func (x *SendCommandWithReplyRequest) Reset() {
+	*x = SendCommandWithReplyRequest{}
 	mi := &file_worldengine_cardinal_v1_cardinal_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *StartEventStreamRequest) String() string {
+func (x *SendCommandWithReplyRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*StartEventStreamRequest) ProtoMessage() {}
+func (*SendCommandWithReplyRequest) ProtoMessage() {}
 
-func (x *StartEventStreamRequest) ProtoReflect() protoreflect.Message {
+func (x *SendCommandWithReplyRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_worldengine_cardinal_v1_cardinal_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -298,42 +442,58 @@ func (x *StartEventStreamRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use StartEventStreamRequest.ProtoReflect.Descriptor instead.
-func (*StartEventStreamRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use SendCommandWithReplyRequest.ProtoReflect.Descriptor instead.
+func (*SendCommandWithReplyRequest) Descriptor() ([]byte, []int) {
 	return file_worldengine_cardinal_v1_cardinal_proto_rawDescGZIP(), []int{5}
 }
 
-func (x *StartEventStreamRequest) GetSubscriptions() []*EventSubscription {
+func (x *SendCommandWithReplyRequest) GetCommand() *v1.Command {
 	if x != nil {
-		return x.Subscriptions
+		return x.Command
 	}
 	return nil
 }
 
-type StartEventStreamResponse struct {
+// Deprecated: Marked as deprecated in worldengine/cardinal/v1/cardinal.proto.
+func (x *SendCommandWithReplyRequest) GetEventName() string {
+	if x != nil {
+		return x.EventName
+	}
+	return ""
+}
+
+func (x *SendCommandWithReplyRequest) GetTimeout() *durationpb.Duration {
+	if x != nil {
+		return x.Timeout
+	}
+	return nil
+}
+
+// SendCommandWithReplyResponse is returned with the event from the command execution.
+type SendCommandWithReplyResponse struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
-	// Specifies the publisher's service address.
-	Address *v11.ServiceAddress `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
-	// The event payload.
-	Event         *v1.Event `protobuf:"bytes,2,opt,name=event,proto3" json:"event,omitempty"`
+	// The event the system replied with.
+	Event *v1.Event `protobuf:"bytes,1,opt,name=event,proto3" json:"event,omitempty"`
+	// ID of the command's receipt, used to look up its status with GetCommandReceipt.
+	ReceiptId     uint64 `protobuf:"varint,2,opt,name=receipt_id,json=receiptId,proto3" json:"receipt_id,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *StartEventStreamResponse) Reset() {
-	*x = StartEventStreamResponse{}
+func (x *SendCommandWithReplyResponse) Reset() {
+	*x = SendCommandWithReplyResponse{}
 	mi := &file_worldengine_cardinal_v1_cardinal_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *StartEventStreamResponse) String() string {
+func (x *SendCommandWithReplyResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*StartEventStreamResponse) ProtoMessage() {}
+func (*SendCommandWithReplyResponse) ProtoMessage() {}
 
-func (x *StartEventStreamResponse) ProtoReflect() protoreflect.Message {
+func (x *SendCommandWithReplyResponse) ProtoReflect() protoreflect.Message {
 	mi := &file_worldengine_cardinal_v1_cardinal_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -345,47 +505,55 @@ func (x *StartEventStreamResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use StartEventStreamResponse.ProtoReflect.Descriptor instead.
-func (*StartEventStreamResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use SendCommandWithReplyResponse.ProtoReflect.Descriptor instead.
+func (*SendCommandWithReplyResponse) Descriptor() ([]byte, []int) {
 	return file_worldengine_cardinal_v1_cardinal_proto_rawDescGZIP(), []int{6}
 }
 
-func (x *StartEventStreamResponse) GetAddress() *v11.ServiceAddress {
+func (x *SendCommandWithReplyResponse) GetEvent() *v1.Event {
 	if x != nil {
-		return x.Address
+		return x.Event
 	}
 	return nil
 }
 
-func (x *StartEventStreamResponse) GetEvent() *v1.Event {
+func (x *SendCommandWithReplyResponse) GetReceiptId() uint64 {
 	if x != nil {
-		return x.Event
+		return x.ReceiptId
 	}
-	return nil
+	return 0
 }
 
-// SubscribeEventsRequest represents a request to add new event types to an existing stream.
-type SubscribeEventsRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Subscriptions []*EventSubscription   `protobuf:"bytes,1,rep,name=subscriptions,proto3" json:"subscriptions,omitempty"`
+// ScheduleCommandRequest represents a request to process a command at a future tick.
+type ScheduleCommandRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The command to execute on the shard.
+	Command *v1.Command `protobuf:"bytes,1,opt,name=command,proto3" json:"command,omitempty"`
+	// When the command should be processed.
+	//
+	// Types that are valid to be assigned to When:
+	//
+	//	*ScheduleCommandRequest_TickHeight
+	//	*ScheduleCommandRequest_Delay
+	When          isScheduleCommandRequest_When `protobuf_oneof:"when"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *SubscribeEventsRequest) Reset() {
-	*x = SubscribeEventsRequest{}
+func (x *ScheduleCommandRequest) Reset() {
+	*x = ScheduleCommandRequest{}
 	mi := &file_worldengine_cardinal_v1_cardinal_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *SubscribeEventsRequest) String() string {
+func (x *ScheduleCommandRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SubscribeEventsRequest) ProtoMessage() {}
+func (*ScheduleCommandRequest) ProtoMessage() {}
 
-func (x *SubscribeEventsRequest) ProtoReflect() protoreflect.Message {
+func (x *ScheduleCommandRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_worldengine_cardinal_v1_cardinal_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -397,39 +565,88 @@ func (x *SubscribeEventsRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SubscribeEventsRequest.ProtoReflect.Descriptor instead.
-func (*SubscribeEventsRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use ScheduleCommandRequest.ProtoReflect.Descriptor instead.
+func (*ScheduleCommandRequest) Descriptor() ([]byte, []int) {
 	return file_worldengine_cardinal_v1_cardinal_proto_rawDescGZIP(), []int{7}
 }
 
-func (x *SubscribeEventsRequest) GetSubscriptions() []*EventSubscription {
+func (x *ScheduleCommandRequest) GetCommand() *v1.Command {
 	if x != nil {
-		return x.Subscriptions
+		return x.Command
 	}
 	return nil
 }
 
-// SubscribeEventsResponse is returned when the request is successfully handled.
-type SubscribeEventsResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
+func (x *ScheduleCommandRequest) GetWhen() isScheduleCommandRequest_When {
+	if x != nil {
+		return x.When
+	}
+	return nil
+}
+
+func (x *ScheduleCommandRequest) GetTickHeight() uint64 {
+	if x != nil {
+		if x, ok := x.When.(*ScheduleCommandRequest_TickHeight); ok {
+			return x.TickHeight
+		}
+	}
+	return 0
+}
+
+func (x *ScheduleCommandRequest) GetDelay() *durationpb.Duration {
+	if x != nil {
+		if x, ok := x.When.(*ScheduleCommandRequest_Delay); ok {
+			return x.Delay
+		}
+	}
+	return nil
+}
+
+type isScheduleCommandRequest_When interface {
+	isScheduleCommandRequest_When()
+}
+
+type ScheduleCommandRequest_TickHeight struct {
+	// Process the command at the first tick with a height greater than or equal to this one. It can be
+	// at most 2^24 ticks after the next tick.
+	TickHeight uint64 `protobuf:"varint,2,opt,name=tick_height,json=tickHeight,proto3,oneof"`
+}
+
+type ScheduleCommandRequest_Delay struct {
+	// Process the command after this delay, rounded up to whole ticks. It can be at most 7 days, and
+	// at most 2^24 ticks.
+	Delay *durationpb.Duration `protobuf:"bytes,3,opt,name=delay,proto3,oneof"`
+}
+
+func (*ScheduleCommandRequest_TickHeight) isScheduleCommandRequest_When() {}
+
+func (*ScheduleCommandRequest_Delay) isScheduleCommandRequest_When() {}
+
+// ScheduleCommandResponse is returned when the command is successfully scheduled.
+type ScheduleCommandResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Handle of the scheduled command, used to cancel it.
+	Handle uint64 `protobuf:"varint,1,opt,name=handle,proto3" json:"handle,omitempty"`
+	// The tick the command is scheduled to be processed at.
+	TickHeight    uint64 `protobuf:"varint,2,opt,name=tick_height,json=tickHeight,proto3" json:"tick_height,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *SubscribeEventsResponse) Reset() {
-	*x = SubscribeEventsResponse{}
+func (x *ScheduleCommandResponse) Reset() {
+	*x = ScheduleCommandResponse{}
 	mi := &file_worldengine_cardinal_v1_cardinal_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *SubscribeEventsResponse) String() string {
+func (x *ScheduleCommandResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SubscribeEventsResponse) ProtoMessage() {}
+func (*ScheduleCommandResponse) ProtoMessage() {}
 
-func (x *SubscribeEventsResponse) ProtoReflect() protoreflect.Message {
+func (x *ScheduleCommandResponse) ProtoReflect() protoreflect.Message {
 	mi := &file_worldengine_cardinal_v1_cardinal_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -441,33 +658,50 @@ func (x *SubscribeEventsResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SubscribeEventsResponse.ProtoReflect.Descriptor instead.
-func (*SubscribeEventsResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use ScheduleCommandResponse.ProtoReflect.Descriptor instead.
+func (*ScheduleCommandResponse) Descriptor() ([]byte, []int) {
 	return file_worldengine_cardinal_v1_cardinal_proto_rawDescGZIP(), []int{8}
 }
 
-// UnsubscribeEventsRequest represents a request to remove event types from an existing stream.
-type UnsubscribeEventsRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Subscriptions []*EventSubscription   `protobuf:"bytes,1,rep,name=subscriptions,proto3" json:"subscriptions,omitempty"`
+func (x *ScheduleCommandResponse) GetHandle() uint64 {
+	if x != nil {
+		return x.Handle
+	}
+	return 0
+}
+
+func (x *ScheduleCommandResponse) GetTickHeight() uint64 {
+	if x != nil {
+		return x.TickHeight
+	}
+	return 0
+}
+
+// CancelScheduledCommandRequest represents a request to cancel a scheduled command.
+type CancelScheduledCommandRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Specifies the shard address the command was scheduled on.
+	Address *v11.ServiceAddress `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	// Handle returned by ScheduleCommand.
+	Handle        uint64 `protobuf:"varint,2,opt,name=handle,proto3" json:"handle,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *UnsubscribeEventsRequest) Reset() {
-	*x = UnsubscribeEventsRequest{}
+func (x *CancelScheduledCommandRequest) Reset() {
+	*x = CancelScheduledCommandRequest{}
 	mi := &file_worldengine_cardinal_v1_cardinal_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *UnsubscribeEventsRequest) String() string {
+func (x *CancelScheduledCommandRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*UnsubscribeEventsRequest) ProtoMessage() {}
+func (*CancelScheduledCommandRequest) ProtoMessage() {}
 
-func (x *UnsubscribeEventsRequest) ProtoReflect() protoreflect.Message {
+func (x *CancelScheduledCommandRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_worldengine_cardinal_v1_cardinal_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -479,40 +713,893 @@ func (x *UnsubscribeEventsRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use UnsubscribeEventsRequest.ProtoReflect.Descriptor instead.
-func (*UnsubscribeEventsRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use CancelScheduledCommandRequest.ProtoReflect.Descriptor instead.
+func (*CancelScheduledCommandRequest) Descriptor() ([]byte, []int) {
 	return file_worldengine_cardinal_v1_cardinal_proto_rawDescGZIP(), []int{9}
 }
 
-func (x *UnsubscribeEventsRequest) GetSubscriptions() []*EventSubscription {
+func (x *CancelScheduledCommandRequest) GetAddress() *v11.ServiceAddress {
 	if x != nil {
-		return x.Subscriptions
+		return x.Address
 	}
 	return nil
 }
 
-// UnsubscribeEventsResponse is returned when the request is successfully handled.
-type UnsubscribeEventsResponse struct {
+func (x *CancelScheduledCommandRequest) GetHandle() uint64 {
+	if x != nil {
+		return x.Handle
+	}
+	return 0
+}
+
+// CancelScheduledCommandResponse is returned when the scheduled command is cancelled.
+type CancelScheduledCommandResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CancelScheduledCommandResponse) Reset() {
+	*x = CancelScheduledCommandResponse{}
+	mi := &file_worldengine_cardinal_v1_cardinal_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CancelScheduledCommandResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CancelScheduledCommandResponse) ProtoMessage() {}
+
+func (x *CancelScheduledCommandResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_worldengine_cardinal_v1_cardinal_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CancelScheduledCommandResponse.ProtoReflect.Descriptor instead.
+func (*CancelScheduledCommandResponse) Descriptor() ([]byte, []int) {
+	return file_worldengine_cardinal_v1_cardinal_proto_rawDescGZIP(), []int{10}
+}
+
+// GetCommandReceiptRequest represents a request to look up the receipt of a command.
+type GetCommandReceiptRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Specifies the shard address the command was sent to.
+	Address *v11.ServiceAddress `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	// Receipt ID returned by SendCommand.
+	ReceiptId     uint64 `protobuf:"varint,2,opt,name=receipt_id,json=receiptId,proto3" json:"receipt_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetCommandReceiptRequest) Reset() {
+	*x = GetCommandReceiptRequest{}
+	mi := &file_worldengine_cardinal_v1_cardinal_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetCommandReceiptRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCommandReceiptRequest) ProtoMessage() {}
+
+func (x *GetCommandReceiptRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_worldengine_cardinal_v1_cardinal_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCommandReceiptRequest.ProtoReflect.Descriptor instead.
+func (*GetCommandReceiptRequest) Descriptor() ([]byte, []int) {
+	return file_worldengine_cardinal_v1_cardinal_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *GetCommandReceiptRequest) GetAddress() *v11.ServiceAddress {
+	if x != nil {
+		return x.Address
+	}
+	return nil
+}
+
+func (x *GetCommandReceiptRequest) GetReceiptId() uint64 {
+	if x != nil {
+		return x.ReceiptId
+	}
+	return 0
+}
+
+// GetCommandReceiptResponse is returned with the receipt of the command.
+type GetCommandReceiptResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Receipt       *CommandReceipt        `protobuf:"bytes,1,opt,name=receipt,proto3" json:"receipt,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetCommandReceiptResponse) Reset() {
+	*x = GetCommandReceiptResponse{}
+	mi := &file_worldengine_cardinal_v1_cardinal_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetCommandReceiptResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCommandReceiptResponse) ProtoMessage() {}
+
+func (x *GetCommandReceiptResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_worldengine_cardinal_v1_cardinal_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCommandReceiptResponse.ProtoReflect.Descriptor instead.
+func (*GetCommandReceiptResponse) Descriptor() ([]byte, []int) {
+	return file_worldengine_cardinal_v1_cardinal_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *GetCommandReceiptResponse) GetReceipt() *CommandReceipt {
+	if x != nil {
+		return x.Receipt
+	}
+	return nil
+}
+
+// CommandReceipt describes what happened to a command sent to the shard.
+type CommandReceipt struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The receipt ID.
+	ReceiptId uint64 `protobuf:"varint,1,opt,name=receipt_id,json=receiptId,proto3" json:"receipt_id,omitempty"`
+	// The command name.
+	CommandName string `protobuf:"bytes,2,opt,name=command_name,json=commandName,proto3" json:"command_name,omitempty"`
+	// The processing status of the command.
+	Status CommandStatus `protobuf:"varint,3,opt,name=status,proto3,enum=worldengine.cardinal.v1.CommandStatus" json:"status,omitempty"`
+	// The tick the command is (or is expected to be) processed at.
+	TickHeight uint64 `protobuf:"varint,4,opt,name=tick_height,json=tickHeight,proto3" json:"tick_height,omitempty"`
+	// The failure reason reported by the system, set if status is FAILED.
+	Error string `protobuf:"bytes,5,opt,name=error,proto3" json:"error,omitempty"`
+	// The result reported by the system, if any.
+	Result        *v1.Event `protobuf:"bytes,6,opt,name=result,proto3" json:"result,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CommandReceipt) Reset() {
+	*x = CommandReceipt{}
+	mi := &file_worldengine_cardinal_v1_cardinal_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CommandReceipt) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CommandReceipt) ProtoMessage() {}
+
+func (x *CommandReceipt) ProtoReflect() protoreflect.Message {
+	mi := &file_worldengine_cardinal_v1_cardinal_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CommandReceipt.ProtoReflect.Descriptor instead.
+func (*CommandReceipt) Descriptor() ([]byte, []int) {
+	return file_worldengine_cardinal_v1_cardinal_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *CommandReceipt) GetReceiptId() uint64 {
+	if x != nil {
+		return x.ReceiptId
+	}
+	return 0
+}
+
+func (x *CommandReceipt) GetCommandName() string {
+	if x != nil {
+		return x.CommandName
+	}
+	return ""
+}
+
+func (x *CommandReceipt) GetStatus() CommandStatus {
+	if x != nil {
+		return x.Status
+	}
+	return CommandStatus_COMMAND_STATUS_UNSPECIFIED
+}
+
+func (x *CommandReceipt) GetTickHeight() uint64 {
+	if x != nil {
+		return x.TickHeight
+	}
+	return 0
+}
+
+func (x *CommandReceipt) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *CommandReceipt) GetResult() *v1.Event {
+	if x != nil {
+		return x.Result
+	}
+	return nil
+}
+
+type EventSubscription struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Specifies the target shard address from which to stream events.
+	Address *v11.ServiceAddress `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	// A list of events to subscribe to. If the event belongs to a group, use the `<group>.<event>`
+	// format. This also supports the '*' and '>' wildcards.
+	Events []string `protobuf:"bytes,2,rep,name=events,proto3" json:"events,omitempty"`
+	// An optional CEL expression over the event's fields, which are available as `event`, e.g.
+	// `event.zone_id == 3`. Only events for which it evaluates to true are sent. Events that don't
+	// have a field the expression uses don't match.
+	Filter        string `protobuf:"bytes,3,opt,name=filter,proto3" json:"filter,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EventSubscription) Reset() {
+	*x = EventSubscription{}
+	mi := &file_worldengine_cardinal_v1_cardinal_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EventSubscription) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EventSubscription) ProtoMessage() {}
+
+func (x *EventSubscription) ProtoReflect() protoreflect.Message {
+	mi := &file_worldengine_cardinal_v1_cardinal_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EventSubscription.ProtoReflect.Descriptor instead.
+func (*EventSubscription) Descriptor() ([]byte, []int) {
+	return file_worldengine_cardinal_v1_cardinal_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *EventSubscription) GetAddress() *v11.ServiceAddress {
+	if x != nil {
+		return x.Address
+	}
+	return nil
+}
+
+func (x *EventSubscription) GetEvents() []string {
+	if x != nil {
+		return x.Events
+	}
+	return nil
+}
+
+func (x *EventSubscription) GetFilter() string {
+	if x != nil {
+		return x.Filter
+	}
+	return ""
+}
+
+// StartEventStreamRequest represents a request to establish a stream of events from Cardinal.
+// This enables real-time monitoring of system events through a server-side streaming connection.
+type StartEventStreamRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// TODO: replace this with bidi streams.
+	Subscriptions []*EventSubscription `protobuf:"bytes,1,rep,name=subscriptions,proto3" json:"subscriptions,omitempty"`
+	// Resumes a dropped stream. The shard replays the retained events the stream is subscribed to
+	// with a sequence number greater than this, usually the sequence of the last event the client
+	// received. Unset starts a new stream without replaying any events.
+	ResumeAfter *uint64 `protobuf:"varint,2,opt,name=resume_after,json=resumeAfter,proto3,oneof" json:"resume_after,omitempty"`
+	// Identifies the stream among the user's open streams, e.g. per device or app. The shard generates
+	// one if it's empty. Opening a stream with the ID of an open stream replaces the open stream.
+	StreamId string `protobuf:"bytes,3,opt,name=stream_id,json=streamId,proto3" json:"stream_id,omitempty"`
+	// Sends the events of each tick together in a single message with the tick's height and timestamp,
+	// instead of one message per event. A batch is sent every tick, even if it has no events.
+	BatchByTick   bool `protobuf:"varint,4,opt,name=batch_by_tick,json=batchByTick,proto3" json:"batch_by_tick,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StartEventStreamRequest) Reset() {
+	*x = StartEventStreamRequest{}
+	mi := &file_worldengine_cardinal_v1_cardinal_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StartEventStreamRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StartEventStreamRequest) ProtoMessage() {}
+
+func (x *StartEventStreamRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_worldengine_cardinal_v1_cardinal_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StartEventStreamRequest.ProtoReflect.Descriptor instead.
+func (*StartEventStreamRequest) Descriptor() ([]byte, []int) {
+	return file_worldengine_cardinal_v1_cardinal_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *StartEventStreamRequest) GetSubscriptions() []*EventSubscription {
+	if x != nil {
+		return x.Subscriptions
+	}
+	return nil
+}
+
+func (x *StartEventStreamRequest) GetResumeAfter() uint64 {
+	if x != nil && x.ResumeAfter != nil {
+		return *x.ResumeAfter
+	}
+	return 0
+}
+
+func (x *StartEventStreamRequest) GetStreamId() string {
+	if x != nil {
+		return x.StreamId
+	}
+	return ""
+}
+
+func (x *StartEventStreamRequest) GetBatchByTick() bool {
+	if x != nil {
+		return x.BatchByTick
+	}
+	return false
+}
+
+type StartEventStreamResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Specifies the publisher's service address.
+	Address *v11.ServiceAddress `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	// The event payload.
+	Event *v1.Event `protobuf:"bytes,2,opt,name=event,proto3" json:"event,omitempty"`
+	// The event's sequence number, which increases with every event the shard publishes. Clients keep
+	// the latest one to resume the stream with. 0 for messages without an event, e.g. keepalives.
+	Sequence uint64 `protobuf:"varint,3,opt,name=sequence,proto3" json:"sequence,omitempty"`
+	// Set on the first message of a resumed stream if events after the resume cursor have already
+	// been evicted from the shard's history and can't be replayed, or if the cursor is from before the
+	// shard restarted.
+	HistoryTruncated bool `protobuf:"varint,4,opt,name=history_truncated,json=historyTruncated,proto3" json:"history_truncated,omitempty"`
+	// The stream's ID, used to target the stream in SubscribeEvents and UnsubscribeEvents. Only set on
+	// the first message of the stream.
+	StreamId string `protobuf:"bytes,5,opt,name=stream_id,json=streamId,proto3" json:"stream_id,omitempty"`
+	// The events of a tick, for streams started with batch_by_tick. The message's sequence is the
+	// sequence of the batch's last event, or of the stream's latest event if the batch is empty, so it
+	// can always be used to resume the stream.
+	Batch         *EventBatch `protobuf:"bytes,6,opt,name=batch,proto3" json:"batch,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StartEventStreamResponse) Reset() {
+	*x = StartEventStreamResponse{}
+	mi := &file_worldengine_cardinal_v1_cardinal_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StartEventStreamResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StartEventStreamResponse) ProtoMessage() {}
+
+func (x *StartEventStreamResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_worldengine_cardinal_v1_cardinal_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StartEventStreamResponse.ProtoReflect.Descriptor instead.
+func (*StartEventStreamResponse) Descriptor() ([]byte, []int) {
+	return file_worldengine_cardinal_v1_cardinal_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *StartEventStreamResponse) GetAddress() *v11.ServiceAddress {
+	if x != nil {
+		return x.Address
+	}
+	return nil
+}
+
+func (x *StartEventStreamResponse) GetEvent() *v1.Event {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+func (x *StartEventStreamResponse) GetSequence() uint64 {
+	if x != nil {
+		return x.Sequence
+	}
+	return 0
+}
+
+func (x *StartEventStreamResponse) GetHistoryTruncated() bool {
+	if x != nil {
+		return x.HistoryTruncated
+	}
+	return false
+}
+
+func (x *StartEventStreamResponse) GetStreamId() string {
+	if x != nil {
+		return x.StreamId
+	}
+	return ""
+}
+
+func (x *StartEventStreamResponse) GetBatch() *EventBatch {
+	if x != nil {
+		return x.Batch
+	}
+	return nil
+}
+
+// EventBatch is the events a stream receives from a single tick, in sequence order.
+type EventBatch struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The height of the tick the events were emitted in.
+	TickHeight uint64 `protobuf:"varint,1,opt,name=tick_height,json=tickHeight,proto3" json:"tick_height,omitempty"`
+	// The timestamp of the tick the events were emitted in.
+	Timestamp     *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Events        []*BatchedEvent        `protobuf:"bytes,3,rep,name=events,proto3" json:"events,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EventBatch) Reset() {
+	*x = EventBatch{}
+	mi := &file_worldengine_cardinal_v1_cardinal_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EventBatch) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EventBatch) ProtoMessage() {}
+
+func (x *EventBatch) ProtoReflect() protoreflect.Message {
+	mi := &file_worldengine_cardinal_v1_cardinal_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EventBatch.ProtoReflect.Descriptor instead.
+func (*EventBatch) Descriptor() ([]byte, []int) {
+	return file_worldengine_cardinal_v1_cardinal_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *EventBatch) GetTickHeight() uint64 {
+	if x != nil {
+		return x.TickHeight
+	}
+	return 0
+}
+
+func (x *EventBatch) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+func (x *EventBatch) GetEvents() []*BatchedEvent {
+	if x != nil {
+		return x.Events
+	}
+	return nil
+}
+
+// BatchedEvent is an event in an EventBatch.
+type BatchedEvent struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The event payload.
+	Event *v1.Event `protobuf:"bytes,1,opt,name=event,proto3" json:"event,omitempty"`
+	// The event's sequence number.
+	Sequence      uint64 `protobuf:"varint,2,opt,name=sequence,proto3" json:"sequence,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchedEvent) Reset() {
+	*x = BatchedEvent{}
+	mi := &file_worldengine_cardinal_v1_cardinal_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchedEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchedEvent) ProtoMessage() {}
+
+func (x *BatchedEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_worldengine_cardinal_v1_cardinal_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchedEvent.ProtoReflect.Descriptor instead.
+func (*BatchedEvent) Descriptor() ([]byte, []int) {
+	return file_worldengine_cardinal_v1_cardinal_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *BatchedEvent) GetEvent() *v1.Event {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+func (x *BatchedEvent) GetSequence() uint64 {
+	if x != nil {
+		return x.Sequence
+	}
+	return 0
+}
+
+// SubscribeEventsRequest represents a request to add new event types to an existing stream.
+type SubscribeEventsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Subscriptions []*EventSubscription   `protobuf:"bytes,1,rep,name=subscriptions,proto3" json:"subscriptions,omitempty"`
+	// The stream to add the subscriptions to. If empty, they're added to all of the user's streams.
+	StreamId      string `protobuf:"bytes,2,opt,name=stream_id,json=streamId,proto3" json:"stream_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubscribeEventsRequest) Reset() {
+	*x = SubscribeEventsRequest{}
+	mi := &file_worldengine_cardinal_v1_cardinal_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubscribeEventsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubscribeEventsRequest) ProtoMessage() {}
+
+func (x *SubscribeEventsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_worldengine_cardinal_v1_cardinal_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubscribeEventsRequest.ProtoReflect.Descriptor instead.
+func (*SubscribeEventsRequest) Descriptor() ([]byte, []int) {
+	return file_worldengine_cardinal_v1_cardinal_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *SubscribeEventsRequest) GetSubscriptions() []*EventSubscription {
+	if x != nil {
+		return x.Subscriptions
+	}
+	return nil
+}
+
+func (x *SubscribeEventsRequest) GetStreamId() string {
+	if x != nil {
+		return x.StreamId
+	}
+	return ""
+}
+
+// SubscribeEventsResponse is returned when the request is successfully handled.
+type SubscribeEventsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubscribeEventsResponse) Reset() {
+	*x = SubscribeEventsResponse{}
+	mi := &file_worldengine_cardinal_v1_cardinal_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubscribeEventsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubscribeEventsResponse) ProtoMessage() {}
+
+func (x *SubscribeEventsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_worldengine_cardinal_v1_cardinal_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubscribeEventsResponse.ProtoReflect.Descriptor instead.
+func (*SubscribeEventsResponse) Descriptor() ([]byte, []int) {
+	return file_worldengine_cardinal_v1_cardinal_proto_rawDescGZIP(), []int{20}
+}
+
+// UnsubscribeEventsRequest represents a request to remove event types from an existing stream.
+type UnsubscribeEventsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Subscriptions []*EventSubscription   `protobuf:"bytes,1,rep,name=subscriptions,proto3" json:"subscriptions,omitempty"`
+	// The stream to remove the subscriptions from. If empty, they're removed from all of the user's
+	// streams.
+	StreamId      string `protobuf:"bytes,2,opt,name=stream_id,json=streamId,proto3" json:"stream_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UnsubscribeEventsRequest) Reset() {
+	*x = UnsubscribeEventsRequest{}
+	mi := &file_worldengine_cardinal_v1_cardinal_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UnsubscribeEventsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UnsubscribeEventsRequest) ProtoMessage() {}
+
+func (x *UnsubscribeEventsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_worldengine_cardinal_v1_cardinal_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UnsubscribeEventsRequest.ProtoReflect.Descriptor instead.
+func (*UnsubscribeEventsRequest) Descriptor() ([]byte, []int) {
+	return file_worldengine_cardinal_v1_cardinal_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *UnsubscribeEventsRequest) GetSubscriptions() []*EventSubscription {
+	if x != nil {
+		return x.Subscriptions
+	}
+	return nil
+}
+
+func (x *UnsubscribeEventsRequest) GetStreamId() string {
+	if x != nil {
+		return x.StreamId
+	}
+	return ""
+}
+
+// UnsubscribeEventsResponse is returned when the request is successfully handled.
+type UnsubscribeEventsResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *UnsubscribeEventsResponse) Reset() {
-	*x = UnsubscribeEventsResponse{}
-	mi := &file_worldengine_cardinal_v1_cardinal_proto_msgTypes[10]
+func (x *UnsubscribeEventsResponse) Reset() {
+	*x = UnsubscribeEventsResponse{}
+	mi := &file_worldengine_cardinal_v1_cardinal_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UnsubscribeEventsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UnsubscribeEventsResponse) ProtoMessage() {}
+
+func (x *UnsubscribeEventsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_worldengine_cardinal_v1_cardinal_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UnsubscribeEventsResponse.ProtoReflect.Descriptor instead.
+func (*UnsubscribeEventsResponse) Descriptor() ([]byte, []int) {
+	return file_worldengine_cardinal_v1_cardinal_proto_rawDescGZIP(), []int{22}
+}
+
+// SubscribeStateRequest selects the ECS state to stream.
+type SubscribeStateRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The components to stream. Entities with at least one of them match the query, and only these
+	// components of the entities are sent.
+	Components []string `protobuf:"bytes,1,rep,name=components,proto3" json:"components,omitempty"`
+	// Only streams these entities, if set.
+	EntityIds []uint32 `protobuf:"varint,2,rep,packed,name=entity_ids,json=entityIds,proto3" json:"entity_ids,omitempty"`
+	// Only streams the entities the user owns, if set. It's a `<component>.<field>` path to the field
+	// that holds the ID of the entity's owner, e.g. `Owner.persona_id`. Entities without the component
+	// don't match.
+	OwnerField    string `protobuf:"bytes,3,opt,name=owner_field,json=ownerField,proto3" json:"owner_field,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubscribeStateRequest) Reset() {
+	*x = SubscribeStateRequest{}
+	mi := &file_worldengine_cardinal_v1_cardinal_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubscribeStateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubscribeStateRequest) ProtoMessage() {}
+
+func (x *SubscribeStateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_worldengine_cardinal_v1_cardinal_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubscribeStateRequest.ProtoReflect.Descriptor instead.
+func (*SubscribeStateRequest) Descriptor() ([]byte, []int) {
+	return file_worldengine_cardinal_v1_cardinal_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *SubscribeStateRequest) GetComponents() []string {
+	if x != nil {
+		return x.Components
+	}
+	return nil
+}
+
+func (x *SubscribeStateRequest) GetEntityIds() []uint32 {
+	if x != nil {
+		return x.EntityIds
+	}
+	return nil
+}
+
+func (x *SubscribeStateRequest) GetOwnerField() string {
+	if x != nil {
+		return x.OwnerField
+	}
+	return ""
+}
+
+// SubscribeStateResponse is either the initial snapshot of the subscribed state, or the changes to
+// it in a tick.
+type SubscribeStateResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The tick the state is from.
+	TickHeight uint64 `protobuf:"varint,1,opt,name=tick_height,json=tickHeight,proto3" json:"tick_height,omitempty"`
+	// The timestamp of the tick the state is from.
+	Timestamp *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	// Types that are valid to be assigned to State:
+	//
+	//	*SubscribeStateResponse_Snapshot
+	//	*SubscribeStateResponse_Delta
+	State         isSubscribeStateResponse_State `protobuf_oneof:"state"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubscribeStateResponse) Reset() {
+	*x = SubscribeStateResponse{}
+	mi := &file_worldengine_cardinal_v1_cardinal_proto_msgTypes[24]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *UnsubscribeEventsResponse) String() string {
+func (x *SubscribeStateResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*UnsubscribeEventsResponse) ProtoMessage() {}
+func (*SubscribeStateResponse) ProtoMessage() {}
 
-func (x *UnsubscribeEventsResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_worldengine_cardinal_v1_cardinal_proto_msgTypes[10]
+func (x *SubscribeStateResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_worldengine_cardinal_v1_cardinal_proto_msgTypes[24]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -523,45 +1610,553 @@ func (x *UnsubscribeEventsResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use UnsubscribeEventsResponse.ProtoReflect.Descriptor instead.
-func (*UnsubscribeEventsResponse) Descriptor() ([]byte, []int) {
-	return file_worldengine_cardinal_v1_cardinal_proto_rawDescGZIP(), []int{10}
+// Deprecated: Use SubscribeStateResponse.ProtoReflect.Descriptor instead.
+func (*SubscribeStateResponse) Descriptor() ([]byte, []int) {
+	return file_worldengine_cardinal_v1_cardinal_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *SubscribeStateResponse) GetTickHeight() uint64 {
+	if x != nil {
+		return x.TickHeight
+	}
+	return 0
+}
+
+func (x *SubscribeStateResponse) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+func (x *SubscribeStateResponse) GetState() isSubscribeStateResponse_State {
+	if x != nil {
+		return x.State
+	}
+	return nil
+}
+
+func (x *SubscribeStateResponse) GetSnapshot() *StateSnapshot {
+	if x != nil {
+		if x, ok := x.State.(*SubscribeStateResponse_Snapshot); ok {
+			return x.Snapshot
+		}
+	}
+	return nil
+}
+
+func (x *SubscribeStateResponse) GetDelta() *StateDelta {
+	if x != nil {
+		if x, ok := x.State.(*SubscribeStateResponse_Delta); ok {
+			return x.Delta
+		}
+	}
+	return nil
+}
+
+type isSubscribeStateResponse_State interface {
+	isSubscribeStateResponse_State()
+}
+
+type SubscribeStateResponse_Snapshot struct {
+	// The entities matching the query. Only sent in the first message.
+	Snapshot *StateSnapshot `protobuf:"bytes,3,opt,name=snapshot,proto3,oneof"`
+}
+
+type SubscribeStateResponse_Delta struct {
+	// The changes to the matching entities since the previous message.
+	Delta *StateDelta `protobuf:"bytes,4,opt,name=delta,proto3,oneof"`
+}
+
+func (*SubscribeStateResponse_Snapshot) isSubscribeStateResponse_State() {}
+
+func (*SubscribeStateResponse_Delta) isSubscribeStateResponse_State() {}
+
+// StateSnapshot is the state of the entities matching a state query.
+type StateSnapshot struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The matching entities, in entity ID order.
+	Entities      []*EntityState `protobuf:"bytes,1,rep,name=entities,proto3" json:"entities,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StateSnapshot) Reset() {
+	*x = StateSnapshot{}
+	mi := &file_worldengine_cardinal_v1_cardinal_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StateSnapshot) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StateSnapshot) ProtoMessage() {}
+
+func (x *StateSnapshot) ProtoReflect() protoreflect.Message {
+	mi := &file_worldengine_cardinal_v1_cardinal_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StateSnapshot.ProtoReflect.Descriptor instead.
+func (*StateSnapshot) Descriptor() ([]byte, []int) {
+	return file_worldengine_cardinal_v1_cardinal_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *StateSnapshot) GetEntities() []*EntityState {
+	if x != nil {
+		return x.Entities
+	}
+	return nil
+}
+
+// StateDelta is the changes to the entities matching a state query in a tick.
+type StateDelta struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Entities that started matching the query, with all their subscribed components.
+	Added []*EntityState `protobuf:"bytes,1,rep,name=added,proto3" json:"added,omitempty"`
+	// Entities that still match the query but have changed, with only the changed components.
+	Updated []*EntityState `protobuf:"bytes,2,rep,name=updated,proto3" json:"updated,omitempty"`
+	// IDs of the entities that no longer match the query, e.g. because they were destroyed.
+	Removed       []uint32 `protobuf:"varint,3,rep,packed,name=removed,proto3" json:"removed,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StateDelta) Reset() {
+	*x = StateDelta{}
+	mi := &file_worldengine_cardinal_v1_cardinal_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StateDelta) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StateDelta) ProtoMessage() {}
+
+func (x *StateDelta) ProtoReflect() protoreflect.Message {
+	mi := &file_worldengine_cardinal_v1_cardinal_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StateDelta.ProtoReflect.Descriptor instead.
+func (*StateDelta) Descriptor() ([]byte, []int) {
+	return file_worldengine_cardinal_v1_cardinal_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *StateDelta) GetAdded() []*EntityState {
+	if x != nil {
+		return x.Added
+	}
+	return nil
+}
+
+func (x *StateDelta) GetUpdated() []*EntityState {
+	if x != nil {
+		return x.Updated
+	}
+	return nil
+}
+
+func (x *StateDelta) GetRemoved() []uint32 {
+	if x != nil {
+		return x.Removed
+	}
+	return nil
+}
+
+// EntityState is the state of an entity's subscribed components.
+type EntityState struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	EntityId uint32                 `protobuf:"varint,1,opt,name=entity_id,json=entityId,proto3" json:"entity_id,omitempty"`
+	// Component name -> serialized component, for added or updated components.
+	Components map[string][]byte `protobuf:"bytes,2,rep,name=components,proto3" json:"components,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// Names of the components the entity no longer has. Only set for updated entities.
+	RemovedComponents []string `protobuf:"bytes,3,rep,name=removed_components,json=removedComponents,proto3" json:"removed_components,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *EntityState) Reset() {
+	*x = EntityState{}
+	mi := &file_worldengine_cardinal_v1_cardinal_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EntityState) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EntityState) ProtoMessage() {}
+
+func (x *EntityState) ProtoReflect() protoreflect.Message {
+	mi := &file_worldengine_cardinal_v1_cardinal_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EntityState.ProtoReflect.Descriptor instead.
+func (*EntityState) Descriptor() ([]byte, []int) {
+	return file_worldengine_cardinal_v1_cardinal_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *EntityState) GetEntityId() uint32 {
+	if x != nil {
+		return x.EntityId
+	}
+	return 0
+}
+
+func (x *EntityState) GetComponents() map[string][]byte {
+	if x != nil {
+		return x.Components
+	}
+	return nil
+}
+
+func (x *EntityState) GetRemovedComponents() []string {
+	if x != nil {
+		return x.RemovedComponents
+	}
+	return nil
+}
+
+// QueryEntitiesRequest is a read-only query over the entities of the world state.
+type QueryEntitiesRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The components the entities are filtered by.
+	Components []string `protobuf:"bytes,1,rep,name=components,proto3" json:"components,omitempty"`
+	// How the components are matched.
+	Match QueryMatch `protobuf:"varint,2,opt,name=match,proto3,enum=worldengine.cardinal.v1.QueryMatch" json:"match,omitempty"`
+	// The components returned for each entity. If empty, the filter's components are returned.
+	Projection []string `protobuf:"bytes,3,rep,name=projection,proto3" json:"projection,omitempty"`
+	// The maximum number of entities to return. Defaults to 100 if unset.
+	PageSize uint32 `protobuf:"varint,4,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	// The next_page_token of the previous response, to get the next page.
+	PageToken     string `protobuf:"bytes,5,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *QueryEntitiesRequest) Reset() {
+	*x = QueryEntitiesRequest{}
+	mi := &file_worldengine_cardinal_v1_cardinal_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QueryEntitiesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QueryEntitiesRequest) ProtoMessage() {}
+
+func (x *QueryEntitiesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_worldengine_cardinal_v1_cardinal_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QueryEntitiesRequest.ProtoReflect.Descriptor instead.
+func (*QueryEntitiesRequest) Descriptor() ([]byte, []int) {
+	return file_worldengine_cardinal_v1_cardinal_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *QueryEntitiesRequest) GetComponents() []string {
+	if x != nil {
+		return x.Components
+	}
+	return nil
+}
+
+func (x *QueryEntitiesRequest) GetMatch() QueryMatch {
+	if x != nil {
+		return x.Match
+	}
+	return QueryMatch_QUERY_MATCH_UNSPECIFIED
+}
+
+func (x *QueryEntitiesRequest) GetProjection() []string {
+	if x != nil {
+		return x.Projection
+	}
+	return nil
+}
+
+func (x *QueryEntitiesRequest) GetPageSize() uint32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *QueryEntitiesRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+// QueryEntitiesResponse is a page of the entities matching a query, in entity ID order.
+type QueryEntitiesResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The tick of the world state the query was evaluated against.
+	TickHeight uint64         `protobuf:"varint,1,opt,name=tick_height,json=tickHeight,proto3" json:"tick_height,omitempty"`
+	Entities   []*EntityState `protobuf:"bytes,2,rep,name=entities,proto3" json:"entities,omitempty"`
+	// Token to get the next page with, empty if this is the last page.
+	NextPageToken string `protobuf:"bytes,3,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *QueryEntitiesResponse) Reset() {
+	*x = QueryEntitiesResponse{}
+	mi := &file_worldengine_cardinal_v1_cardinal_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QueryEntitiesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QueryEntitiesResponse) ProtoMessage() {}
+
+func (x *QueryEntitiesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_worldengine_cardinal_v1_cardinal_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QueryEntitiesResponse.ProtoReflect.Descriptor instead.
+func (*QueryEntitiesResponse) Descriptor() ([]byte, []int) {
+	return file_worldengine_cardinal_v1_cardinal_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *QueryEntitiesResponse) GetTickHeight() uint64 {
+	if x != nil {
+		return x.TickHeight
+	}
+	return 0
+}
+
+func (x *QueryEntitiesResponse) GetEntities() []*EntityState {
+	if x != nil {
+		return x.Entities
+	}
+	return nil
+}
+
+func (x *QueryEntitiesResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
 }
 
 var File_worldengine_cardinal_v1_cardinal_proto protoreflect.FileDescriptor
 
 const file_worldengine_cardinal_v1_cardinal_proto_rawDesc = "" +
 	"\n" +
-	"&worldengine/cardinal/v1/cardinal.proto\x12\x17worldengine.cardinal.v1\x1a\x1bbuf/validate/validate.proto\x1a worldengine/isc/v1/command.proto\x1a\x1eworldengine/isc/v1/event.proto\x1a\"worldengine/micro/v1/service.proto\"S\n" +
+	"&worldengine/cardinal/v1/cardinal.proto\x12\x17worldengine.cardinal.v1\x1a\x1bbuf/validate/validate.proto\x1a\x1egoogle/protobuf/duration.proto\x1a\x1fgoogle/protobuf/timestamp.proto\x1a worldengine/isc/v1/command.proto\x1a\x1eworldengine/isc/v1/event.proto\x1a\"worldengine/micro/v1/service.proto\"S\n" +
 	"\x12SendCommandRequest\x12=\n" +
-	"\acommand\x18\x01 \x01(\v2\x1b.worldengine.isc.v1.CommandB\x06\xbaH\x03\xc8\x01\x01R\acommand\"\x15\n" +
-	"\x13SendCommandResponse\"\x9c\x01\n" +
+	"\acommand\x18\x01 \x01(\v2\x1b.worldengine.isc.v1.CommandB\x06\xbaH\x03\xc8\x01\x01R\acommand\"U\n" +
+	"\x13SendCommandResponse\x12\x1d\n" +
+	"\n" +
+	"receipt_id\x18\x01 \x01(\x04R\treceiptId\x12\x1f\n" +
+	"\vtick_height\x18\x02 \x01(\x04R\n" +
+	"tickHeight\"[\n" +
+	"\x13SendCommandsRequest\x12D\n" +
+	"\bcommands\x18\x01 \x03(\v2\x1b.worldengine.isc.v1.CommandB\v\xbaH\b\x92\x01\x05\b\x01\x10\x80\x02R\bcommands\"\x99\x01\n" +
+	"\x14SendCommandsResponse\x12\x1a\n" +
+	"\baccepted\x18\x01 \x01(\bR\baccepted\x12\x1f\n" +
+	"\vtick_height\x18\x02 \x01(\x04R\n" +
+	"tickHeight\x12D\n" +
+	"\aresults\x18\x03 \x03(\v2*.worldengine.cardinal.v1.SendCommandResultR\aresults\"H\n" +
+	"\x11SendCommandResult\x12\x1d\n" +
+	"\n" +
+	"receipt_id\x18\x01 \x01(\x04R\treceiptId\x12\x14\n" +
+	"\x05error\x18\x02 \x01(\tR\x05error\"\xc3\x01\n" +
 	"\x1bSendCommandWithReplyRequest\x12=\n" +
-	"\acommand\x18\x01 \x01(\v2\x1b.worldengine.isc.v1.CommandB\x06\xbaH\x03\xc8\x01\x01R\acommand\x12>\n" +
+	"\acommand\x18\x01 \x01(\v2\x1b.worldengine.isc.v1.CommandB\x06\xbaH\x03\xc8\x01\x01R\acommand\x12!\n" +
 	"\n" +
-	"event_name\x18\x02 \x01(\tB\x1f\xbaH\x1c\xc8\x01\x01r\x17\x10\x01\x18\x80\x012\x10^[a-zA-Z0-9_-]+$R\teventName\"O\n" +
+	"event_name\x18\x02 \x01(\tB\x02\x18\x01R\teventName\x12B\n" +
+	"\atimeout\x18\x03 \x01(\v2\x19.google.protobuf.DurationB\r\xbaH\n" +
+	"\xaa\x01\a\"\x03\b\xac\x02*\x00R\atimeout\"n\n" +
 	"\x1cSendCommandWithReplyResponse\x12/\n" +
-	"\x05event\x18\x01 \x01(\v2\x19.worldengine.isc.v1.EventR\x05event\"\x9b\x01\n" +
+	"\x05event\x18\x01 \x01(\v2\x19.worldengine.isc.v1.EventR\x05event\x12\x1d\n" +
+	"\n" +
+	"receipt_id\x18\x02 \x01(\x04R\treceiptId\"\xcc\x01\n" +
+	"\x16ScheduleCommandRequest\x12=\n" +
+	"\acommand\x18\x01 \x01(\v2\x1b.worldengine.isc.v1.CommandB\x06\xbaH\x03\xc8\x01\x01R\acommand\x12!\n" +
+	"\vtick_height\x18\x02 \x01(\x04H\x00R\n" +
+	"tickHeight\x12A\n" +
+	"\x05delay\x18\x03 \x01(\v2\x19.google.protobuf.DurationB\x0e\xbaH\v\xaa\x01\b\"\x04\b\x80\xf5$2\x00H\x00R\x05delayB\r\n" +
+	"\x04when\x12\x05\xbaH\x02\b\x01\"R\n" +
+	"\x17ScheduleCommandResponse\x12\x16\n" +
+	"\x06handle\x18\x01 \x01(\x04R\x06handle\x12\x1f\n" +
+	"\vtick_height\x18\x02 \x01(\x04R\n" +
+	"tickHeight\"\x7f\n" +
+	"\x1dCancelScheduledCommandRequest\x12F\n" +
+	"\aaddress\x18\x01 \x01(\v2$.worldengine.micro.v1.ServiceAddressB\x06\xbaH\x03\xc8\x01\x01R\aaddress\x12\x16\n" +
+	"\x06handle\x18\x02 \x01(\x04R\x06handle\" \n" +
+	"\x1eCancelScheduledCommandResponse\"\x81\x01\n" +
+	"\x18GetCommandReceiptRequest\x12F\n" +
+	"\aaddress\x18\x01 \x01(\v2$.worldengine.micro.v1.ServiceAddressB\x06\xbaH\x03\xc8\x01\x01R\aaddress\x12\x1d\n" +
+	"\n" +
+	"receipt_id\x18\x02 \x01(\x04R\treceiptId\"^\n" +
+	"\x19GetCommandReceiptResponse\x12A\n" +
+	"\areceipt\x18\x01 \x01(\v2'.worldengine.cardinal.v1.CommandReceiptR\areceipt\"\xfc\x01\n" +
+	"\x0eCommandReceipt\x12\x1d\n" +
+	"\n" +
+	"receipt_id\x18\x01 \x01(\x04R\treceiptId\x12!\n" +
+	"\fcommand_name\x18\x02 \x01(\tR\vcommandName\x12>\n" +
+	"\x06status\x18\x03 \x01(\x0e2&.worldengine.cardinal.v1.CommandStatusR\x06status\x12\x1f\n" +
+	"\vtick_height\x18\x04 \x01(\x04R\n" +
+	"tickHeight\x12\x14\n" +
+	"\x05error\x18\x05 \x01(\tR\x05error\x121\n" +
+	"\x06result\x18\x06 \x01(\v2\x19.worldengine.isc.v1.EventR\x06result\"\xbd\x01\n" +
 	"\x11EventSubscription\x12F\n" +
 	"\aaddress\x18\x01 \x01(\v2$.worldengine.micro.v1.ServiceAddressB\x06\xbaH\x03\xc8\x01\x01R\aaddress\x12>\n" +
-	"\x06events\x18\x02 \x03(\tB&\xbaH#\x92\x01 \b\x01\"\x1cr\x1a\x10\x01\x18\x80\x012\x13^[a-zA-Z0-9.*>_-]+$R\x06events\"k\n" +
+	"\x06events\x18\x02 \x03(\tB&\xbaH#\x92\x01 \b\x01\"\x1cr\x1a\x10\x01\x18\x80\x012\x13^[a-zA-Z0-9.*>_-]+$R\x06events\x12 \n" +
+	"\x06filter\x18\x03 \x01(\tB\b\xbaH\x05r\x03\x18\x80\bR\x06filter\"\x80\x02\n" +
 	"\x17StartEventStreamRequest\x12P\n" +
-	"\rsubscriptions\x18\x01 \x03(\v2*.worldengine.cardinal.v1.EventSubscriptionR\rsubscriptions\"\x8b\x01\n" +
+	"\rsubscriptions\x18\x01 \x03(\v2*.worldengine.cardinal.v1.EventSubscriptionR\rsubscriptions\x12&\n" +
+	"\fresume_after\x18\x02 \x01(\x04H\x00R\vresumeAfter\x88\x01\x01\x126\n" +
+	"\tstream_id\x18\x03 \x01(\tB\x19\xbaH\x16r\x14\x18@2\x10^[a-zA-Z0-9_-]*$R\bstreamId\x12\"\n" +
+	"\rbatch_by_tick\x18\x04 \x01(\bR\vbatchByTickB\x0f\n" +
+	"\r_resume_after\"\xac\x02\n" +
 	"\x18StartEventStreamResponse\x12>\n" +
 	"\aaddress\x18\x01 \x01(\v2$.worldengine.micro.v1.ServiceAddressR\aaddress\x12/\n" +
-	"\x05event\x18\x02 \x01(\v2\x19.worldengine.isc.v1.EventR\x05event\"t\n" +
+	"\x05event\x18\x02 \x01(\v2\x19.worldengine.isc.v1.EventR\x05event\x12\x1a\n" +
+	"\bsequence\x18\x03 \x01(\x04R\bsequence\x12+\n" +
+	"\x11history_truncated\x18\x04 \x01(\bR\x10historyTruncated\x12\x1b\n" +
+	"\tstream_id\x18\x05 \x01(\tR\bstreamId\x129\n" +
+	"\x05batch\x18\x06 \x01(\v2#.worldengine.cardinal.v1.EventBatchR\x05batch\"\xa6\x01\n" +
+	"\n" +
+	"EventBatch\x12\x1f\n" +
+	"\vtick_height\x18\x01 \x01(\x04R\n" +
+	"tickHeight\x128\n" +
+	"\ttimestamp\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x12=\n" +
+	"\x06events\x18\x03 \x03(\v2%.worldengine.cardinal.v1.BatchedEventR\x06events\"[\n" +
+	"\fBatchedEvent\x12/\n" +
+	"\x05event\x18\x01 \x01(\v2\x19.worldengine.isc.v1.EventR\x05event\x12\x1a\n" +
+	"\bsequence\x18\x02 \x01(\x04R\bsequence\"\x9a\x01\n" +
 	"\x16SubscribeEventsRequest\x12Z\n" +
-	"\rsubscriptions\x18\x01 \x03(\v2*.worldengine.cardinal.v1.EventSubscriptionB\b\xbaH\x05\x92\x01\x02\b\x01R\rsubscriptions\"\x19\n" +
-	"\x17SubscribeEventsResponse\"v\n" +
+	"\rsubscriptions\x18\x01 \x03(\v2*.worldengine.cardinal.v1.EventSubscriptionB\b\xbaH\x05\x92\x01\x02\b\x01R\rsubscriptions\x12$\n" +
+	"\tstream_id\x18\x02 \x01(\tB\a\xbaH\x04r\x02\x18@R\bstreamId\"\x19\n" +
+	"\x17SubscribeEventsResponse\"\x9c\x01\n" +
 	"\x18UnsubscribeEventsRequest\x12Z\n" +
-	"\rsubscriptions\x18\x01 \x03(\v2*.worldengine.cardinal.v1.EventSubscriptionB\b\xbaH\x05\x92\x01\x02\b\x01R\rsubscriptions\"\x1b\n" +
-	"\x19UnsubscribeEventsResponse2\xf8\x04\n" +
+	"\rsubscriptions\x18\x01 \x03(\v2*.worldengine.cardinal.v1.EventSubscriptionB\b\xbaH\x05\x92\x01\x02\b\x01R\rsubscriptions\x12$\n" +
+	"\tstream_id\x18\x02 \x01(\tB\a\xbaH\x04r\x02\x18@R\bstreamId\"\x1b\n" +
+	"\x19UnsubscribeEventsResponse\"\xe7\x01\n" +
+	"\x15SubscribeStateRequest\x12M\n" +
+	"\n" +
+	"components\x18\x01 \x03(\tB-\xbaH*\x92\x01'\b\x01\x10@\"!r\x1f\x10\x01\x18\x80\x012\x18^[a-zA-Z_][a-zA-Z0-9_]*$R\n" +
+	"components\x12(\n" +
+	"\n" +
+	"entity_ids\x18\x02 \x03(\rB\t\xbaH\x06\x92\x01\x03\x10\x80\bR\tentityIds\x12U\n" +
+	"\vowner_field\x18\x03 \x01(\tB4\xbaH1r/\x18\x80\x022*^([a-zA-Z_][a-zA-Z0-9_]*\\.[a-zA-Z0-9_]+)?$R\n" +
+	"ownerField\"\xff\x01\n" +
+	"\x16SubscribeStateResponse\x12\x1f\n" +
+	"\vtick_height\x18\x01 \x01(\x04R\n" +
+	"tickHeight\x128\n" +
+	"\ttimestamp\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x12D\n" +
+	"\bsnapshot\x18\x03 \x01(\v2&.worldengine.cardinal.v1.StateSnapshotH\x00R\bsnapshot\x12;\n" +
+	"\x05delta\x18\x04 \x01(\v2#.worldengine.cardinal.v1.StateDeltaH\x00R\x05deltaB\a\n" +
+	"\x05state\"Q\n" +
+	"\rStateSnapshot\x12@\n" +
+	"\bentities\x18\x01 \x03(\v2$.worldengine.cardinal.v1.EntityStateR\bentities\"\xa2\x01\n" +
+	"\n" +
+	"StateDelta\x12:\n" +
+	"\x05added\x18\x01 \x03(\v2$.worldengine.cardinal.v1.EntityStateR\x05added\x12>\n" +
+	"\aupdated\x18\x02 \x03(\v2$.worldengine.cardinal.v1.EntityStateR\aupdated\x12\x18\n" +
+	"\aremoved\x18\x03 \x03(\rR\aremoved\"\xee\x01\n" +
+	"\vEntityState\x12\x1b\n" +
+	"\tentity_id\x18\x01 \x01(\rR\bentityId\x12T\n" +
+	"\n" +
+	"components\x18\x02 \x03(\v24.worldengine.cardinal.v1.EntityState.ComponentsEntryR\n" +
+	"components\x12-\n" +
+	"\x12removed_components\x18\x03 \x03(\tR\x11removedComponents\x1a=\n" +
+	"\x0fComponentsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\fR\x05value:\x028\x01\"\xc8\x02\n" +
+	"\x14QueryEntitiesRequest\x12M\n" +
+	"\n" +
+	"components\x18\x01 \x03(\tB-\xbaH*\x92\x01'\b\x01\x10@\"!r\x1f\x10\x01\x18\x80\x012\x18^[a-zA-Z_][a-zA-Z0-9_]*$R\n" +
+	"components\x12E\n" +
+	"\x05match\x18\x02 \x01(\x0e2#.worldengine.cardinal.v1.QueryMatchB\n" +
+	"\xbaH\a\x82\x01\x04\x10\x01 \x00R\x05match\x12K\n" +
+	"\n" +
+	"projection\x18\x03 \x03(\tB+\xbaH(\x92\x01%\x10@\"!r\x1f\x10\x01\x18\x80\x012\x18^[a-zA-Z_][a-zA-Z0-9_]*$R\n" +
+	"projection\x12%\n" +
+	"\tpage_size\x18\x04 \x01(\rB\b\xbaH\x05*\x03\x18\xe8\aR\bpageSize\x12&\n" +
+	"\n" +
+	"page_token\x18\x05 \x01(\tB\a\xbaH\x04r\x02\x18@R\tpageToken\"\xa2\x01\n" +
+	"\x15QueryEntitiesResponse\x12\x1f\n" +
+	"\vtick_height\x18\x01 \x01(\x04R\n" +
+	"tickHeight\x12@\n" +
+	"\bentities\x18\x02 \x03(\v2$.worldengine.cardinal.v1.EntityStateR\bentities\x12&\n" +
+	"\x0fnext_page_token\x18\x03 \x01(\tR\rnextPageToken*\x84\x01\n" +
+	"\rCommandStatus\x12\x1e\n" +
+	"\x1aCOMMAND_STATUS_UNSPECIFIED\x10\x00\x12\x1a\n" +
+	"\x16COMMAND_STATUS_PENDING\x10\x01\x12\x1c\n" +
+	"\x18COMMAND_STATUS_PROCESSED\x10\x02\x12\x19\n" +
+	"\x15COMMAND_STATUS_FAILED\x10\x03*Z\n" +
+	"\n" +
+	"QueryMatch\x12\x1b\n" +
+	"\x17QUERY_MATCH_UNSPECIFIED\x10\x00\x12\x18\n" +
+	"\x14QUERY_MATCH_CONTAINS\x10\x01\x12\x15\n" +
+	"\x11QUERY_MATCH_EXACT\x10\x022\xd4\n" +
+	"\n" +
 	"\x0fCardinalService\x12j\n" +
-	"\vSendCommand\x12+.worldengine.cardinal.v1.SendCommandRequest\x1a,.worldengine.cardinal.v1.SendCommandResponse\"\x00\x12\x85\x01\n" +
-	"\x14SendCommandWithReply\x124.worldengine.cardinal.v1.SendCommandWithReplyRequest\x1a5.worldengine.cardinal.v1.SendCommandWithReplyResponse\"\x00\x12{\n" +
+	"\vSendCommand\x12+.worldengine.cardinal.v1.SendCommandRequest\x1a,.worldengine.cardinal.v1.SendCommandResponse\"\x00\x12m\n" +
+	"\fSendCommands\x12,.worldengine.cardinal.v1.SendCommandsRequest\x1a-.worldengine.cardinal.v1.SendCommandsResponse\"\x00\x12\x85\x01\n" +
+	"\x14SendCommandWithReply\x124.worldengine.cardinal.v1.SendCommandWithReplyRequest\x1a5.worldengine.cardinal.v1.SendCommandWithReplyResponse\"\x00\x12v\n" +
+	"\x0fScheduleCommand\x12/.worldengine.cardinal.v1.ScheduleCommandRequest\x1a0.worldengine.cardinal.v1.ScheduleCommandResponse\"\x00\x12\x8b\x01\n" +
+	"\x16CancelScheduledCommand\x126.worldengine.cardinal.v1.CancelScheduledCommandRequest\x1a7.worldengine.cardinal.v1.CancelScheduledCommandResponse\"\x00\x12|\n" +
+	"\x11GetCommandReceipt\x121.worldengine.cardinal.v1.GetCommandReceiptRequest\x1a2.worldengine.cardinal.v1.GetCommandReceiptResponse\"\x00\x12{\n" +
 	"\x10StartEventStream\x120.worldengine.cardinal.v1.StartEventStreamRequest\x1a1.worldengine.cardinal.v1.StartEventStreamResponse\"\x000\x01\x12v\n" +
 	"\x0fSubscribeEvents\x12/.worldengine.cardinal.v1.SubscribeEventsRequest\x1a0.worldengine.cardinal.v1.SubscribeEventsResponse\"\x00\x12|\n" +
-	"\x11UnsubscribeEvents\x121.worldengine.cardinal.v1.UnsubscribeEventsRequest\x1a2.worldengine.cardinal.v1.UnsubscribeEventsResponse\"\x00BtZRgithub.com/argus-labs/world-engine/proto/gen/go/worldengine/cardinal/v1;cardinalv1\xaa\x02\x1dWorldEngine.Proto.Cardinal.V1b\x06proto3"
+	"\x11UnsubscribeEvents\x121.worldengine.cardinal.v1.UnsubscribeEventsRequest\x1a2.worldengine.cardinal.v1.UnsubscribeEventsResponse\"\x00\x12u\n" +
+	"\x0eSubscribeState\x12..worldengine.cardinal.v1.SubscribeStateRequest\x1a/.worldengine.cardinal.v1.SubscribeStateResponse\"\x000\x01\x12p\n" +
+	"\rQueryEntities\x12-.worldengine.cardinal.v1.QueryEntitiesRequest\x1a..worldengine.cardinal.v1.QueryEntitiesResponse\"\x00BtZRgithub.com/argus-labs/world-engine/proto/gen/go/worldengine/cardinal/v1;cardinalv1\xaa\x02\x1dWorldEngine.Proto.Cardinal.V1b\x06proto3"
 
 var (
 	file_worldengine_cardinal_v1_cardinal_proto_rawDescOnce sync.Once
@@ -575,48 +2170,108 @@ func file_worldengine_cardinal_v1_cardinal_proto_rawDescGZIP() []byte {
 	return file_worldengine_cardinal_v1_cardinal_proto_rawDescData
 }
 
-var file_worldengine_cardinal_v1_cardinal_proto_msgTypes = make([]protoimpl.MessageInfo, 11)
+var file_worldengine_cardinal_v1_cardinal_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_worldengine_cardinal_v1_cardinal_proto_msgTypes = make([]protoimpl.MessageInfo, 31)
 var file_worldengine_cardinal_v1_cardinal_proto_goTypes = []any{
-	(*SendCommandRequest)(nil),           // 0: worldengine.cardinal.v1.SendCommandRequest
-	(*SendCommandResponse)(nil),          // 1: worldengine.cardinal.v1.SendCommandResponse
-	(*SendCommandWithReplyRequest)(nil),  // 2: worldengine.cardinal.v1.SendCommandWithReplyRequest
-	(*SendCommandWithReplyResponse)(nil), // 3: worldengine.cardinal.v1.SendCommandWithReplyResponse
-	(*EventSubscription)(nil),            // 4: worldengine.cardinal.v1.EventSubscription
-	(*StartEventStreamRequest)(nil),      // 5: worldengine.cardinal.v1.StartEventStreamRequest
-	(*StartEventStreamResponse)(nil),     // 6: worldengine.cardinal.v1.StartEventStreamResponse
-	(*SubscribeEventsRequest)(nil),       // 7: worldengine.cardinal.v1.SubscribeEventsRequest
-	(*SubscribeEventsResponse)(nil),      // 8: worldengine.cardinal.v1.SubscribeEventsResponse
-	(*UnsubscribeEventsRequest)(nil),     // 9: worldengine.cardinal.v1.UnsubscribeEventsRequest
-	(*UnsubscribeEventsResponse)(nil),    // 10: worldengine.cardinal.v1.UnsubscribeEventsResponse
-	(*v1.Command)(nil),                   // 11: worldengine.isc.v1.Command
-	(*v1.Event)(nil),                     // 12: worldengine.isc.v1.Event
-	(*v11.ServiceAddress)(nil),           // 13: worldengine.micro.v1.ServiceAddress
+	(CommandStatus)(0),                     // 0: worldengine.cardinal.v1.CommandStatus
+	(QueryMatch)(0),                        // 1: worldengine.cardinal.v1.QueryMatch
+	(*SendCommandRequest)(nil),             // 2: worldengine.cardinal.v1.SendCommandRequest
+	(*SendCommandResponse)(nil),            // 3: worldengine.cardinal.v1.SendCommandResponse
+	(*SendCommandsRequest)(nil),            // 4: worldengine.cardinal.v1.SendCommandsRequest
+	(*SendCommandsResponse)(nil),           // 5: worldengine.cardinal.v1.SendCommandsResponse
+	(*SendCommandResult)(nil),              // 6: worldengine.cardinal.v1.SendCommandResult
+	(*SendCommandWithReplyRequest)(nil),    // 7: worldengine.cardinal.v1.SendCommandWithReplyRequest
+	(*SendCommandWithReplyResponse)(nil),   // 8: worldengine.cardinal.v1.SendCommandWithReplyResponse
+	(*ScheduleCommandRequest)(nil),         // 9: worldengine.cardinal.v1.ScheduleCommandRequest
+	(*ScheduleCommandResponse)(nil),        // 10: worldengine.cardinal.v1.ScheduleCommandResponse
+	(*CancelScheduledCommandRequest)(nil),  // 11: worldengine.cardinal.v1.CancelScheduledCommandRequest
+	(*CancelScheduledCommandResponse)(nil), // 12: worldengine.cardinal.v1.CancelScheduledCommandResponse
+	(*GetCommandReceiptRequest)(nil),       // 13: worldengine.cardinal.v1.GetCommandReceiptRequest
+	(*GetCommandReceiptResponse)(nil),      // 14: worldengine.cardinal.v1.GetCommandReceiptResponse
+	(*CommandReceipt)(nil),                 // 15: worldengine.cardinal.v1.CommandReceipt
+	(*EventSubscription)(nil),              // 16: worldengine.cardinal.v1.EventSubscription
+	(*StartEventStreamRequest)(nil),        // 17: worldengine.cardinal.v1.StartEventStreamRequest
+	(*StartEventStreamResponse)(nil),       // 18: worldengine.cardinal.v1.StartEventStreamResponse
+	(*EventBatch)(nil),                     // 19: worldengine.cardinal.v1.EventBatch
+	(*BatchedEvent)(nil),                   // 20: worldengine.cardinal.v1.BatchedEvent
+	(*SubscribeEventsRequest)(nil),         // 21: worldengine.cardinal.v1.SubscribeEventsRequest
+	(*SubscribeEventsResponse)(nil),        // 22: worldengine.cardinal.v1.SubscribeEventsResponse
+	(*UnsubscribeEventsRequest)(nil),       // 23: worldengine.cardinal.v1.UnsubscribeEventsRequest
+	(*UnsubscribeEventsResponse)(nil),      // 24: worldengine.cardinal.v1.UnsubscribeEventsResponse
+	(*SubscribeStateRequest)(nil),          // 25: worldengine.cardinal.v1.SubscribeStateRequest
+	(*SubscribeStateResponse)(nil),         // 26: worldengine.cardinal.v1.SubscribeStateResponse
+	(*StateSnapshot)(nil),                  // 27: worldengine.cardinal.v1.StateSnapshot
+	(*StateDelta)(nil),                     // 28: worldengine.cardinal.v1.StateDelta
+	(*EntityState)(nil),                    // 29: worldengine.cardinal.v1.EntityState
+	(*QueryEntitiesRequest)(nil),           // 30: worldengine.cardinal.v1.QueryEntitiesRequest
+	(*QueryEntitiesResponse)(nil),          // 31: worldengine.cardinal.v1.QueryEntitiesResponse
+	nil,                                    // 32: worldengine.cardinal.v1.EntityState.ComponentsEntry
+	(*v1.Command)(nil),                     // 33: worldengine.isc.v1.Command
+	(*durationpb.Duration)(nil),            // 34: google.protobuf.Duration
+	(*v1.Event)(nil),                       // 35: worldengine.isc.v1.Event
+	(*v11.ServiceAddress)(nil),             // 36: worldengine.micro.v1.ServiceAddress
+	(*timestamppb.Timestamp)(nil),          // 37: google.protobuf.Timestamp
 }
 var file_worldengine_cardinal_v1_cardinal_proto_depIdxs = []int32{
-	11, // 0: worldengine.cardinal.v1.SendCommandRequest.command:type_name -> worldengine.isc.v1.Command
-	11, // 1: worldengine.cardinal.v1.SendCommandWithReplyRequest.command:type_name -> worldengine.isc.v1.Command
-	12, // 2: worldengine.cardinal.v1.SendCommandWithReplyResponse.event:type_name -> worldengine.isc.v1.Event
-	13, // 3: worldengine.cardinal.v1.EventSubscription.address:type_name -> worldengine.micro.v1.ServiceAddress
-	4,  // 4: worldengine.cardinal.v1.StartEventStreamRequest.subscriptions:type_name -> worldengine.cardinal.v1.EventSubscription
-	13, // 5: worldengine.cardinal.v1.StartEventStreamResponse.address:type_name -> worldengine.micro.v1.ServiceAddress
-	12, // 6: worldengine.cardinal.v1.StartEventStreamResponse.event:type_name -> worldengine.isc.v1.Event
-	4,  // 7: worldengine.cardinal.v1.SubscribeEventsRequest.subscriptions:type_name -> worldengine.cardinal.v1.EventSubscription
-	4,  // 8: worldengine.cardinal.v1.UnsubscribeEventsRequest.subscriptions:type_name -> worldengine.cardinal.v1.EventSubscription
-	0,  // 9: worldengine.cardinal.v1.CardinalService.SendCommand:input_type -> worldengine.cardinal.v1.SendCommandRequest
-	2,  // 10: worldengine.cardinal.v1.CardinalService.SendCommandWithReply:input_type -> worldengine.cardinal.v1.SendCommandWithReplyRequest
-	5,  // 11: worldengine.cardinal.v1.CardinalService.StartEventStream:input_type -> worldengine.cardinal.v1.StartEventStreamRequest
-	7,  // 12: worldengine.cardinal.v1.CardinalService.SubscribeEvents:input_type -> worldengine.cardinal.v1.SubscribeEventsRequest
-	9,  // 13: worldengine.cardinal.v1.CardinalService.UnsubscribeEvents:input_type -> worldengine.cardinal.v1.UnsubscribeEventsRequest
-	1,  // 14: worldengine.cardinal.v1.CardinalService.SendCommand:output_type -> worldengine.cardinal.v1.SendCommandResponse
-	3,  // 15: worldengine.cardinal.v1.CardinalService.SendCommandWithReply:output_type -> worldengine.cardinal.v1.SendCommandWithReplyResponse
-	6,  // 16: worldengine.cardinal.v1.CardinalService.StartEventStream:output_type -> worldengine.cardinal.v1.StartEventStreamResponse
-	8,  // 17: worldengine.cardinal.v1.CardinalService.SubscribeEvents:output_type -> worldengine.cardinal.v1.SubscribeEventsResponse
-	10, // 18: worldengine.cardinal.v1.CardinalService.UnsubscribeEvents:output_type -> worldengine.cardinal.v1.UnsubscribeEventsResponse
-	14, // [14:19] is the sub-list for method output_type
-	9,  // [9:14] is the sub-list for method input_type
-	9,  // [9:9] is the sub-list for extension type_name
-	9,  // [9:9] is the sub-list for extension extendee
-	0,  // [0:9] is the sub-list for field type_name
+	33, // 0: worldengine.cardinal.v1.SendCommandRequest.command:type_name -> worldengine.isc.v1.Command
+	33, // 1: worldengine.cardinal.v1.SendCommandsRequest.commands:type_name -> worldengine.isc.v1.Command
+	6,  // 2: worldengine.cardinal.v1.SendCommandsResponse.results:type_name -> worldengine.cardinal.v1.SendCommandResult
+	33, // 3: worldengine.cardinal.v1.SendCommandWithReplyRequest.command:type_name -> worldengine.isc.v1.Command
+	34, // 4: worldengine.cardinal.v1.SendCommandWithReplyRequest.timeout:type_name -> google.protobuf.Duration
+	35, // 5: worldengine.cardinal.v1.SendCommandWithReplyResponse.event:type_name -> worldengine.isc.v1.Event
+	33, // 6: worldengine.cardinal.v1.ScheduleCommandRequest.command:type_name -> worldengine.isc.v1.Command
+	34, // 7: worldengine.cardinal.v1.ScheduleCommandRequest.delay:type_name -> google.protobuf.Duration
+	36, // 8: worldengine.cardinal.v1.CancelScheduledCommandRequest.address:type_name -> worldengine.micro.v1.ServiceAddress
+	36, // 9: worldengine.cardinal.v1.GetCommandReceiptRequest.address:type_name -> worldengine.micro.v1.ServiceAddress
+	15, // 10: worldengine.cardinal.v1.GetCommandReceiptResponse.receipt:type_name -> worldengine.cardinal.v1.CommandReceipt
+	0,  // 11: worldengine.cardinal.v1.CommandReceipt.status:type_name -> worldengine.cardinal.v1.CommandStatus
+	35, // 12: worldengine.cardinal.v1.CommandReceipt.result:type_name -> worldengine.isc.v1.Event
+	36, // 13: worldengine.cardinal.v1.EventSubscription.address:type_name -> worldengine.micro.v1.ServiceAddress
+	16, // 14: worldengine.cardinal.v1.StartEventStreamRequest.subscriptions:type_name -> worldengine.cardinal.v1.EventSubscription
+	36, // 15: worldengine.cardinal.v1.StartEventStreamResponse.address:type_name -> worldengine.micro.v1.ServiceAddress
+	35, // 16: worldengine.cardinal.v1.StartEventStreamResponse.event:type_name -> worldengine.isc.v1.Event
+	19, // 17: worldengine.cardinal.v1.StartEventStreamResponse.batch:type_name -> worldengine.cardinal.v1.EventBatch
+	37, // 18: worldengine.cardinal.v1.EventBatch.timestamp:type_name -> google.protobuf.Timestamp
+	20, // 19: worldengine.cardinal.v1.EventBatch.events:type_name -> worldengine.cardinal.v1.BatchedEvent
+	35, // 20: worldengine.cardinal.v1.BatchedEvent.event:type_name -> worldengine.isc.v1.Event
+	16, // 21: worldengine.cardinal.v1.SubscribeEventsRequest.subscriptions:type_name -> worldengine.cardinal.v1.EventSubscription
+	16, // 22: worldengine.cardinal.v1.UnsubscribeEventsRequest.subscriptions:type_name -> worldengine.cardinal.v1.EventSubscription
+	37, // 23: worldengine.cardinal.v1.SubscribeStateResponse.timestamp:type_name -> google.protobuf.Timestamp
+	27, // 24: worldengine.cardinal.v1.SubscribeStateResponse.snapshot:type_name -> worldengine.cardinal.v1.StateSnapshot
+	28, // 25: worldengine.cardinal.v1.SubscribeStateResponse.delta:type_name -> worldengine.cardinal.v1.StateDelta
+	29, // 26: worldengine.cardinal.v1.StateSnapshot.entities:type_name -> worldengine.cardinal.v1.EntityState
+	29, // 27: worldengine.cardinal.v1.StateDelta.added:type_name -> worldengine.cardinal.v1.EntityState
+	29, // 28: worldengine.cardinal.v1.StateDelta.updated:type_name -> worldengine.cardinal.v1.EntityState
+	32, // 29: worldengine.cardinal.v1.EntityState.components:type_name -> worldengine.cardinal.v1.EntityState.ComponentsEntry
+	1,  // 30: worldengine.cardinal.v1.QueryEntitiesRequest.match:type_name -> worldengine.cardinal.v1.QueryMatch
+	29, // 31: worldengine.cardinal.v1.QueryEntitiesResponse.entities:type_name -> worldengine.cardinal.v1.EntityState
+	2,  // 32: worldengine.cardinal.v1.CardinalService.SendCommand:input_type -> worldengine.cardinal.v1.SendCommandRequest
+	4,  // 33: worldengine.cardinal.v1.CardinalService.SendCommands:input_type -> worldengine.cardinal.v1.SendCommandsRequest
+	7,  // 34: worldengine.cardinal.v1.CardinalService.SendCommandWithReply:input_type -> worldengine.cardinal.v1.SendCommandWithReplyRequest
+	9,  // 35: worldengine.cardinal.v1.CardinalService.ScheduleCommand:input_type -> worldengine.cardinal.v1.ScheduleCommandRequest
+	11, // 36: worldengine.cardinal.v1.CardinalService.CancelScheduledCommand:input_type -> worldengine.cardinal.v1.CancelScheduledCommandRequest
+	13, // 37: worldengine.cardinal.v1.CardinalService.GetCommandReceipt:input_type -> worldengine.cardinal.v1.GetCommandReceiptRequest
+	17, // 38: worldengine.cardinal.v1.CardinalService.StartEventStream:input_type -> worldengine.cardinal.v1.StartEventStreamRequest
+	21, // 39: worldengine.cardinal.v1.CardinalService.SubscribeEvents:input_type -> worldengine.cardinal.v1.SubscribeEventsRequest
+	23, // 40: worldengine.cardinal.v1.CardinalService.UnsubscribeEvents:input_type -> worldengine.cardinal.v1.UnsubscribeEventsRequest
+	25, // 41: worldengine.cardinal.v1.CardinalService.SubscribeState:input_type -> worldengine.cardinal.v1.SubscribeStateRequest
+	30, // 42: worldengine.cardinal.v1.CardinalService.QueryEntities:input_type -> worldengine.cardinal.v1.QueryEntitiesRequest
+	3,  // 43: worldengine.cardinal.v1.CardinalService.SendCommand:output_type -> worldengine.cardinal.v1.SendCommandResponse
+	5,  // 44: worldengine.cardinal.v1.CardinalService.SendCommands:output_type -> worldengine.cardinal.v1.SendCommandsResponse
+	8,  // 45: worldengine.cardinal.v1.CardinalService.SendCommandWithReply:output_type -> worldengine.cardinal.v1.SendCommandWithReplyResponse
+	10, // 46: worldengine.cardinal.v1.CardinalService.ScheduleCommand:output_type -> worldengine.cardinal.v1.ScheduleCommandResponse
+	12, // 47: worldengine.cardinal.v1.CardinalService.CancelScheduledCommand:output_type -> worldengine.cardinal.v1.CancelScheduledCommandResponse
+	14, // 48: worldengine.cardinal.v1.CardinalService.GetCommandReceipt:output_type -> worldengine.cardinal.v1.GetCommandReceiptResponse
+	18, // 49: worldengine.cardinal.v1.CardinalService.StartEventStream:output_type -> worldengine.cardinal.v1.StartEventStreamResponse
+	22, // 50: worldengine.cardinal.v1.CardinalService.SubscribeEvents:output_type -> worldengine.cardinal.v1.SubscribeEventsResponse
+	24, // 51: worldengine.cardinal.v1.CardinalService.UnsubscribeEvents:output_type -> worldengine.cardinal.v1.UnsubscribeEventsResponse
+	26, // 52: worldengine.cardinal.v1.CardinalService.SubscribeState:output_type -> worldengine.cardinal.v1.SubscribeStateResponse
+	31, // 53: worldengine.cardinal.v1.CardinalService.QueryEntities:output_type -> worldengine.cardinal.v1.QueryEntitiesResponse
+	43, // [43:54] is the sub-list for method output_type
+	32, // [32:43] is the sub-list for method input_type
+	32, // [32:32] is the sub-list for extension type_name
+	32, // [32:32] is the sub-list for extension extendee
+	0,  // [0:32] is the sub-list for field type_name
 }
 
 func init() { file_worldengine_cardinal_v1_cardinal_proto_init() }
@@ -624,18 +2279,28 @@ func file_worldengine_cardinal_v1_cardinal_proto_init() {
 	if File_worldengine_cardinal_v1_cardinal_proto != nil {
 		return
 	}
+	file_worldengine_cardinal_v1_cardinal_proto_msgTypes[7].OneofWrappers = []any{
+		(*ScheduleCommandRequest_TickHeight)(nil),
+		(*ScheduleCommandRequest_Delay)(nil),
+	}
+	file_worldengine_cardinal_v1_cardinal_proto_msgTypes[15].OneofWrappers = []any{}
+	file_worldengine_cardinal_v1_cardinal_proto_msgTypes[24].OneofWrappers = []any{
+		(*SubscribeStateResponse_Snapshot)(nil),
+		(*SubscribeStateResponse_Delta)(nil),
+	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_worldengine_cardinal_v1_cardinal_proto_rawDesc), len(file_worldengine_cardinal_v1_cardinal_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   11,
+			NumEnums:      2,
+			NumMessages:   31,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
 		GoTypes:           file_worldengine_cardinal_v1_cardinal_proto_goTypes,
 		DependencyIndexes: file_worldengine_cardinal_v1_cardinal_proto_depIdxs,
+		EnumInfos:         file_worldengine_cardinal_v1_cardinal_proto_enumTypes,
 		MessageInfos:      file_worldengine_cardinal_v1_cardinal_proto_msgTypes,
 	}.Build()
 	File_worldengine_cardinal_v1_cardinal_proto = out.File
@@ -36,9 +36,21 @@ const (
 	// CardinalServiceSendCommandProcedure is the fully-qualified name of the CardinalService's
 	// SendCommand RPC.
 	CardinalServiceSendCommandProcedure = "/worldengine.cardinal.v1.CardinalService/SendCommand"
+	// CardinalServiceSendCommandsProcedure is the fully-qualified name of the CardinalService's
+	// SendCommands RPC.
+	CardinalServiceSendCommandsProcedure = "/worldengine.cardinal.v1.CardinalService/SendCommands"
 	// CardinalServiceSendCommandWithReplyProcedure is the fully-qualified name of the CardinalService's
 	// SendCommandWithReply RPC.
 	CardinalServiceSendCommandWithReplyProcedure = "/worldengine.cardinal.v1.CardinalService/SendCommandWithReply"
+	// CardinalServiceScheduleCommandProcedure is the fully-qualified name of the CardinalService's
+	// ScheduleCommand RPC.
+	CardinalServiceScheduleCommandProcedure = "/worldengine.cardinal.v1.CardinalService/ScheduleCommand"
+	// CardinalServiceCancelScheduledCommandProcedure is the fully-qualified name of the
+	// CardinalService's CancelScheduledCommand RPC.
+	CardinalServiceCancelScheduledCommandProcedure = "/worldengine.cardinal.v1.CardinalService/CancelScheduledCommand"
+	// CardinalServiceGetCommandReceiptProcedure is the fully-qualified name of the CardinalService's
+	// GetCommandReceipt RPC.
+	CardinalServiceGetCommandReceiptProcedure = "/worldengine.cardinal.v1.CardinalService/GetCommandReceipt"
 	// CardinalServiceStartEventStreamProcedure is the fully-qualified name of the CardinalService's
 	// StartEventStream RPC.
 	CardinalServiceStartEventStreamProcedure = "/worldengine.cardinal.v1.CardinalService/StartEventStream"
@@ -48,14 +60,30 @@ const (
 	// CardinalServiceUnsubscribeEventsProcedure is the fully-qualified name of the CardinalService's
 	// UnsubscribeEvents RPC.
 	CardinalServiceUnsubscribeEventsProcedure = "/worldengine.cardinal.v1.CardinalService/UnsubscribeEvents"
+	// CardinalServiceSubscribeStateProcedure is the fully-qualified name of the CardinalService's
+	// SubscribeState RPC.
+	CardinalServiceSubscribeStateProcedure = "/worldengine.cardinal.v1.CardinalService/SubscribeState"
+	// CardinalServiceQueryEntitiesProcedure is the fully-qualified name of the CardinalService's
+	// QueryEntities RPC.
+	CardinalServiceQueryEntitiesProcedure = "/worldengine.cardinal.v1.CardinalService/QueryEntities"
 )
 
 // CardinalServiceClient is a client for the worldengine.cardinal.v1.CardinalService service.
 type CardinalServiceClient interface {
 	// SendCommand sends a command to a specific shard (fire-and-forget).
 	SendCommand(context.Context, *connect.Request[v1.SendCommandRequest]) (*connect.Response[v1.SendCommandResponse], error)
-	// SendCommandWithReply sends a command and waits for an event response from the shard.
+	// SendCommands sends a batch of commands to a specific shard. The batch is all-or-nothing: either
+	// every command is enqueued and processed in the same tick, or none are.
+	SendCommands(context.Context, *connect.Request[v1.SendCommandsRequest]) (*connect.Response[v1.SendCommandsResponse], error)
+	// SendCommandWithReply sends a command and waits for the system processing it to reply with an event.
+	// Replies are correlated with the request, so only the reply to this command completes it.
 	SendCommandWithReply(context.Context, *connect.Request[v1.SendCommandWithReplyRequest]) (*connect.Response[v1.SendCommandWithReplyResponse], error)
+	// ScheduleCommand schedules a command to be processed at a future tick of the shard.
+	ScheduleCommand(context.Context, *connect.Request[v1.ScheduleCommandRequest]) (*connect.Response[v1.ScheduleCommandResponse], error)
+	// CancelScheduledCommand cancels a command scheduled with ScheduleCommand before it is processed.
+	CancelScheduledCommand(context.Context, *connect.Request[v1.CancelScheduledCommandRequest]) (*connect.Response[v1.CancelScheduledCommandResponse], error)
+	// GetCommandReceipt returns the processing status of a command sent with SendCommand.
+	GetCommandReceipt(context.Context, *connect.Request[v1.GetCommandReceiptRequest]) (*connect.Response[v1.GetCommandReceiptResponse], error)
 	// StartEventStream establishes a stream of events from specified shards. Clients can subscribe to
 	// specific event types and receive real-time updates.
 	StartEventStream(context.Context, *connect.Request[v1.StartEventStreamRequest]) (*connect.ServerStreamForClient[v1.StartEventStreamResponse], error)
@@ -63,6 +91,12 @@ type CardinalServiceClient interface {
 	SubscribeEvents(context.Context, *connect.Request[v1.SubscribeEventsRequest]) (*connect.Response[v1.SubscribeEventsResponse], error)
 	// UnsubscribeEvents removes event types from an existing event stream.
 	UnsubscribeEvents(context.Context, *connect.Request[v1.UnsubscribeEventsRequest]) (*connect.Response[v1.UnsubscribeEventsResponse], error)
+	// SubscribeState streams the ECS state matching a query: a snapshot of the matching entities
+	// first, and then the changes to them every tick.
+	SubscribeState(context.Context, *connect.Request[v1.SubscribeStateRequest]) (*connect.ServerStreamForClient[v1.SubscribeStateResponse], error)
+	// QueryEntities returns the entities matching a component filter in the latest published world
+	// state, one page at a time.
+	QueryEntities(context.Context, *connect.Request[v1.QueryEntitiesRequest]) (*connect.Response[v1.QueryEntitiesResponse], error)
 }
 
 // NewCardinalServiceClient constructs a client for the worldengine.cardinal.v1.CardinalService
@@ -82,12 +116,36 @@ func NewCardinalServiceClient(httpClient connect.HTTPClient, baseURL string, opt
 			connect.WithSchema(cardinalServiceMethods.ByName("SendCommand")),
 			connect.WithClientOptions(opts...),
 		),
+		sendCommands: connect.NewClient[v1.SendCommandsRequest, v1.SendCommandsResponse](
+			httpClient,
+			baseURL+CardinalServiceSendCommandsProcedure,
+			connect.WithSchema(cardinalServiceMethods.ByName("SendCommands")),
+			connect.WithClientOptions(opts...),
+		),
 		sendCommandWithReply: connect.NewClient[v1.SendCommandWithReplyRequest, v1.SendCommandWithReplyResponse](
 			httpClient,
 			baseURL+CardinalServiceSendCommandWithReplyProcedure,
 			connect.WithSchema(cardinalServiceMethods.ByName("SendCommandWithReply")),
 			connect.WithClientOptions(opts...),
 		),
+		scheduleCommand: connect.NewClient[v1.ScheduleCommandRequest, v1.ScheduleCommandResponse](
+			httpClient,
+			baseURL+CardinalServiceScheduleCommandProcedure,
+			connect.WithSchema(cardinalServiceMethods.ByName("ScheduleCommand")),
+			connect.WithClientOptions(opts...),
+		),
+		cancelScheduledCommand: connect.NewClient[v1.CancelScheduledCommandRequest, v1.CancelScheduledCommandResponse](
+			httpClient,
+			baseURL+CardinalServiceCancelScheduledCommandProcedure,
+			connect.WithSchema(cardinalServiceMethods.ByName("CancelScheduledCommand")),
+			connect.WithClientOptions(opts...),
+		),
+		getCommandReceipt: connect.NewClient[v1.GetCommandReceiptRequest, v1.GetCommandReceiptResponse](
+			httpClient,
+			baseURL+CardinalServiceGetCommandReceiptProcedure,
+			connect.WithSchema(cardinalServiceMethods.ByName("GetCommandReceipt")),
+			connect.WithClientOptions(opts...),
+		),
 		startEventStream: connect.NewClient[v1.StartEventStreamRequest, v1.StartEventStreamResponse](
 			httpClient,
 			baseURL+CardinalServiceStartEventStreamProcedure,
@@ -106,16 +164,34 @@ func NewCardinalServiceClient(httpClient connect.HTTPClient, baseURL string, opt
 			connect.WithSchema(cardinalServiceMethods.ByName("UnsubscribeEvents")),
 			connect.WithClientOptions(opts...),
 		),
+		subscribeState: connect.NewClient[v1.SubscribeStateRequest, v1.SubscribeStateResponse](
+			httpClient,
+			baseURL+CardinalServiceSubscribeStateProcedure,
+			connect.WithSchema(cardinalServiceMethods.ByName("SubscribeState")),
+			connect.WithClientOptions(opts...),
+		),
+		queryEntities: connect.NewClient[v1.QueryEntitiesRequest, v1.QueryEntitiesResponse](
+			httpClient,
+			baseURL+CardinalServiceQueryEntitiesProcedure,
+			connect.WithSchema(cardinalServiceMethods.ByName("QueryEntities")),
+			connect.WithClientOptions(opts...),
+		),
 	}
 }
 
 // cardinalServiceClient implements CardinalServiceClient.
 type cardinalServiceClient struct {
-	sendCommand          *connect.Client[v1.SendCommandRequest, v1.SendCommandResponse]
-	sendCommandWithReply *connect.Client[v1.SendCommandWithReplyRequest, v1.SendCommandWithReplyResponse]
-	startEventStream     *connect.Client[v1.StartEventStreamRequest, v1.StartEventStreamResponse]
-	subscribeEvents      *connect.Client[v1.SubscribeEventsRequest, v1.SubscribeEventsResponse]
-	unsubscribeEvents    *connect.Client[v1.UnsubscribeEventsRequest, v1.UnsubscribeEventsResponse]
+	sendCommand            *connect.Client[v1.SendCommandRequest, v1.SendCommandResponse]
+	sendCommands           *connect.Client[v1.SendCommandsRequest, v1.SendCommandsResponse]
+	sendCommandWithReply   *connect.Client[v1.SendCommandWithReplyRequest, v1.SendCommandWithReplyResponse]
+	scheduleCommand        *connect.Client[v1.ScheduleCommandRequest, v1.ScheduleCommandResponse]
+	cancelScheduledCommand *connect.Client[v1.CancelScheduledCommandRequest, v1.CancelScheduledCommandResponse]
+	getCommandReceipt      *connect.Client[v1.GetCommandReceiptRequest, v1.GetCommandReceiptResponse]
+	startEventStream       *connect.Client[v1.StartEventStreamRequest, v1.StartEventStreamResponse]
+	subscribeEvents        *connect.Client[v1.SubscribeEventsRequest, v1.SubscribeEventsResponse]
+	unsubscribeEvents      *connect.Client[v1.UnsubscribeEventsRequest, v1.UnsubscribeEventsResponse]
+	subscribeState         *connect.Client[v1.SubscribeStateRequest, v1.SubscribeStateResponse]
+	queryEntities          *connect.Client[v1.QueryEntitiesRequest, v1.QueryEntitiesResponse]
 }
 
 // SendCommand calls worldengine.cardinal.v1.CardinalService.SendCommand.
@@ -123,11 +199,31 @@ func (c *cardinalServiceClient) SendCommand(ctx context.Context, req *connect.Re
 	return c.sendCommand.CallUnary(ctx, req)
 }
 
+// SendCommands calls worldengine.cardinal.v1.CardinalService.SendCommands.
+func (c *cardinalServiceClient) SendCommands(ctx context.Context, req *connect.Request[v1.SendCommandsRequest]) (*connect.Response[v1.SendCommandsResponse], error) {
+	return c.sendCommands.CallUnary(ctx, req)
+}
+
 // SendCommandWithReply calls worldengine.cardinal.v1.CardinalService.SendCommandWithReply.
 func (c *cardinalServiceClient) SendCommandWithReply(ctx context.Context, req *connect.Request[v1.SendCommandWithReplyRequest]) (*connect.Response[v1.SendCommandWithReplyResponse], error) {
 	return c.sendCommandWithReply.CallUnary(ctx, req)
 }
 
+// ScheduleCommand calls worldengine.cardinal.v1.CardinalService.ScheduleCommand.
+func (c *cardinalServiceClient) ScheduleCommand(ctx context.Context, req *connect.Request[v1.ScheduleCommandRequest]) (*connect.Response[v1.ScheduleCommandResponse], error) {
+	return c.scheduleCommand.CallUnary(ctx, req)
+}
+
+// CancelScheduledCommand calls worldengine.cardinal.v1.CardinalService.CancelScheduledCommand.
+func (c *cardinalServiceClient) CancelScheduledCommand(ctx context.Context, req *connect.Request[v1.CancelScheduledCommandRequest]) (*connect.Response[v1.CancelScheduledCommandResponse], error) {
+	return c.cancelScheduledCommand.CallUnary(ctx, req)
+}
+
+// GetCommandReceipt calls worldengine.cardinal.v1.CardinalService.GetCommandReceipt.
+func (c *cardinalServiceClient) GetCommandReceipt(ctx context.Context, req *connect.Request[v1.GetCommandReceiptRequest]) (*connect.Response[v1.GetCommandReceiptResponse], error) {
+	return c.getCommandReceipt.CallUnary(ctx, req)
+}
+
 // StartEventStream calls worldengine.cardinal.v1.CardinalService.StartEventStream.
 func (c *cardinalServiceClient) StartEventStream(ctx context.Context, req *connect.Request[v1.StartEventStreamRequest]) (*connect.ServerStreamForClient[v1.StartEventStreamResponse], error) {
 	return c.startEventStream.CallServerStream(ctx, req)
@@ -143,13 +239,33 @@ func (c *cardinalServiceClient) UnsubscribeEvents(ctx context.Context, req *conn
 	return c.unsubscribeEvents.CallUnary(ctx, req)
 }
 
+// SubscribeState calls worldengine.cardinal.v1.CardinalService.SubscribeState.
+func (c *cardinalServiceClient) SubscribeState(ctx context.Context, req *connect.Request[v1.SubscribeStateRequest]) (*connect.ServerStreamForClient[v1.SubscribeStateResponse], error) {
+	return c.subscribeState.CallServerStream(ctx, req)
+}
+
+// QueryEntities calls worldengine.cardinal.v1.CardinalService.QueryEntities.
+func (c *cardinalServiceClient) QueryEntities(ctx context.Context, req *connect.Request[v1.QueryEntitiesRequest]) (*connect.Response[v1.QueryEntitiesResponse], error) {
+	return c.queryEntities.CallUnary(ctx, req)
+}
+
 // CardinalServiceHandler is an implementation of the worldengine.cardinal.v1.CardinalService
 // service.
 type CardinalServiceHandler interface {
 	// SendCommand sends a command to a specific shard (fire-and-forget).
 	SendCommand(context.Context, *connect.Request[v1.SendCommandRequest]) (*connect.Response[v1.SendCommandResponse], error)
-	// SendCommandWithReply sends a command and waits for an event response from the shard.
+	// SendCommands sends a batch of commands to a specific shard. The batch is all-or-nothing: either
+	// every command is enqueued and processed in the same tick, or none are.
+	SendCommands(context.Context, *connect.Request[v1.SendCommandsRequest]) (*connect.Response[v1.SendCommandsResponse], error)
+	// SendCommandWithReply sends a command and waits for the system processing it to reply with an event.
+	// Replies are correlated with the request, so only the reply to this command completes it.
 	SendCommandWithReply(context.Context, *connect.Request[v1.SendCommandWithReplyRequest]) (*connect.Response[v1.SendCommandWithReplyResponse], error)
+	// ScheduleCommand schedules a command to be processed at a future tick of the shard.
+	ScheduleCommand(context.Context, *connect.Request[v1.ScheduleCommandRequest]) (*connect.Response[v1.ScheduleCommandResponse], error)
+	// CancelScheduledCommand cancels a command scheduled with ScheduleCommand before it is processed.
+	CancelScheduledCommand(context.Context, *connect.Request[v1.CancelScheduledCommandRequest]) (*connect.Response[v1.CancelScheduledCommandResponse], error)
+	// GetCommandReceipt returns the processing status of a command sent with SendCommand.
+	GetCommandReceipt(context.Context, *connect.Request[v1.GetCommandReceiptRequest]) (*connect.Response[v1.GetCommandReceiptResponse], error)
 	// StartEventStream establishes a stream of events from specified shards. Clients can subscribe to
 	// specific event types and receive real-time updates.
 	StartEventStream(context.Context, *connect.Request[v1.StartEventStreamRequest], *connect.ServerStream[v1.StartEventStreamResponse]) error
@@ -157,6 +273,12 @@ type CardinalServiceHandler interface {
 	SubscribeEvents(context.Context, *connect.Request[v1.SubscribeEventsRequest]) (*connect.Response[v1.SubscribeEventsResponse], error)
 	// UnsubscribeEvents removes event types from an existing event stream.
 	UnsubscribeEvents(context.Context, *connect.Request[v1.UnsubscribeEventsRequest]) (*connect.Response[v1.UnsubscribeEventsResponse], error)
+	// SubscribeState streams the ECS state matching a query: a snapshot of the matching entities
+	// first, and then the changes to them every tick.
+	SubscribeState(context.Context, *connect.Request[v1.SubscribeStateRequest], *connect.ServerStream[v1.SubscribeStateResponse]) error
+	// QueryEntities returns the entities matching a component filter in the latest published world
+	// state, one page at a time.
+	QueryEntities(context.Context, *connect.Request[v1.QueryEntitiesRequest]) (*connect.Response[v1.QueryEntitiesResponse], error)
 }
 
 // NewCardinalServiceHandler builds an HTTP handler from the service implementation. It returns the
@@ -172,12 +294,36 @@ func NewCardinalServiceHandler(svc CardinalServiceHandler, opts ...connect.Handl
 		connect.WithSchema(cardinalServiceMethods.ByName("SendCommand")),
 		connect.WithHandlerOptions(opts...),
 	)
+	cardinalServiceSendCommandsHandler := connect.NewUnaryHandler(
+		CardinalServiceSendCommandsProcedure,
+		svc.SendCommands,
+		connect.WithSchema(cardinalServiceMethods.ByName("SendCommands")),
+		connect.WithHandlerOptions(opts...),
+	)
 	cardinalServiceSendCommandWithReplyHandler := connect.NewUnaryHandler(
 		CardinalServiceSendCommandWithReplyProcedure,
 		svc.SendCommandWithReply,
 		connect.WithSchema(cardinalServiceMethods.ByName("SendCommandWithReply")),
 		connect.WithHandlerOptions(opts...),
 	)
+	cardinalServiceScheduleCommandHandler := connect.NewUnaryHandler(
+		CardinalServiceScheduleCommandProcedure,
+		svc.ScheduleCommand,
+		connect.WithSchema(cardinalServiceMethods.ByName("ScheduleCommand")),
+		connect.WithHandlerOptions(opts...),
+	)
+	cardinalServiceCancelScheduledCommandHandler := connect.NewUnaryHandler(
+		CardinalServiceCancelScheduledCommandProcedure,
+		svc.CancelScheduledCommand,
+		connect.WithSchema(cardinalServiceMethods.ByName("CancelScheduledCommand")),
+		connect.WithHandlerOptions(opts...),
+	)
+	cardinalServiceGetCommandReceiptHandler := connect.NewUnaryHandler(
+		CardinalServiceGetCommandReceiptProcedure,
+		svc.GetCommandReceipt,
+		connect.WithSchema(cardinalServiceMethods.ByName("GetCommandReceipt")),
+		connect.WithHandlerOptions(opts...),
+	)
 	cardinalServiceStartEventStreamHandler := connect.NewServerStreamHandler(
 		CardinalServiceStartEventStreamProcedure,
 		svc.StartEventStream,
@@ -196,18 +342,42 @@ func NewCardinalServiceHandler(svc CardinalServiceHandler, opts ...connect.Handl
 		connect.WithSchema(cardinalServiceMethods.ByName("UnsubscribeEvents")),
 		connect.WithHandlerOptions(opts...),
 	)
+	cardinalServiceSubscribeStateHandler := connect.NewServerStreamHandler(
+		CardinalServiceSubscribeStateProcedure,
+		svc.SubscribeState,
+		connect.WithSchema(cardinalServiceMethods.ByName("SubscribeState")),
+		connect.WithHandlerOptions(opts...),
+	)
+	cardinalServiceQueryEntitiesHandler := connect.NewUnaryHandler(
+		CardinalServiceQueryEntitiesProcedure,
+		svc.QueryEntities,
+		connect.WithSchema(cardinalServiceMethods.ByName("QueryEntities")),
+		connect.WithHandlerOptions(opts...),
+	)
 	return "/worldengine.cardinal.v1.CardinalService/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		switch r.URL.Path {
 		case CardinalServiceSendCommandProcedure:
 			cardinalServiceSendCommandHandler.ServeHTTP(w, r)
+		case CardinalServiceSendCommandsProcedure:
+			cardinalServiceSendCommandsHandler.ServeHTTP(w, r)
 		case CardinalServiceSendCommandWithReplyProcedure:
 			cardinalServiceSendCommandWithReplyHandler.ServeHTTP(w, r)
+		case CardinalServiceScheduleCommandProcedure:
+			cardinalServiceScheduleCommandHandler.ServeHTTP(w, r)
+		case CardinalServiceCancelScheduledCommandProcedure:
+			cardinalServiceCancelScheduledCommandHandler.ServeHTTP(w, r)
+		case CardinalServiceGetCommandReceiptProcedure:
+			cardinalServiceGetCommandReceiptHandler.ServeHTTP(w, r)
 		case CardinalServiceStartEventStreamProcedure:
 			cardinalServiceStartEventStreamHandler.ServeHTTP(w, r)
 		case CardinalServiceSubscribeEventsProcedure:
 			cardinalServiceSubscribeEventsHandler.ServeHTTP(w, r)
 		case CardinalServiceUnsubscribeEventsProcedure:
 			cardinalServiceUnsubscribeEventsHandler.ServeHTTP(w, r)
+		case CardinalServiceSubscribeStateProcedure:
+			cardinalServiceSubscribeStateHandler.ServeHTTP(w, r)
+		case CardinalServiceQueryEntitiesProcedure:
+			cardinalServiceQueryEntitiesHandler.ServeHTTP(w, r)
 		default:
 			http.NotFound(w, r)
 		}
@@ -221,10 +391,26 @@ func (UnimplementedCardinalServiceHandler) SendCommand(context.Context, *connect
 	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("worldengine.cardinal.v1.CardinalService.SendCommand is not implemented"))
 }
 
+func (UnimplementedCardinalServiceHandler) SendCommands(context.Context, *connect.Request[v1.SendCommandsRequest]) (*connect.Response[v1.SendCommandsResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("worldengine.cardinal.v1.CardinalService.SendCommands is not implemented"))
+}
+
 func (UnimplementedCardinalServiceHandler) SendCommandWithReply(context.Context, *connect.Request[v1.SendCommandWithReplyRequest]) (*connect.Response[v1.SendCommandWithReplyResponse], error) {
 	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("worldengine.cardinal.v1.CardinalService.SendCommandWithReply is not implemented"))
 }
 
+func (UnimplementedCardinalServiceHandler) ScheduleCommand(context.Context, *connect.Request[v1.ScheduleCommandRequest]) (*connect.Response[v1.ScheduleCommandResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("worldengine.cardinal.v1.CardinalService.ScheduleCommand is not implemented"))
+}
+
+func (UnimplementedCardinalServiceHandler) CancelScheduledCommand(context.Context, *connect.Request[v1.CancelScheduledCommandRequest]) (*connect.Response[v1.CancelScheduledCommandResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("worldengine.cardinal.v1.CardinalService.CancelScheduledCommand is not implemented"))
+}
+
+func (UnimplementedCardinalServiceHandler) GetCommandReceipt(context.Context, *connect.Request[v1.GetCommandReceiptRequest]) (*connect.Response[v1.GetCommandReceiptResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("worldengine.cardinal.v1.CardinalService.GetCommandReceipt is not implemented"))
+}
+
 func (UnimplementedCardinalServiceHandler) StartEventStream(context.Context, *connect.Request[v1.StartEventStreamRequest], *connect.ServerStream[v1.StartEventStreamResponse]) error {
 	return connect.NewError(connect.CodeUnimplemented, errors.New("worldengine.cardinal.v1.CardinalService.StartEventStream is not implemented"))
 }
@@ -236,3 +422,11 @@ func (UnimplementedCardinalServiceHandler) SubscribeEvents(context.Context, *con
 func (UnimplementedCardinalServiceHandler) UnsubscribeEvents(context.Context, *connect.Request[v1.UnsubscribeEventsRequest]) (*connect.Response[v1.UnsubscribeEventsResponse], error) {
 	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("worldengine.cardinal.v1.CardinalService.UnsubscribeEvents is not implemented"))
 }
+
+func (UnimplementedCardinalServiceHandler) SubscribeState(context.Context, *connect.Request[v1.SubscribeStateRequest], *connect.ServerStream[v1.SubscribeStateResponse]) error {
+	return connect.NewError(connect.CodeUnimplemented, errors.New("worldengine.cardinal.v1.CardinalService.SubscribeState is not implemented"))
+}
+
+func (UnimplementedCardinalServiceHandler) QueryEntities(context.Context, *connect.Request[v1.QueryEntitiesRequest]) (*connect.Response[v1.QueryEntitiesResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("worldengine.cardinal.v1.CardinalService.QueryEntities is not implemented"))
+}
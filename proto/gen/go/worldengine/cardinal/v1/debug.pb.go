@@ -717,7 +717,7 @@ type GetStateResponse struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
 	// Whether the world is currently paused.
 	IsPaused bool `protobuf:"varint,1,opt,name=is_paused,json=isPaused,proto3" json:"is_paused,omitempty"`
-	// The current world state snapshot (includes tick_height).
+	// The current world state snapshot (includes tick_height and state_hash).
 	Snapshot      *Snapshot `protobuf:"bytes,2,opt,name=snapshot,proto3" json:"snapshot,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
@@ -0,0 +1,185 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: worldengine/cardinal/v1/sink.proto
+
+package cardinalv1
+
+import (
+	v11 "github.com/argus-labs/world-engine/proto/gen/go/worldengine/isc/v1"
+	v1 "github.com/argus-labs/world-engine/proto/gen/go/worldengine/micro/v1"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// EventRecord is an event a shard dispatched, as published to the shard's event sink stream.
+type EventRecord struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The address of the shard that emitted the event.
+	Address *v1.ServiceAddress `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	// The height of the tick that emitted the event.
+	TickHeight uint64 `protobuf:"varint,2,opt,name=tick_height,json=tickHeight,proto3" json:"tick_height,omitempty"`
+	// The timestamp of the tick that emitted the event.
+	Timestamp *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	// The user the event was sent to, empty for broadcasts.
+	Recipient string `protobuf:"bytes,4,opt,name=recipient,proto3" json:"recipient,omitempty"`
+	// The event.
+	Event *v11.Event `protobuf:"bytes,5,opt,name=event,proto3" json:"event,omitempty"`
+	// The event's index among the events the tick emitted. Together with the tick height, it uniquely
+	// identifies the event.
+	Index         uint32 `protobuf:"varint,6,opt,name=index,proto3" json:"index,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EventRecord) Reset() {
+	*x = EventRecord{}
+	mi := &file_worldengine_cardinal_v1_sink_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EventRecord) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EventRecord) ProtoMessage() {}
+
+func (x *EventRecord) ProtoReflect() protoreflect.Message {
+	mi := &file_worldengine_cardinal_v1_sink_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EventRecord.ProtoReflect.Descriptor instead.
+func (*EventRecord) Descriptor() ([]byte, []int) {
+	return file_worldengine_cardinal_v1_sink_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *EventRecord) GetAddress() *v1.ServiceAddress {
+	if x != nil {
+		return x.Address
+	}
+	return nil
+}
+
+func (x *EventRecord) GetTickHeight() uint64 {
+	if x != nil {
+		return x.TickHeight
+	}
+	return 0
+}
+
+func (x *EventRecord) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+func (x *EventRecord) GetRecipient() string {
+	if x != nil {
+		return x.Recipient
+	}
+	return ""
+}
+
+func (x *EventRecord) GetEvent() *v11.Event {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+func (x *EventRecord) GetIndex() uint32 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+var File_worldengine_cardinal_v1_sink_proto protoreflect.FileDescriptor
+
+const file_worldengine_cardinal_v1_sink_proto_rawDesc = "" +
+	"\n" +
+	"\"worldengine/cardinal/v1/sink.proto\x12\x17worldengine.cardinal.v1\x1a\x1fgoogle/protobuf/timestamp.proto\x1a\x1eworldengine/isc/v1/event.proto\x1a\"worldengine/micro/v1/service.proto\"\x8d\x02\n" +
+	"\vEventRecord\x12>\n" +
+	"\aaddress\x18\x01 \x01(\v2$.worldengine.micro.v1.ServiceAddressR\aaddress\x12\x1f\n" +
+	"\vtick_height\x18\x02 \x01(\x04R\n" +
+	"tickHeight\x128\n" +
+	"\ttimestamp\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x12\x1c\n" +
+	"\trecipient\x18\x04 \x01(\tR\trecipient\x12/\n" +
+	"\x05event\x18\x05 \x01(\v2\x19.worldengine.isc.v1.EventR\x05event\x12\x14\n" +
+	"\x05index\x18\x06 \x01(\rR\x05indexBtZRgithub.com/argus-labs/world-engine/proto/gen/go/worldengine/cardinal/v1;cardinalv1\xaa\x02\x1dWorldEngine.Proto.Cardinal.V1b\x06proto3"
+
+var (
+	file_worldengine_cardinal_v1_sink_proto_rawDescOnce sync.Once
+	file_worldengine_cardinal_v1_sink_proto_rawDescData []byte
+)
+
+func file_worldengine_cardinal_v1_sink_proto_rawDescGZIP() []byte {
+	file_worldengine_cardinal_v1_sink_proto_rawDescOnce.Do(func() {
+		file_worldengine_cardinal_v1_sink_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_worldengine_cardinal_v1_sink_proto_rawDesc), len(file_worldengine_cardinal_v1_sink_proto_rawDesc)))
+	})
+	return file_worldengine_cardinal_v1_sink_proto_rawDescData
+}
+
+var file_worldengine_cardinal_v1_sink_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_worldengine_cardinal_v1_sink_proto_goTypes = []any{
+	(*EventRecord)(nil),           // 0: worldengine.cardinal.v1.EventRecord
+	(*v1.ServiceAddress)(nil),     // 1: worldengine.micro.v1.ServiceAddress
+	(*timestamppb.Timestamp)(nil), // 2: google.protobuf.Timestamp
+	(*v11.Event)(nil),             // 3: worldengine.isc.v1.Event
+}
+var file_worldengine_cardinal_v1_sink_proto_depIdxs = []int32{
+	1, // 0: worldengine.cardinal.v1.EventRecord.address:type_name -> worldengine.micro.v1.ServiceAddress
+	2, // 1: worldengine.cardinal.v1.EventRecord.timestamp:type_name -> google.protobuf.Timestamp
+	3, // 2: worldengine.cardinal.v1.EventRecord.event:type_name -> worldengine.isc.v1.Event
+	3, // [3:3] is the sub-list for method output_type
+	3, // [3:3] is the sub-list for method input_type
+	3, // [3:3] is the sub-list for extension type_name
+	3, // [3:3] is the sub-list for extension extendee
+	0, // [0:3] is the sub-list for field type_name
+}
+
+func init() { file_worldengine_cardinal_v1_sink_proto_init() }
+func file_worldengine_cardinal_v1_sink_proto_init() {
+	if File_worldengine_cardinal_v1_sink_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_worldengine_cardinal_v1_sink_proto_rawDesc), len(file_worldengine_cardinal_v1_sink_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   1,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_worldengine_cardinal_v1_sink_proto_goTypes,
+		DependencyIndexes: file_worldengine_cardinal_v1_sink_proto_depIdxs,
+		MessageInfos:      file_worldengine_cardinal_v1_sink_proto_msgTypes,
+	}.Build()
+	File_worldengine_cardinal_v1_sink_proto = out.File
+	file_worldengine_cardinal_v1_sink_proto_goTypes = nil
+	file_worldengine_cardinal_v1_sink_proto_depIdxs = nil
+}
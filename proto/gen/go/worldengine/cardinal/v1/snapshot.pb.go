@@ -8,6 +8,7 @@ package cardinalv1
 
 import (
 	_ "buf.build/gen/go/bufbuild/protovalidate/protocolbuffers/go/buf/validate"
+	v1 "github.com/argus-labs/world-engine/proto/gen/go/worldengine/isc/v1"
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
 	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
@@ -23,13 +24,70 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+type SnapshotCompression int32
+
+const (
+	SnapshotCompression_SNAPSHOT_COMPRESSION_UNSPECIFIED SnapshotCompression = 0
+	// The state isn't compressed.
+	SnapshotCompression_SNAPSHOT_COMPRESSION_NONE SnapshotCompression = 1
+	// The state is compressed with zstd.
+	SnapshotCompression_SNAPSHOT_COMPRESSION_ZSTD SnapshotCompression = 2
+)
+
+// Enum value maps for SnapshotCompression.
+var (
+	SnapshotCompression_name = map[int32]string{
+		0: "SNAPSHOT_COMPRESSION_UNSPECIFIED",
+		1: "SNAPSHOT_COMPRESSION_NONE",
+		2: "SNAPSHOT_COMPRESSION_ZSTD",
+	}
+	SnapshotCompression_value = map[string]int32{
+		"SNAPSHOT_COMPRESSION_UNSPECIFIED": 0,
+		"SNAPSHOT_COMPRESSION_NONE":        1,
+		"SNAPSHOT_COMPRESSION_ZSTD":        2,
+	}
+)
+
+func (x SnapshotCompression) Enum() *SnapshotCompression {
+	p := new(SnapshotCompression)
+	*p = x
+	return p
+}
+
+func (x SnapshotCompression) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (SnapshotCompression) Descriptor() protoreflect.EnumDescriptor {
+	return file_worldengine_cardinal_v1_snapshot_proto_enumTypes[0].Descriptor()
+}
+
+func (SnapshotCompression) Type() protoreflect.EnumType {
+	return &file_worldengine_cardinal_v1_snapshot_proto_enumTypes[0]
+}
+
+func (x SnapshotCompression) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use SnapshotCompression.Descriptor instead.
+func (SnapshotCompression) EnumDescriptor() ([]byte, []int) {
+	return file_worldengine_cardinal_v1_snapshot_proto_rawDescGZIP(), []int{0}
+}
+
 // Snapshot represents a point-in-time capture of shard state.
 type Snapshot struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	TickHeight    uint64                 `protobuf:"varint,1,opt,name=tick_height,json=tickHeight,proto3" json:"tick_height,omitempty"`
-	Timestamp     *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
-	WorldState    *WorldState            `protobuf:"bytes,3,opt,name=world_state,json=worldState,proto3" json:"world_state,omitempty"`
-	Version       uint32                 `protobuf:"varint,4,opt,name=version,proto3" json:"version,omitempty"`
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	TickHeight uint64                 `protobuf:"varint,1,opt,name=tick_height,json=tickHeight,proto3" json:"tick_height,omitempty"`
+	Timestamp  *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	WorldState *WorldState            `protobuf:"bytes,3,opt,name=world_state,json=worldState,proto3" json:"world_state,omitempty"`
+	Version    uint32                 `protobuf:"varint,4,opt,name=version,proto3" json:"version,omitempty"`
+	// Changes since the previous snapshot, set instead of world_state in delta snapshots
+	Delta *WorldStateDelta `protobuf:"bytes,5,opt,name=delta,proto3" json:"delta,omitempty"`
+	// Encoded world state or delta, set instead of world_state and delta from version 2
+	Payload *SnapshotPayload `protobuf:"bytes,6,opt,name=payload,proto3" json:"payload,omitempty"`
+	// Hash of the world state, set in published states
+	StateHash     uint64 `protobuf:"varint,7,opt,name=state_hash,json=stateHash,proto3" json:"state_hash,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -92,6 +150,120 @@ func (x *Snapshot) GetVersion() uint32 {
 	return 0
 }
 
+func (x *Snapshot) GetDelta() *WorldStateDelta {
+	if x != nil {
+		return x.Delta
+	}
+	return nil
+}
+
+func (x *Snapshot) GetPayload() *SnapshotPayload {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *Snapshot) GetStateHash() uint64 {
+	if x != nil {
+		return x.StateHash
+	}
+	return 0
+}
+
+// SnapshotPayload is the encoded state of a snapshot. It records how the state was encoded, so it can
+// be decoded regardless of the current settings.
+type SnapshotPayload struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Whether the state is a WorldStateDelta instead of a WorldState
+	Delta bool `protobuf:"varint,1,opt,name=delta,proto3" json:"delta,omitempty"`
+	// Compression applied to the serialized state
+	Compression SnapshotCompression `protobuf:"varint,2,opt,name=compression,proto3,enum=worldengine.cardinal.v1.SnapshotCompression" json:"compression,omitempty"`
+	// Whether the compressed state is encrypted with AES-GCM, with the nonce prepended to the ciphertext.
+	// The tick height, delta, compression, and key_id are authenticated as additional data.
+	Encrypted bool `protobuf:"varint,3,opt,name=encrypted,proto3" json:"encrypted,omitempty"`
+	// Identifies the encryption key: the first 8 bytes of the key's SHA-256 hash
+	KeyId []byte `protobuf:"bytes,4,opt,name=key_id,json=keyId,proto3" json:"key_id,omitempty"`
+	// SHA-256 checksum of data, after compression and encryption
+	Checksum []byte `protobuf:"bytes,5,opt,name=checksum,proto3" json:"checksum,omitempty"`
+	// The serialized state, after compression and encryption
+	Data          []byte `protobuf:"bytes,6,opt,name=data,proto3" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SnapshotPayload) Reset() {
+	*x = SnapshotPayload{}
+	mi := &file_worldengine_cardinal_v1_snapshot_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SnapshotPayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SnapshotPayload) ProtoMessage() {}
+
+func (x *SnapshotPayload) ProtoReflect() protoreflect.Message {
+	mi := &file_worldengine_cardinal_v1_snapshot_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SnapshotPayload.ProtoReflect.Descriptor instead.
+func (*SnapshotPayload) Descriptor() ([]byte, []int) {
+	return file_worldengine_cardinal_v1_snapshot_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *SnapshotPayload) GetDelta() bool {
+	if x != nil {
+		return x.Delta
+	}
+	return false
+}
+
+func (x *SnapshotPayload) GetCompression() SnapshotCompression {
+	if x != nil {
+		return x.Compression
+	}
+	return SnapshotCompression_SNAPSHOT_COMPRESSION_UNSPECIFIED
+}
+
+func (x *SnapshotPayload) GetEncrypted() bool {
+	if x != nil {
+		return x.Encrypted
+	}
+	return false
+}
+
+func (x *SnapshotPayload) GetKeyId() []byte {
+	if x != nil {
+		return x.KeyId
+	}
+	return nil
+}
+
+func (x *SnapshotPayload) GetChecksum() []byte {
+	if x != nil {
+		return x.Checksum
+	}
+	return nil
+}
+
+func (x *SnapshotPayload) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
 // WorldState represents the ECS world state.
 type WorldState struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
@@ -101,14 +273,21 @@ type WorldState struct {
 	// Entity to archetype mapping as sparse set
 	EntityArch []int64 `protobuf:"varint,3,rep,packed,name=entity_arch,json=entityArch,proto3" json:"entity_arch,omitempty"`
 	// Archetypes in the world state
-	Archetypes    []*Archetype `protobuf:"bytes,4,rep,name=archetypes,proto3" json:"archetypes,omitempty"`
+	Archetypes []*Archetype `protobuf:"bytes,4,rep,name=archetypes,proto3" json:"archetypes,omitempty"`
+	// Commands scheduled to be processed at a future tick
+	CommandSchedule *CommandSchedule `protobuf:"bytes,5,opt,name=command_schedule,json=commandSchedule,proto3" json:"command_schedule,omitempty"`
+	// Schema version of each component with migrations, by name. Components without an entry are at
+	// version 0.
+	ComponentVersions map[string]uint32 `protobuf:"bytes,6,rep,name=component_versions,json=componentVersions,proto3" json:"component_versions,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	// Seed of the random numbers of the systems
+	Seed          uint64 `protobuf:"varint,7,opt,name=seed,proto3" json:"seed,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *WorldState) Reset() {
 	*x = WorldState{}
-	mi := &file_worldengine_cardinal_v1_snapshot_proto_msgTypes[1]
+	mi := &file_worldengine_cardinal_v1_snapshot_proto_msgTypes[2]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -120,7 +299,7 @@ func (x *WorldState) String() string {
 func (*WorldState) ProtoMessage() {}
 
 func (x *WorldState) ProtoReflect() protoreflect.Message {
-	mi := &file_worldengine_cardinal_v1_snapshot_proto_msgTypes[1]
+	mi := &file_worldengine_cardinal_v1_snapshot_proto_msgTypes[2]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -133,7 +312,7 @@ func (x *WorldState) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use WorldState.ProtoReflect.Descriptor instead.
 func (*WorldState) Descriptor() ([]byte, []int) {
-	return file_worldengine_cardinal_v1_snapshot_proto_rawDescGZIP(), []int{1}
+	return file_worldengine_cardinal_v1_snapshot_proto_rawDescGZIP(), []int{2}
 }
 
 func (x *WorldState) GetNextId() uint32 {
@@ -164,6 +343,255 @@ func (x *WorldState) GetArchetypes() []*Archetype {
 	return nil
 }
 
+func (x *WorldState) GetCommandSchedule() *CommandSchedule {
+	if x != nil {
+		return x.CommandSchedule
+	}
+	return nil
+}
+
+func (x *WorldState) GetComponentVersions() map[string]uint32 {
+	if x != nil {
+		return x.ComponentVersions
+	}
+	return nil
+}
+
+func (x *WorldState) GetSeed() uint64 {
+	if x != nil {
+		return x.Seed
+	}
+	return 0
+}
+
+// WorldStateDelta represents the changes to the ECS world state since the previous snapshot.
+type WorldStateDelta struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Tick height of the snapshot the changes apply to
+	ParentTickHeight uint64 `protobuf:"varint,1,opt,name=parent_tick_height,json=parentTickHeight,proto3" json:"parent_tick_height,omitempty"`
+	// Entity manager state
+	NextId  uint32   `protobuf:"varint,2,opt,name=next_id,json=nextId,proto3" json:"next_id,omitempty"`
+	FreeIds []uint32 `protobuf:"varint,3,rep,packed,name=free_ids,json=freeIds,proto3" json:"free_ids,omitempty"`
+	// Entity to archetype mapping as sparse set
+	EntityArch []int64 `protobuf:"varint,4,rep,packed,name=entity_arch,json=entityArch,proto3" json:"entity_arch,omitempty"`
+	// Archetypes that changed or were created, ordered by id. Archetypes that existed in the previous
+	// snapshot only contain their changed columns.
+	Archetypes []*Archetype `protobuf:"bytes,5,rep,name=archetypes,proto3" json:"archetypes,omitempty"`
+	// Commands scheduled to be processed at a future tick
+	CommandSchedule *CommandSchedule `protobuf:"bytes,6,opt,name=command_schedule,json=commandSchedule,proto3" json:"command_schedule,omitempty"`
+	// Schema version of each component with migrations, by name
+	ComponentVersions map[string]uint32 `protobuf:"bytes,7,rep,name=component_versions,json=componentVersions,proto3" json:"component_versions,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	// Seed of the random numbers of the systems
+	Seed          uint64 `protobuf:"varint,8,opt,name=seed,proto3" json:"seed,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WorldStateDelta) Reset() {
+	*x = WorldStateDelta{}
+	mi := &file_worldengine_cardinal_v1_snapshot_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WorldStateDelta) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WorldStateDelta) ProtoMessage() {}
+
+func (x *WorldStateDelta) ProtoReflect() protoreflect.Message {
+	mi := &file_worldengine_cardinal_v1_snapshot_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WorldStateDelta.ProtoReflect.Descriptor instead.
+func (*WorldStateDelta) Descriptor() ([]byte, []int) {
+	return file_worldengine_cardinal_v1_snapshot_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *WorldStateDelta) GetParentTickHeight() uint64 {
+	if x != nil {
+		return x.ParentTickHeight
+	}
+	return 0
+}
+
+func (x *WorldStateDelta) GetNextId() uint32 {
+	if x != nil {
+		return x.NextId
+	}
+	return 0
+}
+
+func (x *WorldStateDelta) GetFreeIds() []uint32 {
+	if x != nil {
+		return x.FreeIds
+	}
+	return nil
+}
+
+func (x *WorldStateDelta) GetEntityArch() []int64 {
+	if x != nil {
+		return x.EntityArch
+	}
+	return nil
+}
+
+func (x *WorldStateDelta) GetArchetypes() []*Archetype {
+	if x != nil {
+		return x.Archetypes
+	}
+	return nil
+}
+
+func (x *WorldStateDelta) GetCommandSchedule() *CommandSchedule {
+	if x != nil {
+		return x.CommandSchedule
+	}
+	return nil
+}
+
+func (x *WorldStateDelta) GetComponentVersions() map[string]uint32 {
+	if x != nil {
+		return x.ComponentVersions
+	}
+	return nil
+}
+
+func (x *WorldStateDelta) GetSeed() uint64 {
+	if x != nil {
+		return x.Seed
+	}
+	return 0
+}
+
+// CommandSchedule represents the commands waiting for their scheduled tick.
+type CommandSchedule struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Next handle to assign to a scheduled command
+	NextHandle uint64 `protobuf:"varint,1,opt,name=next_handle,json=nextHandle,proto3" json:"next_handle,omitempty"`
+	// Scheduled commands ordered by tick height, then handle
+	Commands      []*ScheduledCommand `protobuf:"bytes,2,rep,name=commands,proto3" json:"commands,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CommandSchedule) Reset() {
+	*x = CommandSchedule{}
+	mi := &file_worldengine_cardinal_v1_snapshot_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CommandSchedule) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CommandSchedule) ProtoMessage() {}
+
+func (x *CommandSchedule) ProtoReflect() protoreflect.Message {
+	mi := &file_worldengine_cardinal_v1_snapshot_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CommandSchedule.ProtoReflect.Descriptor instead.
+func (*CommandSchedule) Descriptor() ([]byte, []int) {
+	return file_worldengine_cardinal_v1_snapshot_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *CommandSchedule) GetNextHandle() uint64 {
+	if x != nil {
+		return x.NextHandle
+	}
+	return 0
+}
+
+func (x *CommandSchedule) GetCommands() []*ScheduledCommand {
+	if x != nil {
+		return x.Commands
+	}
+	return nil
+}
+
+// ScheduledCommand represents a command to be processed at a future tick.
+type ScheduledCommand struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Handle used to cancel the scheduled command
+	Handle uint64 `protobuf:"varint,1,opt,name=handle,proto3" json:"handle,omitempty"`
+	// Tick height the command is processed at
+	TickHeight uint64 `protobuf:"varint,2,opt,name=tick_height,json=tickHeight,proto3" json:"tick_height,omitempty"`
+	// The command to process
+	Command       *v1.Command `protobuf:"bytes,3,opt,name=command,proto3" json:"command,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ScheduledCommand) Reset() {
+	*x = ScheduledCommand{}
+	mi := &file_worldengine_cardinal_v1_snapshot_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ScheduledCommand) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ScheduledCommand) ProtoMessage() {}
+
+func (x *ScheduledCommand) ProtoReflect() protoreflect.Message {
+	mi := &file_worldengine_cardinal_v1_snapshot_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ScheduledCommand.ProtoReflect.Descriptor instead.
+func (*ScheduledCommand) Descriptor() ([]byte, []int) {
+	return file_worldengine_cardinal_v1_snapshot_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ScheduledCommand) GetHandle() uint64 {
+	if x != nil {
+		return x.Handle
+	}
+	return 0
+}
+
+func (x *ScheduledCommand) GetTickHeight() uint64 {
+	if x != nil {
+		return x.TickHeight
+	}
+	return 0
+}
+
+func (x *ScheduledCommand) GetCommand() *v1.Command {
+	if x != nil {
+		return x.Command
+	}
+	return nil
+}
+
 // Archetype represents a collection of entities with the same component types.
 type Archetype struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
@@ -183,7 +611,7 @@ type Archetype struct {
 
 func (x *Archetype) Reset() {
 	*x = Archetype{}
-	mi := &file_worldengine_cardinal_v1_snapshot_proto_msgTypes[2]
+	mi := &file_worldengine_cardinal_v1_snapshot_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -195,7 +623,7 @@ func (x *Archetype) String() string {
 func (*Archetype) ProtoMessage() {}
 
 func (x *Archetype) ProtoReflect() protoreflect.Message {
-	mi := &file_worldengine_cardinal_v1_snapshot_proto_msgTypes[2]
+	mi := &file_worldengine_cardinal_v1_snapshot_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -208,7 +636,7 @@ func (x *Archetype) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Archetype.ProtoReflect.Descriptor instead.
 func (*Archetype) Descriptor() ([]byte, []int) {
-	return file_worldengine_cardinal_v1_snapshot_proto_rawDescGZIP(), []int{2}
+	return file_worldengine_cardinal_v1_snapshot_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *Archetype) GetId() int32 {
@@ -259,7 +687,7 @@ type Column struct {
 
 func (x *Column) Reset() {
 	*x = Column{}
-	mi := &file_worldengine_cardinal_v1_snapshot_proto_msgTypes[3]
+	mi := &file_worldengine_cardinal_v1_snapshot_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -271,7 +699,7 @@ func (x *Column) String() string {
 func (*Column) ProtoMessage() {}
 
 func (x *Column) ProtoReflect() protoreflect.Message {
-	mi := &file_worldengine_cardinal_v1_snapshot_proto_msgTypes[3]
+	mi := &file_worldengine_cardinal_v1_snapshot_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -284,7 +712,7 @@ func (x *Column) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Column.ProtoReflect.Descriptor instead.
 func (*Column) Descriptor() ([]byte, []int) {
-	return file_worldengine_cardinal_v1_snapshot_proto_rawDescGZIP(), []int{3}
+	return file_worldengine_cardinal_v1_snapshot_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *Column) GetComponentName() string {
@@ -305,14 +733,25 @@ var File_worldengine_cardinal_v1_snapshot_proto protoreflect.FileDescriptor
 
 const file_worldengine_cardinal_v1_snapshot_proto_rawDesc = "" +
 	"\n" +
-	"&worldengine/cardinal/v1/snapshot.proto\x12\x17worldengine.cardinal.v1\x1a\x1bbuf/validate/validate.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\xc5\x01\n" +
+	"&worldengine/cardinal/v1/snapshot.proto\x12\x17worldengine.cardinal.v1\x1a\x1bbuf/validate/validate.proto\x1a\x1fgoogle/protobuf/timestamp.proto\x1a worldengine/isc/v1/command.proto\"\xe8\x02\n" +
 	"\bSnapshot\x12\x1f\n" +
 	"\vtick_height\x18\x01 \x01(\x04R\n" +
 	"tickHeight\x128\n" +
 	"\ttimestamp\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x12D\n" +
 	"\vworld_state\x18\x03 \x01(\v2#.worldengine.cardinal.v1.WorldStateR\n" +
 	"worldState\x12\x18\n" +
-	"\aversion\x18\x04 \x01(\rR\aversion\"\xa5\x01\n" +
+	"\aversion\x18\x04 \x01(\rR\aversion\x12>\n" +
+	"\x05delta\x18\x05 \x01(\v2(.worldengine.cardinal.v1.WorldStateDeltaR\x05delta\x12B\n" +
+	"\apayload\x18\x06 \x01(\v2(.worldengine.cardinal.v1.SnapshotPayloadR\apayload\x12\x1d\n" +
+	"\n" +
+	"state_hash\x18\a \x01(\x04R\tstateHash\"\xe5\x01\n" +
+	"\x0fSnapshotPayload\x12\x14\n" +
+	"\x05delta\x18\x01 \x01(\bR\x05delta\x12N\n" +
+	"\vcompression\x18\x02 \x01(\x0e2,.worldengine.cardinal.v1.SnapshotCompressionR\vcompression\x12\x1c\n" +
+	"\tencrypted\x18\x03 \x01(\bR\tencrypted\x12\x15\n" +
+	"\x06key_id\x18\x04 \x01(\fR\x05keyId\x12#\n" +
+	"\bchecksum\x18\x05 \x01(\fB\a\xbaH\x04z\x02h R\bchecksum\x12\x12\n" +
+	"\x04data\x18\x06 \x01(\fR\x04data\"\xbf\x03\n" +
 	"\n" +
 	"WorldState\x12\x17\n" +
 	"\anext_id\x18\x01 \x01(\rR\x06nextId\x12\x19\n" +
@@ -321,7 +760,37 @@ const file_worldengine_cardinal_v1_snapshot_proto_rawDesc = "" +
 	"entityArch\x12B\n" +
 	"\n" +
 	"archetypes\x18\x04 \x03(\v2\".worldengine.cardinal.v1.ArchetypeR\n" +
-	"archetypes\"\xb3\x01\n" +
+	"archetypes\x12S\n" +
+	"\x10command_schedule\x18\x05 \x01(\v2(.worldengine.cardinal.v1.CommandScheduleR\x0fcommandSchedule\x12i\n" +
+	"\x12component_versions\x18\x06 \x03(\v2:.worldengine.cardinal.v1.WorldState.ComponentVersionsEntryR\x11componentVersions\x12\x12\n" +
+	"\x04seed\x18\a \x01(\x04R\x04seed\x1aD\n" +
+	"\x16ComponentVersionsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\rR\x05value:\x028\x01\"\xf7\x03\n" +
+	"\x0fWorldStateDelta\x12,\n" +
+	"\x12parent_tick_height\x18\x01 \x01(\x04R\x10parentTickHeight\x12\x17\n" +
+	"\anext_id\x18\x02 \x01(\rR\x06nextId\x12\x19\n" +
+	"\bfree_ids\x18\x03 \x03(\rR\afreeIds\x12\x1f\n" +
+	"\ventity_arch\x18\x04 \x03(\x03R\n" +
+	"entityArch\x12B\n" +
+	"\n" +
+	"archetypes\x18\x05 \x03(\v2\".worldengine.cardinal.v1.ArchetypeR\n" +
+	"archetypes\x12S\n" +
+	"\x10command_schedule\x18\x06 \x01(\v2(.worldengine.cardinal.v1.CommandScheduleR\x0fcommandSchedule\x12n\n" +
+	"\x12component_versions\x18\a \x03(\v2?.worldengine.cardinal.v1.WorldStateDelta.ComponentVersionsEntryR\x11componentVersions\x12\x12\n" +
+	"\x04seed\x18\b \x01(\x04R\x04seed\x1aD\n" +
+	"\x16ComponentVersionsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\rR\x05value:\x028\x01\"y\n" +
+	"\x0fCommandSchedule\x12\x1f\n" +
+	"\vnext_handle\x18\x01 \x01(\x04R\n" +
+	"nextHandle\x12E\n" +
+	"\bcommands\x18\x02 \x03(\v2).worldengine.cardinal.v1.ScheduledCommandR\bcommands\"\x8a\x01\n" +
+	"\x10ScheduledCommand\x12\x16\n" +
+	"\x06handle\x18\x01 \x01(\x04R\x06handle\x12\x1f\n" +
+	"\vtick_height\x18\x02 \x01(\x04R\n" +
+	"tickHeight\x12=\n" +
+	"\acommand\x18\x03 \x01(\v2\x1b.worldengine.isc.v1.CommandB\x06\xbaH\x03\xc8\x01\x01R\acommand\"\xb3\x01\n" +
 	"\tArchetype\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\x05R\x02id\x12+\n" +
 	"\x11components_bitmap\x18\x02 \x01(\fR\x10componentsBitmap\x12\x12\n" +
@@ -332,7 +801,11 @@ const file_worldengine_cardinal_v1_snapshot_proto_rawDesc = "" +
 	"\x0ecomponent_name\x18\x01 \x01(\tB\a\xbaH\x04r\x02\x10\x01R\rcomponentName\x12\x1e\n" +
 	"\n" +
 	"components\x18\x02 \x03(\fR\n" +
-	"componentsBtZRgithub.com/argus-labs/world-engine/proto/gen/go/worldengine/cardinal/v1;cardinalv1\xaa\x02\x1dWorldEngine.Proto.Cardinal.V1b\x06proto3"
+	"components*y\n" +
+	"\x13SnapshotCompression\x12$\n" +
+	" SNAPSHOT_COMPRESSION_UNSPECIFIED\x10\x00\x12\x1d\n" +
+	"\x19SNAPSHOT_COMPRESSION_NONE\x10\x01\x12\x1d\n" +
+	"\x19SNAPSHOT_COMPRESSION_ZSTD\x10\x02BtZRgithub.com/argus-labs/world-engine/proto/gen/go/worldengine/cardinal/v1;cardinalv1\xaa\x02\x1dWorldEngine.Proto.Cardinal.V1b\x06proto3"
 
 var (
 	file_worldengine_cardinal_v1_snapshot_proto_rawDescOnce sync.Once
@@ -346,24 +819,43 @@ func file_worldengine_cardinal_v1_snapshot_proto_rawDescGZIP() []byte {
 	return file_worldengine_cardinal_v1_snapshot_proto_rawDescData
 }
 
-var file_worldengine_cardinal_v1_snapshot_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_worldengine_cardinal_v1_snapshot_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_worldengine_cardinal_v1_snapshot_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
 var file_worldengine_cardinal_v1_snapshot_proto_goTypes = []any{
-	(*Snapshot)(nil),              // 0: worldengine.cardinal.v1.Snapshot
-	(*WorldState)(nil),            // 1: worldengine.cardinal.v1.WorldState
-	(*Archetype)(nil),             // 2: worldengine.cardinal.v1.Archetype
-	(*Column)(nil),                // 3: worldengine.cardinal.v1.Column
-	(*timestamppb.Timestamp)(nil), // 4: google.protobuf.Timestamp
+	(SnapshotCompression)(0),      // 0: worldengine.cardinal.v1.SnapshotCompression
+	(*Snapshot)(nil),              // 1: worldengine.cardinal.v1.Snapshot
+	(*SnapshotPayload)(nil),       // 2: worldengine.cardinal.v1.SnapshotPayload
+	(*WorldState)(nil),            // 3: worldengine.cardinal.v1.WorldState
+	(*WorldStateDelta)(nil),       // 4: worldengine.cardinal.v1.WorldStateDelta
+	(*CommandSchedule)(nil),       // 5: worldengine.cardinal.v1.CommandSchedule
+	(*ScheduledCommand)(nil),      // 6: worldengine.cardinal.v1.ScheduledCommand
+	(*Archetype)(nil),             // 7: worldengine.cardinal.v1.Archetype
+	(*Column)(nil),                // 8: worldengine.cardinal.v1.Column
+	nil,                           // 9: worldengine.cardinal.v1.WorldState.ComponentVersionsEntry
+	nil,                           // 10: worldengine.cardinal.v1.WorldStateDelta.ComponentVersionsEntry
+	(*timestamppb.Timestamp)(nil), // 11: google.protobuf.Timestamp
+	(*v1.Command)(nil),            // 12: worldengine.isc.v1.Command
 }
 var file_worldengine_cardinal_v1_snapshot_proto_depIdxs = []int32{
-	4, // 0: worldengine.cardinal.v1.Snapshot.timestamp:type_name -> google.protobuf.Timestamp
-	1, // 1: worldengine.cardinal.v1.Snapshot.world_state:type_name -> worldengine.cardinal.v1.WorldState
-	2, // 2: worldengine.cardinal.v1.WorldState.archetypes:type_name -> worldengine.cardinal.v1.Archetype
-	3, // 3: worldengine.cardinal.v1.Archetype.columns:type_name -> worldengine.cardinal.v1.Column
-	4, // [4:4] is the sub-list for method output_type
-	4, // [4:4] is the sub-list for method input_type
-	4, // [4:4] is the sub-list for extension type_name
-	4, // [4:4] is the sub-list for extension extendee
-	0, // [0:4] is the sub-list for field type_name
+	11, // 0: worldengine.cardinal.v1.Snapshot.timestamp:type_name -> google.protobuf.Timestamp
+	3,  // 1: worldengine.cardinal.v1.Snapshot.world_state:type_name -> worldengine.cardinal.v1.WorldState
+	4,  // 2: worldengine.cardinal.v1.Snapshot.delta:type_name -> worldengine.cardinal.v1.WorldStateDelta
+	2,  // 3: worldengine.cardinal.v1.Snapshot.payload:type_name -> worldengine.cardinal.v1.SnapshotPayload
+	0,  // 4: worldengine.cardinal.v1.SnapshotPayload.compression:type_name -> worldengine.cardinal.v1.SnapshotCompression
+	7,  // 5: worldengine.cardinal.v1.WorldState.archetypes:type_name -> worldengine.cardinal.v1.Archetype
+	5,  // 6: worldengine.cardinal.v1.WorldState.command_schedule:type_name -> worldengine.cardinal.v1.CommandSchedule
+	9,  // 7: worldengine.cardinal.v1.WorldState.component_versions:type_name -> worldengine.cardinal.v1.WorldState.ComponentVersionsEntry
+	7,  // 8: worldengine.cardinal.v1.WorldStateDelta.archetypes:type_name -> worldengine.cardinal.v1.Archetype
+	5,  // 9: worldengine.cardinal.v1.WorldStateDelta.command_schedule:type_name -> worldengine.cardinal.v1.CommandSchedule
+	10, // 10: worldengine.cardinal.v1.WorldStateDelta.component_versions:type_name -> worldengine.cardinal.v1.WorldStateDelta.ComponentVersionsEntry
+	6,  // 11: worldengine.cardinal.v1.CommandSchedule.commands:type_name -> worldengine.cardinal.v1.ScheduledCommand
+	12, // 12: worldengine.cardinal.v1.ScheduledCommand.command:type_name -> worldengine.isc.v1.Command
+	8,  // 13: worldengine.cardinal.v1.Archetype.columns:type_name -> worldengine.cardinal.v1.Column
+	14, // [14:14] is the sub-list for method output_type
+	14, // [14:14] is the sub-list for method input_type
+	14, // [14:14] is the sub-list for extension type_name
+	14, // [14:14] is the sub-list for extension extendee
+	0,  // [0:14] is the sub-list for field type_name
 }
 
 func init() { file_worldengine_cardinal_v1_snapshot_proto_init() }
@@ -376,13 +868,14 @@ func file_worldengine_cardinal_v1_snapshot_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_worldengine_cardinal_v1_snapshot_proto_rawDesc), len(file_worldengine_cardinal_v1_snapshot_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   4,
+			NumEnums:      1,
+			NumMessages:   10,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
 		GoTypes:           file_worldengine_cardinal_v1_snapshot_proto_goTypes,
 		DependencyIndexes: file_worldengine_cardinal_v1_snapshot_proto_depIdxs,
+		EnumInfos:         file_worldengine_cardinal_v1_snapshot_proto_enumTypes,
 		MessageInfos:      file_worldengine_cardinal_v1_snapshot_proto_msgTypes,
 	}.Build()
 	File_worldengine_cardinal_v1_snapshot_proto = out.File
@@ -146,7 +146,9 @@ type TickHeader struct {
 	// Tick number (monotonically increasing).
 	TickHeight uint64 `protobuf:"varint,1,opt,name=tick_height,json=tickHeight,proto3" json:"tick_height,omitempty"`
 	// Timestamp of the tick.
-	Timestamp     *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Timestamp *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	// Hash of the world state after the tick, to find the tick where two runs diverged.
+	StateHash     uint64 `protobuf:"varint,3,opt,name=state_hash,json=stateHash,proto3" json:"state_hash,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -195,11 +197,24 @@ func (x *TickHeader) GetTimestamp() *timestamppb.Timestamp {
 	return nil
 }
 
+func (x *TickHeader) GetStateHash() uint64 {
+	if x != nil {
+		return x.StateHash
+	}
+	return 0
+}
+
 type TickData struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Commands      []*Command             `protobuf:"bytes,1,rep,name=commands,proto3" json:"commands,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Commands []*Command             `protobuf:"bytes,1,rep,name=commands,proto3" json:"commands,omitempty"`
+	// Changes clients made to the command schedule before the tick, in the order they were made. The
+	// changes systems make aren't recorded, as replaying the tick makes them again.
+	ScheduleChanges []*ScheduleChange `protobuf:"bytes,2,rep,name=schedule_changes,json=scheduleChanges,proto3" json:"schedule_changes,omitempty"`
+	// Names of the tick's commands that couldn't be recorded, e.g. because their payload failed to
+	// encode. A tick with any of them can't be replayed.
+	UnrecordedCommands []string `protobuf:"bytes,3,rep,name=unrecorded_commands,json=unrecordedCommands,proto3" json:"unrecorded_commands,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
 }
 
 func (x *TickData) Reset() {
@@ -239,6 +254,84 @@ func (x *TickData) GetCommands() []*Command {
 	return nil
 }
 
+func (x *TickData) GetScheduleChanges() []*ScheduleChange {
+	if x != nil {
+		return x.ScheduleChanges
+	}
+	return nil
+}
+
+func (x *TickData) GetUnrecordedCommands() []string {
+	if x != nil {
+		return x.UnrecordedCommands
+	}
+	return nil
+}
+
+// ScheduleChange is a command a client scheduled or cancelled.
+type ScheduleChange struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Handle of the scheduled command.
+	Handle uint64 `protobuf:"varint,1,opt,name=handle,proto3" json:"handle,omitempty"`
+	// Tick height the command is scheduled at. Unset if the command was cancelled.
+	TickHeight uint64 `protobuf:"varint,2,opt,name=tick_height,json=tickHeight,proto3" json:"tick_height,omitempty"`
+	// The scheduled command. Unset if the command was cancelled.
+	Command       *Command `protobuf:"bytes,3,opt,name=command,proto3" json:"command,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ScheduleChange) Reset() {
+	*x = ScheduleChange{}
+	mi := &file_worldengine_isc_v1_epoch_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ScheduleChange) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ScheduleChange) ProtoMessage() {}
+
+func (x *ScheduleChange) ProtoReflect() protoreflect.Message {
+	mi := &file_worldengine_isc_v1_epoch_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ScheduleChange.ProtoReflect.Descriptor instead.
+func (*ScheduleChange) Descriptor() ([]byte, []int) {
+	return file_worldengine_isc_v1_epoch_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ScheduleChange) GetHandle() uint64 {
+	if x != nil {
+		return x.Handle
+	}
+	return 0
+}
+
+func (x *ScheduleChange) GetTickHeight() uint64 {
+	if x != nil {
+		return x.TickHeight
+	}
+	return 0
+}
+
+func (x *ScheduleChange) GetCommand() *Command {
+	if x != nil {
+		return x.Command
+	}
+	return nil
+}
+
 var File_worldengine_isc_v1_epoch_proto protoreflect.FileDescriptor
 
 const file_worldengine_isc_v1_epoch_proto_rawDesc = "" +
@@ -250,14 +343,23 @@ const file_worldengine_isc_v1_epoch_proto_rawDesc = "" +
 	"\x05ticks\x18\x03 \x03(\v2\x18.worldengine.isc.v1.TickR\x05ticks\"p\n" +
 	"\x04Tick\x126\n" +
 	"\x06header\x18\x01 \x01(\v2\x1e.worldengine.isc.v1.TickHeaderR\x06header\x120\n" +
-	"\x04data\x18\x02 \x01(\v2\x1c.worldengine.isc.v1.TickDataR\x04data\"o\n" +
+	"\x04data\x18\x02 \x01(\v2\x1c.worldengine.isc.v1.TickDataR\x04data\"\x8e\x01\n" +
 	"\n" +
 	"TickHeader\x12\x1f\n" +
 	"\vtick_height\x18\x01 \x01(\x04R\n" +
 	"tickHeight\x12@\n" +
-	"\ttimestamp\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampB\x06\xbaH\x03\xc8\x01\x01R\ttimestamp\"C\n" +
+	"\ttimestamp\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampB\x06\xbaH\x03\xc8\x01\x01R\ttimestamp\x12\x1d\n" +
+	"\n" +
+	"state_hash\x18\x03 \x01(\x04R\tstateHash\"\xc3\x01\n" +
 	"\bTickData\x127\n" +
-	"\bcommands\x18\x01 \x03(\v2\x1b.worldengine.isc.v1.CommandR\bcommandsBeZHgithub.com/argus-labs/world-engine/proto/gen/go/worldengine/isc/v1;iscv1\xaa\x02\x18WorldEngine.Proto.Isc.V1b\x06proto3"
+	"\bcommands\x18\x01 \x03(\v2\x1b.worldengine.isc.v1.CommandR\bcommands\x12M\n" +
+	"\x10schedule_changes\x18\x02 \x03(\v2\".worldengine.isc.v1.ScheduleChangeR\x0fscheduleChanges\x12/\n" +
+	"\x13unrecorded_commands\x18\x03 \x03(\tR\x12unrecordedCommands\"\x80\x01\n" +
+	"\x0eScheduleChange\x12\x16\n" +
+	"\x06handle\x18\x01 \x01(\x04R\x06handle\x12\x1f\n" +
+	"\vtick_height\x18\x02 \x01(\x04R\n" +
+	"tickHeight\x125\n" +
+	"\acommand\x18\x03 \x01(\v2\x1b.worldengine.isc.v1.CommandR\acommandBeZHgithub.com/argus-labs/world-engine/proto/gen/go/worldengine/isc/v1;iscv1\xaa\x02\x18WorldEngine.Proto.Isc.V1b\x06proto3"
 
 var (
 	file_worldengine_isc_v1_epoch_proto_rawDescOnce sync.Once
@@ -271,26 +373,29 @@ func file_worldengine_isc_v1_epoch_proto_rawDescGZIP() []byte {
 	return file_worldengine_isc_v1_epoch_proto_rawDescData
 }
 
-var file_worldengine_isc_v1_epoch_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_worldengine_isc_v1_epoch_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
 var file_worldengine_isc_v1_epoch_proto_goTypes = []any{
 	(*Epoch)(nil),                 // 0: worldengine.isc.v1.Epoch
 	(*Tick)(nil),                  // 1: worldengine.isc.v1.Tick
 	(*TickHeader)(nil),            // 2: worldengine.isc.v1.TickHeader
 	(*TickData)(nil),              // 3: worldengine.isc.v1.TickData
-	(*timestamppb.Timestamp)(nil), // 4: google.protobuf.Timestamp
-	(*Command)(nil),               // 5: worldengine.isc.v1.Command
+	(*ScheduleChange)(nil),        // 4: worldengine.isc.v1.ScheduleChange
+	(*timestamppb.Timestamp)(nil), // 5: google.protobuf.Timestamp
+	(*Command)(nil),               // 6: worldengine.isc.v1.Command
 }
 var file_worldengine_isc_v1_epoch_proto_depIdxs = []int32{
 	1, // 0: worldengine.isc.v1.Epoch.ticks:type_name -> worldengine.isc.v1.Tick
 	2, // 1: worldengine.isc.v1.Tick.header:type_name -> worldengine.isc.v1.TickHeader
 	3, // 2: worldengine.isc.v1.Tick.data:type_name -> worldengine.isc.v1.TickData
-	4, // 3: worldengine.isc.v1.TickHeader.timestamp:type_name -> google.protobuf.Timestamp
-	5, // 4: worldengine.isc.v1.TickData.commands:type_name -> worldengine.isc.v1.Command
-	5, // [5:5] is the sub-list for method output_type
-	5, // [5:5] is the sub-list for method input_type
-	5, // [5:5] is the sub-list for extension type_name
-	5, // [5:5] is the sub-list for extension extendee
-	0, // [0:5] is the sub-list for field type_name
+	5, // 3: worldengine.isc.v1.TickHeader.timestamp:type_name -> google.protobuf.Timestamp
+	6, // 4: worldengine.isc.v1.TickData.commands:type_name -> worldengine.isc.v1.Command
+	4, // 5: worldengine.isc.v1.TickData.schedule_changes:type_name -> worldengine.isc.v1.ScheduleChange
+	6, // 6: worldengine.isc.v1.ScheduleChange.command:type_name -> worldengine.isc.v1.Command
+	7, // [7:7] is the sub-list for method output_type
+	7, // [7:7] is the sub-list for method input_type
+	7, // [7:7] is the sub-list for extension type_name
+	7, // [7:7] is the sub-list for extension extendee
+	0, // [0:7] is the sub-list for field type_name
 }
 
 func init() { file_worldengine_isc_v1_epoch_proto_init() }
@@ -305,7 +410,7 @@ func file_worldengine_isc_v1_epoch_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_worldengine_isc_v1_epoch_proto_rawDesc), len(file_worldengine_isc_v1_epoch_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   4,
+			NumMessages:   5,
 			NumExtensions: 0,
 			NumServices:   0,
 		},